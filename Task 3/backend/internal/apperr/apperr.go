@@ -0,0 +1,100 @@
+package apperr
+
+import "errors"
+
+// Kind определяет класс ошибки; обработчики HTTP сопоставляют его со статусом
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+)
+
+// Params — значения для подстановки в локализованный текст ошибки
+type Params map[string]interface{}
+
+// FieldError описывает ошибку конкретного поля: код правила и его параметры.
+// Message заполняется при ответе клиенту на его языке.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Params  Params `json:"-"`
+	Message string `json:"message"`
+}
+
+// Error — ошибка API со стабильным машиночитаемым кодом.
+// Текст для клиента берется из каталога сообщений по коду, причина (cause)
+// попадает только в журнал.
+type Error struct {
+	Kind   Kind
+	Code   string
+	Params Params
+	Fields []FieldError
+	cause  error
+}
+
+func New(kind Kind, code string) *Error {
+	return &Error{Kind: kind, Code: code}
+}
+
+func Invalid(code string) *Error {
+	return New(KindInvalid, code)
+}
+
+// Internal оборачивает непредвиденную ошибку; клиенту она не показывается
+func Internal(cause error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal_error", cause: cause}
+}
+
+// Validation собирает ошибки полей в одну ошибку входных данных
+func Validation(fields []FieldError) *Error {
+	return &Error{Kind: KindInvalid, Code: "validation_failed", Fields: fields}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.cause.Error()
+	}
+	return e.Code
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is сравнивает ошибки по коду, поэтому копии из With и Wrap совпадают с исходной
+func (e *Error) Is(target error) bool {
+	var t *Error
+	return errors.As(target, &t) && t.Code == e.Code
+}
+
+// With возвращает копию ошибки с параметром для текста сообщения
+func (e *Error) With(key string, value interface{}) *Error {
+	c := *e
+	c.Params = Params{}
+	for k, v := range e.Params {
+		c.Params[k] = v
+	}
+	c.Params[key] = value
+	return &c
+}
+
+// Wrap возвращает копию ошибки с внутренней причиной для журнала
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// From приводит любую ошибку к *Error; неизвестные ошибки считаются внутренними
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return Internal(err)
+}
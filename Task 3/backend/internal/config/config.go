@@ -0,0 +1,392 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"catpc-backend/internal/money"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultJWTSecret допустим только в режиме разработки
+const DefaultJWTSecret = "catpc-secret-key-2024"
+
+type Config struct {
+	Env               string        `yaml:"env"`
+	Port              string        `yaml:"port"`
+	DBHost            string        `yaml:"db_host"`
+	DBPort            string        `yaml:"db_port"`
+	DBName            string        `yaml:"db_name"`
+	DBUser            string        `yaml:"db_user"`
+	DBPassword        string        `yaml:"db_password"`
+	DBSSLMode         string        `yaml:"db_sslmode"`
+	JWTSecret         string        `yaml:"jwt_secret"`
+	CORSOrigins       []string      `yaml:"cors_origins"`
+	UploadDir         string        `yaml:"upload_dir"`
+	MaxFileSize       int64         `yaml:"max_file_size"`
+	AnalyticsCacheTTL time.Duration `yaml:"analytics_cache_ttl"`
+	BaseCurrency      string        `yaml:"base_currency"`
+	ExchangeRatesFile string        `yaml:"exchange_rates_file"`
+	QAPremoderation   bool          `yaml:"qa_premoderation"`
+	// RecommendationsInterval — период пересчета пар «покупают вместе»; 0 отключает задачу
+	RecommendationsInterval time.Duration `yaml:"recommendations_interval"`
+	LogLevel                string        `yaml:"log_level"`
+	LogFormat               string        `yaml:"log_format"`
+	// ShutdownTimeout — сколько ждать завершения текущих запросов при остановке
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ShutdownDrainDelay — пауза между переводом /readyz в 503 и остановкой приема
+	// соединений, чтобы балансировщик успел убрать экземпляр; 0 — без паузы
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay"`
+	// Пул соединений: 0 в DBMaxOpenConns снимает ограничение
+	DBMaxOpenConns    int           `yaml:"db_max_open_conns"`
+	DBMaxIdleConns    int           `yaml:"db_max_idle_conns"`
+	DBConnMaxLifetime time.Duration `yaml:"db_conn_max_lifetime"`
+	DBConnMaxIdleTime time.Duration `yaml:"db_conn_max_idle_time"`
+	// ImageMaxDimension — наибольшая ширина и высота загружаемого изображения в пикселях
+	ImageMaxDimension int `yaml:"image_max_dimension"`
+	// SalesInterval — как часто планировщик запускает и завершает акции
+	SalesInterval time.Duration `yaml:"sales_interval"`
+	// Ограничения запроса GraphQL: вложенность полей и оценка числа загружаемых объектов
+	GraphQLMaxDepth      int `yaml:"graphql_max_depth"`
+	GraphQLMaxComplexity int `yaml:"graphql_max_complexity"`
+	// Реквизиты магазина в счетах к заказам
+	InvoiceCompany string `yaml:"invoice_company"`
+	InvoiceTaxID   string `yaml:"invoice_tax_id"`
+	InvoiceAddress string `yaml:"invoice_address"`
+}
+
+// Default возвращает настройки по умолчанию. Режим по умолчанию — prod: секрет
+// из репозитория принимается, только если режим dev включен явно.
+func Default() *Config {
+	return &Config{
+		Env:               "prod",
+		Port:              "1323",
+		DBHost:            "localhost",
+		DBPort:            "5432",
+		DBName:            "barsikdb",
+		DBUser:            "barsikuser",
+		DBPassword:        "barsik_password",
+		DBSSLMode:         "disable",
+		DBMaxOpenConns:    25,
+		DBMaxIdleConns:    10,
+		DBConnMaxLifetime: 30 * time.Minute,
+		DBConnMaxIdleTime: 5 * time.Minute,
+		JWTSecret:         DefaultJWTSecret,
+		CORSOrigins:       []string{"http://localhost:5173"},
+		UploadDir:         "./public/img",
+		MaxFileSize:       10 << 20,
+		AnalyticsCacheTTL: 5 * time.Minute,
+		BaseCurrency:      "RUB",
+
+		RecommendationsInterval: time.Hour,
+		LogLevel:                "info",
+		LogFormat:               "json",
+		ShutdownTimeout:         15 * time.Second,
+		ShutdownDrainDelay:      5 * time.Second,
+		ImageMaxDimension:       4096,
+		SalesInterval:           time.Minute,
+		GraphQLMaxDepth:         8,
+		GraphQLMaxComplexity:    1000,
+		InvoiceCompany:          "CatPC",
+	}
+}
+
+// Load собирает конфигурацию: значения по умолчанию, затем YAML-файл
+// (путь из CONFIG_FILE), затем переменные окружения.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) IsDev() bool {
+	return c.Env == "dev"
+}
+
+// Logger создает журнал приложения с уровнем и форматом из конфигурации
+func (c *Config) Logger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(c.LogLevel))
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.LogFormat == "text" {
+		return slog.New(slog.NewTextHandler(w, opts))
+	}
+	return slog.New(slog.NewJSONHandler(w, opts))
+}
+
+func (c *Config) DSN() string {
+	return fmt.Sprintf("user=%s password=%s dbname=%s host=%s port=%s sslmode=%s",
+		c.DBUser, c.DBPassword, c.DBName, c.DBHost, c.DBPort, c.DBSSLMode)
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Env {
+	case "dev", "staging", "prod":
+	default:
+		errs = append(errs, fmt.Errorf("env: неизвестный режим %q (dev, staging, prod)", c.Env))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("port: неверный порт %q", c.Port))
+	}
+
+	if c.DBHost == "" || c.DBName == "" || c.DBUser == "" {
+		errs = append(errs, errors.New("db: host, name и user обязательны"))
+	}
+
+	if c.DBMaxOpenConns < 0 || c.DBMaxIdleConns < 0 {
+		errs = append(errs, errors.New("db_max_open_conns, db_max_idle_conns: не могут быть отрицательными"))
+	} else if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		errs = append(errs, errors.New("db_max_idle_conns: не может быть больше db_max_open_conns"))
+	}
+
+	if c.DBConnMaxLifetime < 0 || c.DBConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("db_conn_max_lifetime, db_conn_max_idle_time: не могут быть отрицательными"))
+	}
+
+	if c.JWTSecret == "" {
+		errs = append(errs, errors.New("jwt_secret: не задан"))
+	} else if !c.IsDev() {
+		if c.JWTSecret == DefaultJWTSecret {
+			errs = append(errs, errors.New("jwt_secret: секрет по умолчанию разрешен только в режиме dev (APP_ENV=dev)"))
+		} else if len(c.JWTSecret) < 32 {
+			errs = append(errs, errors.New("jwt_secret: должен быть не короче 32 символов"))
+		}
+	}
+
+	if len(c.CORSOrigins) == 0 {
+		errs = append(errs, errors.New("cors_origins: нужен хотя бы один источник"))
+	}
+
+	if c.UploadDir == "" {
+		errs = append(errs, errors.New("upload_dir: не задан"))
+	}
+
+	if c.MaxFileSize <= 0 {
+		errs = append(errs, errors.New("max_file_size: должен быть больше 0"))
+	}
+
+	if c.ImageMaxDimension <= 0 {
+		errs = append(errs, errors.New("image_max_dimension: должен быть больше 0"))
+	}
+
+	if c.AnalyticsCacheTTL < 0 {
+		errs = append(errs, errors.New("analytics_cache_ttl: не может быть отрицательным"))
+	}
+
+	if c.RecommendationsInterval < 0 {
+		errs = append(errs, errors.New("recommendations_interval: не может быть отрицательным"))
+	}
+
+	// Без планировщика акции не запустятся, поэтому отключить его нельзя
+	if c.SalesInterval <= 0 {
+		errs = append(errs, errors.New("sales_interval: должен быть больше 0"))
+	}
+
+	if c.InvoiceCompany == "" {
+		errs = append(errs, errors.New("invoice_company: не задан"))
+	}
+
+	if c.GraphQLMaxDepth <= 0 {
+		errs = append(errs, errors.New("graphql_max_depth: должен быть больше 0"))
+	}
+
+	if c.GraphQLMaxComplexity <= 0 {
+		errs = append(errs, errors.New("graphql_max_complexity: должен быть больше 0"))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: неизвестный уровень %q (debug, info, warn, error)", c.LogLevel))
+	}
+
+	switch c.LogFormat {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("log_format: неизвестный формат %q (json, text)", c.LogFormat))
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("shutdown_timeout: должен быть больше 0"))
+	}
+	if c.ShutdownDrainDelay < 0 {
+		errs = append(errs, errors.New("shutdown_drain_delay: не может быть отрицательным"))
+	}
+
+	if !money.ValidCurrency(c.BaseCurrency) {
+		errs = append(errs, fmt.Errorf("base_currency: ожидается код ISO 4217, получено %q", c.BaseCurrency))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("неверная конфигурация: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("чтение файла конфигурации: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("разбор файла конфигурации %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	c.Env = getEnv("APP_ENV", c.Env)
+	c.Port = getEnv("PORT", c.Port)
+	c.DBHost = getEnv("DB_HOST", c.DBHost)
+	c.DBPort = getEnv("DB_PORT", c.DBPort)
+	c.DBName = getEnv("DB_NAME", c.DBName)
+	c.DBUser = getEnv("DB_USER", c.DBUser)
+	c.DBPassword = getEnv("DB_PASSWORD", c.DBPassword)
+	c.DBSSLMode = getEnv("DB_SSLMODE", c.DBSSLMode)
+	c.JWTSecret = getEnv("JWT_SECRET", c.JWTSecret)
+	c.CORSOrigins = getEnvAsList("CORS_ORIGINS", c.CORSOrigins)
+	c.UploadDir = getEnv("UPLOAD_DIR", c.UploadDir)
+	c.BaseCurrency = getEnv("BASE_CURRENCY", c.BaseCurrency)
+	c.ExchangeRatesFile = getEnv("EXCHANGE_RATES_FILE", c.ExchangeRatesFile)
+	c.LogLevel = getEnv("LOG_LEVEL", c.LogLevel)
+	c.LogFormat = getEnv("LOG_FORMAT", c.LogFormat)
+	c.InvoiceCompany = getEnv("INVOICE_COMPANY", c.InvoiceCompany)
+	c.InvoiceTaxID = getEnv("INVOICE_TAX_ID", c.InvoiceTaxID)
+	c.InvoiceAddress = getEnv("INVOICE_ADDRESS", c.InvoiceAddress)
+
+	var err error
+	if c.MaxFileSize, err = getEnvAsInt64("MAX_FILE_SIZE", c.MaxFileSize); err != nil {
+		return err
+	}
+	if c.ImageMaxDimension, err = getEnvAsInt("IMAGE_MAX_DIMENSION", c.ImageMaxDimension); err != nil {
+		return err
+	}
+	if c.GraphQLMaxDepth, err = getEnvAsInt("GRAPHQL_MAX_DEPTH", c.GraphQLMaxDepth); err != nil {
+		return err
+	}
+	if c.GraphQLMaxComplexity, err = getEnvAsInt("GRAPHQL_MAX_COMPLEXITY", c.GraphQLMaxComplexity); err != nil {
+		return err
+	}
+	if c.DBMaxOpenConns, err = getEnvAsInt("DB_MAX_OPEN_CONNS", c.DBMaxOpenConns); err != nil {
+		return err
+	}
+	if c.DBMaxIdleConns, err = getEnvAsInt("DB_MAX_IDLE_CONNS", c.DBMaxIdleConns); err != nil {
+		return err
+	}
+	if c.DBConnMaxLifetime, err = getEnvAsDuration("DB_CONN_MAX_LIFETIME", c.DBConnMaxLifetime); err != nil {
+		return err
+	}
+	if c.DBConnMaxIdleTime, err = getEnvAsDuration("DB_CONN_MAX_IDLE_TIME", c.DBConnMaxIdleTime); err != nil {
+		return err
+	}
+	if c.ShutdownTimeout, err = getEnvAsDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout); err != nil {
+		return err
+	}
+	if c.ShutdownDrainDelay, err = getEnvAsDuration("SHUTDOWN_DRAIN_DELAY", c.ShutdownDrainDelay); err != nil {
+		return err
+	}
+	if c.AnalyticsCacheTTL, err = getEnvAsDuration("ANALYTICS_CACHE_TTL", c.AnalyticsCacheTTL); err != nil {
+		return err
+	}
+	if c.RecommendationsInterval, err = getEnvAsDuration("RECOMMENDATIONS_INTERVAL", c.RecommendationsInterval); err != nil {
+		return err
+	}
+	if c.SalesInterval, err = getEnvAsDuration("SALES_INTERVAL", c.SalesInterval); err != nil {
+		return err
+	}
+	if c.QAPremoderation, err = getEnvAsBool("QA_PREMODERATION", c.QAPremoderation); err != nil {
+		return err
+	}
+	return nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvAsInt64(key string, defaultValue int64) (int64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	intValue, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: ожидается целое число, получено %q", key, value)
+	}
+	return intValue, nil
+}
+
+func getEnvAsInt(key string, defaultValue int) (int, error) {
+	value, err := getEnvAsInt64(key, int64(defaultValue))
+	return int(value), err
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: ожидается длительность (например 5m), получено %q", key, value)
+	}
+	return duration, nil
+}
+
+func getEnvAsBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s: ожидается true или false, получено %q", key, value)
+	}
+	return boolValue, nil
+}
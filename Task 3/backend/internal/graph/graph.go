@@ -0,0 +1,129 @@
+// Package graph — GraphQL API витрины поверх сервиса: каталог, категории, продавцы,
+// а также корзина и заказы вошедшего пользователя. API только читает данные:
+// изменения по-прежнему проходят через REST.
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/service"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+var (
+	ErrInvalidQuery    = apperr.Invalid("invalid_query")
+	ErrQueryTooDeep    = apperr.Invalid("query_too_deep")
+	ErrQueryTooComplex = apperr.Invalid("query_too_complex")
+	// Коды совпадают с ответами REST, чтобы клиент обрабатывал их одинаково
+	ErrUnauthorized = apperr.New(apperr.KindUnauthorized, "unauthorized")
+	ErrForbidden    = apperr.New(apperr.KindForbidden, "forbidden")
+)
+
+// Viewer — пользователь, от имени которого выполняется запрос; нулевой Viewer — гость
+type Viewer struct {
+	UserID int
+	Role   string
+}
+
+type Schema struct {
+	schema  graphql.Schema
+	service *service.Service
+}
+
+// New строит схему; ошибка означает ошибку в описании типов
+func New(svc *service.Service) (*Schema, error) {
+	schema, err := newSchema()
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: schema, service: svc}, nil
+}
+
+// Result — ответ на запрос. Data пуст, если запрос отклонен до исполнения.
+type Result struct {
+	Data   interface{}
+	Errors []Error
+}
+
+// Error — ошибка запроса или поля; Path указывает на поле, которое не удалось получить
+type Error struct {
+	Err       *apperr.Error
+	Path      []interface{}
+	Locations []location.SourceLocation
+}
+
+// Execute разбирает, проверяет и исполняет запрос. Глубина и сложность запроса
+// проверяются сразу после разбора: полная проверка по схеме (в том числе
+// совместимость одноименных полей) на большом запросе медленная, и слишком
+// глубокий или дорогой запрос отклоняется до нее. Цены в ответе переводятся
+// в валюту currency.
+func (s *Schema) Execute(ctx context.Context, req models.GraphQLRequest, viewer Viewer, currency string) *Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &Result{Errors: convert(gqlerrors.FormatErrors(err))}
+	}
+
+	maxDepth, maxComplexity := s.service.GraphQLLimits()
+	complexity, err := measure(s.schema, doc, req.OperationName, req.Variables, maxDepth)
+	if err != nil {
+		return &Result{Errors: []Error{{Err: apperr.From(err)}}}
+	}
+	if complexity > maxComplexity {
+		return &Result{Errors: []Error{{Err: ErrQueryTooComplex.With("max", maxComplexity).With("complexity", complexity)}}}
+	}
+
+	if validation := graphql.ValidateDocument(&s.schema, doc, nil); !validation.IsValid {
+		return &Result{Errors: convert(validation.Errors)}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, requestKey{}, newRequest(s.service, viewer, currency)),
+	})
+	return &Result{Data: result.Data, Errors: convert(result.Errors)}
+}
+
+// convert сохраняет ошибки резолверов как есть, а ошибки разбора и проверки
+// запроса (у них нет пути) сводит к ErrInvalidQuery с текстом graphql-go в reason
+func convert(errs []gqlerrors.FormattedError) []Error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	converted := make([]Error, 0, len(errs))
+	for _, e := range errs {
+		cause := e.OriginalError()
+		var gqlErr *gqlerrors.Error
+		if errors.As(cause, &gqlErr) && gqlErr.OriginalError != nil {
+			cause = gqlErr.OriginalError
+		}
+
+		var err *apperr.Error
+		switch {
+		case errors.As(cause, &err):
+		case len(e.Path) > 0:
+			if cause == nil {
+				cause = errors.New(e.Message)
+			}
+			err = apperr.Internal(cause)
+		default:
+			err = ErrInvalidQuery.With("reason", e.Message)
+		}
+		converted = append(converted, Error{Err: err, Path: e.Path, Locations: e.Locations})
+	}
+	return converted
+}
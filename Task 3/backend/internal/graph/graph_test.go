@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestLoaderBatchesKeys(t *testing.T) {
+	var batches [][]int
+	l := newLoader(func(keys []int) (map[int]string, error) {
+		batches = append(batches, keys)
+		values := map[int]string{}
+		for _, k := range keys {
+			if k != 3 {
+				values[k] = string(rune('a' + k))
+			}
+		}
+		return values, nil
+	})
+
+	first, second, again, missing := l.load(1), l.load(2), l.load(1), l.load(3)
+	if v, ok, err := second(); v != "c" || !ok || err != nil {
+		t.Errorf("second: %q %v %v", v, ok, err)
+	}
+	for _, wait := range []func() (string, bool, error){first, again} {
+		if v, ok, _ := wait(); v != "b" || !ok {
+			t.Errorf("first: %q %v", v, ok)
+		}
+	}
+	if _, ok, err := missing(); ok || err != nil {
+		t.Errorf("missing: %v %v", ok, err)
+	}
+	// Загруженный ключ берется из памяти, новый уходит отдельной пачкой
+	l.load(1)()
+	l.load(4)()
+	if want := [][]int{{1, 2, 3}, {4}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+
+	failing := newLoader(func(keys []int) (map[int]string, error) {
+		return nil, errors.New("база недоступна")
+	})
+	if _, _, err := failing.load(1)(); err == nil {
+		t.Error("fetch error lost")
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	schema, err := newSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Каждый фрагмент дважды раскрывает предыдущий: без запоминания обход занял бы 2^40 шагов
+	chain := strings.Builder{}
+	chain.WriteString("{ ...F40 } fragment F0 on Query { categories { name } }")
+	for i := 1; i <= 40; i++ {
+		fmt.Fprintf(&chain, " fragment F%d on Query { ...F%d ...F%d }", i, i-1, i-1)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		maxDepth  int
+		cost      int
+		err       error
+	}{
+		{name: "list without limit", query: `{ categories { name } }`, cost: 11},
+		{name: "page limit applies to its list", query: `{ products(limit: 5) { total products { id name } } }`, cost: 13},
+		{name: "nested limit", query: `{ product(id: 1) { seller { products(limit: 3) { id } } } }`, cost: 6},
+		{name: "limit out of range", query: `{ seller(id: 1) { products(limit: 1000) { id } } }`, cost: 12},
+		{name: "variable default", query: `query($n: Int = 4) { seller(id: 1) { products(limit: $n) { id } } }`, cost: 6},
+		{name: "variable", query: `query($n: Int = 4) { seller(id: 1) { products(limit: $n) { id } } }`,
+			variables: map[string]interface{}{"n": float64(2)}, cost: 4},
+		{name: "fragment", query: `{ ...F } fragment F on Query { categories { name } }`, cost: 11},
+		{name: "introspection is free", query: `{ __schema { types { name fields { name } } } categories { name } }`, cost: 11},
+		{name: "fragment cycle", query: `{ ...A } fragment A on Query { categories { name } ...B } fragment B on Query { ...A }`, cost: 11},
+		{name: "fragment chain", query: chain.String(), cost: maxCost},
+		{name: "too deep", query: `{ seller(id: 1) { products { seller { id } } } }`, maxDepth: 3, err: ErrQueryTooDeep},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			maxDepth := tt.maxDepth
+			if maxDepth == 0 {
+				maxDepth = 8
+			}
+
+			cost, err := measure(schema, doc, "", tt.variables, maxDepth)
+			if !errors.Is(err, tt.err) || cost != tt.cost {
+				t.Errorf("got %d, %v; want %d, %v", cost, err, tt.cost, tt.err)
+			}
+		})
+	}
+}
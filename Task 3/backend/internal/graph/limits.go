@@ -0,0 +1,231 @@
+package graph
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// defaultListSize — сколько элементов ожидается в списке без аргумента limit
+	// (позиции заказа, заказы пользователя, категории)
+	defaultListSize = 10
+	// Аргумент limit у списков товаров, как в REST: от 1 до 100, иначе 10
+	defaultLimit = 10
+	maxLimit     = 100
+	// maxCost — предел оценки: больше любой допустимой сложности, а сумма
+	// множества дорогих полей на нем останавливается, не переполняя int
+	maxCost = math.MaxInt32
+)
+
+// cost проверяет глубину запроса и оценивает его сложность — сколько объектов
+// придется загрузить. Каждое поле стоит 1; список умножает стоимость вложенных
+// полей на свой limit или defaultListSize, поэтому
+// products(limit: 50) { products { seller { products(limit: 50) { id } } } }
+// обходится дорого, хотя вложенность у него небольшая. limit у поля-страницы
+// (products возвращает ProductPage) относится к списку внутри страницы.
+// Служебные поля __schema, __type и __typename не учитываются: они не обращаются к базе.
+// Оценка идет до проверки запроса по схеме, поэтому не полагается на его корректность.
+type cost struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	defaults  map[string]ast.Value
+	maxDepth  int
+	// measured — оценки фрагментов, visiting — фрагменты, которые сейчас обходятся
+	measured map[fragmentUse]int
+	visiting map[string]bool
+}
+
+// fragmentUse — место, где раскрывается фрагмент: от него зависит оценка
+type fragmentUse struct {
+	name      string
+	parent    string
+	depth     int
+	pageLimit int
+}
+
+// measure возвращает сложность операции или ErrQueryTooDeep
+func measure(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, maxDepth int) (int, error) {
+	c := &cost{
+		schema:    schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		defaults:  map[string]ast.Value{},
+		maxDepth:  maxDepth,
+		measured:  map[fragmentUse]int{},
+		visiting:  map[string]bool{},
+	}
+
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	// Операцию без названия среди нескольких отклонит исполнитель запроса
+	if operation == nil || operation.Operation != ast.OperationTypeQuery {
+		return 0, nil
+	}
+	for _, v := range operation.VariableDefinitions {
+		if v.DefaultValue != nil {
+			c.defaults[v.Variable.Name.Value] = v.DefaultValue
+		}
+	}
+
+	return c.selections(operation.SelectionSet, schema.QueryType(), 1, 0)
+}
+
+// selections оценивает набор полей; pageLimit — limit страницы, в которой лежит набор
+func (c *cost) selections(set *ast.SelectionSet, parent *graphql.Object, depth, pageLimit int) (int, error) {
+	if set == nil || parent == nil {
+		return 0, nil
+	}
+
+	total := 0
+	for _, selection := range set.Selections {
+		var n int
+		var err error
+		switch s := selection.(type) {
+		case *ast.Field:
+			n, err = c.field(s, parent, depth, pageLimit)
+		case *ast.InlineFragment:
+			n, err = c.selections(s.SelectionSet, parent, depth, pageLimit)
+		case *ast.FragmentSpread:
+			n, err = c.fragment(s.Name.Value, parent, depth, pageLimit)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total = min(total+n, maxCost)
+	}
+	return total, nil
+}
+
+// fragment оценивает раскрытие фрагмента. Цикл фрагментов не учитывается — его
+// отклонит проверка запроса. Оценка запоминается: иначе цепочка фрагментов, каждый
+// из которых дважды раскрывает предыдущий, обходилась бы за экспоненциальное время.
+func (c *cost) fragment(name string, parent *graphql.Object, depth, pageLimit int) (int, error) {
+	fragment, ok := c.fragments[name]
+	if !ok || c.visiting[name] {
+		return 0, nil
+	}
+	use := fragmentUse{name: name, parent: parent.Name(), depth: depth, pageLimit: pageLimit}
+	if n, ok := c.measured[use]; ok {
+		return n, nil
+	}
+
+	c.visiting[name] = true
+	n, err := c.selections(fragment.SelectionSet, parent, depth, pageLimit)
+	delete(c.visiting, name)
+	if err != nil {
+		return 0, err
+	}
+	c.measured[use] = n
+	return n, nil
+}
+
+func (c *cost) field(f *ast.Field, parent *graphql.Object, depth, pageLimit int) (int, error) {
+	if strings.HasPrefix(f.Name.Value, "__") {
+		return 0, nil
+	}
+	if depth > c.maxDepth {
+		return 0, ErrQueryTooDeep.With("max", c.maxDepth)
+	}
+
+	def, ok := parent.Fields()[f.Name.Value]
+	if !ok {
+		return 1, nil
+	}
+
+	t, list := unwrap(def.Type)
+	limit, hasLimit := c.limit(f, def)
+	multiplier, nestedPage := 1, 0
+	switch {
+	case list && hasLimit:
+		multiplier = limit
+	case list && pageLimit > 0:
+		multiplier = pageLimit
+	case list:
+		multiplier = defaultListSize
+	case hasLimit:
+		nestedPage = limit
+	}
+
+	object, _ := t.(*graphql.Object)
+	nested, err := c.selections(f.SelectionSet, object, depth+1, nestedPage)
+	if err != nil {
+		return 0, err
+	}
+	return min(1+multiplier*nested, maxCost), nil
+}
+
+// limit возвращает значение аргумента limit поля так же, как его увидит резолвер
+func (c *cost) limit(f *ast.Field, def *graphql.FieldDefinition) (int, bool) {
+	found := false
+	for _, a := range def.Args {
+		found = found || a.Name() == "limit"
+	}
+	if !found {
+		return 0, false
+	}
+
+	limit := 0
+	for _, a := range f.Arguments {
+		if a.Name.Value == "limit" {
+			limit, _ = c.intValue(a.Value)
+		}
+	}
+	return clampLimit(limit), true
+}
+
+func (c *cost) intValue(v ast.Value) (int, bool) {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch value := c.variables[v.Name.Value].(type) {
+		case float64:
+			return int(value), true
+		case int:
+			return value, true
+		}
+		if def, ok := c.defaults[v.Name.Value]; ok {
+			return c.intValue(def)
+		}
+	}
+	return 0, false
+}
+
+// clampLimit приводит limit к допустимому диапазону: вне его действует значение по умолчанию
+func clampLimit(limit int) int {
+	if limit < 1 || limit > maxLimit {
+		return defaultLimit
+	}
+	return limit
+}
+
+// unwrap снимает с типа NonNull и List и сообщает, был ли это список
+func unwrap(t graphql.Type) (graphql.Type, bool) {
+	list := false
+	for {
+		switch w := t.(type) {
+		case *graphql.NonNull:
+			t = w.OfType
+		case *graphql.List:
+			list = true
+			t = w.OfType
+		default:
+			return t, list
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package graph
+
+import "sync"
+
+// loader собирает ключи, запрошенные резолверами, и загружает их одним вызовом
+// fetch. Резолвер возвращает отложенное значение: graphql-go вычисляет отложенные
+// значения уровня запроса только после того, как отработали все резолверы уровня,
+// поэтому первое же вычисление загружает ключи всего уровня разом.
+// Загруженное запоминается до конца запроса.
+type loader[K comparable, V any] struct {
+	fetch func(keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	values  map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch func(keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, values: map[K]V{}, errs: map[K]error{}}
+}
+
+// load ставит ключ в очередь и возвращает функцию, которая дождется загрузки.
+// ok = false — значения для ключа нет.
+func (l *loader[K, V]) load(key K) func() (value V, ok bool, err error) {
+	l.mu.Lock()
+	if !l.done(key) && !l.queued(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if !l.done(key) {
+			l.flush()
+		}
+		value, ok := l.values[key]
+		return value, ok, l.errs[key]
+	}
+}
+
+// done сообщает, что ключ уже загружен, пусть и безуспешно
+func (l *loader[K, V]) done(key K) bool {
+	if _, ok := l.values[key]; ok {
+		return true
+	}
+	_, ok := l.errs[key]
+	return ok
+}
+
+func (l *loader[K, V]) queued(key K) bool {
+	for _, k := range l.pending {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *loader[K, V]) flush() {
+	keys := l.pending
+	l.pending = nil
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.fetch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+		} else if value, ok := values[key]; ok {
+			l.values[key] = value
+		} else {
+			// Отсутствие значения тоже запоминается, чтобы не загружать ключ снова
+			l.errs[key] = nil
+		}
+	}
+}
@@ -0,0 +1,527 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/service"
+
+	"github.com/graphql-go/graphql"
+)
+
+type requestKey struct{}
+
+// request — состояние одного запроса: кто спрашивает, в какой валюте цены
+// и загрузчики, которые собирают выборки товаров в пачки
+type request struct {
+	service  *service.Service
+	viewer   Viewer
+	currency string
+
+	products         *loader[int, models.Product]
+	sellerProducts   *loader[listKey[int], []models.Product]
+	categoryProducts *loader[listKey[string], []models.Product]
+}
+
+// listKey — ключ списка товаров: владелец списка и сколько товаров нужно
+type listKey[K comparable] struct {
+	key   K
+	limit int
+}
+
+func newRequest(svc *service.Service, viewer Viewer, currency string) *request {
+	return &request{
+		service:  svc,
+		viewer:   viewer,
+		currency: currency,
+		products: newLoader(func(ids []int) (map[int]models.Product, error) {
+			return svc.ProductsByIDs(ids, currency)
+		}),
+		sellerProducts: newLoader(func(keys []listKey[int]) (map[listKey[int]][]models.Product, error) {
+			return byLimit(keys, func(ids []int, limit int) (map[int][]models.Product, error) {
+				return svc.SellerProducts(ids, limit, currency)
+			})
+		}),
+		categoryProducts: newLoader(func(keys []listKey[string]) (map[listKey[string]][]models.Product, error) {
+			return byLimit(keys, func(names []string, limit int) (map[string][]models.Product, error) {
+				return svc.CategoryProducts(names, limit, currency)
+			})
+		}),
+	}
+}
+
+// byLimit загружает списки одним запросом на каждое встретившееся значение limit
+func byLimit[K comparable](keys []listKey[K], fetch func(keys []K, limit int) (map[K][]models.Product, error)) (map[listKey[K]][]models.Product, error) {
+	groups := map[int][]K{}
+	for _, k := range keys {
+		groups[k.limit] = append(groups[k.limit], k.key)
+	}
+
+	lists := map[listKey[K]][]models.Product{}
+	for limit, group := range groups {
+		found, err := fetch(group, limit)
+		if err != nil {
+			return nil, err
+		}
+		for key, products := range found {
+			lists[listKey[K]{key: key, limit: limit}] = products
+		}
+	}
+	return lists, nil
+}
+
+func requestFrom(ctx context.Context) *request {
+	return ctx.Value(requestKey{}).(*request)
+}
+
+// user возвращает id вошедшего пользователя, а если передан список ролей — проверяет роль
+func (r *request) user(roles ...string) (int, error) {
+	if r.viewer.UserID == 0 {
+		return 0, ErrUnauthorized
+	}
+	if len(roles) == 0 {
+		return r.viewer.UserID, nil
+	}
+	for _, role := range roles {
+		if r.viewer.Role == role {
+			return r.viewer.UserID, nil
+		}
+	}
+	return 0, ErrForbidden
+}
+
+// product возвращает отложенную загрузку товара; удаленного или скрытого товара нет
+func (r *request) product(id int) func() (interface{}, error) {
+	wait := r.products.load(id)
+	return func() (interface{}, error) {
+		p, ok, err := wait()
+		if err != nil || !ok {
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// productList возвращает отложенную загрузку списка; пустой список — не null
+func productList[K comparable](l *loader[listKey[K], []models.Product], key K, limit int) func() (interface{}, error) {
+	wait := l.load(listKey[K]{key: key, limit: limit})
+	return func() (interface{}, error) {
+		products, _, err := wait()
+		if err != nil {
+			return nil, err
+		}
+		if products == nil {
+			products = []models.Product{}
+		}
+		return products, nil
+	}
+}
+
+// Money — сумма, как в REST: число без потери точности
+var Money = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Money",
+	Description: "Денежная сумма; в JSON — число",
+	Serialize: func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case money.Amount:
+			return json.Number(v.String())
+		case *money.Amount:
+			if v == nil {
+				return nil
+			}
+			return json.Number(v.String())
+		}
+		return nil
+	},
+})
+
+type attribute struct {
+	Name  string
+	Value string
+}
+
+func nonNull(t graphql.Output) graphql.Output {
+	return graphql.NewNonNull(t)
+}
+
+func listOf(t graphql.Output) graphql.Output {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+var limitArg = &graphql.ArgumentConfig{
+	Type:         graphql.Int,
+	DefaultValue: defaultLimit,
+	Description:  "Сколько товаров вернуть: от 1 до 100, иначе 10",
+}
+
+func newSchema() (graphql.Schema, error) {
+	attributeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Attribute",
+		Fields: graphql.Fields{
+			"name":  &graphql.Field{Type: nonNull(graphql.String)},
+			"value": &graphql.Field{Type: nonNull(graphql.String)},
+		},
+	})
+
+	saleType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Sale",
+		Description: "Акция, которая снижает цену сейчас",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: nonNull(graphql.Int)},
+			"name":   &graphql.Field{Type: nonNull(graphql.String)},
+			"endsAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+		},
+	})
+
+	// Товар и продавец ссылаются друг на друга, поэтому их поля задаются функциями
+	var productType, sellerType *graphql.Object
+
+	productType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Product",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":            &graphql.Field{Type: nonNull(graphql.Int)},
+				"name":          &graphql.Field{Type: nonNull(graphql.String)},
+				"description":   &graphql.Field{Type: nonNull(graphql.String)},
+				"price":         &graphql.Field{Type: nonNull(Money), Description: "Цена с учетом действующей акции"},
+				"originalPrice": &graphql.Field{Type: Money, Description: "Цена без скидки"},
+				"currency":      &graphql.Field{Type: graphql.String},
+				"image":         &graphql.Field{Type: nonNull(graphql.String)},
+				"stock":         &graphql.Field{Type: nonNull(graphql.Int)},
+				"weight":        &graphql.Field{Type: nonNull(graphql.Int), Description: "Вес в граммах"},
+				"category":      &graphql.Field{Type: nonNull(graphql.String)},
+				"isApproved":    &graphql.Field{Type: nonNull(graphql.Boolean)},
+				"sale":          &graphql.Field{Type: saleType},
+				"attributes": &graphql.Field{
+					Type:        listOf(attributeType),
+					Description: "Характеристики по названию",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						product := p.Source.(models.Product)
+						attributes := make([]attribute, 0, len(product.Attributes))
+						for name, value := range product.Attributes {
+							attributes = append(attributes, attribute{Name: name, Value: value})
+						}
+						sort.Slice(attributes, func(i, j int) bool { return attributes[i].Name < attributes[j].Name })
+						return attributes, nil
+					},
+				},
+				"seller": &graphql.Field{
+					Type:        sellerType,
+					Description: "null, если у товара нет владельца",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						product := p.Source.(models.Product)
+						if product.UserID == nil {
+							return nil, nil
+						}
+						return models.Seller{ID: *product.UserID, Username: product.Username}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	sellerType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Seller",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":       &graphql.Field{Type: nonNull(graphql.Int)},
+				"username": &graphql.Field{Type: nonNull(graphql.String)},
+				"products": &graphql.Field{
+					Type:        listOf(productType),
+					Description: "Одобренные товары продавца",
+					Args:        graphql.FieldConfigArgument{"limit": limitArg},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						seller := p.Source.(models.Seller)
+						return productList(requestFrom(p.Context).sellerProducts, seller.ID, clampLimit(intArg(p, "limit"))), nil
+					},
+				},
+			}
+		}),
+	})
+
+	categoryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Category",
+		Fields: graphql.Fields{
+			"name":         &graphql.Field{Type: nonNull(graphql.String)},
+			"productCount": &graphql.Field{Type: nonNull(graphql.Int), Description: "Число одобренных товаров"},
+			"products": &graphql.Field{
+				Type: listOf(productType),
+				Args: graphql.FieldConfigArgument{"limit": limitArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					category := p.Source.(models.Category)
+					return productList(requestFrom(p.Context).categoryProducts, strings.ToLower(category.Name), clampLimit(intArg(p, "limit"))), nil
+				},
+			},
+		},
+	})
+
+	// productField — товар позиции корзины или заказа; null, если товар удален или скрыт
+	productField := func(id func(source interface{}) int) *graphql.Field {
+		return &graphql.Field{
+			Type:        productType,
+			Description: "Товар в каталоге сейчас; null, если он удален или скрыт",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				productID := id(p.Source)
+				if productID == 0 {
+					return nil, nil
+				}
+				return requestFrom(p.Context).product(productID), nil
+			},
+		}
+	}
+
+	productPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ProductPage",
+		Fields: graphql.Fields{
+			"products":   &graphql.Field{Type: listOf(productType)},
+			"page":       &graphql.Field{Type: nonNull(graphql.Int)},
+			"limit":      &graphql.Field{Type: nonNull(graphql.Int)},
+			"totalPages": &graphql.Field{Type: nonNull(graphql.Int)},
+			"total":      &graphql.Field{Type: nonNull(graphql.Int)},
+		},
+	})
+
+	cartItemType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CartItem",
+		Fields: graphql.Fields{
+			"id":            &graphql.Field{Type: nonNull(graphql.Int)},
+			"productId":     &graphql.Field{Type: nonNull(graphql.Int)},
+			"name":          &graphql.Field{Type: nonNull(graphql.String)},
+			"price":         &graphql.Field{Type: nonNull(Money), Description: "Цена с учетом действующей акции"},
+			"originalPrice": &graphql.Field{Type: nonNull(Money)},
+			"quantity":      &graphql.Field{Type: nonNull(graphql.Int)},
+			"weight":        &graphql.Field{Type: nonNull(graphql.Int)},
+			"image":         &graphql.Field{Type: nonNull(graphql.String)},
+			"sale":          &graphql.Field{Type: saleType},
+			"product": productField(func(source interface{}) int {
+				return source.(models.CartItem).ProductID
+			}),
+		},
+	})
+
+	cartType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Cart",
+		Fields: graphql.Fields{
+			"items": &graphql.Field{
+				Type: listOf(cartItemType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nonNilSlice(p.Source.(*service.Cart).Items), nil
+				},
+			},
+			"subtotal": &graphql.Field{Type: nonNull(Money)},
+			"tax":      &graphql.Field{Type: nonNull(Money)},
+			"total":    &graphql.Field{Type: nonNull(Money), Description: "Сумма товаров и налогов сверх цен, без доставки"},
+			"currency": &graphql.Field{Type: nonNull(graphql.String)},
+			"weight":   &graphql.Field{Type: nonNull(graphql.Int), Description: "Вес корзины в граммах"},
+			"count":    &graphql.Field{Type: nonNull(graphql.Int)},
+		},
+	})
+
+	orderItemType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "OrderItem",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: nonNull(graphql.Int)},
+			"productId": &graphql.Field{Type: nonNull(graphql.Int), Description: "0, если товар удален из каталога"},
+			"name":      &graphql.Field{Type: nonNull(graphql.String)},
+			"quantity":  &graphql.Field{Type: nonNull(graphql.Int)},
+			"price": &graphql.Field{
+				Type:        nonNull(Money),
+				Description: "Цена на момент оформления",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(models.OrderItem).PriceAtTime, nil
+				},
+			},
+			"product": productField(func(source interface{}) int {
+				return source.(models.OrderItem).ProductID
+			}),
+		},
+	})
+
+	orderType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Order",
+		Fields: graphql.Fields{
+			"id":     &graphql.Field{Type: nonNull(graphql.Int)},
+			"status": &graphql.Field{Type: nonNull(graphql.String)},
+			"items": &graphql.Field{
+				Type: listOf(orderItemType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nonNilSlice(p.Source.(models.Order).Items), nil
+				},
+			},
+			"subtotal":  &graphql.Field{Type: nonNull(Money)},
+			"tax":       &graphql.Field{Type: nonNull(Money)},
+			"total":     &graphql.Field{Type: nonNull(Money)},
+			"refunded":  &graphql.Field{Type: nonNull(Money)},
+			"currency":  &graphql.Field{Type: nonNull(graphql.String)},
+			"createdAt": &graphql.Field{Type: nonNull(graphql.DateTime)},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"products": &graphql.Field{
+				Type:        nonNull(productPageType),
+				Description: "Страница каталога",
+				Args: graphql.FieldConfigArgument{
+					"page":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"limit": limitArg,
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					page, err := r.service.GetProducts(intArg(p, "page"), intArg(p, "limit"), r.currency)
+					if err != nil {
+						return nil, err
+					}
+					page.Products = nonNilSlice(page.Products)
+					return page, nil
+				},
+			},
+			"product": &graphql.Field{
+				Type:        productType,
+				Description: "Товар каталога; скрытые видны только в myProducts и pendingProducts",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: nonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					product, err := r.service.GetProduct(p.Args["id"].(int), r.currency)
+					if err != nil {
+						return nil, err
+					}
+					if !product.IsApproved {
+						return nil, service.ErrProductNotFound
+					}
+					return *product, nil
+				},
+			},
+			"categories": &graphql.Field{
+				Type: listOf(categoryType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					categories, err := requestFrom(p.Context).service.GetCategories()
+					return nonNilSlice(categories), err
+				},
+			},
+			"category": &graphql.Field{
+				Type:        categoryType,
+				Description: "Категория по названию без учета регистра; null, если такой нет",
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: nonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					categories, err := requestFrom(p.Context).service.GetCategories()
+					if err != nil {
+						return nil, err
+					}
+					for _, c := range categories {
+						if strings.EqualFold(c.Name, p.Args["name"].(string)) {
+							return c, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+			"seller": &graphql.Field{
+				Type: sellerType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: nonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					seller, err := requestFrom(p.Context).service.GetSeller(p.Args["id"].(int))
+					if err != nil {
+						return nil, err
+					}
+					return *seller, nil
+				},
+			},
+			"cart": &graphql.Field{
+				Type:        nonNull(cartType),
+				Description: "Корзина вошедшего пользователя",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					userID, err := r.user()
+					if err != nil {
+						return nil, err
+					}
+					return r.service.GetCart(userID, r.currency, 0, 0)
+				},
+			},
+			"orders": &graphql.Field{
+				Type:        listOf(orderType),
+				Description: "Заказы вошедшего пользователя",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					userID, err := r.user()
+					if err != nil {
+						return nil, err
+					}
+					orders, err := r.service.GetOrders(userID, r.currency)
+					return nonNilSlice(orders), err
+				},
+			},
+			"order": &graphql.Field{
+				Type: orderType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: nonNull(graphql.Int)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					userID, err := r.user()
+					if err != nil {
+						return nil, err
+					}
+					order, err := r.service.GetOrder(userID, p.Args["id"].(int), r.currency)
+					if err != nil {
+						return nil, err
+					}
+					return *order, nil
+				},
+			},
+			"myProducts": &graphql.Field{
+				Type:        listOf(productType),
+				Description: "Товары продавца, в том числе не прошедшие модерацию",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					userID, err := r.user(models.RoleSeller, models.RoleAdmin)
+					if err != nil {
+						return nil, err
+					}
+					products, err := r.service.GetMyProducts(userID)
+					return nonNilSlice(products), err
+				},
+			},
+			"pendingProducts": &graphql.Field{
+				Type:        listOf(productType),
+				Description: "Товары на модерации; только для администратора",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					r := requestFrom(p.Context)
+					if _, err := r.user(models.RoleAdmin); err != nil {
+						return nil, err
+					}
+					products, err := r.service.GetPendingProducts()
+					return nonNilSlice(products), err
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// intArg возвращает необязательный числовой аргумент; явный null дает 0
+func intArg(p graphql.ResolveParams, name string) int {
+	n, _ := p.Args[name].(int)
+	return n
+}
+
+// nonNilSlice заменяет nil пустым срезом: списки схемы не бывают null
+func nonNilSlice[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
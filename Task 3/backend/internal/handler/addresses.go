@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetAddresses(c echo.Context) error {
+	addresses, err := h.service.GetAddresses(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AddressListResponse{Success: true, Data: addresses})
+}
+
+func (h *Handler) CreateAddress(c echo.Context) error {
+	var req models.AddressRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	address, err := h.service.CreateAddress(getUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, AddressResponse{Success: true, Data: *address})
+}
+
+func (h *Handler) UpdateAddress(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.AddressRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	address, err := h.service.UpdateAddress(getUserID(c), id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AddressResponse{Success: true, Data: *address})
+}
+
+func (h *Handler) DeleteAddress(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteAddress(getUserID(c), id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.address_deleted", nil)})
+}
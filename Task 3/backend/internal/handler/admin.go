@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/i18n"
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetAllUsers(c echo.Context) error {
+	filter := models.UserFilter{
+		Query: strings.TrimSpace(c.QueryParam("q")),
+		Role:  c.QueryParam("role"),
+	}
+	var err error
+	if filter.Active, err = boolParam(c, "active"); err != nil {
+		return err
+	}
+	if filter.Protected, err = boolParam(c, "protected"); err != nil {
+		return err
+	}
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	users, err := h.service.GetAllUsers(filter, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UserPageResponse{Success: true, Data: *users})
+}
+
+func (h *Handler) GetUserDetail(c echo.Context) error {
+	userID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	detail, err := h.service.GetUserDetail(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UserDetailResponse{Success: true, Data: *detail})
+}
+
+// BulkUpdateUsers отвечает 200, даже если часть пользователей изменить не удалось:
+// итог по каждому — в data
+func (h *Handler) BulkUpdateUsers(c echo.Context) error {
+	var req models.BulkUserRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	outcomes, err := h.service.BulkUpdateUsers(getActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	lang := language(c)
+	results := make([]models.BulkUserResult, len(outcomes))
+	updated := 0
+	for i, outcome := range outcomes {
+		results[i] = models.BulkUserResult{UserID: outcome.UserID, Success: outcome.Err == nil}
+		if outcome.Err == nil {
+			updated++
+			continue
+		}
+		_, appErr := classify(outcome.Err)
+		results[i].Code = appErr.Code
+		results[i].Error = i18n.T(lang, appErr.Code, appErr.Params)
+	}
+
+	return c.JSON(http.StatusOK, BulkUsersResponse{
+		Success: updated == len(results),
+		Message: message(c, "msg.bulk_users", apperr.Params{"updated": updated, "total": len(results)}),
+		Data:    results,
+	})
+}
+
+// boolParam разбирает необязательный логический query-параметр
+func boolParam(c echo.Context, name string) (*bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, apperr.Validation([]apperr.FieldError{{Field: name, Code: "boolean"}})
+	}
+	return &b, nil
+}
+
+func (h *Handler) UpdateUserRole(c echo.Context) error {
+	userID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateRoleRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	change, err := h.service.UpdateUserRole(getActor(c), userID, req.Role)
+	if err != nil {
+		return err
+	}
+
+	user := change.User
+
+	// Если пользователь меняет свою роль, возвращаем новый токен
+	if change.ChangedSelf {
+		return c.JSON(http.StatusOK, SelfRoleChangeResponse{
+			Success: true,
+			Message: message(c, "msg.role_updated_self", nil),
+			Data: SelfRoleChange{
+				NewToken: change.NewToken,
+				User: RoleChangeUser{
+					ID:       user.ID,
+					Username: user.Username,
+					Email:    user.Email,
+					Role:     user.Role,
+					IsActive: user.IsActive,
+				},
+			},
+		})
+	}
+
+	roleName := message(c, "role."+user.Role, nil)
+	return c.JSON(http.StatusOK, RoleChangeResponse{
+		Success: true,
+		Message: message(c, "msg.role_updated", apperr.Params{
+			"username": user.Username,
+			"role":     roleName,
+		}),
+		Data: RoleChange{
+			UserID:   user.ID,
+			Username: user.Username,
+			NewRole:  user.Role,
+			RoleName: roleName,
+		},
+	})
+}
+
+func (h *Handler) ToggleUserActive(c echo.Context) error {
+	userID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	isActive, err := h.service.ToggleUserActive(getActor(c), userID)
+	if err != nil {
+		return err
+	}
+
+	key := "msg.user_blocked"
+	if isActive {
+		key = "msg.user_unblocked"
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, key, nil)})
+}
+
+func (h *Handler) GetPendingProducts(c echo.Context) error {
+	products, err := h.service.GetPendingProducts()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ProductListResponse{Success: true, Data: products})
+}
+
+func (h *Handler) ApproveProduct(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.ApproveProduct(productID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.product_approved", nil)})
+}
+
+func (h *Handler) ForceDeleteProduct(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.ForceDeleteProduct(productID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.product_force_deleted", nil)})
+}
+
+func (h *Handler) GetAdminAnalytics(c echo.Context) error {
+	return h.respondAnalytics(c, 0)
+}
+
+// respondAnalytics отдает отчет за период из query-параметров from, to и top
+func (h *Handler) respondAnalytics(c echo.Context, sellerID int) error {
+	top, _ := strconv.Atoi(c.QueryParam("top"))
+
+	report, err := h.service.GetAnalytics(sellerID, c.QueryParam("from"), c.QueryParam("to"), top)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AnalyticsResponse{Success: true, Data: *report})
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) Register(c echo.Context) error {
+	var req models.RegisterRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user, token, err := h.service.Register(req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, authResponse(user, token))
+}
+
+func (h *Handler) Login(c echo.Context) error {
+	var req models.LoginRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	client := models.ClientInfo{IP: c.RealIP(), UserAgent: c.Request().UserAgent()}
+	user, token, err := h.service.Login(req, client)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, authResponse(user, token))
+}
+
+func (h *Handler) GetProfile(c echo.Context) error {
+	user, err := h.service.GetProfile(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ProfileResponse{Success: true, Data: *user})
+}
+
+func (h *Handler) UpdateProfile(c echo.Context) error {
+	var req models.ProfileRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user, token, err := h.service.UpdateProfile(getUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, authResponse(user, token))
+}
+
+func (h *Handler) ConfirmEmail(c echo.Context) error {
+	var req models.EmailConfirmRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.service.ConfirmEmail(req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ProfileResponse{Success: true, Data: *user})
+}
+
+// ChangePassword возвращает новый токен: все остальные сессии после смены пароля отзываются
+func (h *Handler) ChangePassword(c echo.Context) error {
+	var req models.PasswordChangeRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	user, token, err := h.service.ChangePassword(getUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, authResponse(user, token))
+}
+
+func (h *Handler) DeleteAccount(c echo.Context) error {
+	var req models.DeleteAccountRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteAccount(getUserID(c), req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.account_deleted", nil)})
+}
+
+func authResponse(user *models.User, token string) AuthResponse {
+	return AuthResponse{
+		Success: true,
+		Data: AuthData{
+			Token: token,
+			User: AuthUser{
+				ID:           user.ID,
+				Username:     user.Username,
+				Email:        user.Email,
+				Role:         user.Role,
+				PendingEmail: user.PendingEmail,
+			},
+		},
+	}
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// notModified ставит заголовок ETag и сообщает, есть ли он уже у клиента (If-None-Match).
+// Сравнение слабое, как требует RFC 9110 для If-None-Match.
+func notModified(c echo.Context, etag string) bool {
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	// Клиент может хранить ответ, но перед использованием должен его перепроверить
+	header.Set(echo.HeaderCacheControl, "no-cache")
+
+	ifNoneMatch := c.Request().Header.Get("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetCart(c echo.Context) error {
+	// delivery_method добавляет к корзине расчет доставки, address — налоги региона доставки
+	methodID, _ := strconv.Atoi(c.QueryParam("delivery_method"))
+	addressID, _ := strconv.Atoi(c.QueryParam("address"))
+
+	cart, err := h.service.GetCart(getUserID(c), c.QueryParam("currency"), methodID, addressID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, CartResponse{Success: true, Data: *cart})
+}
+
+func (h *Handler) AddToCart(c echo.Context) error {
+	var req models.AddToCartRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.AddToCart(getUserID(c), req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.cart_added", nil)})
+}
+
+func (h *Handler) UpdateCartItem(c echo.Context) error {
+	itemID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.UpdateCartItemRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.UpdateCartItem(getUserID(c), itemID, req.Quantity); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.cart_updated", nil)})
+}
+
+func (h *Handler) RemoveFromCart(c echo.Context) error {
+	itemID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.RemoveFromCart(getUserID(c), itemID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.cart_removed", nil)})
+}
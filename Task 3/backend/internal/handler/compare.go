@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Список сравнения принадлежит пользователю, если передан токен, иначе анонимной
+// сессии из X-Session-ID — так же, как недавно просмотренные товары.
+
+func (h *Handler) GetComparison(c echo.Context) error {
+	comparison, err := h.service.GetComparison(h.viewer(c), c.QueryParam("currency"))
+	if err != nil {
+		return err
+	}
+	return h.comparison(c, comparison)
+}
+
+func (h *Handler) AddToComparison(c echo.Context) error {
+	var req models.CompareRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	comparison, err := h.service.AddToComparison(h.viewer(c), req.ProductID, c.QueryParam("currency"))
+	if err != nil {
+		return err
+	}
+	return h.comparison(c, comparison)
+}
+
+func (h *Handler) RemoveFromComparison(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	comparison, err := h.service.RemoveFromComparison(h.viewer(c), productID, c.QueryParam("currency"))
+	if err != nil {
+		return err
+	}
+	return h.comparison(c, comparison)
+}
+
+func (h *Handler) ClearComparison(c echo.Context) error {
+	if err := h.service.ClearComparison(h.viewer(c)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.compare_cleared", nil)})
+}
+
+// comparison отвечает списком; ответ зависит от токена и сессии, поэтому кэши его не делят
+func (h *Handler) comparison(c echo.Context, comparison *service.Comparison) error {
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAuthorization+", "+HeaderSessionID)
+	return c.JSON(http.StatusOK, ComparisonResponse{Success: true, Data: *comparison})
+}
@@ -0,0 +1,11 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetCurrencies(c echo.Context) error {
+	return c.JSON(http.StatusOK, CurrenciesResponse{Success: true, Data: h.service.GetCurrencies()})
+}
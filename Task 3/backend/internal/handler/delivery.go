@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetDeliveryMethods возвращает включенные способы доставки для оформления заказа
+func (h *Handler) GetDeliveryMethods(c echo.Context) error {
+	methods, err := h.service.GetDeliveryMethods(true)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, DeliveryMethodListResponse{Success: true, Data: methods})
+}
+
+// GetAllDeliveryMethods возвращает администратору и отключенные способы
+func (h *Handler) GetAllDeliveryMethods(c echo.Context) error {
+	methods, err := h.service.GetDeliveryMethods(false)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, DeliveryMethodListResponse{Success: true, Data: methods})
+}
+
+func (h *Handler) CreateDeliveryMethod(c echo.Context) error {
+	var req models.DeliveryMethodRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	method, err := h.service.CreateDeliveryMethod(req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, DeliveryMethodResponse{Success: true, Data: *method})
+}
+
+func (h *Handler) UpdateDeliveryMethod(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.DeliveryMethodRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	method, err := h.service.UpdateDeliveryMethod(id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, DeliveryMethodResponse{Success: true, Data: *method})
+}
+
+func (h *Handler) DeleteDeliveryMethod(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteDeliveryMethod(id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.delivery_method_deleted", nil)})
+}
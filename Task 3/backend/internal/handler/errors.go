@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/i18n"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrUnauthorized = apperr.New(apperr.KindUnauthorized, "unauthorized")
+	ErrInvalidToken = apperr.New(apperr.KindUnauthorized, "invalid_token")
+	ErrForbidden    = apperr.New(apperr.KindForbidden, "forbidden")
+	ErrInvalidID    = apperr.Invalid("invalid_id")
+	ErrInvalidBody  = apperr.Invalid("invalid_body")
+	ErrUploadFailed = apperr.Invalid("upload_failed")
+	ErrExportFormat = apperr.Invalid("export_unsupported_format")
+)
+
+var kindStatuses = map[apperr.Kind]int{
+	apperr.KindInternal:     http.StatusInternalServerError,
+	apperr.KindInvalid:      http.StatusBadRequest,
+	apperr.KindUnauthorized: http.StatusUnauthorized,
+	apperr.KindForbidden:    http.StatusForbidden,
+	apperr.KindNotFound:     http.StatusNotFound,
+	apperr.KindConflict:     http.StatusConflict,
+}
+
+// echoCodes — коды для ошибок, которые возвращает сам echo (маршрутизация, разбор тела)
+var echoCodes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "route_not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusRequestEntityTooLarge: "request_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+}
+
+// ErrorResponse — тело ответа с ошибкой
+type ErrorResponse struct {
+	Success bool                `json:"success"`
+	Code    string              `json:"code"`
+	Error   string              `json:"error"`
+	Details []apperr.FieldError `json:"details,omitempty"`
+	// RequestID совпадает с заголовком X-Request-ID и строкой в журнале сервера
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// HTTPErrorHandler — единая точка превращения ошибок в ответы API.
+// Текст берется из каталога по коду ошибки на языке из Accept-Language;
+// подробности внутренних ошибок пишутся только в журнал.
+func (h *Handler) HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status, appErr := classify(err)
+	if status >= http.StatusInternalServerError {
+		requestLogger(c).Error("Ошибка обработки запроса",
+			"method", c.Request().Method, "path", c.Request().URL.Path, "error", err)
+	}
+
+	lang := language(c)
+	resp := ErrorResponse{
+		Success: false,
+		Code:    appErr.Code,
+		Error:   i18n.T(lang, appErr.Code, appErr.Params),
+		Details: localizeFields(lang, appErr.Fields),
+
+		RequestID: requestID(c),
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = c.JSON(status, resp)
+	}
+	if err != nil {
+		requestLogger(c).Error("Ошибка отправки ответа", "error", err)
+	}
+}
+
+func classify(err error) (int, *apperr.Error) {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		status, ok := kindStatuses[appErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		return status, appErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		if code, ok := echoCodes[httpErr.Code]; ok {
+			return httpErr.Code, &apperr.Error{Code: code}
+		}
+	}
+
+	return http.StatusInternalServerError, apperr.Internal(err)
+}
+
+func language(c echo.Context) i18n.Lang {
+	return i18n.FromAcceptLanguage(c.Request().Header.Get("Accept-Language"))
+}
+
+// message возвращает локализованный текст сообщения об успехе
+func message(c echo.Context, key string, params apperr.Params) string {
+	return i18n.T(language(c), key, params)
+}
+
+// localizeFields заполняет тексты ошибок полей на языке клиента
+func localizeFields(lang i18n.Lang, fields []apperr.FieldError) []apperr.FieldError {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	localized := make([]apperr.FieldError, len(fields))
+	for i, fe := range fields {
+		fe.Message = i18n.T(lang, "field."+fe.Code, fe.Params)
+		localized[i] = fe
+	}
+	return localized
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/graph"
+	"catpc-backend/internal/i18n"
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GraphQL исполняет запрос GraphQL. Маршрут публичный: без токена доступен каталог,
+// с токеном — еще корзина, заказы и товары по роли. Неверный токен отклоняется
+// с 401, как в REST, а не превращает посетителя в гостя.
+// Ошибки полей возвращаются в errors с кодом 200, вместе с тем, что удалось получить.
+func (h *Handler) GraphQL(c echo.Context) error {
+	var viewer graph.Viewer
+	if c.Request().Header.Get(echo.HeaderAuthorization) != "" {
+		claims, err := h.authenticate(c)
+		if err != nil {
+			return err
+		}
+		viewer = graph.Viewer{UserID: claims.UserID, Role: claims.Role}
+	}
+
+	var req models.GraphQLRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	result := h.graph.Execute(c.Request().Context(), req, viewer, c.QueryParam("currency"))
+
+	lang := language(c)
+	resp := GraphQLResponse{Data: result.Data}
+	for _, e := range result.Errors {
+		if e.Err.Kind == apperr.KindInternal {
+			requestLogger(c).Error("Ошибка поля GraphQL", "path", e.Path, "error", e.Err)
+		}
+		resp.Errors = append(resp.Errors, graphQLError(lang, e, requestID(c)))
+	}
+
+	// Ответ зависит от токена, поэтому кэши его не делят
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAuthorization)
+	return c.JSON(http.StatusOK, resp)
+}
+
+func graphQLError(lang i18n.Lang, e graph.Error, requestID string) GraphQLError {
+	gqlErr := GraphQLError{
+		Message:    i18n.T(lang, e.Err.Code, e.Err.Params),
+		Path:       e.Path,
+		Extensions: GraphQLExtensions{Code: e.Err.Code, RequestID: requestID},
+	}
+	for _, l := range e.Locations {
+		gqlErr.Locations = append(gqlErr.Locations, GraphQLLocation{Line: l.Line, Column: l.Column})
+	}
+	return gqlErr
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"catpc-backend/internal/graph"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/service"
+	"catpc-backend/internal/validate"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// HeaderSessionID — идентификатор анонимной сессии, который генерирует клиент
+const HeaderSessionID = "X-Session-ID"
+
+// graphQLBodyLimit ограничивает тело запроса GraphQL: разбор запроса идет до
+// проверки глубины и сложности, и его время растет с размером запроса
+const graphQLBodyLimit = "64K"
+
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9-]{8,64}$`)
+
+type Handler struct {
+	service *service.Service
+	graph   *graph.Schema
+	// draining выставляется при остановке сервера, см. Drain
+	draining atomic.Bool
+}
+
+func NewHandler(service *service.Service) *Handler {
+	schema, err := graph.New(service)
+	if err != nil {
+		panic(fmt.Sprintf("graph: ошибка в описании схемы: %v", err))
+	}
+	return &Handler{service: service, graph: schema}
+}
+
+func (h *Handler) RegisterEndpoints(e *echo.Echo) {
+	e.HTTPErrorHandler = h.HTTPErrorHandler
+
+	e.GET("/healthz", h.Healthz)
+	e.GET("/readyz", h.Readyz)
+	e.GET("/metrics", h.GetMetrics)
+	e.GET("/api/openapi.json", h.GetOpenAPI)
+	e.POST("/api/register", h.Register)
+	e.POST("/api/login", h.Login)
+	e.GET("/api/products", h.GetProducts)
+	e.GET("/api/products/:id", h.GetProductDetail)
+	e.GET("/api/products/:id/price-history", h.GetPriceHistory)
+	e.GET("/api/products/:id/questions", h.GetQuestions)
+	e.GET("/api/currencies", h.GetCurrencies)
+	e.GET("/api/delivery-methods", h.GetDeliveryMethods)
+	e.GET("/api/compare", h.GetComparison)
+	e.POST("/api/compare", h.AddToComparison)
+	e.DELETE("/api/compare", h.ClearComparison)
+	e.DELETE("/api/compare/:id", h.RemoveFromComparison)
+	e.POST("/api/profile/email/confirm", h.ConfirmEmail)
+	e.POST("/api/graphql", h.GraphQL, middleware.BodyLimit(graphQLBodyLimit))
+	e.GET("/api/notifications/stream", h.StreamNotifications, h.StreamAuthMiddleware)
+
+	authGroup := e.Group("/api")
+	authGroup.Use(h.AuthMiddleware)
+
+	authGroup.GET("/profile", h.GetProfile)
+	authGroup.PUT("/profile", h.UpdateProfile)
+	authGroup.PUT("/profile/password", h.ChangePassword)
+	authGroup.DELETE("/profile", h.DeleteAccount)
+	authGroup.GET("/cart", h.GetCart)
+	authGroup.POST("/cart/add", h.AddToCart)
+	authGroup.PUT("/cart/update/:id", h.UpdateCartItem)
+	authGroup.DELETE("/cart/remove/:id", h.RemoveFromCart)
+	authGroup.POST("/upload", h.UploadImage)
+	authGroup.GET("/price-alerts", h.GetPriceAlerts)
+	authGroup.PUT("/products/:id/price-alert", h.SetPriceAlert)
+	authGroup.DELETE("/products/:id/price-alert", h.DeletePriceAlert)
+	authGroup.POST("/products/:id/questions", h.AskQuestion)
+	authGroup.POST("/questions/:id/answers", h.AnswerQuestion)
+	authGroup.PUT("/answers/:id/vote", h.VoteAnswer)
+	authGroup.DELETE("/answers/:id/vote", h.VoteAnswer)
+	authGroup.GET("/addresses", h.GetAddresses)
+	authGroup.POST("/addresses", h.CreateAddress)
+	authGroup.PUT("/addresses/:id", h.UpdateAddress)
+	authGroup.DELETE("/addresses/:id", h.DeleteAddress)
+	authGroup.POST("/orders", h.Checkout)
+	authGroup.GET("/orders", h.GetOrders)
+	authGroup.GET("/orders/:id", h.GetOrder)
+	authGroup.GET("/orders/:id/invoice", h.GetInvoice)
+	authGroup.POST("/orders/:id/returns", h.CreateReturn)
+	authGroup.GET("/returns", h.GetReturns)
+	authGroup.GET("/notifications", h.GetNotifications)
+	authGroup.POST("/notifications/stream-token", h.CreateNotificationStreamToken)
+	authGroup.PUT("/notifications/read", h.MarkAllNotificationsRead)
+	authGroup.PUT("/notifications/:id/read", h.MarkNotificationRead)
+
+	sellerGroup := authGroup.Group("/seller")
+	sellerGroup.Use(RequireRole("seller", "admin"))
+
+	sellerGroup.GET("/my-products", h.GetMyProducts)
+	sellerGroup.POST("/products", h.CreateProduct)
+	sellerGroup.PUT("/products/:id", h.UpdateProduct)
+	sellerGroup.DELETE("/products/:id", h.DeleteProduct)
+	sellerGroup.POST("/products/import", h.ImportProducts)
+	sellerGroup.GET("/products/export", h.ExportProducts)
+	sellerGroup.GET("/analytics", h.GetSellerAnalytics)
+	sellerGroup.GET("/returns", h.GetSellerReturns)
+	sellerGroup.PUT("/returns/:id/status", h.UpdateReturnStatus)
+	sellerGroup.GET("/sales", h.GetSales)
+	sellerGroup.POST("/sales", h.CreateSale)
+	sellerGroup.DELETE("/sales/:id", h.CancelSale)
+
+	adminGroup := authGroup.Group("/admin")
+	adminGroup.Use(RequireRole("admin"))
+
+	adminGroup.GET("/users", h.GetAllUsers)
+	adminGroup.POST("/users/bulk", h.BulkUpdateUsers)
+	adminGroup.GET("/users/:id", h.GetUserDetail)
+	adminGroup.PUT("/users/:id/role", h.UpdateUserRole)
+	adminGroup.PUT("/users/:id/active", h.ToggleUserActive)
+	adminGroup.GET("/pending-products", h.GetPendingProducts)
+	adminGroup.PUT("/products/:id/approve", h.ApproveProduct)
+	adminGroup.DELETE("/products/:id/force", h.ForceDeleteProduct)
+	adminGroup.GET("/analytics", h.GetAdminAnalytics)
+	adminGroup.PUT("/orders/:id/status", h.UpdateOrderStatus)
+	adminGroup.GET("/moderation", h.GetModerationQueue)
+	adminGroup.PUT("/questions/:id/status", h.ModerateQuestion)
+	adminGroup.PUT("/answers/:id/status", h.ModerateAnswer)
+	adminGroup.GET("/delivery-methods", h.GetAllDeliveryMethods)
+	adminGroup.POST("/delivery-methods", h.CreateDeliveryMethod)
+	adminGroup.PUT("/delivery-methods/:id", h.UpdateDeliveryMethod)
+	adminGroup.DELETE("/delivery-methods/:id", h.DeleteDeliveryMethod)
+	adminGroup.GET("/tax-rates", h.GetTaxRates)
+	adminGroup.POST("/tax-rates", h.CreateTaxRate)
+	adminGroup.PUT("/tax-rates/:id", h.UpdateTaxRate)
+	adminGroup.DELETE("/tax-rates/:id", h.DeleteTaxRate)
+}
+
+func (h *Handler) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		claims, err := h.authenticate(c)
+		if err != nil {
+			return err
+		}
+
+		setClaims(c, claims)
+		return next(c)
+	}
+}
+
+// StreamAuthMiddleware пускает в поток уведомлений по токену потока из параметра
+// token — EventSource не умеет передавать заголовки — или по заголовку Authorization
+func (h *Handler) StreamAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := c.QueryParam("token")
+		if token == "" {
+			return h.AuthMiddleware(next)(c)
+		}
+
+		claims, err := h.service.ValidateStreamToken(token)
+		if err != nil {
+			return ErrInvalidToken
+		}
+		if err := h.service.CheckSession(claims); err != nil {
+			return err
+		}
+
+		setClaims(c, claims)
+		return next(c)
+	}
+}
+
+func setClaims(c echo.Context, claims *models.JWTClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("claims", claims)
+}
+
+// authenticate проверяет токен из заголовка Authorization и сессию, к которой он выдан
+func (h *Handler) authenticate(c echo.Context) (*models.JWTClaims, error) {
+	authHeader := c.Request().Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, ErrUnauthorized
+	}
+
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	claims, err := h.service.ValidateJWT(tokenString)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if err := h.service.CheckSession(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userRole := getRole(c)
+
+			for _, role := range roles {
+				if userRole == role {
+					return next(c)
+				}
+			}
+
+			return ErrForbidden
+		}
+	}
+}
+
+func getUserID(c echo.Context) int {
+	return c.Get("user_id").(int)
+}
+
+func getRole(c echo.Context) string {
+	return c.Get("role").(string)
+}
+
+func getActor(c echo.Context) service.Actor {
+	return service.Actor{
+		ID:       getUserID(c),
+		Username: c.Get("username").(string),
+		Role:     getRole(c),
+	}
+}
+
+// pathID разбирает числовой параметр :id из пути
+func pathID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return 0, ErrInvalidID
+	}
+	return id, nil
+}
+
+// bind читает тело запроса или форму и проверяет поля по тегам validate
+func bind(c echo.Context, req interface{}) error {
+	if err := c.Bind(req); err != nil {
+		return ErrInvalidBody.Wrap(err)
+	}
+	return validate.Struct(req)
+}
@@ -0,0 +1,3354 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/config"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+	"catpc-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type testEnv struct {
+	t    *testing.T
+	e    *echo.Echo
+	repo *repository.MemoryRepository
+	svc  *service.Service
+	cfg  *config.Config
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.UploadDir = t.TempDir()
+
+	repo := repository.NewMemoryRepository()
+	svc := service.NewService(repo, cfg)
+
+	e := echo.New()
+	NewHandler(svc).RegisterEndpoints(e)
+
+	return &testEnv{t: t, e: e, repo: repo, svc: svc, cfg: cfg}
+}
+
+// user создает пользователя с паролем "password" и возвращает его вместе с токеном
+func (env *testEnv) user(username, role string) (*models.User, string) {
+	env.t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		env.t.Fatal(err)
+	}
+
+	user := &models.User{
+		Username:     username,
+		Email:        username + "@example.com",
+		Role:         role,
+		PasswordHash: string(hash),
+	}
+	if err := env.repo.CreateUser(user); err != nil {
+		env.t.Fatal(err)
+	}
+
+	token, err := env.svc.GenerateJWT(user)
+	if err != nil {
+		env.t.Fatal(err)
+	}
+	return user, token
+}
+
+func (env *testEnv) product(ownerID int, name string, price int64, stock int, approved bool) *models.Product {
+	env.t.Helper()
+
+	product := &models.Product{
+		Name:       name,
+		Price:      money.FromInt(price),
+		Stock:      stock,
+		Image:      "default.png",
+		UserID:     &ownerID,
+		IsApproved: approved,
+	}
+	if err := env.repo.CreateProduct(product); err != nil {
+		env.t.Fatal(err)
+	}
+	return product
+}
+
+type response struct {
+	Status  int
+	Success bool                `json:"success"`
+	Code    string              `json:"code"`
+	Error   string              `json:"error"`
+	Details []apperr.FieldError `json:"details"`
+	Message string              `json:"message"`
+	Data    json.RawMessage     `json:"data"`
+}
+
+func (env *testEnv) do(method, path, token string, body interface{}) response {
+	env.t.Helper()
+
+	var reader *bytes.Reader
+	contentType := echo.MIMEApplicationJSON
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+		contentType = "text/csv"
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			env.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set(echo.HeaderContentType, contentType)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	env.e.ServeHTTP(rec, req)
+
+	resp := response{Status: rec.Code}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		env.t.Fatalf("%s %s: invalid JSON %q: %v", method, path, rec.Body.String(), err)
+	}
+	return resp
+}
+
+func decode[T any](t *testing.T, raw json.RawMessage) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(raw, &v); err != nil {
+		t.Fatalf("decode %s: %v", raw, err)
+	}
+	return v
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	env := newTestEnv(t)
+	_, _ = env.user("existing", models.RoleCustomer)
+	blocked, _ := env.user("blocked", models.RoleCustomer)
+	env.repo.ToggleUserActive(blocked.ID)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   map[string]string
+		status int
+		error  string
+	}{
+		{"register missing fields", "/api/register", map[string]string{"username": "new"}, http.StatusBadRequest, "Проверьте введенные данные"},
+		{"register invalid email", "/api/register", map[string]string{"username": "new", "email": "new", "password": "secret"}, http.StatusBadRequest, "Проверьте введенные данные"},
+		{"register duplicate username", "/api/register", map[string]string{"username": "existing", "email": "x@example.com", "password": "secret"}, http.StatusConflict, "Пользователь уже существует"},
+		{"register duplicate email", "/api/register", map[string]string{"username": "other", "email": "existing@example.com", "password": "secret"}, http.StatusConflict, "Пользователь уже существует"},
+		{"register", "/api/register", map[string]string{"username": "new", "email": "new@example.com", "password": "secret"}, http.StatusCreated, ""},
+		{"login unknown user", "/api/login", map[string]string{"username": "nobody", "password": "p"}, http.StatusUnauthorized, "Неверный логин или пароль"},
+		{"login wrong password", "/api/login", map[string]string{"username": "existing", "password": "wrong"}, http.StatusUnauthorized, "Неверный логин или пароль"},
+		{"login blocked", "/api/login", map[string]string{"username": "blocked", "password": "password"}, http.StatusForbidden, "Аккаунт заблокирован"},
+		{"login by username", "/api/login", map[string]string{"username": "existing", "password": "password"}, http.StatusOK, ""},
+		{"login by email", "/api/login", map[string]string{"username": "existing@example.com", "password": "password"}, http.StatusOK, ""},
+		{"login registered user", "/api/login", map[string]string{"username": "new", "password": "secret"}, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPost, tt.path, "", tt.body)
+			if resp.Status != tt.status || resp.Error != tt.error {
+				t.Fatalf("got %d %q, want %d %q", resp.Status, resp.Error, tt.status, tt.error)
+			}
+
+			if tt.status/100 == 2 {
+				data := decode[struct {
+					Token string `json:"token"`
+					User  struct {
+						Role string `json:"role"`
+					} `json:"user"`
+				}](t, resp.Data)
+				if data.Token == "" || data.User.Role == "" {
+					t.Fatalf("missing token or user in %s", resp.Data)
+				}
+			}
+		})
+	}
+}
+
+// recordingMailer запоминает отправленные письма вместо отправки
+type recordingMailer struct {
+	to, body []string
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.to = append(m.to, to)
+	m.body = append(m.body, body)
+	return nil
+}
+
+func TestAccount(t *testing.T) {
+	env := newTestEnv(t)
+	mailer := &recordingMailer{}
+	env.svc.Mailer = mailer
+
+	user, token := env.user("user", models.RoleCustomer)
+	env.user("other", models.RoleCustomer)
+	login := func(name, password string) response {
+		return env.do(http.MethodPost, "/api/login", "", models.LoginRequest{Username: name, Password: password})
+	}
+
+	// Новый email вступает в силу только после подтверждения
+	resp := env.do(http.MethodPut, "/api/profile", token, models.ProfileRequest{Username: "renamed", Email: "new@example.com"})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("update profile: %d %s", resp.Status, resp.Code)
+	}
+	updated := decode[AuthData](t, resp.Data)
+	if updated.User.Username != "renamed" || updated.User.Email != "user@example.com" || updated.User.PendingEmail != "new@example.com" {
+		t.Errorf("updated %+v", updated.User)
+	}
+	if claims, err := env.svc.ValidateJWT(updated.Token); err != nil || claims.Username != "renamed" {
+		t.Errorf("new token: %+v %v", claims, err)
+	}
+	if len(mailer.to) != 1 || mailer.to[0] != "new@example.com" {
+		t.Fatalf("mail sent to %v", mailer.to)
+	}
+	if resp := login("new@example.com", "password"); resp.Status != http.StatusUnauthorized {
+		t.Errorf("login with unconfirmed email: %d", resp.Status)
+	}
+	if resp := env.do(http.MethodPut, "/api/profile", token, models.ProfileRequest{Username: "other", Email: "user@example.com"}); resp.Code != "user_exists" {
+		t.Errorf("taken username: %d %q", resp.Status, resp.Code)
+	}
+
+	pending, _ := env.repo.GetUserByID(user.ID)
+	if !strings.Contains(mailer.body[0], pending.EmailToken) {
+		t.Fatalf("mail %q has no token", mailer.body[0])
+	}
+
+	// Просроченный код не принимается; повторное сохранение email выдает новый
+	expired := pending.EmailToken
+	pending.EmailTokenExpiresAt = time.Now().Add(-time.Minute)
+	env.repo.UpdateUser(pending)
+	if resp := env.do(http.MethodPost, "/api/profile/email/confirm", "", models.EmailConfirmRequest{Token: expired}); resp.Code != "email_token_expired" {
+		t.Errorf("expired token: %d %q", resp.Status, resp.Code)
+	}
+	if resp := env.do(http.MethodPut, "/api/profile", token, models.ProfileRequest{Username: "renamed", Email: "new@example.com"}); resp.Status != http.StatusOK {
+		t.Fatalf("resend code: %d %s", resp.Status, resp.Code)
+	}
+	pending, _ = env.repo.GetUserByID(user.ID)
+	if pending.EmailToken == expired || len(mailer.body) != 2 || !strings.Contains(mailer.body[1], pending.EmailToken) {
+		t.Fatalf("new code not sent: %v", mailer.body)
+	}
+	resp = env.do(http.MethodPost, "/api/profile/email/confirm", "", models.EmailConfirmRequest{Token: pending.EmailToken})
+	if profile := decode[models.User](t, resp.Data); profile.Email != "new@example.com" || profile.PendingEmail != "" {
+		t.Errorf("confirmed %+v", profile)
+	}
+	if resp := login("new@example.com", "password"); resp.Status != http.StatusOK {
+		t.Errorf("login with confirmed email: %d %s", resp.Status, resp.Code)
+	}
+
+	// Смена пароля отзывает остальные токены
+	resp = env.do(http.MethodPut, "/api/profile/password", token, models.PasswordChangeRequest{CurrentPassword: "password", NewPassword: "new-secret"})
+	if resp.Status != http.StatusOK {
+		t.Fatalf("change password: %d %s", resp.Status, resp.Code)
+	}
+	fresh := decode[AuthData](t, resp.Data).Token
+	if resp := env.do(http.MethodGet, "/api/profile", token, nil); resp.Code != "session_revoked" {
+		t.Errorf("old token: %d %q", resp.Status, resp.Code)
+	}
+	if resp := env.do(http.MethodGet, "/api/profile", updated.Token, nil); resp.Code != "session_revoked" {
+		t.Errorf("token from profile update: %d %q", resp.Status, resp.Code)
+	}
+	if resp := env.do(http.MethodGet, "/api/profile", fresh, nil); resp.Status != http.StatusOK {
+		t.Errorf("new token: %d %q", resp.Status, resp.Code)
+	}
+	if login("renamed", "password").Status != http.StatusUnauthorized || login("renamed", "new-secret").Status != http.StatusOK {
+		t.Error("login does not use the new password")
+	}
+
+	// Защищенный аккаунт нельзя переименовать и удалить
+	protected, protectedToken := env.user("protected", models.RoleAdmin)
+	env.repo.SetUserProtected(protected.ID, true)
+	if resp := env.do(http.MethodPut, "/api/profile", protectedToken, models.ProfileRequest{Username: "boss", Email: "protected@example.com"}); resp.Code != "protected_user_rename" {
+		t.Errorf("rename protected: %d %q", resp.Status, resp.Code)
+	}
+	if resp := env.do(http.MethodDelete, "/api/profile", protectedToken, models.DeleteAccountRequest{Password: "password"}); resp.Code != "protected_user_delete" {
+		t.Errorf("delete protected: %d %q", resp.Status, resp.Code)
+	}
+
+	// Удаление обезличивает продавца, снимает его товары и сохраняет заказы
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	gpu := env.product(seller.ID, "GPU", 1000, 10, true)
+	env.repo.AddOrder(models.Order{
+		UserID: seller.ID,
+		Status: models.OrderPaid,
+		Items:  []models.OrderItem{{ProductID: gpu.ID, Name: "GPU", Quantity: 1, PriceAtTime: money.FromInt(1000)}},
+	})
+	env.repo.AddCartItem(seller.ID, gpu.ID, 1)
+	if login("seller", "password").Status != http.StatusOK {
+		t.Fatal("seller login failed")
+	}
+
+	if resp := env.do(http.MethodDelete, "/api/profile", sellerToken, models.DeleteAccountRequest{Password: "password"}); resp.Status != http.StatusOK {
+		t.Fatalf("delete: %d %s", resp.Status, resp.Code)
+	}
+	deleted, _ := env.repo.GetUserByID(seller.ID)
+	if deleted.Username != fmt.Sprintf("deleted-%d", seller.ID) || deleted.Email == "seller@example.com" || deleted.IsActive {
+		t.Errorf("deleted user %+v", deleted)
+	}
+	if order, err := env.repo.GetOrderByID(1); err != nil || order.UserID != seller.ID {
+		t.Errorf("order after delete: %+v %v", order, err)
+	}
+	if items, _ := env.repo.GetCartItems(seller.ID); len(items) != 0 {
+		t.Errorf("cart after delete: %+v", items)
+	}
+	if logins, _ := env.repo.ListLogins(seller.ID, 10); len(logins) != 0 {
+		t.Errorf("login history after delete: %+v", logins)
+	}
+	if p, _ := env.repo.GetProductByID(gpu.ID); p.IsApproved {
+		t.Error("product of deleted seller is still on sale")
+	}
+	if resp := env.do(http.MethodGet, "/api/profile", sellerToken, nil); resp.Code != "session_revoked" {
+		t.Errorf("token after delete: %d %q", resp.Status, resp.Code)
+	}
+	if login("seller", "password").Status != http.StatusUnauthorized {
+		t.Error("deleted user can log in")
+	}
+	if resp := env.do(http.MethodPost, "/api/register", "", models.RegisterRequest{Username: "seller", Email: "seller@example.com", Password: "secret"}); resp.Status != http.StatusCreated {
+		t.Errorf("register with freed name: %d %s", resp.Status, resp.Code)
+	}
+}
+
+func TestAuthAndRoles(t *testing.T) {
+	env := newTestEnv(t)
+	_, customer := env.user("customer", models.RoleCustomer)
+	_, seller := env.user("seller", models.RoleSeller)
+	_, admin := env.user("admin", models.RoleAdmin)
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"no token", "/api/profile", "", http.StatusUnauthorized},
+		{"bad token", "/api/profile", "garbage", http.StatusUnauthorized},
+		{"profile", "/api/profile", customer, http.StatusOK},
+		{"customer on seller route", "/api/seller/my-products", customer, http.StatusForbidden},
+		{"seller on seller route", "/api/seller/my-products", seller, http.StatusOK},
+		{"admin on seller route", "/api/seller/my-products", admin, http.StatusOK},
+		{"seller on admin route", "/api/admin/users", seller, http.StatusForbidden},
+		{"admin on admin route", "/api/admin/users", admin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodGet, tt.path, tt.token, nil)
+			if resp.Status != tt.status {
+				t.Fatalf("got %d (%s), want %d", resp.Status, resp.Error, tt.status)
+			}
+		})
+	}
+}
+
+func TestGetProducts(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	for i := 1; i <= 5; i++ {
+		env.product(seller.ID, fmt.Sprintf("Товар %d", i), 100, 1, true)
+	}
+	hidden := env.product(seller.ID, "Скрытый", 100, 1, false)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantIDs   []int
+		wantPage  int
+		wantLimit int
+		wantPages int
+		wantTotal int
+	}{
+		{"defaults", "", []int{1, 2, 3, 4, 5}, 1, 10, 1, 5},
+		{"first page", "?page=1&limit=2", []int{1, 2}, 1, 2, 3, 5},
+		{"last page", "?page=3&limit=2", []int{5}, 3, 2, 3, 5},
+		{"limit out of range", "?limit=1000", []int{1, 2, 3, 4, 5}, 1, 10, 1, 5},
+		{"page below one", "?page=-1&limit=2", []int{1, 2}, 1, 2, 3, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodGet, "/api/products"+tt.query, "", nil)
+			if resp.Status != http.StatusOK {
+				t.Fatalf("status %d", resp.Status)
+			}
+
+			page := decode[service.ProductPage](t, resp.Data)
+			var ids []int
+			for _, p := range page.Products {
+				ids = append(ids, p.ID)
+				if p.Username != "seller" {
+					t.Errorf("product %d username %q", p.ID, p.Username)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantIDs) {
+				t.Errorf("ids %v, want %v", ids, tt.wantIDs)
+			}
+			if page.Page != tt.wantPage || page.Limit != tt.wantLimit || page.TotalPages != tt.wantPages || page.Total != tt.wantTotal {
+				t.Errorf("page=%d limit=%d pages=%d total=%d", page.Page, page.Limit, page.TotalPages, page.Total)
+			}
+		})
+	}
+
+	detailTests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"detail", "/api/products/1", http.StatusOK},
+		{"detail of unapproved product", fmt.Sprintf("/api/products/%d", hidden.ID), http.StatusOK},
+		{"detail not found", "/api/products/999", http.StatusNotFound},
+		{"detail bad id", "/api/products/abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range detailTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := env.do(http.MethodGet, tt.path, "", nil); resp.Status != tt.status {
+				t.Fatalf("got %d, want %d", resp.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestProductsCursor(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	for i := 1; i <= 5; i++ {
+		env.product(seller.ID, fmt.Sprintf("Товар %d", i), 100, 1, i != 3)
+	}
+
+	var ids []int
+	var pages int
+	cursor := ""
+	for {
+		resp := env.do(http.MethodGet, "/api/products?limit=2&cursor="+cursor, "", nil)
+		if resp.Status != http.StatusOK {
+			t.Fatalf("status %d: %s", resp.Status, resp.Error)
+		}
+
+		page := decode[service.ProductCursorPage](t, resp.Data)
+		pages++
+		for _, p := range page.Products {
+			ids = append(ids, p.ID)
+		}
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Errorf("next_cursor %q on the last page", page.NextCursor)
+			}
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	if fmt.Sprint(ids) != fmt.Sprint([]int{1, 2, 4, 5}) || pages != 2 {
+		t.Errorf("ids %v in %d pages", ids, pages)
+	}
+
+	for _, bad := range []string{"!!", "YWJj"} {
+		if resp := env.do(http.MethodGet, "/api/products?cursor="+bad, "", nil); resp.Code != "invalid_cursor" {
+			t.Errorf("cursor %q: status %d code %q", bad, resp.Status, resp.Code)
+		}
+	}
+}
+
+func TestProductsETag(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+	gpu := env.product(seller.ID, "GPU", 100, 1, true)
+	cpu := env.product(seller.ID, "CPU", 100, 1, true)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	paths := []string{"/api/products", "/api/products?cursor=", fmt.Sprintf("/api/products/%d", gpu.ID)}
+	etags := map[string]string{}
+	for _, path := range paths {
+		rec := get(path, "")
+		etag := rec.Header().Get("ETag")
+		if rec.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) {
+			t.Fatalf("%s: status %d etag %q", path, rec.Code, etag)
+		}
+		etags[path] = etag
+
+		if rec := get(path, `"other", `+etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+			t.Errorf("%s: matching If-None-Match gave %d", path, rec.Code)
+		}
+		if rec := get(path, `W/"other"`); rec.Code != http.StatusOK {
+			t.Errorf("%s: stale If-None-Match gave %d", path, rec.Code)
+		}
+	}
+
+	// Изменение товара и удаление из выборки меняют ETag
+	update := "name=GPU+Pro&price=150&stock=1&image=default.png"
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/seller/products/%d", gpu.ID), strings.NewReader(update))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+	env.e.ServeHTTP(httptest.NewRecorder(), req)
+
+	if rec := get(paths[2], etags[paths[2]]); rec.Code != http.StatusOK {
+		t.Errorf("detail after update: %d", rec.Code)
+	}
+
+	if resp := env.do(http.MethodDelete, fmt.Sprintf("/api/seller/products/%d", cpu.ID), sellerToken, nil); resp.Status != http.StatusOK {
+		t.Fatalf("delete: %d", resp.Status)
+	}
+	for _, path := range paths[:2] {
+		if rec := get(path, etags[path]); rec.Code != http.StatusOK {
+			t.Errorf("%s after delete: %d", path, rec.Code)
+		}
+	}
+}
+
+func TestCurrency(t *testing.T) {
+	env := newTestEnv(t)
+	env.svc.Rates = money.NewStaticRates("RUB", map[string]money.Amount{"USD": money.FromInt(80)})
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+
+	fan := env.product(seller.ID, "Fan", 1, 10, true)
+	env.repo.UpdateProduct(&models.Product{ID: fan.ID, Name: "Fan", Price: money.MustParse("19.99"), Stock: 10, Image: "default.png"}, false, seller.ID)
+	gpu := env.product(seller.ID, "GPU", 1000, 10, true)
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: fan.ID, Quantity: 3})
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: gpu.ID, Quantity: 1})
+
+	tests := []struct {
+		currency string
+		want     string // валюта, цена Fan, цена GPU, итог корзины
+	}{
+		{"", "RUB 19.99 1000 1059.97"},
+		{"usd", "USD 0.25 12.5 13.25"},
+		{"RUB", "RUB 19.99 1000 1059.97"},
+	}
+	for _, tt := range tests {
+		t.Run("currency="+tt.currency, func(t *testing.T) {
+			page := decode[service.ProductPage](t, env.do(http.MethodGet, "/api/products?currency="+tt.currency, "", nil).Data)
+			prices := map[string]money.Amount{}
+			for _, p := range page.Products {
+				prices[p.Name] = p.Price
+				if p.Currency != page.Products[0].Currency {
+					t.Errorf("mixed currencies in page")
+				}
+			}
+
+			detail := decode[models.Product](t, env.do(http.MethodGet, fmt.Sprintf("/api/products/%d?currency=%s", fan.ID, tt.currency), "", nil).Data)
+			if !detail.Price.Equal(prices["Fan"]) {
+				t.Errorf("detail price %s, catalog %s", detail.Price, prices["Fan"])
+			}
+
+			cart := decode[service.Cart](t, env.do(http.MethodGet, "/api/cart?currency="+tt.currency, buyerToken, nil).Data)
+			if cart.Currency != page.Products[0].Currency {
+				t.Errorf("cart currency %s, catalog %s", cart.Currency, page.Products[0].Currency)
+			}
+
+			got := fmt.Sprintf("%s %s %s %s", detail.Currency, prices["Fan"], prices["GPU"], cart.Total)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	for _, path := range []string{"/api/products?currency=EUR", "/api/products/1?currency=EUR", "/api/cart?currency=EUR"} {
+		if resp := env.do(http.MethodGet, path, buyerToken, nil); resp.Status != http.StatusBadRequest || resp.Code != "unknown_currency" {
+			t.Errorf("%s: %d %q", path, resp.Status, resp.Code)
+		}
+	}
+
+	// Ответы в разных валютах не должны совпадать по ETag
+	etag := func(path string) string {
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Header().Get("ETag")
+	}
+	if etag("/api/products") == etag("/api/products?currency=USD") {
+		t.Error("catalog ETag does not depend on currency")
+	}
+
+	currencies := decode[service.Currencies](t, env.do(http.MethodGet, "/api/currencies", "", nil).Data)
+	if currencies.Base != "RUB" || len(currencies.Rates) != 2 || !currencies.Rates["USD"].Equal(money.FromInt(80)) {
+		t.Errorf("currencies %+v", currencies)
+	}
+}
+
+func TestPriceHistoryAndAlerts(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 5, true)
+	hidden := env.product(seller.ID, "Hidden", 1000, 5, false)
+
+	updatePrice := func(token, price string) {
+		t.Helper()
+		form := "name=GPU&price=" + price + "&stock=5&image=default.png"
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/seller/products/%d", gpu.ID), strings.NewReader(form))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("update price: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+	alerts := func() []models.PriceAlert {
+		return decode[[]models.PriceAlert](t, env.do(http.MethodGet, "/api/price-alerts", buyerToken, nil).Data)
+	}
+	alertPath := fmt.Sprintf("/api/products/%d/price-alert", gpu.ID)
+
+	alertTests := []struct {
+		name   string
+		path   string
+		target int64
+		code   string
+	}{
+		{"target not below price", alertPath, 1000, "price_alert_target"},
+		{"unapproved product", fmt.Sprintf("/api/products/%d/price-alert", hidden.ID), 10, "product_unavailable"},
+		{"missing product", "/api/products/999/price-alert", 10, "product_not_found"},
+		{"invalid target", alertPath, 0, "validation_failed"},
+		{"subscribed", alertPath, 900, ""},
+	}
+	for _, tt := range alertTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPut, tt.path, buyerToken, models.PriceAlertRequest{TargetPrice: money.FromInt(tt.target)})
+			if resp.Code != tt.code {
+				t.Errorf("status %d code %q, want %q", resp.Status, resp.Code, tt.code)
+			}
+		})
+	}
+
+	// Цена выше цели: подписка ждет. Правка продавца уходит на модерацию,
+	// и подписка срабатывает только после одобрения.
+	updatePrice(adminToken, "950")
+	if a := alerts(); len(a) != 1 || a[0].TriggeredAt != nil {
+		t.Fatalf("alert triggered too early: %+v", a)
+	}
+	updatePrice(sellerToken, "850,00")
+	if a := alerts(); a[0].TriggeredAt != nil {
+		t.Fatal("alert triggered for a product under moderation")
+	}
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/products/%d/approve", gpu.ID), adminToken, nil)
+	if a := alerts(); a[0].TriggeredAt == nil || !a[0].TriggeredPrice.Equal(money.FromInt(850)) || !a[0].CurrentPrice.Equal(money.FromInt(850)) {
+		t.Fatalf("alert not triggered after approval: %+v", a[0])
+	}
+
+	// Изменение без смены цены не попадает в историю
+	updatePrice(adminToken, "850")
+
+	resp := env.do(http.MethodGet, fmt.Sprintf("/api/products/%d/price-history", gpu.ID), "", nil)
+	history := decode[[]models.PriceChange](t, resp.Data)
+	var got []string
+	for _, change := range history {
+		old := "nil"
+		if change.OldPrice != nil {
+			old = fmt.Sprint(*change.OldPrice)
+		}
+		got = append(got, fmt.Sprintf("%s:%s->%v", change.Source, old, change.NewPrice))
+	}
+	want := []string{"create:nil->1000", "update:1000->950", "update:950->850"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("history %v, want %v", got, want)
+	}
+	if strings.Contains(string(resp.Data), "changed_by") {
+		t.Error("history exposes the author of the change")
+	}
+
+	if resp := env.do(http.MethodGet, "/api/products/999/price-history", "", nil); resp.Status != http.StatusNotFound {
+		t.Errorf("history of missing product: %d", resp.Status)
+	}
+
+	if resp := env.do(http.MethodDelete, alertPath, buyerToken, nil); resp.Status != http.StatusOK {
+		t.Errorf("delete alert: %d", resp.Status)
+	}
+	if resp := env.do(http.MethodDelete, alertPath, buyerToken, nil); resp.Code != "price_alert_not_found" {
+		t.Errorf("delete missing alert: %q", resp.Code)
+	}
+}
+
+func TestAddToCart(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, customer := env.user("customer", models.RoleCustomer)
+
+	gpu := env.product(seller.ID, "GPU", 50000, 10, true)
+	pending := env.product(seller.ID, "Pending", 100, 10, false)
+
+	tests := []struct {
+		name      string
+		productID int
+		quantity  int
+		status    int
+		error     string
+	}{
+		{"zero quantity", gpu.ID, 0, http.StatusBadRequest, "Проверьте введенные данные"},
+		{"negative quantity", gpu.ID, -1, http.StatusBadRequest, "Проверьте введенные данные"},
+		{"unknown product", 999, 1, http.StatusNotFound, "Товар не найден"},
+		{"unapproved product", pending.ID, 1, http.StatusBadRequest, "Товар не доступен для покупки"},
+		{"more than in stock", gpu.ID, 11, http.StatusBadRequest, "Недостаточно товара в наличии"},
+		{"add", gpu.ID, 2, http.StatusOK, ""},
+		{"add same product again", gpu.ID, 3, http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPost, "/api/cart/add", customer, models.AddToCartRequest{
+				ProductID: tt.productID,
+				Quantity:  tt.quantity,
+			})
+			if resp.Status != tt.status || resp.Error != tt.error {
+				t.Fatalf("got %d %q, want %d %q", resp.Status, resp.Error, tt.status, tt.error)
+			}
+		})
+	}
+
+	// ON CONFLICT (user_id, product_id): количество суммируется в одной позиции
+	resp := env.do(http.MethodGet, "/api/cart", customer, nil)
+	cart := decode[service.Cart](t, resp.Data)
+	if cart.Count != 1 || len(cart.Items) != 1 {
+		t.Fatalf("cart has %d items, want 1 merged item", cart.Count)
+	}
+	if cart.Items[0].Quantity != 5 {
+		t.Errorf("quantity %d, want 5", cart.Items[0].Quantity)
+	}
+	if !cart.Total.Equal(money.FromInt(250000)) {
+		t.Errorf("total %v, want 250000", cart.Total)
+	}
+}
+
+func TestCartUpdateAndRemove(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, alice := env.user("alice", models.RoleCustomer)
+	_, bob := env.user("bob", models.RoleCustomer)
+
+	cpu := env.product(seller.ID, "CPU", 100, 10, true)
+	ram := env.product(seller.ID, "RAM", 10, 10, true)
+	soldOut := env.product(seller.ID, "Sold out", 10, 1, true)
+
+	for _, id := range []int{cpu.ID, ram.ID, soldOut.ID} {
+		env.do(http.MethodPost, "/api/cart/add", alice, models.AddToCartRequest{ProductID: id, Quantity: 1})
+	}
+	env.do(http.MethodPost, "/api/cart/add", bob, models.AddToCartRequest{ProductID: cpu.ID, Quantity: 1})
+
+	// Товары без остатка не показываются в корзине
+	env.repo.UpdateProduct(&models.Product{ID: soldOut.ID, Name: "Sold out", Price: money.FromInt(10), Stock: 0}, false, seller.ID)
+
+	cartOf := func(token string) service.Cart {
+		return decode[service.Cart](t, env.do(http.MethodGet, "/api/cart", token, nil).Data)
+	}
+
+	items := cartOf(alice).Items
+	if len(items) != 2 || items[0].ProductID != ram.ID || items[1].ProductID != cpu.ID {
+		t.Fatalf("cart %+v, want RAM then CPU (newest first)", items)
+	}
+	cpuItem, ramItem := items[1].ID, items[0].ID
+	bobItem := cartOf(bob).Items[0].ID
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		body     interface{}
+		status   int
+		wantCart map[int]int
+	}{
+		{"bad id", http.MethodPut, "/api/cart/update/abc", alice, map[string]int{"quantity": 1}, http.StatusBadRequest, map[int]int{cpu.ID: 1, ram.ID: 1}},
+		{"set quantity", http.MethodPut, fmt.Sprintf("/api/cart/update/%d", cpuItem), alice, map[string]int{"quantity": 4}, http.StatusOK, map[int]int{cpu.ID: 4, ram.ID: 1}},
+		{"other user's item is untouched", http.MethodPut, fmt.Sprintf("/api/cart/update/%d", bobItem), alice, map[string]int{"quantity": 9}, http.StatusOK, map[int]int{cpu.ID: 4, ram.ID: 1}},
+		{"zero quantity removes", http.MethodPut, fmt.Sprintf("/api/cart/update/%d", ramItem), alice, map[string]int{"quantity": 0}, http.StatusOK, map[int]int{cpu.ID: 4}},
+		{"remove", http.MethodDelete, fmt.Sprintf("/api/cart/remove/%d", cpuItem), alice, nil, http.StatusOK, map[int]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := env.do(tt.method, tt.path, tt.token, tt.body); resp.Status != tt.status {
+				t.Fatalf("got %d, want %d", resp.Status, tt.status)
+			}
+
+			got := map[int]int{}
+			for _, item := range cartOf(alice).Items {
+				got[item.ProductID] = item.Quantity
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantCart) {
+				t.Errorf("cart %v, want %v", got, tt.wantCart)
+			}
+		})
+	}
+
+	if bobCart := cartOf(bob); len(bobCart.Items) != 1 || bobCart.Items[0].Quantity != 1 {
+		t.Errorf("bob's cart changed: %+v", bobCart.Items)
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	env := newTestEnv(t)
+	_, alice := env.user("alice", models.RoleCustomer)
+	_, bob := env.user("bob", models.RoleCustomer)
+
+	create := func(token, street string, isDefault bool) models.Address {
+		resp := env.do(http.MethodPost, "/api/addresses", token, models.AddressRequest{
+			Recipient: "Алиса", Phone: "+79990000000", City: "Москва", Street: street, IsDefault: isDefault,
+		})
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("create %s: %d %s", street, resp.Status, resp.Code)
+		}
+		return decode[models.Address](t, resp.Data)
+	}
+	defaults := func(token string) []string {
+		var streets []string
+		for _, a := range decode[[]models.Address](t, env.do(http.MethodGet, "/api/addresses", token, nil).Data) {
+			if a.IsDefault {
+				streets = append(streets, a.Street)
+			}
+		}
+		return streets
+	}
+
+	// Первый адрес становится адресом по умолчанию сам
+	home := create(alice, "Тверская, 1", false)
+	if !home.IsDefault {
+		t.Error("first address is not default")
+	}
+	work := create(alice, "Арбат, 2", false)
+	if got := defaults(alice); fmt.Sprint(got) != "[Тверская, 1]" {
+		t.Errorf("defaults %v after second address", got)
+	}
+
+	// Новый адрес по умолчанию снимает отметку со старого
+	create(alice, "Ленина, 3", true)
+	if got := defaults(alice); fmt.Sprint(got) != "[Ленина, 3]" {
+		t.Errorf("defaults %v after new default", got)
+	}
+
+	// Чужой адрес нельзя ни изменить, ни удалить
+	create(bob, "Невский, 4", false)
+	if resp := env.do(http.MethodPut, fmt.Sprintf("/api/addresses/%d", work.ID), bob, models.AddressRequest{
+		Recipient: "Боб", Phone: "1", City: "СПб", Street: "Невский, 5",
+	}); resp.Status != http.StatusNotFound || resp.Code != "address_not_found" {
+		t.Errorf("update foreign address: %d %q", resp.Status, resp.Code)
+	}
+	if resp := env.do(http.MethodDelete, fmt.Sprintf("/api/addresses/%d", work.ID), bob, nil); resp.Status != http.StatusNotFound {
+		t.Errorf("delete foreign address: %d", resp.Status)
+	}
+
+	// После удаления адреса по умолчанию отметка переходит на самый новый из оставшихся
+	current := decode[[]models.Address](t, env.do(http.MethodGet, "/api/addresses", alice, nil).Data)
+	for _, a := range current {
+		if a.IsDefault {
+			env.do(http.MethodDelete, fmt.Sprintf("/api/addresses/%d", a.ID), alice, nil)
+		}
+	}
+	if got := defaults(alice); fmt.Sprint(got) != "[Арбат, 2]" {
+		t.Errorf("defaults %v after delete", got)
+	}
+	if got := defaults(bob); fmt.Sprint(got) != "[Невский, 4]" {
+		t.Errorf("bob's defaults %v", got)
+	}
+}
+
+func TestShippingQuote(t *testing.T) {
+	env := newTestEnv(t)
+	env.svc.Rates = money.NewStaticRates("RUB", map[string]money.Amount{"USD": money.FromInt(80)})
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	cpu := env.product(seller.ID, "CPU", 3000, 10, true)
+	env.repo.UpdateProduct(&models.Product{ID: cpu.ID, Name: "CPU", Price: money.FromInt(3000), Stock: 10, Weight: 400, Image: "default.png"}, false, seller.ID)
+
+	method := func(pricing string, rules ...int64) int {
+		req := models.DeliveryMethodRequest{Name: pricing, Kind: models.DeliveryCourier, Pricing: pricing, IsActive: true}
+		for i := 0; i < len(rules); i += 2 {
+			req.Rules = append(req.Rules, models.DeliveryRule{From: money.FromInt(rules[i]), Cost: money.FromInt(rules[i+1])})
+		}
+		resp := env.do(http.MethodPost, "/api/admin/delivery-methods", adminToken, req)
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("create method: %d %s %+v", resp.Status, resp.Code, resp.Details)
+		}
+		return decode[models.DeliveryMethod](t, resp.Data).ID
+	}
+	// Ступени передаются не по порядку: сервис сортирует их сам
+	byWeight := method(models.PricingByWeight, 1000, 500, 0, 200, 2000, 900)
+	byTotal := method(models.PricingByTotal, 0, 300, 10000, 0)
+
+	tests := []struct {
+		quantity int
+		method   int
+		currency string
+		want     string // вес, доставка, итог
+	}{
+		{1, byWeight, "", "400 200 3200"},
+		{3, byWeight, "", "1200 500 9500"},
+		{5, byWeight, "", "2000 900 15900"},
+		{3, byTotal, "", "1200 300 9300"},
+		{4, byTotal, "", "1600 0 12000"},
+		{1, byWeight, "USD", "400 2.5 40"},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d×CPU method %d %s", tt.quantity, tt.method, tt.currency), func(t *testing.T) {
+			for _, item := range decode[service.Cart](t, env.do(http.MethodGet, "/api/cart", buyerToken, nil).Data).Items {
+				env.do(http.MethodDelete, fmt.Sprintf("/api/cart/remove/%d", item.ID), buyerToken, nil)
+			}
+			env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: cpu.ID, Quantity: tt.quantity})
+
+			path := fmt.Sprintf("/api/cart?delivery_method=%d&currency=%s", tt.method, tt.currency)
+			cart := decode[service.Cart](t, env.do(http.MethodGet, path, buyerToken, nil).Data)
+			if cart.Shipping == nil {
+				t.Fatal("no shipping quote")
+			}
+			if got := fmt.Sprintf("%d %s %s", cart.Weight, cart.Shipping.Cost, cart.Total); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Без способа доставки итог равен сумме товаров
+	if cart := decode[service.Cart](t, env.do(http.MethodGet, "/api/cart", buyerToken, nil).Data); cart.Shipping != nil || !cart.Total.Equal(cart.Subtotal) {
+		t.Errorf("cart without delivery: %+v", cart)
+	}
+
+	invalid := []struct {
+		name  string
+		rules []models.DeliveryRule
+		code  string
+	}{
+		{"no rules", nil, "required"},
+		{"no zero step", []models.DeliveryRule{{From: money.FromInt(1), Cost: money.FromInt(1)}}, "rules_start"},
+		{"duplicate step", []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(1)}, {From: money.Zero, Cost: money.FromInt(2)}}, "rules_duplicate"},
+		{"negative cost", []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(-1)}}, "gte"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPost, "/api/admin/delivery-methods", adminToken, models.DeliveryMethodRequest{
+				Name: "Bad", Kind: models.DeliveryPost, Pricing: models.PricingByWeight, Rules: tt.rules,
+			})
+			if resp.Status != http.StatusBadRequest || len(resp.Details) != 1 || resp.Details[0].Field != "rules" || resp.Details[0].Code != tt.code {
+				t.Errorf("got %d %+v, want rules/%s", resp.Status, resp.Details, tt.code)
+			}
+		})
+	}
+
+	// Отключенный способ не виден покупателям и не считается
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/delivery-methods/%d", byTotal), adminToken, models.DeliveryMethodRequest{
+		Name: "total", Kind: models.DeliveryCourier, Pricing: models.PricingByTotal,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(300)}},
+	})
+	if methods := decode[[]models.DeliveryMethod](t, env.do(http.MethodGet, "/api/delivery-methods", "", nil).Data); len(methods) != 1 || methods[0].ID != byWeight {
+		t.Errorf("public methods %+v", methods)
+	}
+	if all := decode[[]models.DeliveryMethod](t, env.do(http.MethodGet, "/api/admin/delivery-methods", adminToken, nil).Data); len(all) != 2 {
+		t.Errorf("admin methods %+v", all)
+	}
+	if resp := env.do(http.MethodGet, fmt.Sprintf("/api/cart?delivery_method=%d", byTotal), buyerToken, nil); resp.Status != http.StatusNotFound {
+		t.Errorf("inactive method quote: %d", resp.Status)
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, otherToken := env.user("other", models.RoleCustomer)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 5, true)
+	fan := env.product(seller.ID, "Fan", 50, 5, true)
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: gpu.ID, Quantity: 2})
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: fan.ID, Quantity: 1})
+
+	courier, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Курьер", Kind: models.DeliveryCourier, Pricing: models.PricingByTotal, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(300)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	home := decode[models.Address](t, env.do(http.MethodPost, "/api/addresses", buyerToken, models.AddressRequest{
+		Recipient: "Иван", Phone: "+79990000000", City: "Москва", Street: "Тверская, 1",
+	}).Data)
+	foreign := decode[models.Address](t, env.do(http.MethodPost, "/api/addresses", otherToken, models.AddressRequest{
+		Recipient: "Петр", Phone: "+79990000001", City: "Казань", Street: "Баумана, 2",
+	}).Data)
+
+	errs := []struct {
+		name string
+		req  models.CheckoutRequest
+		code string
+	}{
+		{"courier without address", models.CheckoutRequest{DeliveryMethodID: courier.ID}, "address_required"},
+		{"foreign address", models.CheckoutRequest{DeliveryMethodID: courier.ID, AddressID: foreign.ID}, "address_not_found"},
+		{"unknown method", models.CheckoutRequest{DeliveryMethodID: 999}, "delivery_method_not_found"},
+		{"no method", models.CheckoutRequest{}, "validation_failed"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := env.do(http.MethodPost, "/api/orders", buyerToken, tt.req); resp.Code != tt.code {
+				t.Errorf("got %d %q, want %q", resp.Status, resp.Code, tt.code)
+			}
+		})
+	}
+
+	// Остаток кончился после того, как покупатель открыл корзину: заказ не оформляется
+	env.repo.UpdateProduct(&models.Product{ID: gpu.ID, Name: "GPU", Price: money.FromInt(1000), Stock: 1, Image: "default.png"}, false, seller.ID)
+	if resp := env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: courier.ID, AddressID: home.ID}); resp.Status != http.StatusConflict || resp.Code != "cart_changed" {
+		t.Errorf("checkout with short stock: %d %q", resp.Status, resp.Code)
+	}
+	if p, _ := env.repo.GetProductByID(fan.ID); p.Stock != 5 {
+		t.Errorf("failed checkout changed stock: %d", p.Stock)
+	}
+	env.repo.UpdateProduct(&models.Product{ID: gpu.ID, Name: "GPU", Price: money.FromInt(1000), Stock: 5, Image: "default.png"}, false, seller.ID)
+
+	resp := env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: courier.ID, AddressID: home.ID})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("checkout: %d %s", resp.Status, resp.Code)
+	}
+	order := decode[models.Order](t, resp.Data)
+	if got := fmt.Sprintf("%s %s %s %s %s", order.Status, order.Subtotal, order.Shipping.Cost, order.Total, order.Currency); got != "pending 2050 300 2350 RUB" {
+		t.Errorf("order %q", got)
+	}
+	if order.Address == nil || order.Address.Street != "Тверская, 1" {
+		t.Errorf("order address %+v", order.Address)
+	}
+
+	// Остатки списаны, корзина пуста
+	if p, _ := env.repo.GetProductByID(gpu.ID); p.Stock != 3 {
+		t.Errorf("GPU stock %d, want 3", p.Stock)
+	}
+	if cart := decode[service.Cart](t, env.do(http.MethodGet, "/api/cart", buyerToken, nil).Data); cart.Count != 0 {
+		t.Errorf("cart not cleared: %+v", cart.Items)
+	}
+	if resp := env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: courier.ID, AddressID: home.ID}); resp.Code != "cart_empty" {
+		t.Errorf("second checkout: %d %q", resp.Status, resp.Code)
+	}
+
+	// Правка тарифа, адреса и цены не меняет оформленный заказ
+	env.svc.UpdateDeliveryMethod(courier.ID, models.DeliveryMethodRequest{
+		Name: "Курьер", Kind: models.DeliveryCourier, Pricing: models.PricingByTotal, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(999)}},
+	})
+	env.do(http.MethodPut, fmt.Sprintf("/api/addresses/%d", home.ID), buyerToken, models.AddressRequest{
+		Recipient: "Иван", Phone: "+79990000000", City: "Москва", Street: "Арбат, 2",
+	})
+	env.repo.UpdateProduct(&models.Product{ID: gpu.ID, Name: "GPU", Price: money.FromInt(1500), Stock: 3, Image: "default.png"}, false, seller.ID)
+
+	saved := decode[models.Order](t, env.do(http.MethodGet, fmt.Sprintf("/api/orders/%d", order.ID), buyerToken, nil).Data)
+	prices := map[string]string{}
+	for _, item := range saved.Items {
+		prices[item.Name] = item.PriceAtTime.String()
+	}
+	if saved.Shipping.Cost.String() != "300" || saved.Address.Street != "Тверская, 1" || prices["GPU"] != "1000" || !saved.Total.Equal(order.Total) {
+		t.Errorf("saved order changed: %+v %+v %+v", saved.Shipping, saved.Address, saved.Items)
+	}
+	if orders := decode[[]models.Order](t, env.do(http.MethodGet, "/api/orders", otherToken, nil).Data); len(orders) != 0 {
+		t.Errorf("other user sees %d orders", len(orders))
+	}
+}
+
+func TestInvoices(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	_, rivalToken := env.user("rival", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 5, true)
+	env.repo.AddOrder(models.Order{
+		UserID:    buyer.ID,
+		CreatedAt: time.Now().UTC(),
+		Subtotal:  money.FromInt(2000),
+		Total:     money.FromInt(2000),
+		Items:     []models.OrderItem{{ProductID: gpu.ID, Name: "GPU", Quantity: 2, PriceAtTime: money.FromInt(1000)}},
+	})
+	env.repo.AddOrder(models.Order{
+		UserID:    buyer.ID,
+		Status:    models.OrderCancelled,
+		CreatedAt: time.Now().UTC(),
+		Items:     []models.OrderItem{{ProductID: gpu.ID, Name: "GPU", Quantity: 1, PriceAtTime: money.FromInt(1000)}},
+	})
+
+	get := func(token string, orderID int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/orders/%d/invoice", orderID), nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+		return rec
+	}
+	code := func(rec *httptest.ResponseRecorder) string {
+		var resp response
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.Code
+	}
+
+	rec := get(buyerToken, 1)
+	if rec.Code != http.StatusOK || rec.Header().Get(echo.HeaderContentType) != "application/pdf" {
+		t.Fatalf("invoice: %d %s %s", rec.Code, rec.Header().Get(echo.HeaderContentType), rec.Body.String())
+	}
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte("%PDF-")) {
+		t.Fatal("invoice is not a PDF")
+	}
+	if got := rec.Header().Get(echo.HeaderContentDisposition); !strings.Contains(got, "invoice-CP-000001.pdf") {
+		t.Errorf("content disposition %q", got)
+	}
+	issued := rec.Body.Bytes()
+
+	// Выставленный счет не меняется: ни реквизиты магазина, ни цена товара на него не влияют
+	env.cfg.InvoiceCompany = "ООО «Новое название»"
+	env.repo.UpdateProduct(&models.Product{ID: gpu.ID, Name: "GPU", Price: money.FromInt(1500), Stock: 5, Image: "default.png"}, false, seller.ID)
+	for name, token := range map[string]string{"buyer": buyerToken, "seller": sellerToken, "admin": adminToken} {
+		if rec := get(token, 1); rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), issued) {
+			t.Errorf("%s: %d, same document %v", name, rec.Code, bytes.Equal(rec.Body.Bytes(), issued))
+		}
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		orderID int
+		status  int
+		code    string
+	}{
+		{"foreign seller", rivalToken, 1, http.StatusNotFound, "order_not_found"},
+		{"missing order", adminToken, 999, http.StatusNotFound, "order_not_found"},
+		{"cancelled order", buyerToken, 2, http.StatusBadRequest, "invoice_unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := get(tt.token, tt.orderID); rec.Code != tt.status || code(rec) != tt.code {
+				t.Errorf("got %d %q, want %d %q", rec.Code, code(rec), tt.status, tt.code)
+			}
+		})
+	}
+
+	// Счет, выставленный до отмены заказа, остается доступным
+	if err := env.repo.UpdateOrderStatus(1, models.OrderPending, models.OrderCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if rec := get(buyerToken, 1); rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), issued) {
+		t.Errorf("invoice of cancelled order: %d", rec.Code)
+	}
+}
+
+func TestTaxes(t *testing.T) {
+	env := newTestEnv(t)
+	env.svc.Rates = money.NewStaticRates("RUB", map[string]money.Amount{"USD": money.FromInt(80)})
+	seller, _ := env.user("seller", models.RoleSeller)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	product := func(name, category string, price int64) *models.Product {
+		p := env.product(seller.ID, name, price, 10, true)
+		p.Category = category
+		if err := env.repo.UpdateProduct(p, false, seller.ID); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	gpu := product("GPU", "Видеокарты", 1000)
+	fan := product("Fan", "Охлаждение", 500)
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: gpu.ID, Quantity: 2})
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: fan.ID, Quantity: 1})
+
+	// Из подходящих ставок действует самая точная: категория важнее региона
+	rates := []models.TaxRateRequest{
+		{Name: "НДС 20%", Rate: money.FromInt(20), Inclusive: true},
+		{Name: "НДС 10%", Rate: money.FromInt(10), Category: "видеокарты", Inclusive: true},
+		{Name: "Налог с продаж 5%", Rate: money.FromInt(5), Region: "Техас"},
+		{Name: "Налог на GPU 8%", Rate: money.FromInt(8), Category: "Видеокарты", Region: "Техас"},
+	}
+	var created []models.TaxRate
+	for _, req := range rates {
+		resp := env.do(http.MethodPost, "/api/admin/tax-rates", adminToken, req)
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("create %q: %d %s", req.Name, resp.Status, resp.Code)
+		}
+		created = append(created, decode[models.TaxRate](t, resp.Data))
+	}
+
+	texas := decode[models.Address](t, env.do(http.MethodPost, "/api/addresses", buyerToken, models.AddressRequest{
+		Recipient: "John", Phone: "+15550000000", City: "Остин", Street: "Congress Ave, 1", Region: "техас",
+	}).Data)
+
+	taxOf := func(items []models.CartItem, name string) string {
+		for _, item := range items {
+			if item.Name == name && item.Tax != nil {
+				return fmt.Sprintf("%s %s", item.Tax.Name, item.Tax.Amount)
+			}
+		}
+		return ""
+	}
+	tests := []struct {
+		name  string
+		query string
+		// налог GPU, налог Fan, все налоги, итог, строк разбивки
+		want string
+	}{
+		{"without address", "", "НДС 10% 181.82|НДС 20% 83.33|265.15 2500 2"},
+		{"region address", fmt.Sprintf("?address=%d", texas.ID), "Налог на GPU 8% 160|Налог с продаж 5% 25|185 2685 2"},
+		{"converted", fmt.Sprintf("?currency=USD&address=%d", texas.ID), "Налог на GPU 8% 2|Налог с продаж 5% 0.31|2.31 33.56 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cart := decode[service.Cart](t, env.do(http.MethodGet, "/api/cart"+tt.query, buyerToken, nil).Data)
+			got := fmt.Sprintf("%s|%s|%s %s %d", taxOf(cart.Items, "GPU"), taxOf(cart.Items, "Fan"), cart.Tax, cart.Total, len(cart.Taxes))
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if resp := env.do(http.MethodGet, "/api/cart?address=999", buyerToken, nil); resp.Code != "address_not_found" {
+		t.Errorf("unknown address: %d %q", resp.Status, resp.Code)
+	}
+
+	courier, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Курьер", Kind: models.DeliveryCourier, Pricing: models.PricingByTotal, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(300)}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: courier.ID, AddressID: texas.ID})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("checkout: %d %s", resp.Status, resp.Code)
+	}
+	order := decode[models.Order](t, resp.Data)
+	if got := fmt.Sprintf("%s %s %s %d", order.Subtotal, order.Tax, order.Total, len(order.Taxes)); got != "2500 185 2985 2" {
+		t.Errorf("order %q", got)
+	}
+
+	// Ставки меняются, а налоги оформленного заказа остаются прежними
+	if resp := env.do(http.MethodDelete, fmt.Sprintf("/api/admin/tax-rates/%d", created[3].ID), adminToken, nil); resp.Status != http.StatusOK {
+		t.Fatalf("delete rate: %d %s", resp.Status, resp.Code)
+	}
+	saved := decode[models.Order](t, env.do(http.MethodGet, fmt.Sprintf("/api/orders/%d", order.ID), buyerToken, nil).Data)
+	if !saved.Tax.Equal(order.Tax) || !saved.Total.Equal(order.Total) {
+		t.Errorf("saved order tax %s total %s", saved.Tax, saved.Total)
+	}
+
+	admin := []struct {
+		name   string
+		method string
+		url    string
+		body   any
+		code   string
+	}{
+		{"duplicate pair", http.MethodPost, "/api/admin/tax-rates", models.TaxRateRequest{Name: "Дубль", Rate: money.FromInt(1), Category: "ВИДЕОКАРТЫ"}, "tax_rate_exists"},
+		{"rename into taken pair", http.MethodPut, fmt.Sprintf("/api/admin/tax-rates/%d", created[2].ID), models.TaxRateRequest{Name: "НДС", Rate: money.FromInt(1)}, "tax_rate_exists"},
+		{"negative rate", http.MethodPost, "/api/admin/tax-rates", models.TaxRateRequest{Name: "Минус", Rate: money.FromInt(-1)}, "validation_failed"},
+		{"update deleted", http.MethodPut, fmt.Sprintf("/api/admin/tax-rates/%d", created[3].ID), rates[3], "tax_rate_not_found"},
+		{"delete deleted", http.MethodDelete, fmt.Sprintf("/api/admin/tax-rates/%d", created[3].ID), nil, "tax_rate_not_found"},
+	}
+	for _, tt := range admin {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := env.do(tt.method, tt.url, adminToken, tt.body); resp.Code != tt.code {
+				t.Errorf("got %d %q, want %q", resp.Status, resp.Code, tt.code)
+			}
+		})
+	}
+
+	// Налог сверх цены возвращается долями, а вся позиция — ровно на оплаченную сумму
+	env.repo.AddOrder(models.Order{
+		UserID:   buyer.ID,
+		Status:   models.OrderDelivered,
+		Subtotal: money.MustParse("999.99"),
+		Total:    money.MustParse("1049.99"),
+		Items: []models.OrderItem{{
+			ProductID: fan.ID, Name: "Fan", Quantity: 3, PriceAtTime: money.MustParse("333.33"),
+			Tax: &models.ItemTax{Name: "Налог с продаж 5%", Rate: money.FromInt(5), Amount: money.FromInt(50)},
+		}},
+	})
+	taxed := order.ID + 1
+	line := decode[models.Order](t, env.do(http.MethodGet, fmt.Sprintf("/api/orders/%d", taxed), buyerToken, nil).Data).Items[0].ID
+	var amounts []string
+	for i := 0; i < 3; i++ {
+		resp := env.do(http.MethodPost, fmt.Sprintf("/api/orders/%d/returns", taxed), buyerToken, models.ReturnRequest{OrderItemID: line, Quantity: 1, Reason: "Шумит"})
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("return %d: %d %s", i, resp.Status, resp.Code)
+		}
+		ret := decode[models.Return](t, resp.Data)
+		amounts = append(amounts, ret.Amount.String())
+		for _, status := range []string{models.ReturnAwaitingItem, models.ReturnReceived, models.ReturnRefunded} {
+			if resp := env.do(http.MethodPut, fmt.Sprintf("/api/seller/returns/%d/status", ret.ID), adminToken, models.ReturnStatusRequest{Status: status}); resp.Status != http.StatusOK {
+				t.Fatalf("return %d %s: %d %s", ret.ID, status, resp.Status, resp.Code)
+			}
+		}
+	}
+	if got := strings.Join(amounts, " "); got != "350 349.99 350" {
+		t.Errorf("return amounts %q", got)
+	}
+	refunded := decode[models.Order](t, env.do(http.MethodGet, fmt.Sprintf("/api/orders/%d", taxed), buyerToken, nil).Data)
+	if refunded.Status != models.OrderRefunded || refunded.Refunded.String() != "1049.99" {
+		t.Errorf("after full refund: %s %s", refunded.Status, refunded.Refunded)
+	}
+}
+
+func TestOrderStatus(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 5, true)
+	pickup, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Самовывоз", Kind: models.DeliveryPickup, Pricing: models.PricingByWeight, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.Zero}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkout := func() models.Order {
+		env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: gpu.ID, Quantity: 2})
+		resp := env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: pickup.ID})
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("checkout: %d %s", resp.Status, resp.Code)
+		}
+		return decode[models.Order](t, resp.Data)
+	}
+	setStatus := func(id int, status string) response {
+		return env.do(http.MethodPut, fmt.Sprintf("/api/admin/orders/%d/status", id), adminToken, models.OrderStatusRequest{Status: status})
+	}
+	stock := func() int {
+		p, _ := env.repo.GetProductByID(gpu.ID)
+		return p.Stock
+	}
+
+	order := checkout()
+	for _, tt := range []struct {
+		status string
+		code   string
+	}{
+		{models.OrderShipped, "order_transition"},
+		{models.OrderPaid, ""},
+		{models.OrderPaid, "order_transition"},
+		{models.OrderShipped, ""},
+		{models.OrderCancelled, "order_transition"},
+		{models.OrderDelivered, ""},
+		{models.OrderRefunded, "validation_failed"},
+	} {
+		if resp := setStatus(order.ID, tt.status); resp.Code != tt.code {
+			t.Errorf("%s: got %d %q, want %q", tt.status, resp.Status, resp.Code, tt.code)
+		}
+	}
+	if stock() != 3 {
+		t.Errorf("stock after delivery %d, want 3", stock())
+	}
+
+	// Отмена возвращает товары на склад
+	cancelled := checkout()
+	if stock() != 1 {
+		t.Fatalf("stock after second order %d, want 1", stock())
+	}
+	if resp := setStatus(cancelled.ID, models.OrderCancelled); resp.Status != http.StatusOK {
+		t.Fatalf("cancel: %d %s", resp.Status, resp.Code)
+	}
+	if stock() != 3 {
+		t.Errorf("stock after cancel %d, want 3", stock())
+	}
+	saved := decode[models.Order](t, env.do(http.MethodGet, fmt.Sprintf("/api/orders/%d", cancelled.ID), buyerToken, nil).Data)
+	if saved.Status != models.OrderCancelled {
+		t.Errorf("status %q", saved.Status)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, watcherToken := env.user("watcher", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	inbox := func(token, query string) service.NotificationInbox {
+		t.Helper()
+		resp := env.do(http.MethodGet, "/api/notifications"+query, token, nil)
+		if resp.Status != http.StatusOK {
+			t.Fatalf("inbox: %d %s", resp.Status, resp.Code)
+		}
+		return decode[service.NotificationInbox](t, resp.Data)
+	}
+	latest := func(token string) string {
+		t.Helper()
+		list := inbox(token, "").Notifications
+		if len(list) == 0 {
+			return ""
+		}
+		return list[0].Type + " " + list[0].Message
+	}
+
+	// Новый товар продавца ждет модерации, решение приходит продавцу
+	created, err := env.svc.CreateProduct(seller.ID, models.RoleSeller, models.ProductForm{Name: "GPU", Price: "1000", Stock: "0"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := latest(adminToken); got != "product_pending Товар «GPU» ожидает модерации" {
+		t.Errorf("admin: %q", got)
+	}
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/products/%d/approve", created.ID), adminToken, nil)
+	if got := latest(sellerToken); got != "product_approved Товар «GPU» одобрен и опубликован в каталоге" {
+		t.Errorf("seller after approve: %q", got)
+	}
+	rejected := env.product(seller.ID, "CPU", 500, 1, false)
+	env.do(http.MethodDelete, fmt.Sprintf("/api/admin/products/%d/force", rejected.ID), adminToken, nil)
+	if got := latest(sellerToken); got != "product_rejected Товар «CPU» отклонен модератором" {
+		t.Errorf("seller after reject: %q", got)
+	}
+
+	// О поступлении узнают подписчики на цену; пока остаток не кончался, уведомлений нет
+	env.do(http.MethodPut, fmt.Sprintf("/api/products/%d/price-alert", created.ID), watcherToken, models.PriceAlertRequest{TargetPrice: money.FromInt(900)})
+	restock := func(stock string) {
+		t.Helper()
+		if err := env.svc.UpdateProduct(created.ID, 0, models.RoleAdmin, models.ProductForm{Name: "GPU", Price: "1000", Stock: stock}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restock("3")
+	restock("5")
+	if list := inbox(watcherToken, "").Notifications; len(list) != 1 || list[0].Type != models.NotifyBackInStock {
+		t.Errorf("watcher: %+v", list)
+	}
+
+	// Статус заказа и возврат отмененного товара на склад
+	pickup, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Самовывоз", Kind: models.DeliveryPickup, Pricing: models.PricingByWeight, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.Zero}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: created.ID, Quantity: 5})
+	order := decode[models.Order](t, env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: pickup.ID}).Data)
+	env.do(http.MethodPost, "/api/cart/add", watcherToken, models.AddToCartRequest{ProductID: created.ID, Quantity: 1})
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/orders/%d/status", order.ID), adminToken, models.OrderStatusRequest{Status: models.OrderCancelled})
+	if got := latest(buyerToken); got != fmt.Sprintf("order_status Заказ №%d отменен", order.ID) {
+		t.Errorf("buyer: %q", got)
+	}
+	if got := inbox(watcherToken, "?unread=true"); got.Unread != 2 || len(got.Notifications) != 2 {
+		t.Errorf("watcher after cancel: %+v", got)
+	}
+
+	// Прочитать можно только свое уведомление
+	own := inbox(buyerToken, "").Notifications[0]
+	if resp := env.do(http.MethodPut, fmt.Sprintf("/api/notifications/%d/read", own.ID), watcherToken, nil); resp.Code != "notification_not_found" {
+		t.Errorf("foreign read: %d %s", resp.Status, resp.Code)
+	}
+	env.do(http.MethodPut, fmt.Sprintf("/api/notifications/%d/read", own.ID), buyerToken, nil)
+	if got := inbox(buyerToken, ""); got.Unread != 0 || !got.Notifications[0].Read {
+		t.Errorf("after read: %+v", got)
+	}
+	if resp := env.do(http.MethodPut, "/api/notifications/read", watcherToken, nil); resp.Message != "Прочитано уведомлений: 2" {
+		t.Errorf("read all: %q", resp.Message)
+	}
+	if got := inbox(watcherToken, "?unread=true"); got.Unread != 0 || len(got.Notifications) != 0 {
+		t.Errorf("after read all: %+v", got)
+	}
+
+	// Текст собирается на языке запроса
+	req := httptest.NewRequest(http.MethodGet, "/api/notifications?limit=1", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer "+sellerToken)
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+	env.e.ServeHTTP(rec, req)
+	if !strings.Contains(rec.Body.String(), `"message":"Product “CPU” has been rejected by a moderator"`) {
+		t.Errorf("english: %s", rec.Body.String())
+	}
+
+	// После долгого отключения поток получает все пропущенное от старых к новым, а не последнюю страницу
+	backlog := make([]models.Notification, 250)
+	for i := range backlog {
+		backlog[i] = models.Notification{UserID: seller.ID, Type: models.NotifyProductApproved, ProductName: strconv.Itoa(i)}
+	}
+	if err := env.repo.CreateNotifications(backlog); err != nil {
+		t.Fatal(err)
+	}
+	missed, err := env.svc.MissedNotifications(seller.ID, backlog[0].ID)
+	if err != nil || len(missed) != len(backlog)-1 {
+		t.Fatalf("missed %d, %v", len(missed), err)
+	}
+	for i, n := range missed {
+		if n.ID != backlog[i+1].ID {
+			t.Fatalf("missed[%d] = %d, want %d", i, n.ID, backlog[i+1].ID)
+		}
+	}
+}
+
+func TestNotificationStream(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	server := httptest.NewServer(env.e)
+	defer server.Close()
+
+	approve := func(name string) {
+		t.Helper()
+		p := env.product(seller.ID, name, 100, 1, false)
+		if err := env.svc.ApproveProduct(p.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	approve("GPU")
+	approve("CPU")
+	first := decode[service.NotificationInbox](t, env.do(http.MethodGet, "/api/notifications", sellerToken, nil).Data).Notifications[1]
+
+	// EventSource не передает заголовки: поток открывается по токену потока в URL,
+	// который не годится для остальных запросов
+	resp := env.do(http.MethodPost, "/api/notifications/stream-token", sellerToken, nil)
+	if resp.Status != http.StatusOK {
+		t.Fatalf("stream token: %d %s", resp.Status, resp.Code)
+	}
+	streamToken := decode[service.StreamToken](t, resp.Data)
+	if ttl := time.Until(streamToken.ExpiresAt); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("stream token expires in %v", ttl)
+	}
+	if resp := env.do(http.MethodGet, "/api/profile", streamToken.Token, nil); resp.Code != "invalid_token" {
+		t.Errorf("stream token as bearer: %d %q", resp.Status, resp.Code)
+	}
+
+	// После переподключения поток сначала отдает пропущенное после last_event_id
+	query := url.Values{"token": {streamToken.Token}, "last_event_id": {strconv.Itoa(first.ID)}}
+	stream, err := http.Get(server.URL + "/api/notifications/stream?" + query.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if ct := stream.Header.Get(echo.HeaderContentType); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	lines := bufio.NewScanner(stream.Body)
+	next := func() models.Notification {
+		t.Helper()
+		var n models.Notification
+		for lines.Scan() {
+			if data, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+				if err := json.Unmarshal([]byte(data), &n); err != nil {
+					t.Fatal(err)
+				}
+				return n
+			}
+		}
+		t.Fatalf("stream ended: %v", lines.Err())
+		return n
+	}
+	if n := next(); n.ID != first.ID+1 || n.ProductName != "CPU" || n.Message == "" {
+		t.Errorf("replayed %+v", n)
+	}
+
+	// Новое уведомление приходит сразу
+	approve("RAM")
+	if n := next(); n.ProductName != "RAM" || n.Type != models.NotifyProductApproved {
+		t.Errorf("live %+v", n)
+	}
+
+	// Уведомление с меньшим id, опубликованное позже (транзакции завершились не по
+	// порядку), тоже доходит; повтор уже отданного из базы — нет
+	env.svc.Notifications.Publish(models.Notification{ID: first.ID + 1, UserID: seller.ID, Type: models.NotifyProductApproved, ProductName: "CPU"})
+	env.svc.Notifications.Publish(models.Notification{ID: first.ID, UserID: seller.ID, Type: models.NotifyProductApproved, ProductName: "Late"})
+	if n := next(); n.ID != first.ID || n.ProductName != "Late" {
+		t.Errorf("out of order %+v", n)
+	}
+
+	// При остановке сервера поток закрывается
+	env.svc.Notifications.Close()
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), "data: ") {
+			t.Errorf("unexpected event %s", lines.Text())
+		}
+	}
+}
+
+// countingRepository считает выборки товаров пачкой, чтобы проверить, что
+// резолверы одного уровня запроса обходятся одним обращением к базе
+type countingRepository struct {
+	*repository.MemoryRepository
+	calls map[string]int
+}
+
+func (r *countingRepository) ListApprovedProductsByIDs(ids []int) ([]models.Product, error) {
+	r.calls["ids"]++
+	return r.MemoryRepository.ListApprovedProductsByIDs(ids)
+}
+
+func (r *countingRepository) ListApprovedProductsBySellers(sellerIDs []int, limit int) ([]models.Product, error) {
+	r.calls["sellers"]++
+	return r.MemoryRepository.ListApprovedProductsBySellers(sellerIDs, limit)
+}
+
+func (r *countingRepository) ListApprovedProductsByCategories(categories []string, limit int) ([]models.Product, error) {
+	r.calls["categories"]++
+	return r.MemoryRepository.ListApprovedProductsByCategories(categories, limit)
+}
+
+type graphQLResult struct {
+	Status int
+	Data   json.RawMessage `json:"data"`
+	Errors []GraphQLError  `json:"errors"`
+}
+
+func TestGraphQL(t *testing.T) {
+	env := newTestEnv(t)
+	repo := &countingRepository{MemoryRepository: env.repo, calls: map[string]int{}}
+	env.svc.Repo = repo
+
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	other, _ := env.user("other", models.RoleSeller)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	product := func(owner *models.User, name, category string, approved bool) *models.Product {
+		p := env.product(owner.ID, name, 1000, 5, approved)
+		p.Category, p.Attributes = category, map[string]string{"Шина": "PCIe", "Память": "8 ГБ"}
+		if err := env.repo.UpdateProduct(p, false, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	gpu := product(seller, "GPU", "Видеокарты", true)
+	product(seller, "GPU Pro", "видеокарты", true)
+	cpu := product(other, "CPU", "Процессоры", true)
+	hidden := product(seller, "Hidden", "Видеокарты", false)
+
+	query := func(token, lang, q string, variables map[string]interface{}) graphQLResult {
+		t.Helper()
+		body, err := json.Marshal(models.GraphQLRequest{Query: q, Variables: variables})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+
+		result := graphQLResult{Status: rec.Code}
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+		}
+		return result
+	}
+
+	// Продавцы всех товаров страницы и их товары загружаются одним запросом
+	result := query("", "", `{ products(limit: 10) { total products { name seller { username products(limit: 1) { name } } } } }`, nil)
+	if len(result.Errors) != 0 {
+		t.Fatalf("catalog: %+v", result.Errors)
+	}
+	catalog := decode[struct {
+		Products struct {
+			Total    int
+			Products []struct {
+				Name   string
+				Seller struct {
+					Username string
+					Products []struct{ Name string }
+				}
+			}
+		}
+	}](t, result.Data)
+	if catalog.Products.Total != 3 || len(catalog.Products.Products) != 3 {
+		t.Fatalf("catalog: %+v", catalog)
+	}
+	for _, p := range catalog.Products.Products {
+		want := map[string]string{"seller": "GPU", "other": "CPU"}[p.Seller.Username]
+		if len(p.Seller.Products) != 1 || p.Seller.Products[0].Name != want {
+			t.Errorf("%s: seller products %+v", p.Name, p.Seller)
+		}
+	}
+	if repo.calls["sellers"] != 1 {
+		t.Errorf("seller products loaded in %d queries", repo.calls["sellers"])
+	}
+
+	// Категории без учета регистра, товары категорий — одним запросом
+	result = query("", "", `{ categories { name productCount products(limit: 3) { name attributes { name value } } } }`, nil)
+	categories := decode[struct {
+		Categories []struct {
+			Name         string
+			ProductCount int
+			Products     []struct {
+				Name       string
+				Attributes []struct{ Name, Value string }
+			}
+		}
+	}](t, result.Data).Categories
+	if len(categories) != 2 || categories[0].Name != "Видеокарты" || categories[0].ProductCount != 2 || len(categories[0].Products) != 2 {
+		t.Fatalf("categories: %+v", categories)
+	}
+	if attrs := categories[0].Products[0].Attributes; len(attrs) != 2 || attrs[0].Name != "Память" {
+		t.Errorf("attributes: %+v", attrs)
+	}
+	if repo.calls["categories"] != 1 {
+		t.Errorf("category products loaded in %d queries", repo.calls["categories"])
+	}
+
+	// Товары позиций корзины тоже загружаются пачкой
+	for _, p := range []*models.Product{gpu, cpu} {
+		if err := env.repo.AddCartItem(buyer.ID, p.ID, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result = query(buyerToken, "", `{ cart { count total items { name product { id stock } } } orders { id } }`, nil)
+	cart := decode[struct {
+		Cart struct {
+			Count int
+			Total json.Number
+			Items []struct {
+				Name    string
+				Product *struct{ ID int }
+			}
+		}
+		Orders []struct{ ID int }
+	}](t, result.Data)
+	if len(result.Errors) != 0 || cart.Cart.Count != 2 || cart.Cart.Total != "2000" || cart.Orders == nil {
+		t.Fatalf("cart: %+v %+v", cart, result.Errors)
+	}
+	for _, item := range cart.Cart.Items {
+		if want := map[string]int{"GPU": gpu.ID, "CPU": cpu.ID}[item.Name]; item.Product == nil || item.Product.ID != want {
+			t.Errorf("%s: product %+v", item.Name, item.Product)
+		}
+	}
+	if repo.calls["ids"] != 1 {
+		t.Errorf("cart products loaded in %d queries", repo.calls["ids"])
+	}
+
+	id := strconv.Itoa
+	for _, tt := range []struct {
+		name  string
+		token string
+		query string
+		vars  map[string]interface{}
+		code  string
+	}{
+		{"guest cart", "", `{ cart { total } }`, nil, "unauthorized"},
+		{"guest orders", "", `{ orders { id } }`, nil, "unauthorized"},
+		{"buyer products", buyerToken, `{ myProducts { id } }`, nil, "forbidden"},
+		{"seller moderation", sellerToken, `{ pendingProducts { id } }`, nil, "forbidden"},
+		{"seller products", sellerToken, `{ myProducts { id } }`, nil, ""},
+		{"moderation", adminToken, `{ pendingProducts { id isApproved } }`, nil, ""},
+		{"hidden product", "", `{ product(id: ` + id(hidden.ID) + `) { id } }`, nil, "product_not_found"},
+		{"product", "", `{ product(id: ` + id(gpu.ID) + `) { name seller { id } } }`, nil, ""},
+		{"buyer is not a seller", "", `{ seller(id: ` + id(buyer.ID) + `) { username } }`, nil, "seller_not_found"},
+		{"missing order", buyerToken, `{ order(id: 999) { id } }`, nil, "order_not_found"},
+		{"syntax", "", `{ products {`, nil, "invalid_query"},
+		{"unknown field", "", `{ users { id } }`, nil, "invalid_query"},
+		{"too deep", "", `{ seller(id: ` + id(seller.ID) + `) { products { seller { products { seller { products { seller { products { id } } } } } } } } }`, nil, "query_too_deep"},
+		{"too complex", "", `{ products(limit: 100) { products { seller { products(limit: 100) { id name } } } } }`, nil, "query_too_complex"},
+		{"too complex by variables", "", `query($n: Int) { products(limit: $n) { products { seller { products(limit: $n) { id } } } } }`,
+			map[string]interface{}{"n": 100}, "query_too_complex"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result := query(tt.token, "", tt.query, tt.vars)
+			if result.Status != http.StatusOK {
+				t.Fatalf("status %d", result.Status)
+			}
+			if tt.code == "" {
+				if len(result.Errors) != 0 {
+					t.Fatalf("errors: %+v", result.Errors)
+				}
+				return
+			}
+			if len(result.Errors) != 1 || result.Errors[0].Extensions.Code != tt.code || result.Errors[0].Message == "" {
+				t.Fatalf("errors: %+v, want %s", result.Errors, tt.code)
+			}
+		})
+	}
+
+	// Ошибка поля указывает путь и переводится на язык запроса
+	result = query("", "en", `{ categories { name } cart { total } }`, nil)
+	if len(result.Errors) != 1 || result.Errors[0].Message != "Authorization required" || fmt.Sprint(result.Errors[0].Path) != "[cart]" {
+		t.Errorf("english: %+v", result.Errors)
+	}
+	// Неверный токен — ошибка запроса целиком, а не гость
+	if result := query("broken", "", `{ categories { name } }`, nil); result.Status != http.StatusUnauthorized {
+		t.Errorf("invalid token: %d", result.Status)
+	}
+	// Слишком большой запрос отклоняется до разбора
+	if result := query("", "", "{ categories { name } }"+strings.Repeat(" ", 64<<10), nil); result.Status != http.StatusRequestEntityTooLarge {
+		t.Errorf("large query: %d", result.Status)
+	}
+}
+
+func TestReturns(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	other, otherToken := env.user("other", models.RoleSeller)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 10, true)
+	fan := env.product(other.ID, "Fan", 50, 10, true)
+	env.repo.AddOrder(models.Order{
+		UserID:   buyer.ID,
+		Status:   models.OrderDelivered,
+		Subtotal: money.FromInt(2050),
+		Total:    money.FromInt(2050),
+		Items: []models.OrderItem{
+			{ProductID: gpu.ID, Name: "GPU", Quantity: 2, PriceAtTime: money.FromInt(1000)},
+			{ProductID: fan.ID, Name: "Fan", Quantity: 1, PriceAtTime: money.FromInt(50)},
+		},
+	})
+	order, _ := env.repo.GetOrderByID(1)
+	gpuLine, fanLine := order.Items[0].ID, order.Items[1].ID
+
+	upload := func(token string, img []byte) string {
+		contentType, body := multipartBody(t, nil, map[string][]byte{"image": img})
+		req := httptest.NewRequest(http.MethodPost, "/api/upload", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, contentType)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+		var resp UploadResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Filename == "" {
+			t.Fatalf("upload: %s", rec.Body.String())
+		}
+		return resp.Filename
+	}
+	open := func(line, quantity int, photos ...string) response {
+		return env.do(http.MethodPost, "/api/orders/1/returns", buyerToken, models.ReturnRequest{
+			OrderItemID: line, Quantity: quantity, Reason: "Брак", Photos: photos,
+		})
+	}
+	advance := func(token string, id int, status string) response {
+		return env.do(http.MethodPut, fmt.Sprintf("/api/seller/returns/%d/status", id), token, models.ReturnStatusRequest{Status: status, Comment: "ok"})
+	}
+	stock := func(id int) int {
+		p, _ := env.repo.GetProductByID(id)
+		return p.Stock
+	}
+
+	// Имя файла — хэш содержимого, поэтому у чужой загрузки другая картинка
+	var foreignImage bytes.Buffer
+	if err := png.Encode(&foreignImage, image.NewRGBA(image.Rect(0, 0, 3, 3))); err != nil {
+		t.Fatal(err)
+	}
+	photo, foreign := upload(buyerToken, pngImage(t)), upload(otherToken, foreignImage.Bytes())
+	for _, tt := range []struct {
+		name     string
+		line     int
+		quantity int
+		photos   []string
+		code     string
+	}{
+		{"more than bought", gpuLine, 3, nil, "return_quantity"},
+		{"unknown line", 999, 1, nil, "order_item_not_found"},
+		{"photo not uploaded", gpuLine, 1, []string{"missing.png"}, "validation_failed"},
+		{"photo outside uploads", gpuLine, 1, []string{"../secret.png"}, "validation_failed"},
+		{"photo of another user", gpuLine, 1, []string{photo, foreign}, "validation_failed"},
+		{"too many photos", gpuLine, 1, []string{photo, photo, photo, photo, photo, photo}, "validation_failed"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := open(tt.line, tt.quantity, tt.photos...); resp.Code != tt.code {
+				t.Errorf("got %d %q, want %q", resp.Status, resp.Code, tt.code)
+			}
+		})
+	}
+
+	first := open(gpuLine, 1, photo)
+	if first.Status != http.StatusCreated {
+		t.Fatalf("create return: %d %s %+v", first.Status, first.Code, first.Details)
+	}
+	ret := decode[models.Return](t, first.Data)
+	if ret.Status != models.ReturnRequested || ret.Amount.String() != "1000" || len(ret.Photos) != 1 || ret.ProductName != "GPU" {
+		t.Errorf("return %+v", ret)
+	}
+
+	// Отклоненная заявка не занимает количество в позиции
+	second := decode[models.Return](t, open(gpuLine, 1).Data)
+	if resp := open(gpuLine, 1); resp.Code != "return_quantity" {
+		t.Errorf("third return: %d %q", resp.Status, resp.Code)
+	}
+	if resp := advance(sellerToken, second.ID, models.ReturnRejected); resp.Status != http.StatusOK {
+		t.Fatalf("reject: %d %s", resp.Status, resp.Code)
+	}
+	if resp := open(gpuLine, 1); resp.Status != http.StatusCreated {
+		t.Errorf("return after reject: %d %q", resp.Status, resp.Code)
+	}
+
+	// Продавец видит и рассматривает только заявки по своим товарам
+	if resp := advance(otherToken, ret.ID, models.ReturnAwaitingItem); resp.Status != http.StatusForbidden {
+		t.Errorf("other seller: %d %q", resp.Status, resp.Code)
+	}
+	if list := decode[[]models.Return](t, env.do(http.MethodGet, "/api/seller/returns", otherToken, nil).Data); len(list) != 0 {
+		t.Errorf("other seller sees %d returns", len(list))
+	}
+	if list := decode[[]models.Return](t, env.do(http.MethodGet, "/api/seller/returns", adminToken, nil).Data); len(list) != 3 {
+		t.Errorf("admin sees %d returns, want 3", len(list))
+	}
+
+	steps := []struct {
+		status string
+		code   string
+		stock  int
+	}{
+		{models.ReturnReceived, "return_transition", 10},
+		{models.ReturnAwaitingItem, "", 10},
+		{models.ReturnRefunded, "return_transition", 10},
+		{models.ReturnReceived, "", 11},
+		{models.ReturnReceived, "return_transition", 11},
+		{models.ReturnRefunded, "", 11},
+	}
+	for _, step := range steps {
+		if resp := advance(sellerToken, ret.ID, step.status); resp.Code != step.code {
+			t.Errorf("%s: got %d %q, want %q", step.status, resp.Status, resp.Code, step.code)
+		}
+		if got := stock(gpu.ID); got != step.stock {
+			t.Errorf("%s: stock %d, want %d", step.status, got, step.stock)
+		}
+	}
+
+	// Частичный возврат денег не меняет статус заказа
+	saved := decode[models.Order](t, env.do(http.MethodGet, "/api/orders/1", buyerToken, nil).Data)
+	if saved.Status != models.OrderDelivered || saved.Refunded.String() != "1000" {
+		t.Errorf("after partial refund: %s %s", saved.Status, saved.Refunded)
+	}
+
+	// Когда возвращены все товары, заказ становится refunded
+	rest := []int{}
+	for _, r := range decode[[]models.Return](t, env.do(http.MethodGet, "/api/returns", buyerToken, nil).Data) {
+		if r.Status == models.ReturnRequested {
+			rest = append(rest, r.ID)
+		}
+	}
+	rest = append(rest, decode[models.Return](t, open(fanLine, 1).Data).ID)
+	for _, id := range rest {
+		for _, status := range []string{models.ReturnAwaitingItem, models.ReturnReceived, models.ReturnRefunded} {
+			if resp := advance(adminToken, id, status); resp.Status != http.StatusOK {
+				t.Fatalf("return %d %s: %d %s", id, status, resp.Status, resp.Code)
+			}
+		}
+	}
+	saved = decode[models.Order](t, env.do(http.MethodGet, "/api/orders/1", buyerToken, nil).Data)
+	if saved.Status != models.OrderRefunded || saved.Refunded.String() != "2050" {
+		t.Errorf("after full refund: %s %s", saved.Status, saved.Refunded)
+	}
+	refundNotices := 0
+	for _, n := range decode[service.NotificationInbox](t, env.do(http.MethodGet, "/api/notifications", buyerToken, nil).Data).Notifications {
+		if n.Type == models.NotifyOrderStatus && n.Status == models.OrderRefunded {
+			refundNotices++
+		}
+	}
+	if refundNotices != 1 {
+		t.Errorf("refund notifications: %d", refundNotices)
+	}
+	if stock(gpu.ID) != 12 || stock(fan.ID) != 11 {
+		t.Errorf("stock GPU %d, Fan %d", stock(gpu.ID), stock(fan.ID))
+	}
+}
+
+func TestQuestions(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, otherToken := env.user("other", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 10, true)
+	env.repo.AddOrder(models.Order{
+		UserID: buyer.ID,
+		Status: models.OrderPaid,
+		Items:  []models.OrderItem{{ProductID: gpu.ID, Name: "GPU", Quantity: 1, PriceAtTime: money.FromInt(1000)}},
+	})
+
+	ask := func(token, body string) models.Question {
+		resp := env.do(http.MethodPost, fmt.Sprintf("/api/products/%d/questions", gpu.ID), token, models.QuestionRequest{Body: body})
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("ask: %d %s", resp.Status, resp.Code)
+		}
+		return decode[models.Question](t, resp.Data)
+	}
+	answer := func(token string, questionID int, body string) response {
+		return env.do(http.MethodPost, fmt.Sprintf("/api/questions/%d/answers", questionID), token, models.AnswerRequest{Body: body})
+	}
+	vote := func(token string, answerID int) response {
+		return env.do(http.MethodPut, fmt.Sprintf("/api/answers/%d/vote", answerID), token, nil)
+	}
+	detail := func() service.ProductDetail {
+		return decode[service.ProductDetail](t, env.do(http.MethodGet, fmt.Sprintf("/api/products/%d", gpu.ID), "", nil).Data)
+	}
+
+	fits := ask(otherToken, "Поместится в корпус mATX?")
+	ask(otherToken, "Есть ли подсветка?")
+
+	// Отвечать могут продавец товара и те, кто его покупал
+	if resp := answer(otherToken, fits.ID, "Не знаю"); resp.Code != "answer_forbidden" {
+		t.Errorf("answer without purchase: %d %q", resp.Status, resp.Code)
+	}
+	byBuyer := decode[models.Answer](t, answer(buyerToken, fits.ID, "У меня поместилась").Data)
+	bySeller := decode[models.Answer](t, answer(sellerToken, fits.ID, "Да, длина 240 мм").Data)
+	if !byBuyer.IsBuyer || byBuyer.IsSeller || !bySeller.IsSeller || bySeller.IsBuyer {
+		t.Errorf("badges: buyer %+v, seller %+v", byBuyer, bySeller)
+	}
+
+	if resp := vote(buyerToken, byBuyer.ID); resp.Code != "vote_own_answer" {
+		t.Errorf("own vote: %d %q", resp.Status, resp.Code)
+	}
+	// Повторный голос того же пользователя не учитывается
+	vote(otherToken, byBuyer.ID)
+	if voted := decode[models.Answer](t, vote(otherToken, byBuyer.ID).Data); voted.Votes != 1 {
+		t.Errorf("votes after repeat: %d", voted.Votes)
+	}
+
+	// В карточке только вопросы с ответами, ответ продавца первым
+	d := detail()
+	if len(d.Questions) != 1 || d.Questions[0].ID != fits.ID || len(d.Questions[0].Answers) != 2 {
+		t.Fatalf("detail questions %+v", d.Questions)
+	}
+	if first := d.Questions[0].Answers[0]; first.ID != bySeller.ID || first.Username != "seller" {
+		t.Errorf("first answer %+v", first)
+	}
+	all := decode[[]models.Question](t, env.do(http.MethodGet, fmt.Sprintf("/api/products/%d/questions", gpu.ID), "", nil).Data)
+	if len(all) != 2 {
+		t.Errorf("questions %d, want 2", len(all))
+	}
+
+	// Голос меняет ETag карточки
+	before := service.ProductDetailETag(&d)
+	vote(sellerToken, byBuyer.ID)
+	if d = detail(); service.ProductDetailETag(&d) == before {
+		t.Error("ETag did not change after vote")
+	}
+
+	// При премодерации новые записи не видны до проверки
+	env.svc.Moderator = service.PremoderateAll{}
+	hidden := ask(otherToken, "Какая гарантия?")
+	if hidden.Status != models.QAPending {
+		t.Errorf("status %q", hidden.Status)
+	}
+	if resp := answer(sellerToken, hidden.ID, "Три года"); resp.Code != "question_not_found" {
+		t.Errorf("answer to pending question: %d %q", resp.Status, resp.Code)
+	}
+	queue := decode[service.ModerationQueue](t, env.do(http.MethodGet, "/api/admin/moderation", adminToken, nil).Data)
+	if len(queue.Questions) != 1 || queue.Questions[0].ID != hidden.ID {
+		t.Fatalf("queue %+v", queue)
+	}
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/questions/%d/status", hidden.ID), adminToken, models.ModerationRequest{Status: models.QAPublished})
+	pendingAnswer := decode[models.Answer](t, answer(sellerToken, hidden.ID, "Три года").Data)
+	if d = detail(); len(d.Questions) != 1 {
+		t.Errorf("pending answer shown: %+v", d.Questions)
+	}
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/answers/%d/status", pendingAnswer.ID), adminToken, models.ModerationRequest{Status: models.QAPublished})
+	if d = detail(); len(d.Questions) != 2 {
+		t.Errorf("detail after moderation: %+v", d.Questions)
+	}
+
+	// Скрытый ответ пропадает из карточки
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/answers/%d/status", bySeller.ID), adminToken, models.ModerationRequest{Status: models.QAHidden})
+	for _, q := range detail().Questions {
+		for _, a := range q.Answers {
+			if a.ID == bySeller.ID {
+				t.Error("hidden answer is visible")
+			}
+		}
+	}
+}
+
+func TestRecommendations(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 10, true)
+	cpu := env.product(seller.ID, "CPU", 500, 10, true)
+	ram := env.product(seller.ID, "RAM", 100, 10, true)
+	fan := env.product(seller.ID, "Fan", 50, 10, true)
+	hidden := env.product(seller.ID, "Hidden", 50, 10, false)
+	soldOut := env.product(seller.ID, "Sold out", 50, 0, true)
+
+	order := func(status string, products ...*models.Product) {
+		items := []models.OrderItem{}
+		for _, p := range products {
+			items = append(items, models.OrderItem{ProductID: p.ID, Name: p.Name, Quantity: 1, PriceAtTime: p.Price})
+		}
+		env.repo.AddOrder(models.Order{UserID: buyer.ID, Status: status, Items: items})
+	}
+	order(models.OrderDelivered, gpu, cpu, ram)
+	order(models.OrderPaid, gpu, cpu)
+	order(models.OrderPaid, gpu, hidden, soldOut)
+	order(models.OrderCancelled, gpu, fan)
+	if err := env.svc.RefreshProductPairs(); err != nil {
+		t.Fatal(err)
+	}
+
+	view := func(id int, token, session string) (service.ProductDetail, string) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/products/%d", id), nil)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		if session != "" {
+			req.Header.Set(HeaderSessionID, session)
+		}
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("view %d: %d %s", id, rec.Code, rec.Body.String())
+		}
+		var resp ProductResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Data, rec.Header().Get("ETag")
+	}
+	names := func(products []models.Product) string {
+		list := []string{}
+		for _, p := range products {
+			list = append(list, p.Name)
+		}
+		return strings.Join(list, ",")
+	}
+
+	// Скрытые, распроданные и товары из отмененных заказов не советуются
+	detail, _ := view(gpu.ID, "", "")
+	if got := names(detail.Recommendations.BoughtTogether); got != "CPU,RAM" {
+		t.Errorf("bought together %q, want CPU,RAM", got)
+	}
+	if len(detail.Recommendations.RecentlyViewed) != 0 {
+		t.Errorf("viewed without session: %+v", detail.Recommendations.RecentlyViewed)
+	}
+
+	// Анонимная сессия: последние просмотры первыми, без самого товара
+	const session = "3f1c2a9e-anon"
+	view(cpu.ID, "", session)
+	view(ram.ID, "", session)
+	view(hidden.ID, "", session)
+	view(cpu.ID, "", session)
+	detail, before := view(gpu.ID, "", session)
+	if got := names(detail.Recommendations.RecentlyViewed); got != "CPU,RAM" {
+		t.Errorf("session viewed %q, want CPU,RAM", got)
+	}
+	view(fan.ID, "", session)
+	if _, after := view(gpu.ID, "", session); after == before {
+		t.Error("ETag did not change after another view")
+	}
+
+	// Неверный токен на публичной карточке не мешает, просмотр идет в сессию
+	if detail, _ = view(ram.ID, "broken", session); names(detail.Recommendations.RecentlyViewed) != "GPU,Fan,CPU" {
+		t.Errorf("viewed with broken token: %q", names(detail.Recommendations.RecentlyViewed))
+	}
+
+	// История пользователя отдельна от сессии и попадает в корзину без товаров из нее
+	view(fan.ID, buyerToken, session)
+	view(gpu.ID, buyerToken, session)
+	if err := env.repo.AddCartItem(buyer.ID, gpu.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	cart := decode[service.Cart](t, env.do(http.MethodGet, "/api/cart", buyerToken, nil).Data)
+	if got := names(cart.Recommendations.RecentlyViewed); got != "Fan" {
+		t.Errorf("cart viewed %q, want Fan", got)
+	}
+	if got := names(cart.Recommendations.BoughtTogether); got != "CPU,RAM" {
+		t.Errorf("cart bought together %q, want CPU,RAM", got)
+	}
+}
+
+func TestComparison(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+
+	product := func(name, category string, attributes map[string]string) *models.Product {
+		p := env.product(seller.ID, name, 1000, 5, true)
+		p.Category, p.Attributes = category, attributes
+		if err := env.repo.UpdateProduct(p, false, seller.ID); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	compare := func(method, path, token, session string, body interface{}) (int, service.Comparison, response) {
+		var reader *bytes.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req := httptest.NewRequest(method, path, reader)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		if session != "" {
+			req.Header.Set(HeaderSessionID, session)
+		}
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+
+		var ok ComparisonResponse
+		var fail response
+		json.Unmarshal(rec.Body.Bytes(), &ok)
+		json.Unmarshal(rec.Body.Bytes(), &fail)
+		return rec.Code, ok.Data, fail
+	}
+	add := func(token, session string, id int) (int, service.Comparison, response) {
+		return compare(http.MethodPost, "/api/compare", token, session, models.CompareRequest{ProductID: id})
+	}
+
+	rtx := product("RTX 4060", "Видеокарты", map[string]string{"Память": "8 ГБ", "Шина": "128 бит"})
+	rx := product("RX 7600", "видеокарты", map[string]string{"память": "8  гб", "Шина": "128 бит", "Разъем питания": "8-pin"})
+	arc := product("Arc A750", "Видеокарты", map[string]string{"Память": "8 ГБ", "Шина": "256 бит"})
+	gt := product("GT 1030", "Видеокарты", nil)
+	rtx2 := product("RTX 4070", "Видеокарты", nil)
+	cpu := product("Ryzen 5", "Процессоры", nil)
+	hidden := env.product(seller.ID, "Hidden", 10, 1, false)
+
+	if status, _, fail := compare(http.MethodGet, "/api/compare", "", "", nil); status != http.StatusBadRequest || fail.Code != "compare_owner_required" {
+		t.Fatalf("without owner: %d %q", status, fail.Code)
+	}
+
+	// Анонимная сессия: категория сравнивается без учета регистра,
+	// одинаковые значения с разным регистром и пробелами не считаются отличием
+	const session = "5b2e7c1d-anon"
+	add("", session, rtx.ID)
+	add("", session, rtx.ID)
+	status, list, _ := add("", session, rx.ID)
+	if status != http.StatusOK || len(list.Products) != 2 || list.Category != "Видеокарты" {
+		t.Fatalf("session list: %d %+v", status, list)
+	}
+	rows := map[string]service.CompareRow{}
+	for _, row := range list.Rows {
+		rows[row.Name] = row
+	}
+	if len(list.Rows) != 3 {
+		t.Fatalf("rows %+v", list.Rows)
+	}
+	if memory := rows["Память"]; memory.Differs || len(memory.Values) != 2 {
+		t.Errorf("memory row %+v", memory)
+	}
+	if power := rows["Разъем питания"]; !power.Differs || power.Values[0] != "" || power.Values[1] != "8-pin" {
+		t.Errorf("power row %+v", power)
+	}
+
+	if _, _, fail := add("", session, cpu.ID); fail.Code != "compare_category" {
+		t.Errorf("other category: %q", fail.Code)
+	}
+	if _, _, fail := add("", session, hidden.ID); fail.Code != "product_unavailable" {
+		t.Errorf("hidden product: %q", fail.Code)
+	}
+	add("", session, arc.ID)
+	add("", session, gt.ID)
+	if status, _, fail := add("", session, rtx2.ID); status != http.StatusBadRequest || fail.Code != "compare_full" {
+		t.Errorf("fifth product: %d %q", status, fail.Code)
+	}
+
+	// Список пользователя не смешивается с сессией
+	if _, list, _ := compare(http.MethodGet, "/api/compare", buyerToken, session, nil); len(list.Products) != 0 {
+		t.Errorf("user list sees session items: %+v", list.Products)
+	}
+
+	status, list, _ = compare(http.MethodDelete, fmt.Sprintf("/api/compare/%d", rtx.ID), "", session, nil)
+	if status != http.StatusOK || len(list.Products) != 3 || list.Products[0].ID != rx.ID {
+		t.Errorf("after remove: %d %+v", status, list.Products)
+	}
+	if status, _, fail := compare(http.MethodDelete, fmt.Sprintf("/api/compare/%d", rtx.ID), "", session, nil); status != http.StatusNotFound || fail.Code != "compare_item_not_found" {
+		t.Errorf("remove twice: %d %q", status, fail.Code)
+	}
+	compare(http.MethodDelete, "/api/compare", "", session, nil)
+	if _, list, _ := compare(http.MethodGet, "/api/compare", "", session, nil); len(list.Products) != 0 || list.Category != "" {
+		t.Errorf("after clear: %+v", list)
+	}
+
+	// Категория и характеристики задаются в форме товара
+	send := func(method, path string, values url.Values) response {
+		req := httptest.NewRequest(method, path, strings.NewReader(values.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+
+		resp := response{Status: rec.Code}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+	form := url.Values{"name": {"SSD"}, "description": {"d"}, "price": {"10"}, "stock": {"3"},
+		"category": {" Накопители "}, "attributes": {`{"Объем": "1 ТБ", " Форм-фактор ": "M.2", "Пусто": ""}`}}
+	resp := send(http.MethodPost, "/api/seller/products", form)
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("create: %d %s", resp.Status, resp.Error)
+	}
+	created := decode[CreatedProduct](t, resp.Data)
+	stored, _ := env.repo.GetProductByID(created.ID)
+	if stored.Category != "Накопители" || len(stored.Attributes) != 2 || stored.Attributes["Форм-фактор"] != "M.2" {
+		t.Errorf("stored %q %+v", stored.Category, stored.Attributes)
+	}
+
+	// Пустые поля при редактировании оставляют прежние значения
+	update := url.Values{"name": {"SSD 2"}, "description": {"d"}, "price": {"10"}, "stock": {"3"}}
+	path := fmt.Sprintf("/api/seller/products/%d", created.ID)
+	if resp := send(http.MethodPut, path, update); resp.Status != http.StatusOK {
+		t.Fatalf("update: %d %s", resp.Status, resp.Error)
+	}
+	stored, _ = env.repo.GetProductByID(created.ID)
+	if stored.Category != "Накопители" || len(stored.Attributes) != 2 {
+		t.Errorf("update lost attributes: %q %+v", stored.Category, stored.Attributes)
+	}
+
+	for _, attributes := range []string{`[1]`, `{"Объем": "1 ТБ", "объем": "2 ТБ"}`, `{"` + strings.Repeat("x", 65) + `": "1"}`} {
+		update.Set("attributes", attributes)
+		if resp := send(http.MethodPut, path, update); resp.Status != http.StatusBadRequest || resp.Code != "invalid_attributes" {
+			t.Errorf("attributes %s: %d %q", attributes, resp.Status, resp.Code)
+		}
+	}
+}
+
+func TestSales(t *testing.T) {
+	env := newTestEnv(t)
+	env.svc.Rates = money.NewStaticRates("RUB", map[string]money.Amount{"USD": money.FromInt(80)})
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	rival, rivalToken := env.user("rival", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+	_, buyerToken := env.user("buyer", models.RoleCustomer)
+
+	product := func(owner *models.User, name, category string, price int64) *models.Product {
+		p := env.product(owner.ID, name, price, 10, true)
+		p.Category = category
+		if err := env.repo.UpdateProduct(p, false, owner.ID); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	gpu := product(seller, "GPU", "Видеокарты", 1000)
+	fan := product(seller, "Fan", "Охлаждение", 500)
+	foreign := product(rival, "Rival GPU", "Видеокарты", 2000)
+
+	now := time.Now()
+	request := func(discountType string, value int64, startsAt time.Time, category string, ids ...int) models.SaleRequest {
+		return models.SaleRequest{Name: "Распродажа", DiscountType: discountType, DiscountValue: money.FromInt(value),
+			StartsAt: startsAt, EndsAt: startsAt.Add(2 * time.Hour), Category: category, ProductIDs: ids}
+	}
+	create := func(token string, req models.SaleRequest) models.Sale {
+		t.Helper()
+		resp := env.do(http.MethodPost, "/api/seller/sales", token, req)
+		if resp.Status != http.StatusCreated {
+			t.Fatalf("create sale: %d %q", resp.Status, resp.Code)
+		}
+		return decode[models.Sale](t, resp.Data)
+	}
+	catalog := func(currency string) map[string]models.Product {
+		t.Helper()
+		page := decode[service.ProductPage](t, env.do(http.MethodGet, "/api/products?currency="+currency, "", nil).Data)
+		products := map[string]models.Product{}
+		for _, p := range page.Products {
+			products[p.Name] = p
+		}
+		return products
+	}
+	price := func(p models.Product) string {
+		sale := 0
+		if p.Sale != nil {
+			sale = p.Sale.ID
+		}
+		return fmt.Sprintf("%s/%s sale=%d", p.Price, p.OriginalPrice, sale)
+	}
+
+	errs := []struct {
+		name  string
+		token string
+		req   models.SaleRequest
+		code  string
+	}{
+		{"percent too high", sellerToken, request(models.DiscountPercent, 95, now, "Видеокарты"), "sale_percent"},
+		{"already over", sellerToken, request(models.DiscountPercent, 10, now.Add(-3*time.Hour), "Видеокарты"), "sale_window"},
+		{"no target", sellerToken, request(models.DiscountPercent, 10, now, ""), "sale_target_required"},
+		{"foreign product", sellerToken, request(models.DiscountFixed, 100, now, "", foreign.ID), "sale_product_invalid"},
+		{"missing product", sellerToken, request(models.DiscountFixed, 100, now, "", 999), "sale_product_invalid"},
+		{"zero discount", sellerToken, request(models.DiscountFixed, 0, now, "Видеокарты"), "validation_failed"},
+		{"customer", buyerToken, request(models.DiscountPercent, 10, now, "Видеокарты"), "forbidden"},
+	}
+	for _, tt := range errs {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := env.do(http.MethodPost, "/api/seller/sales", tt.token, tt.req); resp.Code != tt.code {
+				t.Errorf("got %d %q, want %q", resp.Status, resp.Code, tt.code)
+			}
+		})
+	}
+
+	// Запланированная акция не меняет цены до старта. Со стартом срабатывает
+	// подписка на снижение цены, с окончанием акция завершается.
+	env.do(http.MethodPut, fmt.Sprintf("/api/products/%d/price-alert", gpu.ID), buyerToken, models.PriceAlertRequest{TargetPrice: money.FromInt(950)})
+	start := now.Add(time.Hour)
+	scheduled := create(sellerToken, request(models.DiscountPercent, 10, start, "видеокарты"))
+	if scheduled.Status != models.SaleScheduled || scheduled.SellerID == nil || *scheduled.SellerID != seller.ID {
+		t.Fatalf("scheduled sale %+v", scheduled)
+	}
+	if got := price(catalog("")["GPU"]); got != "1000/1000 sale=0" {
+		t.Errorf("price before start %s", got)
+	}
+	if err := env.svc.AdvanceSales(start); err != nil {
+		t.Fatal(err)
+	}
+	alerts := decode[[]models.PriceAlert](t, env.do(http.MethodGet, "/api/price-alerts", buyerToken, nil).Data)
+	if len(alerts) != 1 || alerts[0].TriggeredAt == nil || !alerts[0].TriggeredPrice.Equal(money.FromInt(900)) {
+		t.Errorf("alert on sale start %+v", alerts)
+	}
+	// Окно акции проверяется при чтении: статус уже active, но время еще не наступило
+	if got := price(catalog("")["GPU"]); got != "1000/1000 sale=0" {
+		t.Errorf("price before start by clock %s", got)
+	}
+	if err := env.svc.AdvanceSales(start.Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if sale, _ := env.repo.GetSale(scheduled.ID); sale.Status != models.SaleEnded {
+		t.Errorf("status after end %q", sale.Status)
+	}
+
+	// Акция продавца не трогает чужие товары той же категории; скидки не суммируются,
+	// действует самая выгодная
+	own := create(sellerToken, request(models.DiscountPercent, 20, now.Add(-time.Minute), "ВИДЕОКАРТЫ"))
+	shop := create(adminToken, request(models.DiscountFixed, 300, now.Add(-time.Minute), "", gpu.ID, foreign.ID))
+	half := create(sellerToken, request(models.DiscountPercent, 50, now.Add(-time.Minute), "", fan.ID))
+	if own.Status != models.SaleActive || shop.SellerID != nil {
+		t.Fatalf("immediate sales %+v %+v", own, shop)
+	}
+
+	products := catalog("")
+	for name, want := range map[string]string{
+		"GPU":       fmt.Sprintf("700/1000 sale=%d", shop.ID),
+		"Rival GPU": fmt.Sprintf("1700/2000 sale=%d", shop.ID),
+		"Fan":       fmt.Sprintf("250/500 sale=%d", half.ID),
+	} {
+		if got := price(products[name]); got != want {
+			t.Errorf("%s: %s, want %s", name, got, want)
+		}
+	}
+	detail := decode[models.Product](t, env.do(http.MethodGet, fmt.Sprintf("/api/products/%d?currency=usd", gpu.ID), "", nil).Data)
+	if got := price(detail); got != fmt.Sprintf("8.75/12.5 sale=%d", shop.ID) || detail.Currency != "USD" {
+		t.Errorf("detail in USD %s %s", got, detail.Currency)
+	}
+
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: gpu.ID, Quantity: 1})
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: fan.ID, Quantity: 2})
+	cart := decode[service.Cart](t, env.do(http.MethodGet, "/api/cart", buyerToken, nil).Data)
+	for _, item := range cart.Items {
+		if item.ProductID == gpu.ID && (item.Price.String() != "700" || item.OriginalPrice.String() != "1000" || item.Sale == nil) {
+			t.Errorf("cart item %+v", item)
+		}
+	}
+	if cart.Subtotal.String() != "1200" {
+		t.Errorf("cart subtotal %s", cart.Subtotal)
+	}
+
+	pickup, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Самовывоз", Kind: models.DeliveryPickup, Pricing: models.PricingByWeight, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.Zero}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := env.do(http.MethodPost, "/api/orders", buyerToken, models.CheckoutRequest{DeliveryMethodID: pickup.ID})
+	if resp.Status != http.StatusCreated {
+		t.Fatalf("checkout: %d %q", resp.Status, resp.Code)
+	}
+	order := decode[models.Order](t, resp.Data)
+	prices := map[string]string{}
+	for _, item := range order.Items {
+		prices[item.Name] = item.PriceAtTime.String()
+	}
+	if order.Subtotal.String() != "1200" || prices["GPU"] != "700" || prices["Fan"] != "250" {
+		t.Errorf("order %s %v", order.Subtotal, prices)
+	}
+
+	// Отмена: свою акцию отменяет продавец, любую — администратор
+	cancel := func(token string, id int) response {
+		return env.do(http.MethodDelete, fmt.Sprintf("/api/seller/sales/%d", id), token, nil)
+	}
+	if resp := cancel(rivalToken, own.ID); resp.Code != "sale_forbidden" {
+		t.Errorf("rival cancel: %d %q", resp.Status, resp.Code)
+	}
+	if resp := cancel(sellerToken, shop.ID); resp.Code != "sale_forbidden" {
+		t.Errorf("seller cancels shop sale: %d %q", resp.Status, resp.Code)
+	}
+	if resp := cancel(adminToken, shop.ID); resp.Status != http.StatusOK || decode[models.Sale](t, resp.Data).Status != models.SaleCancelled {
+		t.Errorf("admin cancel: %d %q", resp.Status, resp.Code)
+	}
+	if got := price(catalog("")["GPU"]); got != fmt.Sprintf("800/1000 sale=%d", own.ID) {
+		t.Errorf("price after cancel %s", got)
+	}
+	if resp := cancel(adminToken, shop.ID); resp.Code != "sale_finished" {
+		t.Errorf("cancel twice: %q", resp.Code)
+	}
+	if resp := cancel(adminToken, scheduled.ID); resp.Code != "sale_finished" {
+		t.Errorf("cancel ended: %q", resp.Code)
+	}
+	if resp := cancel(adminToken, 999); resp.Code != "sale_not_found" {
+		t.Errorf("cancel missing: %q", resp.Code)
+	}
+
+	count := func(token string) int {
+		return len(decode[[]models.Sale](t, env.do(http.MethodGet, "/api/seller/sales", token, nil).Data))
+	}
+	if got := fmt.Sprint(count(sellerToken), count(rivalToken), count(adminToken)); got != "3 0 4" {
+		t.Errorf("sales visible to seller, rival, admin: %s", got)
+	}
+}
+
+func TestProductOwnership(t *testing.T) {
+	env := newTestEnv(t)
+	owner, ownerToken := env.user("owner", models.RoleSeller)
+	_, otherToken := env.user("other", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+	_, customerToken := env.user("customer", models.RoleCustomer)
+
+	form := func(name string) string {
+		return "name=" + name + "&description=d&price=10.5&stock=3"
+	}
+	put := func(id int, token, body string) response {
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/seller/products/%d", id), strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+
+		resp := response{Status: rec.Code}
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		status       int
+		wantApproved bool
+	}{
+		{"other seller is forbidden", otherToken, http.StatusForbidden, true},
+		{"owner edit goes back to moderation", ownerToken, http.StatusOK, false},
+		{"admin edit keeps approval state", adminToken, http.StatusOK, false},
+	}
+
+	product := env.product(owner.ID, "SSD", 10, 1, true)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := put(product.ID, tt.token, form("Updated"))
+			if resp.Status != tt.status {
+				t.Fatalf("got %d (%s), want %d", resp.Status, resp.Error, tt.status)
+			}
+
+			stored, _ := env.repo.GetProductByID(product.ID)
+			if stored.IsApproved != tt.wantApproved {
+				t.Errorf("approved %v, want %v", stored.IsApproved, tt.wantApproved)
+			}
+		})
+	}
+
+	if resp := put(999, ownerToken, form("x")); resp.Status != http.StatusNotFound {
+		t.Errorf("update missing product: %d", resp.Status)
+	}
+
+	deleteTests := []struct {
+		name    string
+		token   string
+		inCart  bool
+		status  int
+		message string
+	}{
+		{"other seller cannot delete", otherToken, false, http.StatusForbidden, ""},
+		{"product in carts is hidden", ownerToken, true, http.StatusOK, "Товар скрыт (был в корзинах пользователей)"},
+		{"product without carts is deleted", ownerToken, false, http.StatusOK, "Товар удален"},
+	}
+
+	for _, tt := range deleteTests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := env.product(owner.ID, "HDD", 10, 5, true)
+			if tt.inCart {
+				env.repo.AddCartItem(1, p.ID, 1)
+			}
+
+			resp := env.do(http.MethodDelete, fmt.Sprintf("/api/seller/products/%d", p.ID), tt.token, nil)
+			if resp.Status != tt.status {
+				t.Fatalf("got %d, want %d", resp.Status, tt.status)
+			}
+			if tt.message != "" && resp.Message != tt.message {
+				t.Errorf("message %q, want %q", resp.Message, tt.message)
+			}
+		})
+	}
+
+	if resp := env.do(http.MethodDelete, "/api/seller/products/1", customerToken, nil); resp.Status != http.StatusForbidden {
+		t.Errorf("customer delete: %d", resp.Status)
+	}
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	env := newTestEnv(t)
+	mainAdmin, mainAdminToken := env.user(service.MainAdminUsername, models.RoleAdmin)
+	admin, adminToken := env.user("admin", models.RoleAdmin)
+	customer, _ := env.user("customer", models.RoleCustomer)
+	protected, _ := env.user("protected", models.RoleSeller)
+	env.repo.SetUserProtected(protected.ID, true)
+
+	path := func(id int) string { return fmt.Sprintf("/api/admin/users/%d/role", id) }
+
+	tests := []struct {
+		name     string
+		token    string
+		path     string
+		role     string
+		status   int
+		error    string
+		newToken bool
+		wantRole string
+	}{
+		{"bad id", adminToken, "/api/admin/users/x/role", models.RoleSeller, http.StatusBadRequest, "Неверный ID", false, ""},
+		{"unknown role", adminToken, path(customer.ID), "superuser", http.StatusBadRequest, "Проверьте введенные данные", false, ""},
+		{"unknown user", adminToken, path(999), models.RoleSeller, http.StatusNotFound, "Пользователь не найден", false, ""},
+		{"protected user", adminToken, path(protected.ID), models.RoleCustomer, http.StatusForbidden, "Нельзя изменить роль защищенного пользователя", false, ""},
+		{"only main admin assigns admins", adminToken, path(customer.ID), models.RoleAdmin, http.StatusForbidden, "Только главный администратор может назначать администраторов", false, ""},
+		{"promote to seller", adminToken, path(customer.ID), models.RoleSeller, http.StatusOK, "", false, models.RoleSeller},
+		{"main admin assigns admin", mainAdminToken, path(customer.ID), models.RoleAdmin, http.StatusOK, "", false, models.RoleAdmin},
+		{"admin cannot reassign admin to self", adminToken, path(admin.ID), models.RoleAdmin, http.StatusForbidden, "Только главный администратор может назначать администраторов", false, ""},
+		{"self to same role", mainAdminToken, path(mainAdmin.ID), models.RoleAdmin, http.StatusBadRequest, "Роль уже установлена", false, ""},
+		{"self demotion issues new token", adminToken, path(admin.ID), models.RoleSeller, http.StatusOK, "", true, models.RoleSeller},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPut, tt.path, tt.token, models.UpdateRoleRequest{Role: tt.role})
+			if resp.Status != tt.status || resp.Error != tt.error {
+				t.Fatalf("got %d %q, want %d %q", resp.Status, resp.Error, tt.status, tt.error)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			data := decode[struct {
+				NewToken string `json:"new_token"`
+				NewRole  string `json:"new_role"`
+				User     struct {
+					Role string `json:"role"`
+				} `json:"user"`
+			}](t, resp.Data)
+
+			if tt.newToken {
+				claims, err := env.svc.ValidateJWT(data.NewToken)
+				if err != nil || claims.Role != tt.wantRole {
+					t.Fatalf("new token %q invalid or has wrong role: %v", data.NewToken, err)
+				}
+				if data.User.Role != tt.wantRole {
+					t.Errorf("user role %q, want %q", data.User.Role, tt.wantRole)
+				}
+			} else if data.NewRole != tt.wantRole {
+				t.Errorf("new_role %q, want %q", data.NewRole, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestToggleUserActive(t *testing.T) {
+	env := newTestEnv(t)
+	admin, adminToken := env.user("admin", models.RoleAdmin)
+	customer, _ := env.user("customer", models.RoleCustomer)
+	protected, _ := env.user("protected", models.RoleSeller)
+	env.repo.SetUserProtected(protected.ID, true)
+
+	path := func(id int) string { return fmt.Sprintf("/api/admin/users/%d/active", id) }
+
+	tests := []struct {
+		name    string
+		path    string
+		status  int
+		message string
+		error   string
+	}{
+		{"unknown user", path(999), http.StatusNotFound, "", "Пользователь не найден"},
+		{"protected user", path(protected.ID), http.StatusForbidden, "", "Нельзя заблокировать защищенного пользователя"},
+		{"self", path(admin.ID), http.StatusForbidden, "", "Нельзя заблокировать себя"},
+		{"block", path(customer.ID), http.StatusOK, "Пользователь заблокирован", ""},
+		{"unblock", path(customer.ID), http.StatusOK, "Пользователь разблокирован", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPut, tt.path, adminToken, nil)
+			if resp.Status != tt.status || resp.Message != tt.message || resp.Error != tt.error {
+				t.Fatalf("got %d %q %q, want %d %q %q", resp.Status, resp.Message, resp.Error, tt.status, tt.message, tt.error)
+			}
+		})
+	}
+}
+
+func TestUserSearchAndBulk(t *testing.T) {
+	env := newTestEnv(t)
+	admin, adminToken := env.user("admin", models.RoleAdmin)
+	protected, _ := env.user("protected", models.RoleSeller)
+	env.repo.SetUserProtected(protected.ID, true)
+	var customers []int
+	for _, name := range []string{"alice", "bob", "Alina", "carol"} {
+		u, _ := env.user(name, models.RoleCustomer)
+		customers = append(customers, u.ID)
+	}
+
+	list := func(query string) service.UserPage {
+		resp := env.do(http.MethodGet, "/api/admin/users"+query, adminToken, nil)
+		if resp.Status != http.StatusOK {
+			t.Fatalf("%s: %d %s", query, resp.Status, resp.Code)
+		}
+		return decode[service.UserPage](t, resp.Data)
+	}
+	names := func(page service.UserPage) string {
+		var list []string
+		for _, u := range page.Users {
+			list = append(list, u.Username)
+		}
+		return strings.Join(list, ",")
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  string
+		total int
+	}{
+		{"?q=ALI", "Alina,alice", 2},
+		{"?q=%40example", "carol,Alina,bob,alice,protected,admin", 6},
+		{"?role=seller", "protected", 1},
+		{"?protected=true", "protected", 1},
+		{"?role=customer&limit=3&page=2", "alice", 4},
+	} {
+		if page := list(tt.query); names(page) != tt.want || page.Total != tt.total {
+			t.Errorf("%s: %q total %d, want %q total %d", tt.query, names(page), page.Total, tt.want, tt.total)
+		}
+	}
+
+	bulk := func(req models.BulkUserRequest) map[int]string {
+		resp := env.do(http.MethodPost, "/api/admin/users/bulk", adminToken, req)
+		if resp.Status != http.StatusOK {
+			t.Fatalf("bulk: %d %s", resp.Status, resp.Code)
+		}
+		codes := map[int]string{}
+		for _, r := range decode[[]models.BulkUserResult](t, resp.Data) {
+			codes[r.UserID] = r.Code
+		}
+		return codes
+	}
+
+	// Массовая блокировка проходит те же проверки, что и одиночная
+	codes := bulk(models.BulkUserRequest{UserIDs: []int{customers[0], customers[1], protected.ID, admin.ID, 999}, Action: models.BulkBlock})
+	want := map[int]string{customers[0]: "", customers[1]: "", protected.ID: "protected_user_block", admin.ID: "bulk_self", 999: "user_not_found"}
+	for id, code := range want {
+		if codes[id] != code {
+			t.Errorf("block %d: %q, want %q", id, codes[id], code)
+		}
+	}
+	if page := list("?active=false"); names(page) != "bob,alice" {
+		t.Errorf("blocked: %q", names(page))
+	}
+	bulk(models.BulkUserRequest{UserIDs: []int{customers[0]}, Action: models.BulkUnblock})
+	if page := list("?active=false"); names(page) != "bob" {
+		t.Errorf("blocked after unblock: %q", names(page))
+	}
+
+	// Смена роли: администраторов назначает только главный администратор
+	codes = bulk(models.BulkUserRequest{UserIDs: []int{customers[2], customers[3]}, Action: models.BulkRole, Role: models.RoleAdmin})
+	if codes[customers[2]] != "admin_assignment_forbidden" || codes[customers[3]] != "admin_assignment_forbidden" {
+		t.Errorf("admin assignment: %v", codes)
+	}
+	codes = bulk(models.BulkUserRequest{UserIDs: []int{customers[2], protected.ID}, Action: models.BulkRole, Role: models.RoleSeller})
+	if codes[customers[2]] != "" || codes[protected.ID] != "protected_user_role" {
+		t.Errorf("role change: %v", codes)
+	}
+	if page := list("?role=seller"); names(page) != "Alina,protected" {
+		t.Errorf("sellers: %q", names(page))
+	}
+
+	// Карточка пользователя: товары, заказы и входы
+	gpu := env.product(customers[2], "GPU", 1000, 5, true)
+	env.repo.AddOrder(models.Order{
+		UserID: customers[2],
+		Status: models.OrderPaid,
+		Items:  []models.OrderItem{{ProductID: gpu.ID, Name: "GPU", Quantity: 1, PriceAtTime: money.FromInt(1000)}},
+	})
+	env.do(http.MethodPost, "/api/login", "", models.LoginRequest{Username: "Alina", Password: "password"})
+	env.do(http.MethodPost, "/api/login", "", models.LoginRequest{Username: "Alina", Password: "wrong"})
+	detail := decode[service.AdminUserDetail](t, env.do(http.MethodGet, fmt.Sprintf("/api/admin/users/%d", customers[2]), adminToken, nil).Data)
+	if detail.User.Role != models.RoleSeller || len(detail.Products) != 1 || len(detail.Orders) != 1 || len(detail.Logins) != 1 {
+		t.Errorf("detail %+v", detail)
+	}
+}
+
+func TestModeration(t *testing.T) {
+	env := newTestEnv(t)
+	seller, _ := env.user("seller", models.RoleSeller)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	pending := env.product(seller.ID, "Pending", 10, 1, false)
+	env.product(seller.ID, "Approved", 10, 1, true)
+
+	resp := env.do(http.MethodGet, "/api/admin/pending-products", adminToken, nil)
+	products := decode[[]models.Product](t, resp.Data)
+	if len(products) != 1 || products[0].ID != pending.ID || products[0].Username != "seller" {
+		t.Fatalf("pending %+v", products)
+	}
+
+	env.do(http.MethodPut, fmt.Sprintf("/api/admin/products/%d/approve", pending.ID), adminToken, nil)
+	resp = env.do(http.MethodGet, "/api/admin/pending-products", adminToken, nil)
+	if products := decode[[]models.Product](t, resp.Data); len(products) != 0 {
+		t.Fatalf("pending after approve %+v", products)
+	}
+
+	env.repo.AddCartItem(seller.ID, pending.ID, 1)
+	env.do(http.MethodDelete, fmt.Sprintf("/api/admin/products/%d/force", pending.ID), adminToken, nil)
+	if _, err := env.repo.GetProductByID(pending.ID); err != repository.ErrNotFound {
+		t.Fatalf("product still exists after force delete: %v", err)
+	}
+	if inCart, _ := env.repo.ProductInCarts(pending.ID); inCart {
+		t.Fatal("cart items survived force delete")
+	}
+}
+
+func TestImportProducts(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	existing := env.product(seller.ID, "Old name", 10, 1, true)
+	env.repo.ImportProducts(seller.ID, true, []models.ImportRow{{SKU: "SKU-1", Name: "Old name", Price: money.FromInt(10), Stock: 1}}, true)
+	env.repo.DeleteProduct(existing.ID)
+
+	tests := []struct {
+		name        string
+		query       string
+		body        interface{}
+		status      int
+		committed   bool
+		actions     []string
+		wantCatalog int
+	}{
+		{
+			name:        "dry run reports without saving",
+			query:       "?dry_run=true",
+			body:        []models.ImportRow{{SKU: "SKU-1", Name: "New name", Price: money.FromInt(20), Stock: 2}, {SKU: "SKU-2", Name: "Fresh", Price: money.FromInt(5), Stock: 1}},
+			status:      http.StatusOK,
+			actions:     []string{"update", "create"},
+			wantCatalog: 1,
+		},
+		{
+			name:        "invalid row cancels whole import",
+			body:        "sku;name;price;stock\nSKU-2;Fresh;5;1\nSKU-2;Dup;abc;1\n",
+			status:      http.StatusUnprocessableEntity,
+			actions:     []string{"create", "error"},
+			wantCatalog: 1,
+		},
+		{
+			name:        "commit creates and updates by sku",
+			body:        "sku,name,price,stock\nSKU-1,New name,20,2\nSKU-2,Fresh,\"5,50\",1\n",
+			status:      http.StatusOK,
+			committed:   true,
+			actions:     []string{"update", "create"},
+			wantCatalog: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodPost, "/api/seller/products/import"+tt.query, sellerToken, tt.body)
+			if resp.Status != tt.status {
+				t.Fatalf("got %d (%s %s), want %d", resp.Status, resp.Error, resp.Data, tt.status)
+			}
+
+			report := decode[models.ImportReport](t, resp.Data)
+			if report.Committed != tt.committed {
+				t.Errorf("committed %v, want %v", report.Committed, tt.committed)
+			}
+			var actions []string
+			for _, row := range report.Rows {
+				actions = append(actions, row.Action)
+			}
+			if fmt.Sprint(actions) != fmt.Sprint(tt.actions) {
+				t.Errorf("actions %v, want %v", actions, tt.actions)
+			}
+
+			products, _ := env.repo.ListProductsByUser(seller.ID)
+			if len(products) != tt.wantCatalog {
+				t.Errorf("seller has %d products, want %d", len(products), tt.wantCatalog)
+			}
+		})
+	}
+
+	products, _ := env.repo.ListProductsByUser(seller.ID)
+	for _, p := range products {
+		if p.IsApproved {
+			t.Errorf("seller import %s should wait for moderation", p.SKU)
+		}
+		if p.SKU == "SKU-2" && !p.Price.Equal(money.MustParse("5.5")) {
+			t.Errorf("price %v, want 5.5", p.Price)
+		}
+	}
+
+	// Изображение принимается, только если продавец его загружал или оно уже стоит у его товара
+	other, _ := env.user("other", models.RoleSeller)
+	foreign := env.product(other.ID, "Foreign", 10, 1, true)
+	foreign.Image = "theirs.png"
+	if err := env.repo.UpdateProduct(foreign, false, other.ID); err != nil {
+		t.Fatal(err)
+	}
+	env.repo.RecordUpload(seller.ID, "mine.png")
+	rows := []models.ImportRow{
+		{SKU: "SKU-1", Name: "New name", Price: money.FromInt(20), Stock: 2, Image: "mine.png"},
+		{SKU: "SKU-2", Name: "Fresh", Price: money.FromInt(5), Stock: 1, Image: "default.png"},
+		{SKU: "SKU-3", Name: "Stolen", Price: money.FromInt(5), Stock: 1, Image: "theirs.png"},
+	}
+	resp := env.do(http.MethodPost, "/api/seller/products/import?dry_run=true", sellerToken, rows)
+	report := decode[models.ImportReport](t, resp.Data)
+	if resp.Status != http.StatusUnprocessableEntity || report.Failed != 1 || len(report.Rows[2].Errors) != 1 || report.Rows[2].Errors[0].Code != "image_owner" {
+		t.Fatalf("foreign image: %d %+v", resp.Status, report.Rows)
+	}
+	if msg := report.Rows[2].Errors[0].Message; msg != "Изображение не найдено среди ваших загрузок" {
+		t.Errorf("message %q", msg)
+	}
+}
+
+func TestAnalytics(t *testing.T) {
+	env := newTestEnv(t)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	other, _ := env.user("other", models.RoleSeller)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, adminToken := env.user("admin", models.RoleAdmin)
+
+	gpu := env.product(seller.ID, "GPU", 1000, 10, true)
+	cpu := env.product(seller.ID, "CPU", 500, 10, true)
+	foreign := env.product(other.ID, "Foreign", 100, 10, true)
+
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: gpu.ID, Quantity: 1})
+	env.do(http.MethodPost, "/api/cart/add", buyerToken, models.AddToCartRequest{ProductID: cpu.ID, Quantity: 1})
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	env.repo.AddOrder(models.Order{
+		UserID:    buyer.ID,
+		CreatedAt: today.Add(time.Hour),
+		Items: []models.OrderItem{
+			{ProductID: gpu.ID, Quantity: 2, PriceAtTime: money.FromInt(1000)},
+			{ProductID: foreign.ID, Quantity: 3, PriceAtTime: money.MustParse("100.10")},
+		},
+	})
+	env.repo.AddOrder(models.Order{
+		UserID:    buyer.ID,
+		Status:    "cancelled",
+		CreatedAt: today.Add(time.Hour),
+		Items:     []models.OrderItem{{ProductID: cpu.ID, Quantity: 5, PriceAtTime: money.FromInt(500)}},
+	})
+
+	tests := []struct {
+		name        string
+		path        string
+		token       string
+		revenue     string
+		units       int
+		conversion  float64
+		topProducts int
+	}{
+		{"seller sees own products only", "/api/seller/analytics", sellerToken, "2000", 2, 0.5, 1},
+		{"admin sees whole shop", "/api/admin/analytics", adminToken, "2300.3", 5, 0.5, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := env.do(http.MethodGet, tt.path, tt.token, nil)
+			if resp.Status != http.StatusOK {
+				t.Fatalf("status %d: %s", resp.Status, resp.Error)
+			}
+
+			report := decode[models.AnalyticsReport](t, resp.Data)
+			if report.Summary.Revenue.String() != tt.revenue || report.Summary.UnitsSold != tt.units || report.Summary.Orders != 1 {
+				t.Errorf("summary %+v", report.Summary)
+			}
+			if report.Summary.ConversionRate != tt.conversion {
+				t.Errorf("conversion %v, want %v", report.Summary.ConversionRate, tt.conversion)
+			}
+			if len(report.TopProducts) != tt.topProducts || report.TopProducts[0].ProductID != gpu.ID {
+				t.Errorf("top products %+v", report.TopProducts)
+			}
+			if len(report.Daily) != 30 || report.Daily[29].Revenue.String() != tt.revenue {
+				t.Errorf("daily series has %d days, last %+v", len(report.Daily), report.Daily[len(report.Daily)-1])
+			}
+		})
+	}
+
+	rangeTests := []struct {
+		query  string
+		status int
+	}{
+		{"?from=2026-01-10&to=2026-01-01", http.StatusBadRequest},
+		{"?from=bad", http.StatusBadRequest},
+		{"?from=2020-01-01&to=2026-01-01", http.StatusBadRequest},
+		{"?from=2026-01-01&to=2026-01-07", http.StatusOK},
+	}
+
+	for _, tt := range rangeTests {
+		t.Run("range "+tt.query, func(t *testing.T) {
+			if resp := env.do(http.MethodGet, "/api/admin/analytics"+tt.query, adminToken, nil); resp.Status != tt.status {
+				t.Fatalf("got %d, want %d", resp.Status, tt.status)
+			}
+		})
+	}
+}
+
+// failingRepository имитирует сбой базы данных с текстом, который нельзя показывать клиенту
+type failingRepository struct {
+	*repository.MemoryRepository
+}
+
+var errDatabase = errors.New(`pq: relation "products" does not exist`)
+
+func (failingRepository) GetProductByID(int) (*models.Product, error) {
+	return nil, errDatabase
+}
+
+func (failingRepository) UserExists(string, string) (bool, error) {
+	return false, errDatabase
+}
+
+func (failingRepository) Ping(context.Context) error {
+	return errDatabase
+}
+
+func TestErrorResponses(t *testing.T) {
+	env := newTestEnv(t)
+	_, customer := env.user("customer", models.RoleCustomer)
+
+	failing := echo.New()
+	NewHandler(service.NewService(failingRepository{repository.NewMemoryRepository()}, config.Default())).RegisterEndpoints(failing)
+
+	tests := []struct {
+		name    string
+		e       *echo.Echo
+		method  string
+		path    string
+		lang    string
+		body    string
+		token   string
+		status  int
+		code    string
+		error   string
+		details map[string]string
+	}{
+		{
+			name: "db error is not leaked", e: failing, method: http.MethodGet, path: "/api/products/1",
+			status: http.StatusInternalServerError, code: "internal_error", error: "Внутренняя ошибка сервера",
+		},
+		{
+			name: "db error on register is not leaked", e: failing, method: http.MethodPost, path: "/api/register",
+			body: `{"username":"new","email":"new@example.com","password":"secret"}`, lang: "en",
+			status: http.StatusInternalServerError, code: "internal_error", error: "Internal server error",
+		},
+		{
+			name: "english message", e: env.e, method: http.MethodGet, path: "/api/products/999", lang: "en-US,en;q=0.9",
+			status: http.StatusNotFound, code: "product_not_found", error: "Product not found",
+		},
+		{
+			name: "weighted accept-language", e: env.e, method: http.MethodGet, path: "/api/products/999", lang: "de, en;q=0.5, ru;q=0.8",
+			status: http.StatusNotFound, code: "product_not_found", error: "Товар не найден",
+		},
+		{
+			name: "unsupported language falls back to russian", e: env.e, method: http.MethodGet, path: "/api/profile", lang: "fr",
+			status: http.StatusUnauthorized, code: "unauthorized", error: "Требуется авторизация",
+		},
+		{
+			name: "unknown route", e: env.e, method: http.MethodGet, path: "/nothing", lang: "en",
+			status: http.StatusNotFound, code: "route_not_found", error: "Route not found",
+		},
+		{
+			name: "malformed json", e: env.e, method: http.MethodPost, path: "/api/login", body: `{"username":`,
+			status: http.StatusBadRequest, code: "invalid_body", error: "Неверные данные",
+		},
+		{
+			name: "field errors", e: env.e, method: http.MethodPost, path: "/api/register", lang: "en",
+			body:   `{"username":"ab","email":"not-an-email"}`,
+			status: http.StatusBadRequest, code: "validation_failed", error: "Please check the submitted data",
+			details: map[string]string{
+				"username": "Must be at least 3 characters",
+				"email":    "Invalid email address",
+				"password": "Required field",
+			},
+		},
+		{
+			name: "numeric field below minimum", e: env.e, method: http.MethodPost, path: "/api/cart/add", token: customer,
+			body:   `{"product_id":0,"quantity":1}`,
+			status: http.StatusBadRequest, code: "validation_failed", error: "Проверьте введенные данные",
+			details: map[string]string{"product_id": "Обязательное поле"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.lang != "" {
+				req.Header.Set("Accept-Language", tt.lang)
+			}
+			if tt.token != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			tt.e.ServeHTTP(rec, req)
+
+			if strings.Contains(rec.Body.String(), "pq:") {
+				t.Fatalf("database error leaked: %s", rec.Body.String())
+			}
+
+			var resp response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.status || resp.Code != tt.code || resp.Error != tt.error || resp.Success {
+				t.Fatalf("got %d %q %q, want %d %q %q", rec.Code, resp.Code, resp.Error, tt.status, tt.code, tt.error)
+			}
+
+			got := map[string]string{}
+			for _, d := range resp.Details {
+				got[d.Field] = d.Message
+			}
+			if fmt.Sprint(got) != fmt.Sprint(map[string]string(tt.details)) && !(len(got) == 0 && len(tt.details) == 0) {
+				t.Errorf("details %v, want %v", got, tt.details)
+			}
+		})
+	}
+}
+
+func TestObservability(t *testing.T) {
+	env := newTestEnv(t)
+	var logs bytes.Buffer
+	env.e.Use(Observe(slog.New(slog.NewJSONHandler(&logs, nil))))
+	_, token := env.user("customer", models.RoleCustomer)
+	product := env.product(0, "GPU", 1000, 5, true)
+
+	serve := func(method, path, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(echo.HeaderXRequestID, requestID)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Корректный идентификатор клиента сохраняется и попадает в ответ и журнал
+	rec := serve(http.MethodGet, "/api/products/999", "client-req-0001")
+	if got := rec.Header().Get(echo.HeaderXRequestID); got != "client-req-0001" {
+		t.Errorf("request id %q", got)
+	}
+	var errResp ErrorResponse
+	json.Unmarshal(rec.Body.Bytes(), &errResp)
+	if errResp.RequestID != "client-req-0001" {
+		t.Errorf("error body request id %q", errResp.RequestID)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.Split(logs.Bytes(), []byte("\n"))[0], &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["request_id"] != "client-req-0001" || entry["route"] != "/api/products/:id" || entry["status"] != float64(404) {
+		t.Errorf("log entry %v", entry)
+	}
+
+	// Произвольная строка вместо идентификатора заменяется сгенерированной
+	rec = serve(http.MethodGet, "/api/products", "bad id\nforged")
+	if got := rec.Header().Get(echo.HeaderXRequestID); len(got) != 32 {
+		t.Errorf("generated request id %q", got)
+	}
+
+	env.do(http.MethodPost, "/api/cart/add", token, models.AddToCartRequest{ProductID: product.ID, Quantity: 1})
+	env.do(http.MethodPost, "/api/cart/add", token, models.AddToCartRequest{ProductID: product.ID, Quantity: 100})
+	serve(http.MethodGet, "/no-such-route", "")
+
+	rec = serve(http.MethodGet, "/metrics", "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "text/plain") {
+		t.Fatalf("metrics: %d %s", rec.Code, rec.Header().Get(echo.HeaderContentType))
+	}
+	for _, want := range []string{
+		`catpc_http_request_duration_seconds_count{method="GET",route="/api/products/:id",status="404"}`,
+		`catpc_http_request_duration_seconds_count{method="GET",route="unmatched",status="404"}`,
+		`catpc_cart_operations_total{action="add",result="ok"}`,
+		`catpc_cart_operations_total{action="add",result="error"}`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics have no %s", want)
+		}
+	}
+	if strings.Contains(rec.Body.String(), "/no-such-route") {
+		t.Error("unmatched path must not become a label value")
+	}
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	env := newTestEnv(t)
+
+	get := func(e *echo.Echo, path string) (int, HealthResponse) {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if strings.Contains(rec.Body.String(), "pq:") {
+			t.Fatalf("%s leaks error details: %s", path, rec.Body.String())
+		}
+		var resp HealthResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, resp
+	}
+
+	if status, resp := get(env.e, "/healthz"); status != http.StatusOK || resp.Status != "ok" {
+		t.Errorf("healthz: %d %+v", status, resp)
+	}
+	status, resp := get(env.e, "/readyz")
+	if status != http.StatusOK || resp.Checks[service.CheckDatabase] != "ok" || resp.Checks[service.CheckUploads] != "ok" {
+		t.Errorf("readyz: %d %+v", status, resp)
+	}
+
+	// База не отвечает и папки загрузок нет: сервер жив, но не готов
+	cfg := config.Default()
+	cfg.UploadDir = filepath.Join(t.TempDir(), "missing")
+	h := NewHandler(service.NewService(failingRepository{repository.NewMemoryRepository()}, cfg))
+	broken := echo.New()
+	h.RegisterEndpoints(broken)
+
+	if status, _ := get(broken, "/healthz"); status != http.StatusOK {
+		t.Errorf("healthz of broken server: %d", status)
+	}
+	status, resp = get(broken, "/readyz")
+	if status != http.StatusServiceUnavailable || resp.Status != "fail" ||
+		resp.Checks[service.CheckDatabase] != "fail" || resp.Checks[service.CheckUploads] != "fail" {
+		t.Errorf("readyz of broken server: %d %+v", status, resp)
+	}
+
+	// При остановке готовность снимается сразу, до проверок
+	h = NewHandler(env.svc)
+	draining := echo.New()
+	h.RegisterEndpoints(draining)
+	h.Drain()
+	if status, resp := get(draining, "/readyz"); status != http.StatusServiceUnavailable || resp.Status != "shutting_down" {
+		t.Errorf("readyz while draining: %d %+v", status, resp)
+	}
+}
+
+func TestImageUploads(t *testing.T) {
+	env := newTestEnv(t)
+	_, token := env.user("buyer", models.RoleCustomer)
+
+	encode := func(img image.Image, format string) []byte {
+		var buf bytes.Buffer
+		var err error
+		if format == "png" {
+			err = png.Encode(&buf, img)
+		} else {
+			err = jpeg.Encode(&buf, img, nil)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	webp := func(b64 string) []byte {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	photo := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	for i := range photo.Pix {
+		photo.Pix[i] = 0xff
+	}
+
+	upload := func(filename string, data []byte) (int, UploadResponse, response) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		part, _ := w.CreateFormFile("image", filename)
+		part.Write(data)
+		w.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/upload", &buf)
+		req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		rec := httptest.NewRecorder()
+		env.e.ServeHTTP(rec, req)
+
+		var ok UploadResponse
+		var fail response
+		json.Unmarshal(rec.Body.Bytes(), &ok)
+		json.Unmarshal(rec.Body.Bytes(), &fail)
+		return rec.Code, ok, fail
+	}
+
+	pngData := encode(photo, "png")
+	for _, tt := range []struct {
+		name     string
+		filename string
+		data     []byte
+		ext      string
+		code     string
+	}{
+		{"png", "photo.png", pngData, ".png", ""},
+		{"extension comes from content", "shell.php", encode(photo, "jpeg"), ".jpg", ""},
+		{"opaque webp becomes jpeg", "photo.webp", webp("UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA"), ".jpg", ""},
+		{"transparent webp becomes png", "logo.webp", webp("UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="), ".png", ""},
+		{"svg", "logo.svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), "", "not_an_image"},
+		{"gif is not allowed", "anim.gif", []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"), "", "not_an_image"},
+		{"truncated png", "broken.png", pngData[:40], "", "image_corrupt"},
+		{"too wide", "wide.png", encode(image.NewGray(image.Rect(0, 0, 4097, 1)), "png"), "", "image_dimensions"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			status, ok, fail := upload(tt.filename, tt.data)
+			if tt.code != "" {
+				if status != http.StatusBadRequest || fail.Code != tt.code {
+					t.Fatalf("got %d %q, want %q", status, fail.Code, tt.code)
+				}
+				return
+			}
+			if status != http.StatusOK || filepath.Ext(ok.Filename) != tt.ext {
+				t.Fatalf("got %d %q, want extension %s", status, ok.Filename, tt.ext)
+			}
+			if _, err := os.Stat(filepath.Join(env.cfg.UploadDir, ok.Filename)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// Данные, приклеенные к картинке, при перекодировании отбрасываются,
+	// а одинаковые картинки сохраняются одним файлом
+	_, first, _ := upload("a.png", pngData)
+	_, polyglot, _ := upload("b.png", append(append([]byte{}, pngData...), "<?php system($_GET['c']); ?>"...))
+	if polyglot.Filename != first.Filename {
+		t.Errorf("same image stored twice: %s and %s", first.Filename, polyglot.Filename)
+	}
+	stored, err := os.ReadFile(filepath.Join(env.cfg.UploadDir, polyglot.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(stored, []byte("<?php")) {
+		t.Error("payload survived re-encoding")
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// readinessTimeout ограничивает время проверок, чтобы /readyz не зависал вместе с базой
+const readinessTimeout = 2 * time.Second
+
+// HealthResponse — ответ /healthz и /readyz; checks содержит результат каждой проверки
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Healthz отвечает, пока процесс жив; зависимости не проверяются
+func (h *Handler) Healthz(c echo.Context) error {
+	return c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readyz сообщает, можно ли направлять на сервер трафик: база отвечает,
+// папка загрузок доступна на запись и сервер не останавливается.
+func (h *Handler) Readyz(c echo.Context) error {
+	if h.draining.Load() {
+		return c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "shutting_down"})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+	defer cancel()
+
+	resp := HealthResponse{Status: "ok", Checks: map[string]string{}}
+	for name, err := range h.service.Readiness(ctx) {
+		if err != nil {
+			requestLogger(c).Warn("Проверка готовности не пройдена", "check", name, "error", err)
+			resp.Status = "fail"
+			resp.Checks[name] = "fail"
+			continue
+		}
+		resp.Checks[name] = "ok"
+	}
+
+	status := http.StatusOK
+	if resp.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	return c.JSON(status, resp)
+}
+
+// Drain переводит /readyz в состояние 503, чтобы балансировщик перестал
+// направлять новые запросы, пока текущие дорабатывают. Потоки уведомлений
+// закрываются сразу: иначе остановка ждала бы их до таймаута, а клиенты
+// переподключатся сами.
+func (h *Handler) Drain() {
+	h.draining.Store(true)
+	h.service.Notifications.Close()
+}
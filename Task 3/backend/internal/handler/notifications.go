@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/i18n"
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// streamHeartbeat — как часто поток шлет комментарий-пинг: прокси не закрывают
+// соединение по простою, а сервер заново проверяет сессию
+var streamHeartbeat = 25 * time.Second
+
+// GetNotifications возвращает входящие: последние уведомления и число непрочитанных
+func (h *Handler) GetNotifications(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+	unreadOnly := c.QueryParam("unread") == "true"
+
+	inbox, err := h.service.GetNotifications(getUserID(c), unreadOnly, limit)
+	if err != nil {
+		return err
+	}
+
+	lang := language(c)
+	for i := range inbox.Notifications {
+		localizeNotification(lang, &inbox.Notifications[i])
+	}
+	return c.JSON(http.StatusOK, NotificationListResponse{Success: true, Data: *inbox})
+}
+
+func (h *Handler) MarkNotificationRead(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.MarkNotificationRead(getUserID(c), id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.notification_read", nil)})
+}
+
+func (h *Handler) MarkAllNotificationsRead(c echo.Context) error {
+	count, err := h.service.MarkAllNotificationsRead(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.notifications_read", apperr.Params{"count": count})})
+}
+
+// CreateNotificationStreamToken выдает короткий токен для подключения к потоку через EventSource
+func (h *Handler) CreateNotificationStreamToken(c echo.Context) error {
+	token, err := h.service.GenerateStreamToken(c.Get("claims").(*models.JWTClaims))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, StreamTokenResponse{Success: true, Data: *token})
+}
+
+// StreamNotifications держит поток Server-Sent Events с новыми уведомлениями.
+// Событие notification несет уведомление в JSON, id события — id уведомления,
+// поэтому после переподключения с Last-Event-ID (или last_event_id в запросе, если
+// клиент открывает поток заново) поток сначала отдает пропущенное.
+// Поток закрывается, когда истекает сессия или она отозвана: клиент переподключается
+// с действующим токеном.
+func (h *Handler) StreamNotifications(c echo.Context) error {
+	userID := getUserID(c)
+	claims := c.Get("claims").(*models.JWTClaims)
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.QueryParam("last_event_id")
+	}
+	lastID, _ := strconv.Atoi(lastEventID)
+	expiresAt := claims.ExpiresAt
+	if claims.SessionExpiresAt != nil {
+		expiresAt = claims.SessionExpiresAt
+	}
+
+	// Подписка открывается до чтения пропущенного, чтобы не потерять уведомления между ними
+	sub := h.service.SubscribeNotifications(userID)
+	defer sub.Close()
+
+	var missed []models.Notification
+	if lastID > 0 {
+		var err error
+		if missed, err = h.service.MissedNotifications(userID, lastID); err != nil {
+			return err
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// nginx иначе копит поток в буфере
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	lang := language(c)
+	send := func(n models.Notification) error {
+		localizeNotification(lang, &n)
+		data, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(res, "id: %d\nevent: notification\ndata: %s\n\n", n.ID, data)
+		return err
+	}
+
+	if _, err := fmt.Fprint(res, "retry: 5000\n\n"); err != nil {
+		return nil
+	}
+	// Уведомление из пропущенных могло прийти и в подписку. Сравниваются только id
+	// из базы: уведомления публикуются не по порядку id, и меньший id в подписке — новый.
+	replayed := make(map[int]bool, len(missed))
+	for _, n := range missed {
+		replayed[n.ID] = true
+		if err := send(n); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case n, ok := <-sub.C:
+			// Подписка закрыта: поток отстал или сервер останавливается
+			if !ok {
+				return nil
+			}
+			if replayed[n.ID] {
+				continue
+			}
+			if err := send(n); err != nil {
+				return nil
+			}
+		case now := <-heartbeat.C:
+			if expiresAt != nil && now.After(expiresAt.Time) {
+				return nil
+			}
+			if err := h.service.CheckSession(claims); err != nil {
+				return nil
+			}
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+		}
+		res.Flush()
+	}
+}
+
+// localizeNotification собирает текст уведомления на языке клиента
+func localizeNotification(lang i18n.Lang, n *models.Notification) {
+	key := "notification." + n.Type
+	switch {
+	case n.Type == models.NotifyOrderStatus:
+		key += "." + n.Status
+	case n.Type == models.NotifyProductPending && n.Count > 0:
+		key = "notification.products_imported"
+	}
+	n.Message = i18n.T(lang, key, apperr.Params{"product": n.ProductName, "order": n.OrderID, "count": n.Count})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"catpc-backend/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Идентификатор запроса из X-Request-ID принимается, только если он похож на
+// идентификатор: иначе клиент мог бы подставить в журнал что угодно.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+type loggerKey struct{}
+
+// Observe — первый middleware цепочки: назначает запросу идентификатор,
+// пишет строку журнала и метрику длительности по шаблону маршрута.
+// Идентификатор возвращается в X-Request-ID и попадает в тела ошибок.
+func Observe(logger *slog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !requestIDPattern.MatchString(id) {
+				id = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			reqLogger := logger.With("request_id", id)
+			c.SetRequest(req.WithContext(context.WithValue(req.Context(), loggerKey{}, reqLogger)))
+
+			start := time.Now()
+			if err := next(c); err != nil {
+				// Ошибку обрабатываем здесь, чтобы в журнал и метрики попал итоговый статус
+				c.Error(err)
+			}
+			latency := time.Since(start)
+
+			status := c.Response().Status
+			// Для ненайденных маршрутов echo оставляет шаблон ближайшего узла или пустую строку
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.HTTPRequestDuration.With(req.Method, route, strconv.Itoa(status)).ObserveDuration(latency)
+
+			level := slog.LevelInfo
+			switch {
+			case status >= http.StatusInternalServerError:
+				level = slog.LevelError
+			case route == "/healthz" || route == "/readyz":
+				// Пробы оркестратора приходят каждые несколько секунд и не засоряют журнал
+				level = slog.LevelDebug
+			}
+			reqLogger.LogAttrs(req.Context(), level, "Запрос",
+				slog.String("method", req.Method),
+				slog.String("uri", req.RequestURI),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Duration("latency", latency),
+				slog.String("ip", c.RealIP()),
+				slog.Int64("bytes_out", c.Response().Size),
+			)
+			return nil
+		}
+	}
+}
+
+// requestLogger — журнал с идентификатором текущего запроса
+func requestLogger(c echo.Context) *slog.Logger {
+	if logger, ok := c.Request().Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+func requestID(c echo.Context) string {
+	return c.Response().Header().Get(echo.HeaderXRequestID)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// GetMetrics отдает метрики в текстовом формате Prometheus
+func (h *Handler) GetMetrics(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+	return metrics.Default.Write(c.Response())
+}
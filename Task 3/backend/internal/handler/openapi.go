@@ -0,0 +1,583 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/openapi"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ClientCommand — команда, которой перегенерируется клиент фронтенда
+const ClientCommand = "go run . openapi -client ../frontend/src/services/client.js"
+
+// OpenAPIDocument описывает все маршруты из RegisterEndpoints.
+// Схемы ответов строятся по типам из responses.go, поэтому контрактный тест
+// ловит расхождение между документом и тем, что реально отдают обработчики.
+func OpenAPIDocument() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "CatPC API",
+		Version:     "1.0.0",
+		Description: "API интернет-магазина CatPC. Ошибки возвращаются в формате ErrorResponse, язык сообщений выбирается по Accept-Language.",
+	}, ErrorResponse{})
+	b.Scalar(money.Amount{}, openapi.Schema{Type: "number"})
+
+	// Ответы с ошибками для групп маршрутов
+	public := b.Errors(http.StatusBadRequest, http.StatusInternalServerError)
+	auth := append(b.Errors(http.StatusUnauthorized), public...)
+	role := append(b.Errors(http.StatusForbidden), auth...)
+	notFound := b.Errors(http.StatusNotFound)
+
+	replies := func(groups ...[]openapi.Reply) []openapi.Reply {
+		var all []openapi.Reply
+		for _, group := range groups {
+			all = append(all, group...)
+		}
+		return all
+	}
+	ok := func(types ...interface{}) []openapi.Reply {
+		return []openapi.Reply{openapi.JSON(http.StatusOK, types...)}
+	}
+
+	analyticsQuery := []openapi.Param{
+		{Name: "from", Type: "string", Description: "Начало периода ГГГГ-ММ-ДД включительно"},
+		{Name: "to", Type: "string", Description: "Конец периода ГГГГ-ММ-ДД включительно"},
+		{Name: "top", Type: "integer", Description: "Сколько товаров показать в рейтинге"},
+	}
+	formats := []interface{}{"csv", "json"}
+	ifNoneMatch := openapi.Param{Name: "If-None-Match", In: "header", Type: "string", Description: "ETag из предыдущего ответа"}
+	notModified := []openapi.Reply{openapi.Empty(http.StatusNotModified)}
+	currency := openapi.Param{Name: "currency", Type: "string", Description: "Код валюты ISO 4217 для цен; по умолчанию базовая валюта магазина"}
+	session := openapi.Param{Name: HeaderSessionID, In: "header", Type: "string", Description: "Идентификатор анонимной сессии (8–64 символа: латиница, цифры, дефис) для истории просмотров и сравнения"}
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/openapi.json", ID: "getOpenAPI",
+		Summary: "Спецификация OpenAPI", Tag: "meta",
+		Responses: []openapi.Reply{openapi.Raw(http.StatusOK, "application/json", &openapi.Schema{Type: "object"})},
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/register", ID: "register",
+		Summary: "Регистрация покупателя", Tag: "auth",
+		Body:      b.JSONBody(models.RegisterRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, AuthResponse{})}, b.Errors(http.StatusConflict), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/login", ID: "login",
+		Summary: "Вход по логину и паролю", Tag: "auth",
+		Body:      b.JSONBody(models.LoginRequest{}),
+		Responses: replies(ok(AuthResponse{}), b.Errors(http.StatusUnauthorized, http.StatusForbidden), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/products", ID: "getProducts",
+		Summary: "Каталог одобренных товаров: по номеру страницы или по курсору", Tag: "products",
+		Query: []openapi.Param{
+			{Name: "page", Type: "integer", Description: "Номер страницы, с 1"},
+			{Name: "limit", Type: "integer", Description: "Товаров на странице"},
+			{Name: "cursor", Type: "string", Description: "Курсор из next_cursor; пустое значение — первая страница. Включает выборку по курсору без подсчета total"},
+			currency,
+			ifNoneMatch,
+		},
+		Responses: replies(ok(ProductPageResponse{}, ProductCursorResponse{}), notModified, public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/products/:id", ID: "getProduct",
+		Summary: "Карточка товара с последними вопросами, на которые ответили, и рекомендациями. Токен необязателен: с ним просмотр попадает в историю пользователя", Tag: "products",
+		Query: []openapi.Param{
+			currency,
+			ifNoneMatch,
+			session,
+		},
+		Responses: replies(ok(ProductResponse{}), notModified, notFound, public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/currencies", ID: "getCurrencies",
+		Summary: "Базовая валюта и курсы для параметра currency", Tag: "products",
+		Responses: replies(ok(CurrenciesResponse{}), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/compare", ID: "getComparison",
+		Summary: "Список сравнения с таблицей характеристик; владелец — пользователь по токену или сессия", Tag: "products",
+		Query:     []openapi.Param{currency, session},
+		Responses: replies(ok(ComparisonResponse{}), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/compare", ID: "addToComparison",
+		Summary: "Добавить товар в сравнение (только одной категории)", Tag: "products",
+		Query:     []openapi.Param{currency, session},
+		Body:      b.JSONBody(models.CompareRequest{}),
+		Responses: replies(ok(ComparisonResponse{}), notFound, public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/compare/:id", ID: "removeFromComparison",
+		Summary: "Убрать товар из сравнения", Tag: "products",
+		Query:     []openapi.Param{currency, session},
+		Responses: replies(ok(ComparisonResponse{}), notFound, public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/compare", ID: "clearComparison",
+		Summary: "Очистить список сравнения", Tag: "products",
+		Query:     []openapi.Param{session},
+		Responses: replies(ok(MessageResponse{}), public),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/products/:id/price-history", ID: "getPriceHistory",
+		Summary: "История цены товара от старых изменений к новым", Tag: "products",
+		Responses: replies(ok(PriceHistoryResponse{}), notFound, public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/products/:id/questions", ID: "getQuestions",
+		Summary: "Вопросы о товаре с опубликованными ответами", Tag: "questions",
+		Responses: replies(ok(QuestionListResponse{}), notFound, public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/products/:id/questions", ID: "askQuestion",
+		Summary: "Задать вопрос о товаре", Tag: "questions", Auth: true,
+		Body:      b.JSONBody(models.QuestionRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, QuestionResponse{})}, notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/questions/:id/answers", ID: "answerQuestion",
+		Summary: "Ответить на вопрос: продавец товара или покупатель, который его заказывал", Tag: "questions", Auth: true,
+		Body:      b.JSONBody(models.AnswerRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, AnswerResponse{})}, b.Errors(http.StatusForbidden), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/answers/:id/vote", ID: "voteAnswer",
+		Summary: "Отметить ответ полезным", Tag: "questions", Auth: true,
+		Responses: replies(ok(AnswerResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/answers/:id/vote", ID: "unvoteAnswer",
+		Summary: "Снять отметку «полезный ответ»", Tag: "questions", Auth: true,
+		Responses: replies(ok(AnswerResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/price-alerts", ID: "getPriceAlerts",
+		Summary: "Подписки на снижение цены", Tag: "prices", Auth: true,
+		Responses: replies(ok(PriceAlertListResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/products/:id/price-alert", ID: "setPriceAlert",
+		Summary: "Подписаться на снижение цены до target_price", Tag: "prices", Auth: true,
+		Body:      b.JSONBody(models.PriceAlertRequest{}),
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/products/:id/price-alert", ID: "deletePriceAlert",
+		Summary: "Отменить подписку на цену", Tag: "prices", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/profile", ID: "getProfile",
+		Summary: "Профиль текущего пользователя", Tag: "auth", Auth: true,
+		Responses: replies(ok(ProfileResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/profile", ID: "updateProfile",
+		Summary: "Изменить имя и email. Новый email вступает в силу после подтверждения кодом из письма; ответ содержит новый токен", Tag: "auth", Auth: true,
+		Body:      b.JSONBody(models.ProfileRequest{}),
+		Responses: replies(ok(AuthResponse{}), b.Errors(http.StatusForbidden, http.StatusConflict), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/graphql", ID: "graphql",
+		Summary: "Запрос GraphQL: каталог без токена, корзина и заказы с токеном; ошибки полей — в errors с кодом 200", Tag: "graphql",
+		Query:     []openapi.Param{currency},
+		Body:      b.JSONBody(models.GraphQLRequest{}),
+		Responses: replies(ok(GraphQLResponse{}), b.Errors(http.StatusUnauthorized, http.StatusRequestEntityTooLarge), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/profile/email/confirm", ID: "confirmEmail",
+		Summary: "Подтвердить новый email кодом из письма", Tag: "auth",
+		Body:      b.JSONBody(models.EmailConfirmRequest{}),
+		Responses: replies(ok(ProfileResponse{}), b.Errors(http.StatusConflict), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/profile/password", ID: "changePassword",
+		Summary: "Сменить пароль; остальные сессии завершаются, ответ содержит новый токен", Tag: "auth", Auth: true,
+		Body:      b.JSONBody(models.PasswordChangeRequest{}),
+		Responses: replies(ok(AuthResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/profile", ID: "deleteAccount",
+		Summary: "Удалить аккаунт: данные обезличиваются, заказы сохраняются", Tag: "auth", Auth: true,
+		Body:      b.JSONBody(models.DeleteAccountRequest{}),
+		Responses: replies(ok(MessageResponse{}), b.Errors(http.StatusForbidden), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/cart", ID: "getCart",
+		Summary: "Корзина с налогами и рекомендациями; с delivery_method — вместе с расчетом доставки", Tag: "cart", Auth: true,
+		Query: []openapi.Param{
+			currency,
+			{Name: "delivery_method", Type: "integer", Description: "ID способа доставки для расчета стоимости"},
+			{Name: "address", Type: "integer", Description: "ID адреса доставки: налоги считаются по ставкам его региона"},
+		},
+		Responses: replies(ok(CartResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/cart/add", ID: "addToCart",
+		Summary: "Добавить товар в корзину", Tag: "cart", Auth: true,
+		Body:      b.JSONBody(models.AddToCartRequest{}),
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/cart/update/:id", ID: "updateCartItem",
+		Summary: "Изменить количество; 0 и меньше удаляет позицию", Tag: "cart", Auth: true,
+		Body:      b.JSONBody(models.UpdateCartItemRequest{}),
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/cart/remove/:id", ID: "removeFromCart",
+		Summary: "Удалить позицию корзины", Tag: "cart", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/upload", ID: "uploadImage",
+		Summary: "Загрузить изображение JPEG, PNG или WebP", Tag: "products", Auth: true,
+		Body: &openapi.Body{Content: map[string]*openapi.Schema{
+			"multipart/form-data": {
+				Type:       "object",
+				Required:   []string{"image"},
+				Properties: map[string]*openapi.Schema{"image": {Type: "string", Format: "binary"}},
+			},
+		}},
+		Responses: replies(ok(UploadResponse{}), auth),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/delivery-methods", ID: "getDeliveryMethods",
+		Summary: "Доступные способы доставки и их тарифы", Tag: "orders",
+		Responses: replies(ok(DeliveryMethodListResponse{}), public),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/addresses", ID: "getAddresses",
+		Summary: "Адресная книга", Tag: "orders", Auth: true,
+		Responses: replies(ok(AddressListResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/addresses", ID: "createAddress",
+		Summary: "Добавить адрес; первый адрес становится адресом по умолчанию", Tag: "orders", Auth: true,
+		Body:      b.JSONBody(models.AddressRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, AddressResponse{})}, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/addresses/:id", ID: "updateAddress",
+		Summary: "Изменить адрес", Tag: "orders", Auth: true,
+		Body:      b.JSONBody(models.AddressRequest{}),
+		Responses: replies(ok(AddressResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/addresses/:id", ID: "deleteAddress",
+		Summary: "Удалить адрес", Tag: "orders", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/orders", ID: "checkout",
+		Summary: "Оформить заказ из корзины", Tag: "orders", Auth: true,
+		Body: b.JSONBody(models.CheckoutRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, OrderResponse{})},
+			b.Errors(http.StatusConflict), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/orders", ID: "getOrders",
+		Summary: "Заказы покупателя, новые первыми", Tag: "orders", Auth: true,
+		Query:     []openapi.Param{currency},
+		Responses: replies(ok(OrderListResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/orders/:id", ID: "getOrder",
+		Summary: "Заказ покупателя", Tag: "orders", Auth: true,
+		Query:     []openapi.Param{currency},
+		Responses: replies(ok(OrderResponse{}), notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/orders/:id/invoice", ID: "getInvoice",
+		Summary: "PDF-счет к заказу для покупателя, продавцов позиций и администратора", Tag: "orders", Auth: true,
+		Responses: replies([]openapi.Reply{
+			openapi.Raw(http.StatusOK, "application/pdf", &openapi.Schema{Type: "string", Format: "binary"}),
+		}, notFound, auth),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/orders/:id/returns", ID: "createReturn",
+		Summary: "Заявка на возврат позиции доставленного заказа", Tag: "orders", Auth: true,
+		Body:      b.JSONBody(models.ReturnRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, ReturnResponse{})}, notFound, auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/returns", ID: "getReturns",
+		Summary: "Заявки покупателя на возврат", Tag: "orders", Auth: true,
+		Responses: replies(ok(ReturnListResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/notifications", ID: "getNotifications",
+		Summary: "Входящие уведомления, новые первыми, и число непрочитанных", Tag: "notifications", Auth: true,
+		Query: []openapi.Param{
+			{Name: "unread", Type: "boolean", Description: "true — только непрочитанные"},
+			{Name: "limit", Type: "integer", Description: "Сколько уведомлений вернуть: до 100, по умолчанию 50"},
+		},
+		Responses: replies(ok(NotificationListResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/notifications/stream-token", ID: "createNotificationStreamToken",
+		Summary: "Токен на минуту для подключения к потоку уведомлений через EventSource", Tag: "notifications", Auth: true,
+		Responses: replies(ok(StreamTokenResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/notifications/stream", ID: "streamNotifications",
+		Summary: "Поток Server-Sent Events: событие notification с уведомлением в JSON, id события — id уведомления", Tag: "notifications", Auth: true,
+		Query: []openapi.Param{
+			{Name: "token", Type: "string", Description: "Токен потока из /api/notifications/stream-token вместо заголовка Authorization"},
+			{Name: "last_event_id", Type: "integer", Description: "То же, что Last-Event-ID, для нового подключения"},
+			{Name: "Last-Event-ID", In: "header", Type: "integer", Description: "id последнего полученного уведомления: пропущенные после него отправляются первыми"},
+		},
+		Responses: replies([]openapi.Reply{
+			openapi.Raw(http.StatusOK, "text/event-stream", &openapi.Schema{Type: "string"}),
+		}, auth),
+		StreamToken: "createNotificationStreamToken",
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/notifications/read", ID: "markAllNotificationsRead",
+		Summary: "Отметить прочитанными все уведомления", Tag: "notifications", Auth: true,
+		Responses: replies(ok(MessageResponse{}), auth),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/notifications/:id/read", ID: "markNotificationRead",
+		Summary: "Отметить уведомление прочитанным", Tag: "notifications", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, auth),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/seller/my-products", ID: "getMyProducts",
+		Summary: "Товары продавца", Tag: "seller", Auth: true,
+		Responses: replies(ok(ProductListResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/seller/products", ID: "createProduct",
+		Summary: "Создать товар", Tag: "seller", Auth: true,
+		Body:      b.FormBody(models.ProductForm{}, "image"),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, CreateProductResponse{})}, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/seller/products/:id", ID: "updateProduct",
+		Summary: "Изменить товар", Tag: "seller", Auth: true,
+		Body:      b.FormBody(models.ProductForm{}, "image"),
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/seller/products/:id", ID: "deleteProduct",
+		Summary: "Удалить товар или скрыть, если он есть в корзинах", Tag: "seller", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/seller/products/import", ID: "importProducts",
+		Summary: "Массовый импорт товаров из CSV или JSON", Tag: "seller", Auth: true,
+		Query: []openapi.Param{
+			{Name: "format", Type: "string", Enum: formats, Description: "Формат, если его нельзя определить по типу содержимого"},
+			{Name: "dry_run", Type: "boolean", Description: "Только проверить файл"},
+		},
+		Body: &openapi.Body{Content: map[string]*openapi.Schema{
+			"text/csv":         {Type: "string"},
+			"application/json": b.Schema([]models.ImportRow{}),
+			"multipart/form-data": {
+				Type:       "object",
+				Required:   []string{"file"},
+				Properties: map[string]*openapi.Schema{"file": {Type: "string", Format: "binary"}},
+			},
+		}},
+		Responses: replies(ok(ImportResponse{}), []openapi.Reply{openapi.JSON(http.StatusUnprocessableEntity, ImportResponse{})}, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/seller/products/export", ID: "exportProducts",
+		Summary: "Выгрузка своих товаров", Tag: "seller", Auth: true,
+		Query: []openapi.Param{{Name: "format", Type: "string", Enum: formats}},
+		Responses: replies([]openapi.Reply{
+			openapi.Raw(http.StatusOK, "text/csv", &openapi.Schema{Type: "string"}),
+			openapi.JSON(http.StatusOK, []models.Product{}),
+		}, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/seller/analytics", ID: "getSellerAnalytics",
+		Summary: "Аналитика продаж продавца", Tag: "seller", Auth: true,
+		Query:     analyticsQuery,
+		Responses: replies(ok(AnalyticsResponse{}), role),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/seller/returns", ID: "getSellerReturns",
+		Summary: "Заявки на возврат по товарам продавца; администратору — все", Tag: "seller", Auth: true,
+		Responses: replies(ok(ReturnListResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/seller/returns/:id/status", ID: "updateReturnStatus",
+		Summary: "Одобрить или отклонить заявку, отметить получение товара или возврат денег", Tag: "seller", Auth: true,
+		Body:      b.JSONBody(models.ReturnStatusRequest{}),
+		Responses: replies(ok(ReturnResponse{}), b.Errors(http.StatusConflict), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/seller/sales", ID: "getSales",
+		Summary: "Акции продавца; администратору — все", Tag: "seller", Auth: true,
+		Responses: replies(ok(SaleListResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/seller/sales", ID: "createSale",
+		Summary: "Запланировать акцию на товары или категорию; акция продавца действует только на его товары", Tag: "seller", Auth: true,
+		Body:      b.JSONBody(models.SaleRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, SaleResponse{})}, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/seller/sales/:id", ID: "cancelSale",
+		Summary: "Отменить запланированную или идущую акцию", Tag: "seller", Auth: true,
+		Responses: replies(ok(SaleResponse{}), notFound, role),
+	})
+
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/users", ID: "getAllUsers",
+		Summary: "Поиск пользователей с фильтрами и постраничным выводом, новые первыми", Tag: "admin", Auth: true,
+		Query: []openapi.Param{
+			{Name: "q", Type: "string", Description: "Подстрока имени или email без учета регистра"},
+			{Name: "role", Type: "string", Enum: []interface{}{models.RoleCustomer, models.RoleSeller, models.RoleAdmin}},
+			{Name: "active", Type: "boolean"},
+			{Name: "protected", Type: "boolean"},
+			{Name: "page", Type: "integer", Description: "Номер страницы, с 1"},
+			{Name: "limit", Type: "integer", Description: "Пользователей на странице, до 100"},
+		},
+		Responses: replies(ok(UserPageResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/admin/users/bulk", ID: "bulkUpdateUsers",
+		Summary: "Заблокировать, разблокировать или сменить роль нескольким пользователям; итог по каждому — в data", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.BulkUserRequest{}),
+		Responses: replies(ok(BulkUsersResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/users/:id", ID: "getUserDetail",
+		Summary: "Карточка пользователя: товары, заказы и последние входы", Tag: "admin", Auth: true,
+		Responses: replies(ok(UserDetailResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/users/:id/role", ID: "updateUserRole",
+		Summary: "Изменить роль пользователя", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.UpdateRoleRequest{}),
+		Responses: replies(ok(RoleChangeResponse{}, SelfRoleChangeResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/users/:id/active", ID: "toggleUserActive",
+		Summary: "Заблокировать или разблокировать пользователя", Tag: "admin", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/pending-products", ID: "getPendingProducts",
+		Summary: "Товары на модерации", Tag: "admin", Auth: true,
+		Responses: replies(ok(ProductListResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/products/:id/approve", ID: "approveProduct",
+		Summary: "Одобрить товар", Tag: "admin", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/admin/products/:id/force", ID: "forceDeleteProduct",
+		Summary: "Удалить товар вместе с позициями корзин", Tag: "admin", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/analytics", ID: "getAdminAnalytics",
+		Summary: "Аналитика продаж магазина", Tag: "admin", Auth: true,
+		Query:     analyticsQuery,
+		Responses: replies(ok(AnalyticsResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/orders/:id/status", ID: "updateOrderStatus",
+		Summary: "Перевести заказ в следующий статус; отмена возвращает товары на склад", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.OrderStatusRequest{}),
+		Responses: replies(ok(OrderResponse{}), b.Errors(http.StatusConflict), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/moderation", ID: "getModerationQueue",
+		Summary: "Вопросы и ответы, ожидающие модерации", Tag: "admin", Auth: true,
+		Responses: replies(ok(ModerationQueueResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/questions/:id/status", ID: "moderateQuestion",
+		Summary: "Опубликовать или скрыть вопрос", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.ModerationRequest{}),
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/answers/:id/status", ID: "moderateAnswer",
+		Summary: "Опубликовать или скрыть ответ", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.ModerationRequest{}),
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/delivery-methods", ID: "getAllDeliveryMethods",
+		Summary: "Все способы доставки, включая отключенные", Tag: "admin", Auth: true,
+		Responses: replies(ok(DeliveryMethodListResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/admin/delivery-methods", ID: "createDeliveryMethod",
+		Summary: "Создать способ доставки", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.DeliveryMethodRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, DeliveryMethodResponse{})}, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/delivery-methods/:id", ID: "updateDeliveryMethod",
+		Summary: "Изменить способ доставки и заменить ступени тарифа", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.DeliveryMethodRequest{}),
+		Responses: replies(ok(DeliveryMethodResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/admin/delivery-methods/:id", ID: "deleteDeliveryMethod",
+		Summary: "Удалить способ доставки; в оформленных заказах он сохраняется", Tag: "admin", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodGet, Path: "/api/admin/tax-rates", ID: "getTaxRates",
+		Summary: "Ставки налогов по категориям и регионам", Tag: "admin", Auth: true,
+		Responses: replies(ok(TaxRateListResponse{}), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPost, Path: "/api/admin/tax-rates", ID: "createTaxRate",
+		Summary: "Создать ставку налога; пара категория и регион уникальна", Tag: "admin", Auth: true,
+		Body: b.JSONBody(models.TaxRateRequest{}),
+		Responses: replies([]openapi.Reply{openapi.JSON(http.StatusCreated, TaxRateResponse{})},
+			b.Errors(http.StatusConflict), role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodPut, Path: "/api/admin/tax-rates/:id", ID: "updateTaxRate",
+		Summary: "Изменить ставку налога; оформленные заказы не пересчитываются", Tag: "admin", Auth: true,
+		Body:      b.JSONBody(models.TaxRateRequest{}),
+		Responses: replies(ok(TaxRateResponse{}), b.Errors(http.StatusConflict), notFound, role),
+	})
+	b.Add(openapi.Route{
+		Method: http.MethodDelete, Path: "/api/admin/tax-rates/:id", ID: "deleteTaxRate",
+		Summary: "Удалить ставку налога", Tag: "admin", Auth: true,
+		Responses: replies(ok(MessageResponse{}), notFound, role),
+	})
+
+	return b.Document()
+}
+
+var openAPISpec struct {
+	once sync.Once
+	data []byte
+	err  error
+}
+
+func (h *Handler) GetOpenAPI(c echo.Context) error {
+	openAPISpec.once.Do(func() {
+		openAPISpec.data, openAPISpec.err = json.Marshal(OpenAPIDocument())
+	})
+	if openAPISpec.err != nil {
+		return openAPISpec.err
+	}
+
+	return c.JSONBlob(http.StatusOK, openAPISpec.data)
+}
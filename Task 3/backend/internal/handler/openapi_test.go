@@ -0,0 +1,590 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/openapi"
+	"catpc-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+// contractCase — запрос к API, ответ на который проверяется по спецификации
+type contractCase struct {
+	name        string
+	method      string
+	route       string // путь из спецификации, например /api/products/{id}
+	url         string
+	token       string
+	contentType string
+	body        []byte
+	header      map[string]string
+	status      int
+	stream      bool // ответ — поток событий: запрос отменяется, когда начата передача
+}
+
+func jsonBody(t *testing.T, v interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// multipartBody собирает форму из текстовых полей и PNG-файлов
+func multipartBody(t *testing.T, fields map[string]string, files map[string][]byte) (string, []byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, data := range files {
+		part, err := w.CreateFormFile(name, name+".png")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return w.FormDataContentType(), buf.Bytes()
+}
+
+func pngImage(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestOpenAPIContract(t *testing.T) {
+	env := newTestEnv(t)
+	doc := OpenAPIDocument()
+
+	admin, adminToken := env.user("CatPC", models.RoleAdmin)
+	seller, sellerToken := env.user("seller", models.RoleSeller)
+	target, targetToken := env.user("target", models.RoleCustomer)
+	buyer, buyerToken := env.user("buyer", models.RoleCustomer)
+	_, accountToken := env.user("account", models.RoleCustomer)
+	_, leaverToken := env.user("leaver", models.RoleCustomer)
+
+	approved := env.product(seller.ID, "GPU", 1000, 10, true)
+	pending := env.product(seller.ID, "CPU", 500, 10, false)
+	removable := env.product(seller.ID, "RAM", 100, 10, true)
+	forced := env.product(seller.ID, "SSD", 200, 10, true)
+
+	if err := env.repo.AddCartItem(buyer.ID, approved.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.repo.AddCartItem(buyer.ID, forced.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	cart, err := env.repo.GetCartItems(buyer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	courier, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Курьер", Kind: models.DeliveryCourier, Pricing: models.PricingByTotal, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(300)}, {From: money.FromInt(5000), Cost: money.Zero}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pickup, err := env.svc.CreateDeliveryMethod(models.DeliveryMethodRequest{
+		Name: "Самовывоз", Kind: models.DeliveryPickup, Pricing: models.PricingByWeight, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.Zero}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addressReq := models.AddressRequest{Recipient: "Иван", Phone: "+79990000000", City: "Москва", Street: "Тверская, 1"}
+	home, err := env.svc.CreateAddress(buyer.ID, addressReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	work, err := env.svc.CreateAddress(buyer.ID, addressReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vat, err := env.svc.CreateTaxRate(models.TaxRateRequest{Name: "НДС 10%", Rate: money.FromInt(10), Category: "GPU", Inclusive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	obsolete, err := env.svc.CreateTaxRate(models.TaxRateRequest{Name: "НДС 0%", Rate: money.Zero, Category: "Книги"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notices := []models.Notification{{UserID: buyer.ID, Type: models.NotifyBackInStock, ProductID: approved.ID, ProductName: approved.Name}}
+	if err := env.repo.CreateNotifications(notices); err != nil {
+		t.Fatal(err)
+	}
+	notice := strconv.Itoa(notices[0].ID)
+	buyerClaims, err := env.svc.ValidateJWT(buyerToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamToken, err := env.svc.GenerateStreamToken(buyerClaims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	taxReq := models.TaxRateRequest{Name: "Налог с продаж 5%", Rate: money.FromInt(5), Category: "GPU", Region: "Техас"}
+	deliveryReq := models.DeliveryMethodRequest{
+		Name: "Почта", Kind: models.DeliveryPost, Pricing: models.PricingByWeight, IsActive: true,
+		Rules: []models.DeliveryRule{{From: money.Zero, Cost: money.FromInt(250)}, {From: money.FromInt(1000), Cost: money.FromInt(400)}},
+	}
+
+	env.repo.AddOrder(models.Order{
+		UserID:    buyer.ID,
+		CreatedAt: time.Now().UTC(),
+		Items:     []models.OrderItem{{ProductID: approved.ID, Quantity: 1, PriceAtTime: money.FromInt(1000)}},
+	})
+	env.repo.AddOrder(models.Order{
+		UserID:    buyer.ID,
+		Status:    models.OrderDelivered,
+		CreatedAt: time.Now().UTC(),
+		Subtotal:  money.FromInt(2000),
+		Total:     money.FromInt(2000),
+		Items:     []models.OrderItem{{ProductID: approved.ID, Name: "GPU", Quantity: 2, PriceAtTime: money.FromInt(1000)}},
+	})
+	delivered, err := env.repo.GetOrderByID(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	returnReq := models.ReturnRequest{OrderItemID: delivered.Items[0].ID, Quantity: 1, Reason: "Не включается"}
+
+	img := pngImage(t)
+	uploadType, uploadBody := multipartBody(t, nil, map[string][]byte{"image": img})
+	createType, createBody := multipartBody(t, map[string]string{"name": "Case", "price": "99.90", "stock": "3"}, map[string][]byte{"image": img})
+	invalidType, invalidBody := multipartBody(t, map[string]string{"name": "Case", "price": "-1", "stock": "3"}, nil)
+	updateForm := "name=GPU+Pro&price=1200&stock=5&image=default.png"
+	importCSV := "sku,name,description,price,stock,image\nCASE-1,Case,,99.90,3,\n"
+
+	id := func(id int) string { return strconv.Itoa(id) }
+
+	if _, _, err := env.svc.UpdateProfile(target.ID, models.ProfileRequest{Username: "target", Email: "target.new@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	pendingTarget, err := env.repo.GetUserByID(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detail, err := env.svc.GetProductDetail(approved.ID, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	productETag := service.ProductDetailETag(detail)
+
+	saleStart := time.Now().Add(time.Hour)
+	saleRequest := models.SaleRequest{Name: "Выходные SSD", DiscountType: models.DiscountPercent, DiscountValue: money.FromInt(20),
+		StartsAt: saleStart, EndsAt: saleStart.Add(48 * time.Hour), Category: "SSD"}
+	sale, err := env.svc.CreateSale(service.Actor{ID: seller.ID, Role: models.RoleSeller}, saleRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badWindow := saleRequest
+	badWindow.EndsAt = saleStart.Add(-time.Minute)
+	env.svc.Rates = money.NewStaticRates("RUB", map[string]money.Amount{"USD": money.MustParse("92.5")})
+
+	cases := []contractCase{
+		{name: "spec", method: http.MethodGet, route: "/api/openapi.json", url: "/api/openapi.json", status: http.StatusOK},
+
+		{name: "register", method: http.MethodPost, route: "/api/register", url: "/api/register",
+			body: jsonBody(t, models.RegisterRequest{Username: "newbie", Email: "newbie@example.com", Password: "secret"}), status: http.StatusCreated},
+		{name: "register invalid", method: http.MethodPost, route: "/api/register", url: "/api/register",
+			body: jsonBody(t, models.RegisterRequest{Username: "x"}), status: http.StatusBadRequest},
+		{name: "register duplicate", method: http.MethodPost, route: "/api/register", url: "/api/register",
+			body: jsonBody(t, models.RegisterRequest{Username: "buyer", Email: "buyer@example.com", Password: "secret"}), status: http.StatusConflict},
+		{name: "login", method: http.MethodPost, route: "/api/login", url: "/api/login",
+			body: jsonBody(t, models.LoginRequest{Username: "buyer", Password: "password"}), status: http.StatusOK},
+		{name: "login wrong password", method: http.MethodPost, route: "/api/login", url: "/api/login",
+			body: jsonBody(t, models.LoginRequest{Username: "buyer", Password: "wrong"}), status: http.StatusUnauthorized},
+
+		{name: "products", method: http.MethodGet, route: "/api/products", url: "/api/products?page=1&limit=2", status: http.StatusOK},
+		{name: "products by cursor", method: http.MethodGet, route: "/api/products", url: "/api/products?cursor=&limit=1", status: http.StatusOK},
+		{name: "products bad cursor", method: http.MethodGet, route: "/api/products", url: "/api/products?cursor=!!", status: http.StatusBadRequest},
+		{name: "product", method: http.MethodGet, route: "/api/products/{id}", url: "/api/products/" + id(approved.ID), status: http.StatusOK},
+		{name: "product not modified", method: http.MethodGet, route: "/api/products/{id}", url: "/api/products/" + id(approved.ID),
+			header: map[string]string{"If-None-Match": productETag}, status: http.StatusNotModified},
+		{name: "product in currency", method: http.MethodGet, route: "/api/products/{id}", url: "/api/products/" + id(approved.ID) + "?currency=usd", status: http.StatusOK},
+		{name: "product unknown currency", method: http.MethodGet, route: "/api/products/{id}", url: "/api/products/" + id(approved.ID) + "?currency=XXX", status: http.StatusBadRequest},
+		{name: "currencies", method: http.MethodGet, route: "/api/currencies", url: "/api/currencies", status: http.StatusOK},
+		{name: "product missing", method: http.MethodGet, route: "/api/products/{id}", url: "/api/products/999", status: http.StatusNotFound},
+		{name: "product bad id", method: http.MethodGet, route: "/api/products/{id}", url: "/api/products/abc", status: http.StatusBadRequest},
+
+		{name: "compare", method: http.MethodGet, route: "/api/compare", url: "/api/compare", token: buyerToken, status: http.StatusOK},
+		{name: "compare without owner", method: http.MethodGet, route: "/api/compare", url: "/api/compare", status: http.StatusBadRequest},
+		{name: "add to compare", method: http.MethodPost, route: "/api/compare", url: "/api/compare", token: buyerToken,
+			body: jsonBody(t, models.CompareRequest{ProductID: approved.ID}), status: http.StatusOK},
+		{name: "add pending to compare", method: http.MethodPost, route: "/api/compare", url: "/api/compare", token: buyerToken,
+			body: jsonBody(t, models.CompareRequest{ProductID: pending.ID}), status: http.StatusBadRequest},
+		{name: "add missing to compare", method: http.MethodPost, route: "/api/compare", url: "/api/compare", token: buyerToken,
+			body: jsonBody(t, models.CompareRequest{ProductID: 999}), status: http.StatusNotFound},
+		{name: "remove from compare missing", method: http.MethodDelete, route: "/api/compare/{id}", url: "/api/compare/999", token: buyerToken, status: http.StatusNotFound},
+		{name: "remove from compare", method: http.MethodDelete, route: "/api/compare/{id}", url: "/api/compare/" + id(approved.ID), token: buyerToken, status: http.StatusOK},
+		{name: "clear compare", method: http.MethodDelete, route: "/api/compare", url: "/api/compare", token: buyerToken, status: http.StatusOK},
+
+		{name: "graphql catalog", method: http.MethodPost, route: "/api/graphql", url: "/api/graphql?currency=USD",
+			body: jsonBody(t, models.GraphQLRequest{Query: "{ products(limit: 5) { total products { id name price seller { username } } } }"}), status: http.StatusOK},
+		{name: "graphql cart", method: http.MethodPost, route: "/api/graphql", url: "/api/graphql", token: buyerToken,
+			body: jsonBody(t, models.GraphQLRequest{Query: "{ cart { total items { name product { id } } } orders { id } }"}), status: http.StatusOK},
+		{name: "graphql field error", method: http.MethodPost, route: "/api/graphql", url: "/api/graphql",
+			body: jsonBody(t, models.GraphQLRequest{Query: "{ cart { total } }"}), status: http.StatusOK},
+		{name: "graphql invalid token", method: http.MethodPost, route: "/api/graphql", url: "/api/graphql", token: "broken",
+			body: jsonBody(t, models.GraphQLRequest{Query: "{ categories { name } }"}), status: http.StatusUnauthorized},
+		{name: "graphql without query", method: http.MethodPost, route: "/api/graphql", url: "/api/graphql",
+			body: jsonBody(t, models.GraphQLRequest{}), status: http.StatusBadRequest},
+
+		{name: "price history", method: http.MethodGet, route: "/api/products/{id}/price-history", url: "/api/products/" + id(approved.ID) + "/price-history", status: http.StatusOK},
+		{name: "price alert", method: http.MethodPut, route: "/api/products/{id}/price-alert", url: "/api/products/" + id(approved.ID) + "/price-alert", token: buyerToken,
+			body: jsonBody(t, models.PriceAlertRequest{TargetPrice: money.FromInt(900)}), status: http.StatusOK},
+		{name: "price alert too high", method: http.MethodPut, route: "/api/products/{id}/price-alert", url: "/api/products/" + id(approved.ID) + "/price-alert", token: buyerToken,
+			body: jsonBody(t, models.PriceAlertRequest{TargetPrice: money.FromInt(5000)}), status: http.StatusBadRequest},
+		{name: "price alerts", method: http.MethodGet, route: "/api/price-alerts", url: "/api/price-alerts", token: buyerToken, status: http.StatusOK},
+		{name: "delete price alert", method: http.MethodDelete, route: "/api/products/{id}/price-alert", url: "/api/products/" + id(approved.ID) + "/price-alert", token: buyerToken, status: http.StatusOK},
+		{name: "ask question", method: http.MethodPost, route: "/api/products/{id}/questions", url: "/api/products/" + id(approved.ID) + "/questions", token: buyerToken,
+			body: jsonBody(t, models.QuestionRequest{Body: "Поместится в корпус mATX?"}), status: http.StatusCreated},
+		{name: "ask question short", method: http.MethodPost, route: "/api/products/{id}/questions", url: "/api/products/" + id(approved.ID) + "/questions", token: buyerToken,
+			body: jsonBody(t, models.QuestionRequest{Body: "?"}), status: http.StatusBadRequest},
+		{name: "ask question pending product", method: http.MethodPost, route: "/api/products/{id}/questions", url: "/api/products/" + id(pending.ID) + "/questions", token: buyerToken,
+			body: jsonBody(t, models.QuestionRequest{Body: "Когда появится?"}), status: http.StatusBadRequest},
+		{name: "seller answer", method: http.MethodPost, route: "/api/questions/{id}/answers", url: "/api/questions/1/answers", token: sellerToken,
+			body: jsonBody(t, models.AnswerRequest{Body: "Да, длина 240 мм"}), status: http.StatusCreated},
+		{name: "buyer answer", method: http.MethodPost, route: "/api/questions/{id}/answers", url: "/api/questions/1/answers", token: buyerToken,
+			body: jsonBody(t, models.AnswerRequest{Body: "У меня поместилась"}), status: http.StatusCreated},
+		{name: "answer without purchase", method: http.MethodPost, route: "/api/questions/{id}/answers", url: "/api/questions/1/answers", token: targetToken,
+			body: jsonBody(t, models.AnswerRequest{Body: "Не знаю"}), status: http.StatusForbidden},
+		{name: "answer missing question", method: http.MethodPost, route: "/api/questions/{id}/answers", url: "/api/questions/999/answers", token: sellerToken,
+			body: jsonBody(t, models.AnswerRequest{Body: "Да"}), status: http.StatusNotFound},
+		{name: "vote answer", method: http.MethodPut, route: "/api/answers/{id}/vote", url: "/api/answers/2/vote", token: targetToken, status: http.StatusOK},
+		{name: "vote missing answer", method: http.MethodPut, route: "/api/answers/{id}/vote", url: "/api/answers/999/vote", token: targetToken, status: http.StatusNotFound},
+		{name: "unvote answer", method: http.MethodDelete, route: "/api/answers/{id}/vote", url: "/api/answers/2/vote", token: targetToken, status: http.StatusOK},
+		{name: "unvote missing answer", method: http.MethodDelete, route: "/api/answers/{id}/vote", url: "/api/answers/999/vote", token: targetToken, status: http.StatusNotFound},
+		{name: "questions", method: http.MethodGet, route: "/api/products/{id}/questions", url: "/api/products/" + id(approved.ID) + "/questions", status: http.StatusOK},
+		{name: "questions missing product", method: http.MethodGet, route: "/api/products/{id}/questions", url: "/api/products/999/questions", status: http.StatusNotFound},
+		{name: "profile", method: http.MethodGet, route: "/api/profile", url: "/api/profile", token: buyerToken, status: http.StatusOK},
+		{name: "update profile", method: http.MethodPut, route: "/api/profile", url: "/api/profile", token: accountToken,
+			body: jsonBody(t, models.ProfileRequest{Username: "account2", Email: "account.new@example.com"}), status: http.StatusOK},
+		{name: "update profile taken email", method: http.MethodPut, route: "/api/profile", url: "/api/profile", token: accountToken,
+			body: jsonBody(t, models.ProfileRequest{Username: "account2", Email: "buyer@example.com"}), status: http.StatusConflict},
+		{name: "update profile invalid", method: http.MethodPut, route: "/api/profile", url: "/api/profile", token: accountToken,
+			body: jsonBody(t, models.ProfileRequest{Username: "account2", Email: "not-an-email"}), status: http.StatusBadRequest},
+		{name: "confirm email", method: http.MethodPost, route: "/api/profile/email/confirm", url: "/api/profile/email/confirm",
+			body: jsonBody(t, models.EmailConfirmRequest{Token: pendingTarget.EmailToken}), status: http.StatusOK},
+		{name: "confirm email used token", method: http.MethodPost, route: "/api/profile/email/confirm", url: "/api/profile/email/confirm",
+			body: jsonBody(t, models.EmailConfirmRequest{Token: pendingTarget.EmailToken}), status: http.StatusBadRequest},
+		{name: "change password wrong current", method: http.MethodPut, route: "/api/profile/password", url: "/api/profile/password", token: accountToken,
+			body: jsonBody(t, models.PasswordChangeRequest{CurrentPassword: "wrong", NewPassword: "new-secret"}), status: http.StatusBadRequest},
+		{name: "change password", method: http.MethodPut, route: "/api/profile/password", url: "/api/profile/password", token: accountToken,
+			body: jsonBody(t, models.PasswordChangeRequest{CurrentPassword: "password", NewPassword: "new-secret"}), status: http.StatusOK},
+		{name: "change password revoked token", method: http.MethodPut, route: "/api/profile/password", url: "/api/profile/password", token: accountToken,
+			body: jsonBody(t, models.PasswordChangeRequest{CurrentPassword: "new-secret", NewPassword: "newer-secret"}), status: http.StatusUnauthorized},
+		{name: "delete account wrong password", method: http.MethodDelete, route: "/api/profile", url: "/api/profile", token: leaverToken,
+			body: jsonBody(t, models.DeleteAccountRequest{Password: "wrong"}), status: http.StatusBadRequest},
+		{name: "delete account", method: http.MethodDelete, route: "/api/profile", url: "/api/profile", token: leaverToken,
+			body: jsonBody(t, models.DeleteAccountRequest{Password: "password"}), status: http.StatusOK},
+		{name: "delete account again", method: http.MethodDelete, route: "/api/profile", url: "/api/profile", token: leaverToken,
+			body: jsonBody(t, models.DeleteAccountRequest{Password: "password"}), status: http.StatusUnauthorized},
+		{name: "profile anonymous", method: http.MethodGet, route: "/api/profile", url: "/api/profile", status: http.StatusUnauthorized},
+		{name: "cart", method: http.MethodGet, route: "/api/cart", url: "/api/cart", token: buyerToken, status: http.StatusOK},
+		{name: "cart in currency", method: http.MethodGet, route: "/api/cart", url: "/api/cart?currency=USD", token: buyerToken, status: http.StatusOK},
+		{name: "cart add", method: http.MethodPost, route: "/api/cart/add", url: "/api/cart/add", token: buyerToken,
+			body: jsonBody(t, models.AddToCartRequest{ProductID: approved.ID, Quantity: 1}), status: http.StatusOK},
+		{name: "cart add missing", method: http.MethodPost, route: "/api/cart/add", url: "/api/cart/add", token: buyerToken,
+			body: jsonBody(t, models.AddToCartRequest{ProductID: 999, Quantity: 1}), status: http.StatusNotFound},
+		{name: "cart update", method: http.MethodPut, route: "/api/cart/update/{id}", url: "/api/cart/update/" + id(cart[0].ID), token: buyerToken,
+			body: jsonBody(t, models.UpdateCartItemRequest{Quantity: 2}), status: http.StatusOK},
+		{name: "cart remove", method: http.MethodDelete, route: "/api/cart/remove/{id}", url: "/api/cart/remove/" + id(cart[0].ID), token: buyerToken, status: http.StatusOK},
+		{name: "cart with delivery", method: http.MethodGet, route: "/api/cart", url: "/api/cart?delivery_method=" + id(courier.ID), token: buyerToken, status: http.StatusOK},
+		{name: "cart with address", method: http.MethodGet, route: "/api/cart", url: "/api/cart?address=" + id(home.ID), token: buyerToken, status: http.StatusOK},
+		{name: "cart unknown address", method: http.MethodGet, route: "/api/cart", url: "/api/cart?address=999", token: buyerToken, status: http.StatusNotFound},
+		{name: "cart unknown delivery", method: http.MethodGet, route: "/api/cart", url: "/api/cart?delivery_method=999", token: buyerToken, status: http.StatusNotFound},
+		{name: "delivery methods", method: http.MethodGet, route: "/api/delivery-methods", url: "/api/delivery-methods", status: http.StatusOK},
+		{name: "addresses", method: http.MethodGet, route: "/api/addresses", url: "/api/addresses", token: buyerToken, status: http.StatusOK},
+		{name: "create address", method: http.MethodPost, route: "/api/addresses", url: "/api/addresses", token: buyerToken,
+			body: jsonBody(t, addressReq), status: http.StatusCreated},
+		{name: "create address invalid", method: http.MethodPost, route: "/api/addresses", url: "/api/addresses", token: buyerToken,
+			body: jsonBody(t, models.AddressRequest{City: "Москва"}), status: http.StatusBadRequest},
+		{name: "update address", method: http.MethodPut, route: "/api/addresses/{id}", url: "/api/addresses/" + id(work.ID), token: buyerToken,
+			body: jsonBody(t, models.AddressRequest{Recipient: "Иван", Phone: "+79990000000", City: "Москва", Street: "Арбат, 2", IsDefault: true}), status: http.StatusOK},
+		{name: "update address missing", method: http.MethodPut, route: "/api/addresses/{id}", url: "/api/addresses/999", token: buyerToken,
+			body: jsonBody(t, addressReq), status: http.StatusNotFound},
+		{name: "delete address", method: http.MethodDelete, route: "/api/addresses/{id}", url: "/api/addresses/" + id(work.ID), token: buyerToken, status: http.StatusOK},
+		{name: "checkout without address", method: http.MethodPost, route: "/api/orders", url: "/api/orders", token: buyerToken,
+			body: jsonBody(t, models.CheckoutRequest{DeliveryMethodID: courier.ID}), status: http.StatusBadRequest},
+		{name: "checkout unknown delivery", method: http.MethodPost, route: "/api/orders", url: "/api/orders", token: buyerToken,
+			body: jsonBody(t, models.CheckoutRequest{DeliveryMethodID: 999}), status: http.StatusNotFound},
+		{name: "checkout", method: http.MethodPost, route: "/api/orders", url: "/api/orders", token: buyerToken,
+			body: jsonBody(t, models.CheckoutRequest{DeliveryMethodID: courier.ID, AddressID: home.ID}), status: http.StatusCreated},
+		{name: "checkout empty cart", method: http.MethodPost, route: "/api/orders", url: "/api/orders", token: buyerToken,
+			body: jsonBody(t, models.CheckoutRequest{DeliveryMethodID: pickup.ID}), status: http.StatusBadRequest},
+		{name: "orders", method: http.MethodGet, route: "/api/orders", url: "/api/orders?currency=USD", token: buyerToken, status: http.StatusOK},
+		{name: "order", method: http.MethodGet, route: "/api/orders/{id}", url: "/api/orders/1", token: buyerToken, status: http.StatusOK},
+		{name: "create return", method: http.MethodPost, route: "/api/orders/{id}/returns", url: "/api/orders/2/returns", token: buyerToken,
+			body: jsonBody(t, returnReq), status: http.StatusCreated},
+		{name: "create return not delivered", method: http.MethodPost, route: "/api/orders/{id}/returns", url: "/api/orders/1/returns", token: buyerToken,
+			body: jsonBody(t, returnReq), status: http.StatusBadRequest},
+		{name: "create return foreign order", method: http.MethodPost, route: "/api/orders/{id}/returns", url: "/api/orders/2/returns", token: sellerToken,
+			body: jsonBody(t, returnReq), status: http.StatusNotFound},
+		{name: "returns", method: http.MethodGet, route: "/api/returns", url: "/api/returns", token: buyerToken, status: http.StatusOK},
+		{name: "notifications", method: http.MethodGet, route: "/api/notifications", url: "/api/notifications?unread=true&limit=10", token: buyerToken, status: http.StatusOK},
+		{name: "notifications unauthorized", method: http.MethodGet, route: "/api/notifications", url: "/api/notifications", status: http.StatusUnauthorized},
+		{name: "notification stream", method: http.MethodGet, route: "/api/notifications/stream", url: "/api/notifications/stream", token: buyerToken,
+			header: map[string]string{"Last-Event-ID": "0"}, stream: true, status: http.StatusOK},
+		{name: "notification stream by stream token", method: http.MethodGet, route: "/api/notifications/stream",
+			url: "/api/notifications/stream?last_event_id=0&token=" + streamToken.Token, stream: true, status: http.StatusOK},
+		{name: "notification stream by session token in query", method: http.MethodGet, route: "/api/notifications/stream",
+			url: "/api/notifications/stream?token=" + buyerToken, status: http.StatusUnauthorized},
+		{name: "notification stream token", method: http.MethodPost, route: "/api/notifications/stream-token", url: "/api/notifications/stream-token", token: buyerToken, status: http.StatusOK},
+		{name: "notification stream token unauthorized", method: http.MethodPost, route: "/api/notifications/stream-token", url: "/api/notifications/stream-token", status: http.StatusUnauthorized},
+		{name: "notification read", method: http.MethodPut, route: "/api/notifications/{id}/read", url: "/api/notifications/" + notice + "/read", token: buyerToken, status: http.StatusOK},
+		{name: "notification read foreign", method: http.MethodPut, route: "/api/notifications/{id}/read", url: "/api/notifications/" + notice + "/read", token: targetToken, status: http.StatusNotFound},
+		{name: "notifications read all", method: http.MethodPut, route: "/api/notifications/read", url: "/api/notifications/read", token: buyerToken, status: http.StatusOK},
+		{name: "order of another user", method: http.MethodGet, route: "/api/orders/{id}", url: "/api/orders/1", token: sellerToken, status: http.StatusNotFound},
+		{name: "invoice", method: http.MethodGet, route: "/api/orders/{id}/invoice", url: "/api/orders/1/invoice", token: buyerToken, status: http.StatusOK},
+		{name: "invoice for seller", method: http.MethodGet, route: "/api/orders/{id}/invoice", url: "/api/orders/1/invoice", token: sellerToken, status: http.StatusOK},
+		{name: "invoice of another user", method: http.MethodGet, route: "/api/orders/{id}/invoice", url: "/api/orders/1/invoice", token: targetToken, status: http.StatusNotFound},
+		{name: "upload", method: http.MethodPost, route: "/api/upload", url: "/api/upload", token: buyerToken,
+			contentType: uploadType, body: uploadBody, status: http.StatusOK},
+
+		{name: "my products", method: http.MethodGet, route: "/api/seller/my-products", url: "/api/seller/my-products", token: sellerToken, status: http.StatusOK},
+		{name: "my products as customer", method: http.MethodGet, route: "/api/seller/my-products", url: "/api/seller/my-products", token: buyerToken, status: http.StatusForbidden},
+		{name: "create product", method: http.MethodPost, route: "/api/seller/products", url: "/api/seller/products", token: sellerToken,
+			contentType: createType, body: createBody, status: http.StatusCreated},
+		{name: "create product invalid", method: http.MethodPost, route: "/api/seller/products", url: "/api/seller/products", token: sellerToken,
+			contentType: invalidType, body: invalidBody, status: http.StatusBadRequest},
+		{name: "update product", method: http.MethodPut, route: "/api/seller/products/{id}", url: "/api/seller/products/" + id(approved.ID), token: sellerToken,
+			contentType: echo.MIMEApplicationForm, body: []byte(updateForm), status: http.StatusOK},
+		{name: "delete product", method: http.MethodDelete, route: "/api/seller/products/{id}", url: "/api/seller/products/" + id(removable.ID), token: sellerToken, status: http.StatusOK},
+		{name: "import", method: http.MethodPost, route: "/api/seller/products/import", url: "/api/seller/products/import?dry_run=true", token: sellerToken,
+			contentType: "text/csv", body: []byte(importCSV), status: http.StatusOK},
+		{name: "import with errors", method: http.MethodPost, route: "/api/seller/products/import", url: "/api/seller/products/import", token: sellerToken,
+			contentType: echo.MIMEApplicationJSON, body: jsonBody(t, []models.ImportRow{{SKU: "BAD", Name: "Bad", Price: money.FromInt(-1)}}), status: http.StatusUnprocessableEntity},
+		{name: "export csv", method: http.MethodGet, route: "/api/seller/products/export", url: "/api/seller/products/export", token: sellerToken, status: http.StatusOK},
+		{name: "export json", method: http.MethodGet, route: "/api/seller/products/export", url: "/api/seller/products/export?format=json", token: sellerToken, status: http.StatusOK},
+		{name: "seller returns", method: http.MethodGet, route: "/api/seller/returns", url: "/api/seller/returns", token: sellerToken, status: http.StatusOK},
+		{name: "seller sales", method: http.MethodGet, route: "/api/seller/sales", url: "/api/seller/sales", token: sellerToken, status: http.StatusOK},
+		{name: "create sale", method: http.MethodPost, route: "/api/seller/sales", url: "/api/seller/sales", token: sellerToken,
+			body: jsonBody(t, saleRequest), status: http.StatusCreated},
+		{name: "create sale bad window", method: http.MethodPost, route: "/api/seller/sales", url: "/api/seller/sales", token: sellerToken,
+			body: jsonBody(t, badWindow), status: http.StatusBadRequest},
+		{name: "create sale as customer", method: http.MethodPost, route: "/api/seller/sales", url: "/api/seller/sales", token: buyerToken,
+			body: jsonBody(t, saleRequest), status: http.StatusForbidden},
+		{name: "cancel sale missing", method: http.MethodDelete, route: "/api/seller/sales/{id}", url: "/api/seller/sales/999", token: sellerToken, status: http.StatusNotFound},
+		{name: "cancel sale", method: http.MethodDelete, route: "/api/seller/sales/{id}", url: "/api/seller/sales/" + id(sale.ID), token: sellerToken, status: http.StatusOK},
+		{name: "cancel sale twice", method: http.MethodDelete, route: "/api/seller/sales/{id}", url: "/api/seller/sales/" + id(sale.ID), token: sellerToken, status: http.StatusBadRequest},
+		{name: "approve return", method: http.MethodPut, route: "/api/seller/returns/{id}/status", url: "/api/seller/returns/1/status", token: sellerToken,
+			body: jsonBody(t, models.ReturnStatusRequest{Status: models.ReturnAwaitingItem}), status: http.StatusOK},
+		{name: "refund before receiving", method: http.MethodPut, route: "/api/seller/returns/{id}/status", url: "/api/seller/returns/1/status", token: sellerToken,
+			body: jsonBody(t, models.ReturnStatusRequest{Status: models.ReturnRefunded}), status: http.StatusBadRequest},
+		{name: "return missing", method: http.MethodPut, route: "/api/seller/returns/{id}/status", url: "/api/seller/returns/999/status", token: sellerToken,
+			body: jsonBody(t, models.ReturnStatusRequest{Status: models.ReturnRejected}), status: http.StatusNotFound},
+		{name: "seller analytics", method: http.MethodGet, route: "/api/seller/analytics", url: "/api/seller/analytics?top=5", token: sellerToken, status: http.StatusOK},
+
+		{name: "users", method: http.MethodGet, route: "/api/admin/users", url: "/api/admin/users", token: adminToken, status: http.StatusOK},
+		{name: "users search", method: http.MethodGet, route: "/api/admin/users", url: "/api/admin/users?q=BUY&role=customer&active=true&protected=false&page=1&limit=5", token: adminToken, status: http.StatusOK},
+		{name: "users bad filter", method: http.MethodGet, route: "/api/admin/users", url: "/api/admin/users?active=maybe", token: adminToken, status: http.StatusBadRequest},
+		{name: "user detail", method: http.MethodGet, route: "/api/admin/users/{id}", url: "/api/admin/users/" + id(buyer.ID), token: adminToken, status: http.StatusOK},
+		{name: "user detail missing", method: http.MethodGet, route: "/api/admin/users/{id}", url: "/api/admin/users/999", token: adminToken, status: http.StatusNotFound},
+		{name: "bulk users", method: http.MethodPost, route: "/api/admin/users/bulk", url: "/api/admin/users/bulk", token: adminToken,
+			body: jsonBody(t, models.BulkUserRequest{UserIDs: []int{target.ID, admin.ID, 999}, Action: models.BulkBlock}), status: http.StatusOK},
+		{name: "bulk users bad role", method: http.MethodPost, route: "/api/admin/users/bulk", url: "/api/admin/users/bulk", token: adminToken,
+			body: jsonBody(t, models.BulkUserRequest{UserIDs: []int{target.ID}, Action: models.BulkRole, Role: "root"}), status: http.StatusBadRequest},
+		{name: "bulk users as seller", method: http.MethodPost, route: "/api/admin/users/bulk", url: "/api/admin/users/bulk", token: sellerToken,
+			body: jsonBody(t, models.BulkUserRequest{UserIDs: []int{target.ID}, Action: models.BulkUnblock}), status: http.StatusForbidden},
+		{name: "role change", method: http.MethodPut, route: "/api/admin/users/{id}/role", url: "/api/admin/users/" + id(target.ID) + "/role", token: adminToken,
+			body: jsonBody(t, models.UpdateRoleRequest{Role: models.RoleSeller}), status: http.StatusOK},
+		{name: "role change self", method: http.MethodPut, route: "/api/admin/users/{id}/role", url: "/api/admin/users/" + id(admin.ID) + "/role", token: adminToken,
+			body: jsonBody(t, models.UpdateRoleRequest{Role: models.RoleSeller}), status: http.StatusOK},
+		{name: "toggle active", method: http.MethodPut, route: "/api/admin/users/{id}/active", url: "/api/admin/users/" + id(target.ID) + "/active", token: adminToken, status: http.StatusOK},
+		{name: "toggle active missing", method: http.MethodPut, route: "/api/admin/users/{id}/active", url: "/api/admin/users/999/active", token: adminToken, status: http.StatusNotFound},
+		{name: "pending", method: http.MethodGet, route: "/api/admin/pending-products", url: "/api/admin/pending-products", token: adminToken, status: http.StatusOK},
+		{name: "approve", method: http.MethodPut, route: "/api/admin/products/{id}/approve", url: "/api/admin/products/" + id(pending.ID) + "/approve", token: adminToken, status: http.StatusOK},
+		{name: "force delete", method: http.MethodDelete, route: "/api/admin/products/{id}/force", url: "/api/admin/products/" + id(forced.ID) + "/force", token: adminToken, status: http.StatusOK},
+		{name: "admin analytics", method: http.MethodGet, route: "/api/admin/analytics", url: "/api/admin/analytics", token: adminToken, status: http.StatusOK},
+		{name: "order paid", method: http.MethodPut, route: "/api/admin/orders/{id}/status", url: "/api/admin/orders/3/status", token: adminToken,
+			body: jsonBody(t, models.OrderStatusRequest{Status: models.OrderPaid}), status: http.StatusOK},
+		{name: "order skip shipping", method: http.MethodPut, route: "/api/admin/orders/{id}/status", url: "/api/admin/orders/3/status", token: adminToken,
+			body: jsonBody(t, models.OrderStatusRequest{Status: models.OrderDelivered}), status: http.StatusBadRequest},
+		{name: "order status missing", method: http.MethodPut, route: "/api/admin/orders/{id}/status", url: "/api/admin/orders/999/status", token: adminToken,
+			body: jsonBody(t, models.OrderStatusRequest{Status: models.OrderPaid}), status: http.StatusNotFound},
+		{name: "moderation queue", method: http.MethodGet, route: "/api/admin/moderation", url: "/api/admin/moderation", token: adminToken, status: http.StatusOK},
+		{name: "moderation queue as seller", method: http.MethodGet, route: "/api/admin/moderation", url: "/api/admin/moderation", token: sellerToken, status: http.StatusForbidden},
+		{name: "hide answer", method: http.MethodPut, route: "/api/admin/answers/{id}/status", url: "/api/admin/answers/2/status", token: adminToken,
+			body: jsonBody(t, models.ModerationRequest{Status: models.QAHidden}), status: http.StatusOK},
+		{name: "hide answer missing", method: http.MethodPut, route: "/api/admin/answers/{id}/status", url: "/api/admin/answers/999/status", token: adminToken,
+			body: jsonBody(t, models.ModerationRequest{Status: models.QAHidden}), status: http.StatusNotFound},
+		{name: "publish question", method: http.MethodPut, route: "/api/admin/questions/{id}/status", url: "/api/admin/questions/1/status", token: adminToken,
+			body: jsonBody(t, models.ModerationRequest{Status: models.QAPublished}), status: http.StatusOK},
+		{name: "moderate question bad status", method: http.MethodPut, route: "/api/admin/questions/{id}/status", url: "/api/admin/questions/1/status", token: adminToken,
+			body: jsonBody(t, models.ModerationRequest{Status: models.QAPending}), status: http.StatusBadRequest},
+		{name: "all delivery methods", method: http.MethodGet, route: "/api/admin/delivery-methods", url: "/api/admin/delivery-methods", token: adminToken, status: http.StatusOK},
+		{name: "create delivery method", method: http.MethodPost, route: "/api/admin/delivery-methods", url: "/api/admin/delivery-methods", token: adminToken,
+			body: jsonBody(t, deliveryReq), status: http.StatusCreated},
+		{name: "create delivery method without zero step", method: http.MethodPost, route: "/api/admin/delivery-methods", url: "/api/admin/delivery-methods", token: adminToken,
+			body: jsonBody(t, models.DeliveryMethodRequest{Name: "Почта", Kind: models.DeliveryPost, Pricing: models.PricingByWeight,
+				Rules: []models.DeliveryRule{{From: money.FromInt(100), Cost: money.FromInt(250)}}}), status: http.StatusBadRequest},
+		{name: "update delivery method", method: http.MethodPut, route: "/api/admin/delivery-methods/{id}", url: "/api/admin/delivery-methods/" + id(pickup.ID), token: adminToken,
+			body: jsonBody(t, deliveryReq), status: http.StatusOK},
+		{name: "update delivery method missing", method: http.MethodPut, route: "/api/admin/delivery-methods/{id}", url: "/api/admin/delivery-methods/999", token: adminToken,
+			body: jsonBody(t, deliveryReq), status: http.StatusNotFound},
+		{name: "delete delivery method", method: http.MethodDelete, route: "/api/admin/delivery-methods/{id}", url: "/api/admin/delivery-methods/" + id(pickup.ID), token: adminToken, status: http.StatusOK},
+		{name: "tax rates", method: http.MethodGet, route: "/api/admin/tax-rates", url: "/api/admin/tax-rates", token: adminToken, status: http.StatusOK},
+		{name: "create tax rate", method: http.MethodPost, route: "/api/admin/tax-rates", url: "/api/admin/tax-rates", token: adminToken,
+			body: jsonBody(t, taxReq), status: http.StatusCreated},
+		{name: "create tax rate duplicate", method: http.MethodPost, route: "/api/admin/tax-rates", url: "/api/admin/tax-rates", token: adminToken,
+			body: jsonBody(t, taxReq), status: http.StatusConflict},
+		{name: "create tax rate over 100%", method: http.MethodPost, route: "/api/admin/tax-rates", url: "/api/admin/tax-rates", token: adminToken,
+			body: jsonBody(t, models.TaxRateRequest{Name: "Налог", Rate: money.FromInt(150)}), status: http.StatusBadRequest},
+		{name: "update tax rate", method: http.MethodPut, route: "/api/admin/tax-rates/{id}", url: "/api/admin/tax-rates/" + id(vat.ID), token: adminToken,
+			body: jsonBody(t, models.TaxRateRequest{Name: "НДС 20%", Rate: money.FromInt(20), Category: "GPU", Inclusive: true}), status: http.StatusOK},
+		{name: "update tax rate missing", method: http.MethodPut, route: "/api/admin/tax-rates/{id}", url: "/api/admin/tax-rates/999", token: adminToken,
+			body: jsonBody(t, taxReq), status: http.StatusNotFound},
+		{name: "delete tax rate", method: http.MethodDelete, route: "/api/admin/tax-rates/{id}", url: "/api/admin/tax-rates/" + id(obsolete.ID), token: adminToken, status: http.StatusOK},
+		{name: "delete tax rate missing", method: http.MethodDelete, route: "/api/admin/tax-rates/{id}", url: "/api/admin/tax-rates/999", token: adminToken, status: http.StatusNotFound},
+		{name: "tax rates as seller", method: http.MethodGet, route: "/api/admin/tax-rates", url: "/api/admin/tax-rates", token: sellerToken, status: http.StatusForbidden},
+		{name: "delete delivery method as customer", method: http.MethodDelete, route: "/api/admin/delivery-methods/{id}", url: "/api/admin/delivery-methods/" + id(courier.ID), token: buyerToken, status: http.StatusForbidden},
+		{name: "admin analytics bad range", method: http.MethodGet, route: "/api/admin/analytics", url: "/api/admin/analytics?from=2024-02-01&to=2024-01-01", token: adminToken, status: http.StatusBadRequest},
+	}
+
+	covered := map[string]bool{}
+	for _, tc := range cases {
+		// Случаи зависят друг от друга (смена роли, удаление), поэтому идут по порядку
+		t.Run(tc.name, func(t *testing.T) {
+			op := doc.Operation(tc.method, tc.route)
+			if op == nil {
+				t.Fatalf("%s %s нет в спецификации", tc.method, tc.route)
+			}
+			covered[tc.method+" "+tc.route] = true
+
+			contentType := tc.contentType
+			if contentType == "" && tc.body != nil {
+				contentType = echo.MIMEApplicationJSON
+			}
+
+			req := httptest.NewRequest(tc.method, tc.url, bytes.NewReader(tc.body))
+			if contentType != "" {
+				req.Header.Set(echo.HeaderContentType, contentType)
+			}
+			if tc.token != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tc.token)
+			}
+			for name, value := range tc.header {
+				req.Header.Set(name, value)
+			}
+			if tc.stream {
+				ctx, cancel := context.WithTimeout(req.Context(), 50*time.Millisecond)
+				defer cancel()
+				req = req.WithContext(ctx)
+			}
+			rec := httptest.NewRecorder()
+			env.e.ServeHTTP(rec, req)
+
+			if rec.Code != tc.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tc.status, rec.Body.String())
+			}
+
+			if resp, ok := op.Responses[strconv.Itoa(rec.Code)]; ok && len(resp.Content) == 0 {
+				if rec.Body.Len() > 0 {
+					t.Errorf("ответ %d описан без тела, получено %q", rec.Code, rec.Body.String())
+				}
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(rec.Header().Get(echo.HeaderContentType))
+			if err != nil {
+				t.Fatalf("content type: %v", err)
+			}
+
+			schema, ok := op.ResponseSchema(rec.Code, mediaType)
+			if !ok {
+				t.Fatalf("ответ %d %s не описан в спецификации", rec.Code, mediaType)
+			}
+			if mediaType != echo.MIMEApplicationJSON {
+				return
+			}
+			if err := doc.Validate(schema, rec.Body.Bytes()); err != nil {
+				t.Errorf("ответ не соответствует спецификации: %v\n%s", err, rec.Body.String())
+			}
+		})
+	}
+
+	// Каждая описанная операция должна быть проверена хотя бы одним запросом
+	for path, item := range doc.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + path
+			if !covered[key] {
+				t.Errorf("операция %s не проверена контрактным тестом", key)
+			}
+		}
+	}
+}
+
+func TestOpenAPIDescribesAllRoutes(t *testing.T) {
+	env := newTestEnv(t)
+	doc := OpenAPIDocument()
+
+	var missing []string
+	registered := map[string]bool{}
+	for _, route := range env.e.Routes() {
+		// Group.Use регистрирует служебные маршруты RouteNotFound для групп
+		if !strings.HasPrefix(route.Path, "/api/") || route.Method == echo.RouteNotFound {
+			continue
+		}
+		registered[route.Method+" "+route.Path] = true
+		if doc.Operation(route.Method, openapi.EchoPathToOpenAPI(route.Path)) == nil {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("маршруты без описания в спецификации: %v", missing)
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			key := strings.ToUpper(method) + " " + openapi.OpenAPIPathToEcho(path)
+			if !registered[key] {
+				t.Errorf("в спецификации есть несуществующий маршрут %s", key)
+			}
+		}
+	}
+}
+
+// Клиент фронтенда генерируется из спецификации и должен быть в репозитории актуальным
+func TestGeneratedClientUpToDate(t *testing.T) {
+	const path = "../../../frontend/src/services/client.js"
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Skipf("клиент фронтенда недоступен: %v", err)
+	}
+
+	if !bytes.Equal(current, OpenAPIDocument().JSClient(ClientCommand)) {
+		t.Errorf("client.js устарел, выполните в backend: %s", ClientCommand)
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) Checkout(c echo.Context) error {
+	var req models.CheckoutRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	order, err := h.service.Checkout(getUserID(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, OrderResponse{Success: true, Data: *order})
+}
+
+func (h *Handler) GetOrders(c echo.Context) error {
+	orders, err := h.service.GetOrders(getUserID(c), c.QueryParam("currency"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, OrderListResponse{Success: true, Data: orders})
+}
+
+func (h *Handler) GetOrder(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	order, err := h.service.GetOrder(getUserID(c), id, c.QueryParam("currency"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, OrderResponse{Success: true, Data: *order})
+}
+
+// GetInvoice отдает PDF-счет к заказу; при первом запросе счет выставляется
+func (h *Handler) GetInvoice(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	invoice, err := h.service.GetInvoice(getActor(c), id)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`inline; filename="invoice-%s.pdf"`, invoice.Number))
+	return c.Blob(http.StatusOK, "application/pdf", invoice.PDF)
+}
+
+func (h *Handler) UpdateOrderStatus(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.OrderStatusRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	order, err := h.service.UpdateOrderStatus(id, req.Status)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, OrderResponse{Success: true, Data: *order})
+}
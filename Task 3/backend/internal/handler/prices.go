@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetPriceHistory(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	history, err := h.service.GetPriceHistory(productID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, PriceHistoryResponse{Success: true, Data: history})
+}
+
+func (h *Handler) GetPriceAlerts(c echo.Context) error {
+	alerts, err := h.service.GetPriceAlerts(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, PriceAlertListResponse{Success: true, Data: alerts})
+}
+
+func (h *Handler) SetPriceAlert(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.PriceAlertRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.service.SetPriceAlert(getUserID(c), productID, req.TargetPrice); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{
+		Success: true,
+		Message: message(c, "msg.price_alert_set", apperr.Params{"price": req.TargetPrice}),
+	})
+}
+
+func (h *Handler) DeletePriceAlert(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeletePriceAlert(getUserID(c), productID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.price_alert_deleted", nil)})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"catpc-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetProducts(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("limit"))
+
+	// Параметр cursor включает выборку по ключу; пустой курсор — первая страница
+	if c.QueryParams().Has("cursor") {
+		result, err := h.service.GetProductsAfter(c.QueryParam("cursor"), limit, c.QueryParam("currency"))
+		if err != nil {
+			return err
+		}
+		if notModified(c, service.ProductsETag(result.Products, result.HasMore)) {
+			return c.NoContent(http.StatusNotModified)
+		}
+
+		return c.JSON(http.StatusOK, ProductCursorResponse{Success: true, Data: *result})
+	}
+
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+
+	result, err := h.service.GetProducts(page, limit, c.QueryParam("currency"))
+	if err != nil {
+		return err
+	}
+	if notModified(c, service.ProductsETag(result.Products, result.Total)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(http.StatusOK, ProductPageResponse{Success: true, Data: *result})
+}
+
+func (h *Handler) GetProductDetail(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	detail, err := h.service.GetProductDetail(id, c.QueryParam("currency"), h.viewer(c))
+	if err != nil {
+		return err
+	}
+	// Рекомендации зависят от того, кто смотрит карточку
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAuthorization+", "+HeaderSessionID)
+	if notModified(c, service.ProductDetailETag(detail)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(http.StatusOK, ProductResponse{Success: true, Data: *detail})
+}
+
+// viewer определяет, в чью историю просмотров записать товар: пользователя
+// по необязательному токену или анонимную сессию из X-Session-ID.
+// Неверный токен на публичном маршруте не ошибка — посетитель считается анонимным.
+func (h *Handler) viewer(c echo.Context) string {
+	if token := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); token != "" {
+		if claims, err := h.service.ValidateJWT(token); err == nil && h.service.CheckSession(claims) == nil {
+			return service.UserViewer(claims.UserID)
+		}
+	}
+	if session := c.Request().Header.Get(HeaderSessionID); sessionIDPattern.MatchString(session) {
+		return service.SessionViewer(session)
+	}
+	return ""
+}
+
+func (h *Handler) UploadImage(c echo.Context) error {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return ErrUploadFailed.Wrap(err)
+	}
+
+	filename, err := h.service.UploadImage(getUserID(c), file)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UploadResponse{
+		Success:  true,
+		Filename: filename,
+		URL:      "/img/" + filename,
+	})
+}
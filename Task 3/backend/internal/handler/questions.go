@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetQuestions(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	questions, err := h.service.GetQuestions(productID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, QuestionListResponse{Success: true, Data: questions})
+}
+
+func (h *Handler) AskQuestion(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.QuestionRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	question, err := h.service.AskQuestion(getUserID(c), productID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, QuestionResponse{Success: true, Data: *question})
+}
+
+func (h *Handler) AnswerQuestion(c echo.Context) error {
+	questionID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.AnswerRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	answer, err := h.service.AnswerQuestion(getActor(c), questionID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, AnswerResponse{Success: true, Data: *answer})
+}
+
+// VoteAnswer ставит голос (PUT) или снимает его (DELETE)
+func (h *Handler) VoteAnswer(c echo.Context) error {
+	answerID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	up := c.Request().Method == http.MethodPut
+	answer, err := h.service.VoteAnswer(getUserID(c), answerID, up)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, AnswerResponse{Success: true, Data: *answer})
+}
+
+func (h *Handler) GetModerationQueue(c echo.Context) error {
+	queue, err := h.service.GetModerationQueue()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ModerationQueueResponse{Success: true, Data: *queue})
+}
+
+func (h *Handler) ModerateQuestion(c echo.Context) error {
+	return h.moderate(c, h.service.ModerateQuestion)
+}
+
+func (h *Handler) ModerateAnswer(c echo.Context) error {
+	return h.moderate(c, h.service.ModerateAnswer)
+}
+
+func (h *Handler) moderate(c echo.Context, apply func(id int, status string) error) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ModerationRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	if err := apply(id, req.Status); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.moderation_"+req.Status, nil)})
+}
@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/service"
+)
+
+// Типизированные тела ответов API. По ним же строится спецификация OpenAPI,
+// поэтому поле, добавленное в ответ, сразу попадает в документацию.
+
+type MessageResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+type AuthUser struct {
+	ID           int    `json:"id"`
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	Role         string `json:"role"`
+	PendingEmail string `json:"pending_email,omitempty" doc:"Новый email, ожидающий подтверждения"`
+}
+
+type AuthData struct {
+	Token string   `json:"token"`
+	User  AuthUser `json:"user"`
+}
+
+type AuthResponse struct {
+	Success bool     `json:"success"`
+	Data    AuthData `json:"data"`
+}
+
+type ProfileResponse struct {
+	Success bool        `json:"success"`
+	Data    models.User `json:"data"`
+}
+
+type ProductPageResponse struct {
+	Success bool                `json:"success"`
+	Data    service.ProductPage `json:"data"`
+}
+
+type ProductCursorResponse struct {
+	Success bool                      `json:"success"`
+	Data    service.ProductCursorPage `json:"data"`
+}
+
+type ProductResponse struct {
+	Success bool                  `json:"success"`
+	Data    service.ProductDetail `json:"data"`
+}
+
+type ProductListResponse struct {
+	Success bool             `json:"success"`
+	Data    []models.Product `json:"data"`
+}
+
+type PriceHistoryResponse struct {
+	Success bool                 `json:"success"`
+	Data    []models.PriceChange `json:"data"`
+}
+
+type PriceAlertListResponse struct {
+	Success bool                `json:"success"`
+	Data    []models.PriceAlert `json:"data"`
+}
+
+type CurrenciesResponse struct {
+	Success bool               `json:"success"`
+	Data    service.Currencies `json:"data"`
+}
+
+type ComparisonResponse struct {
+	Success bool               `json:"success"`
+	Data    service.Comparison `json:"data"`
+}
+
+type CartResponse struct {
+	Success bool         `json:"success"`
+	Data    service.Cart `json:"data"`
+}
+
+type AddressResponse struct {
+	Success bool           `json:"success"`
+	Data    models.Address `json:"data"`
+}
+
+type AddressListResponse struct {
+	Success bool             `json:"success"`
+	Data    []models.Address `json:"data"`
+}
+
+type DeliveryMethodResponse struct {
+	Success bool                  `json:"success"`
+	Data    models.DeliveryMethod `json:"data"`
+}
+
+type DeliveryMethodListResponse struct {
+	Success bool                    `json:"success"`
+	Data    []models.DeliveryMethod `json:"data"`
+}
+
+type NotificationListResponse struct {
+	Success bool                      `json:"success"`
+	Data    service.NotificationInbox `json:"data"`
+}
+
+type StreamTokenResponse struct {
+	Success bool                `json:"success"`
+	Data    service.StreamToken `json:"data"`
+}
+
+type TaxRateResponse struct {
+	Success bool           `json:"success"`
+	Data    models.TaxRate `json:"data"`
+}
+
+type TaxRateListResponse struct {
+	Success bool             `json:"success"`
+	Data    []models.TaxRate `json:"data"`
+}
+
+type OrderResponse struct {
+	Success bool         `json:"success"`
+	Data    models.Order `json:"data"`
+}
+
+type OrderListResponse struct {
+	Success bool           `json:"success"`
+	Data    []models.Order `json:"data"`
+}
+
+type ReturnResponse struct {
+	Success bool          `json:"success"`
+	Data    models.Return `json:"data"`
+}
+
+type ReturnListResponse struct {
+	Success bool            `json:"success"`
+	Data    []models.Return `json:"data"`
+}
+
+type SaleResponse struct {
+	Success bool        `json:"success"`
+	Data    models.Sale `json:"data"`
+}
+
+type SaleListResponse struct {
+	Success bool          `json:"success"`
+	Data    []models.Sale `json:"data"`
+}
+
+type QuestionResponse struct {
+	Success bool            `json:"success"`
+	Data    models.Question `json:"data"`
+}
+
+type QuestionListResponse struct {
+	Success bool              `json:"success"`
+	Data    []models.Question `json:"data"`
+}
+
+type AnswerResponse struct {
+	Success bool          `json:"success"`
+	Data    models.Answer `json:"data"`
+}
+
+type ModerationQueueResponse struct {
+	Success bool                    `json:"success"`
+	Data    service.ModerationQueue `json:"data"`
+}
+
+type UploadResponse struct {
+	Success  bool   `json:"success"`
+	Filename string `json:"filename"`
+	URL      string `json:"url" doc:"Путь к изображению от корня сервера"`
+}
+
+type CreatedProduct struct {
+	ID    int    `json:"id"`
+	Image string `json:"image"`
+}
+
+type CreateProductResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    CreatedProduct `json:"data"`
+}
+
+// ImportResponse — отчет импорта; success = false, если хотя бы одна строка с ошибкой
+type ImportResponse struct {
+	Success bool                `json:"success"`
+	Message string              `json:"message"`
+	Data    models.ImportReport `json:"data"`
+}
+
+type AnalyticsResponse struct {
+	Success bool                   `json:"success"`
+	Data    models.AnalyticsReport `json:"data"`
+}
+
+type UserPageResponse struct {
+	Success bool             `json:"success"`
+	Data    service.UserPage `json:"data"`
+}
+
+type UserDetailResponse struct {
+	Success bool                    `json:"success"`
+	Data    service.AdminUserDetail `json:"data"`
+}
+
+type BulkUsersResponse struct {
+	Success bool                    `json:"success" doc:"true, если изменены все пользователи"`
+	Message string                  `json:"message"`
+	Data    []models.BulkUserResult `json:"data"`
+}
+
+type RoleChangeUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	IsActive bool   `json:"is_active"`
+}
+
+type SelfRoleChange struct {
+	NewToken string         `json:"new_token" doc:"Токен с новой ролью, старый больше не подходит"`
+	User     RoleChangeUser `json:"user"`
+}
+
+// SelfRoleChangeResponse возвращается, когда администратор меняет роль самому себе
+type SelfRoleChangeResponse struct {
+	Success bool           `json:"success"`
+	Message string         `json:"message"`
+	Data    SelfRoleChange `json:"data"`
+}
+
+type RoleChange struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	NewRole  string `json:"new_role"`
+	RoleName string `json:"role_name" doc:"Название роли на языке запроса"`
+}
+
+type RoleChangeResponse struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message"`
+	Data    RoleChange `json:"data"`
+}
+
+// GraphQLResponse — ответ по спецификации GraphQL: data и errors вместо success
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+type GraphQLError struct {
+	Message    string            `json:"message"`
+	Path       []interface{}     `json:"path,omitempty" doc:"Поле, которое не удалось получить: имена полей и номера элементов списков"`
+	Locations  []GraphQLLocation `json:"locations,omitempty"`
+	Extensions GraphQLExtensions `json:"extensions"`
+}
+
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLExtensions — код ошибки, как в поле code ответов REST
+type GraphQLExtensions struct {
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) CreateReturn(c echo.Context) error {
+	orderID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ReturnRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	ret, err := h.service.CreateReturn(getUserID(c), orderID, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, ReturnResponse{Success: true, Data: *ret})
+}
+
+func (h *Handler) GetReturns(c echo.Context) error {
+	returns, err := h.service.GetReturns(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ReturnListResponse{Success: true, Data: returns})
+}
+
+func (h *Handler) GetSellerReturns(c echo.Context) error {
+	returns, err := h.service.GetSellerReturns(getActor(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ReturnListResponse{Success: true, Data: returns})
+}
+
+func (h *Handler) UpdateReturnStatus(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.ReturnStatusRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	ret, err := h.service.UpdateReturnStatus(getActor(c), id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ReturnResponse{Success: true, Data: *ret})
+}
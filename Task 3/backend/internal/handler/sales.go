@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSales возвращает продавцу его акции, администратору — все
+func (h *Handler) GetSales(c echo.Context) error {
+	sales, err := h.service.GetSales(getActor(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SaleListResponse{Success: true, Data: sales})
+}
+
+func (h *Handler) CreateSale(c echo.Context) error {
+	var req models.SaleRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	sale, err := h.service.CreateSale(getActor(c), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, SaleResponse{Success: true, Data: *sale})
+}
+
+func (h *Handler) CancelSale(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	sale, err := h.service.CancelSale(getActor(c), id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, SaleResponse{Success: true, Data: *sale})
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/metrics"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/service"
+
+	"github.com/labstack/echo/v4"
+)
+
+func (h *Handler) GetMyProducts(c echo.Context) error {
+	products, err := h.service.GetMyProducts(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, ProductListResponse{Success: true, Data: products})
+}
+
+func (h *Handler) CreateProduct(c echo.Context) error {
+	role := getRole(c)
+
+	var form models.ProductForm
+	if err := bind(c, &form); err != nil {
+		return err
+	}
+
+	// Файл необязателен: без него берется имя из поля "image"
+	file, _ := c.FormFile("image")
+
+	product, err := h.service.CreateProduct(getUserID(c), role, form, file)
+	if err != nil {
+		return err
+	}
+
+	key := "msg.product_created"
+	if !product.IsApproved {
+		key = "msg.product_created_pending"
+	}
+
+	return c.JSON(http.StatusCreated, CreateProductResponse{
+		Success: true,
+		Message: message(c, key, nil),
+		Data: CreatedProduct{
+			ID:    product.ID,
+			Image: product.Image,
+		},
+	})
+}
+
+func (h *Handler) UpdateProduct(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	role := getRole(c)
+
+	var form models.ProductForm
+	if err := bind(c, &form); err != nil {
+		return err
+	}
+	file, _ := c.FormFile("image")
+
+	if err := h.service.UpdateProduct(productID, getUserID(c), role, form, file); err != nil {
+		return err
+	}
+
+	key := "msg.product_updated"
+	if role != models.RoleAdmin {
+		key = "msg.product_updated_pending"
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, key, nil)})
+}
+
+func (h *Handler) DeleteProduct(c echo.Context) error {
+	productID, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	hidden, err := h.service.DeleteProduct(productID, getUserID(c), getRole(c))
+	if err != nil {
+		return err
+	}
+
+	key := "msg.product_deleted"
+	if hidden {
+		key = "msg.product_hidden"
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, key, nil)})
+}
+
+func (h *Handler) ImportProducts(c echo.Context) error {
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	format, data, err := readImportBody(c)
+	if err != nil {
+		return err
+	}
+	metrics.UploadSize.With("import").Observe(float64(len(data)))
+
+	rows, lineErrors, err := service.ParseImport(format, data)
+	if err != nil {
+		return err
+	}
+
+	role := getRole(c)
+	report, err := h.service.ImportProducts(getUserID(c), role, rows, lineErrors, dryRun)
+	if err != nil {
+		return err
+	}
+
+	status := http.StatusOK
+	if report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	key := "msg.import_done"
+	switch {
+	case report.Failed > 0:
+		key = "msg.import_failed"
+	case dryRun:
+		key = "msg.import_dry_run"
+	case role != models.RoleAdmin:
+		key = "msg.import_done_pending"
+	}
+
+	lang := language(c)
+	for i := range report.Rows {
+		report.Rows[i].Errors = localizeFields(lang, report.Rows[i].Errors)
+	}
+
+	return c.JSON(status, ImportResponse{
+		Success: report.Failed == 0,
+		Message: message(c, key, nil),
+		Data:    *report,
+	})
+}
+
+// readImportBody читает файл импорта из формы или тела запроса и определяет формат
+func readImportBody(c echo.Context) (string, []byte, error) {
+	format := c.QueryParam("format")
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+
+	if strings.HasPrefix(contentType, echo.MIMEMultipartForm) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return "", nil, ErrUploadFailed.Wrap(err)
+		}
+		if file.Size > service.ImportMaxBytes {
+			return "", nil, errImportTooLarge
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return "", nil, apperr.Internal(err)
+		}
+		defer src.Close()
+
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return "", nil, apperr.Internal(err)
+		}
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+		}
+		return format, data, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, service.ImportMaxBytes+1))
+	if err != nil {
+		return "", nil, ErrInvalidBody.Wrap(err)
+	}
+	if len(data) > service.ImportMaxBytes {
+		return "", nil, errImportTooLarge
+	}
+	if format == "" && strings.HasPrefix(contentType, echo.MIMEApplicationJSON) {
+		format = "json"
+	}
+	if format == "" && strings.HasPrefix(contentType, "text/csv") {
+		format = "csv"
+	}
+	return format, data, nil
+}
+
+var errImportTooLarge = service.ErrFileTooLarge.With("max_mb", service.ImportMaxBytes>>20)
+
+func (h *Handler) ExportProducts(c echo.Context) error {
+	products, err := h.service.ExportProducts(getUserID(c))
+	if err != nil {
+		return err
+	}
+
+	switch c.QueryParam("format") {
+	case "", "csv":
+		var buf bytes.Buffer
+		if err := service.WriteProductsCSV(&buf, products); err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="products.csv"`)
+		return c.Blob(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+
+	case "json":
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="products.json"`)
+		return c.JSON(http.StatusOK, products)
+
+	default:
+		return ErrExportFormat
+	}
+}
+
+func (h *Handler) GetSellerAnalytics(c echo.Context) error {
+	return h.respondAnalytics(c, getUserID(c))
+}
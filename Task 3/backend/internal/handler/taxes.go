@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+
+	"catpc-backend/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTaxRates возвращает администратору все ставки налогов
+func (h *Handler) GetTaxRates(c echo.Context) error {
+	rates, err := h.service.GetTaxRates()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, TaxRateListResponse{Success: true, Data: rates})
+}
+
+func (h *Handler) CreateTaxRate(c echo.Context) error {
+	var req models.TaxRateRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	rate, err := h.service.CreateTaxRate(req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, TaxRateResponse{Success: true, Data: *rate})
+}
+
+func (h *Handler) UpdateTaxRate(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.TaxRateRequest
+	if err := bind(c, &req); err != nil {
+		return err
+	}
+
+	rate, err := h.service.UpdateTaxRate(id, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, TaxRateResponse{Success: true, Data: *rate})
+}
+
+func (h *Handler) DeleteTaxRate(c echo.Context) error {
+	id, err := pathID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.DeleteTaxRate(id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, MessageResponse{Success: true, Message: message(c, "msg.tax_rate_deleted", nil)})
+}
@@ -0,0 +1,84 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Lang string
+
+const (
+	RU Lang = "ru"
+	EN Lang = "en"
+
+	// Default — язык, если клиент не прислал поддерживаемый Accept-Language
+	Default = RU
+)
+
+// FromAcceptLanguage выбирает поддерживаемый язык с наибольшим весом q
+func FromAcceptLanguage(header string) Lang {
+	type candidate struct {
+		lang Lang
+		q    float64
+		pos  int
+	}
+
+	var candidates []candidate
+	for pos, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		lang := Lang(base)
+		if _, ok := catalogs[lang]; ok && q > 0 {
+			candidates = append(candidates, candidate{lang, q, pos})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Default
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+	return candidates[0].lang
+}
+
+// T возвращает сообщение по ключу с подстановкой параметров вида {name}.
+// Если перевода нет, используется язык по умолчанию, а затем сам ключ.
+func T(lang Lang, key string, params map[string]interface{}) string {
+	message, ok := catalogs[lang][key]
+	if !ok {
+		message, ok = catalogs[Default][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(params) == 0 {
+		return message
+	}
+
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+// Has сообщает, есть ли ключ в каталоге языка по умолчанию
+func Has(key string) bool {
+	_, ok := catalogs[Default][key]
+	return ok
+}
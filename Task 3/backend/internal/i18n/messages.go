@@ -0,0 +1,367 @@
+package i18n
+
+// catalogs содержит тексты по ключам: коды ошибок API, правила проверки
+// полей (field.*), сообщения об успехе (msg.*) и названия ролей (role.*)
+var catalogs = map[Lang]map[string]string{
+	RU: {
+		"internal_error":         "Внутренняя ошибка сервера",
+		"bad_request":            "Неверный запрос",
+		"invalid_body":           "Неверные данные",
+		"invalid_id":             "Неверный ID",
+		"validation_failed":      "Проверьте введенные данные",
+		"unauthorized":           "Требуется авторизация",
+		"invalid_token":          "Неверный токен",
+		"forbidden":              "Недостаточно прав",
+		"route_not_found":        "Маршрут не найден",
+		"method_not_allowed":     "Метод не поддерживается",
+		"request_too_large":      "Слишком большой запрос",
+		"unsupported_media_type": "Неподдерживаемый тип содержимого",
+
+		"required_fields":            "Все поля обязательны",
+		"user_exists":                "Пользователь уже существует",
+		"invalid_credentials":        "Неверный логин или пароль",
+		"account_blocked":            "Аккаунт заблокирован",
+		"user_not_found":             "Пользователь не найден",
+		"invalid_role":               "Неверная роль",
+		"protected_user_role":        "Нельзя изменить роль защищенного пользователя",
+		"admin_assignment_forbidden": "Только главный администратор может назначать администраторов",
+		"role_unchanged":             "Роль уже установлена",
+		"protected_user_block":       "Нельзя заблокировать защищенного пользователя",
+		"block_self":                 "Нельзя заблокировать себя",
+		"session_revoked":            "Сессия завершена, войдите снова",
+		"bulk_self":                  "Себя нельзя менять массовым действием",
+		"wrong_password":             "Неверный текущий пароль",
+		"email_token_invalid":        "Неверный или устаревший код подтверждения",
+		"email_token_expired":        "Срок действия кода истек: сохраните новый email еще раз, чтобы получить новый код",
+		"protected_user_rename":      "Нельзя переименовать защищенного пользователя",
+		"protected_user_delete":      "Защищенный аккаунт нельзя удалить",
+
+		"product_not_found":        "Товар не найден",
+		"invalid_cursor":           "Неверный курсор страницы",
+		"price_alert_target":       "Целевая цена должна быть ниже текущей ({price})",
+		"price_alert_not_found":    "Подписка на цену не найдена",
+		"product_unavailable":      "Товар не доступен для покупки",
+		"insufficient_stock":       "Недостаточно товара в наличии",
+		"invalid_quantity":         "Количество должно быть больше 0",
+		"invalid_price":            "Неверная цена",
+		"invalid_stock":            "Неверное количество",
+		"invalid_weight":           "Неверный вес",
+		"invalid_attributes":       "Характеристики — JSON-объект «название: значение», не больше {max} строк",
+		"unknown_currency":         "Валюта {currency} не поддерживается",
+		"product_edit_forbidden":   "Нет прав на редактирование",
+		"product_delete_forbidden": "Нет прав на удаление",
+
+		"address_not_found":         "Адрес не найден",
+		"delivery_method_not_found": "Способ доставки не найден",
+		"tax_rate_not_found":        "Ставка налога не найдена",
+		"tax_rate_exists":           "Ставка для этой категории и региона уже есть",
+		"address_required":          "Укажите адрес доставки",
+		"cart_empty":                "Корзина пуста",
+		"cart_changed":              "Наличие или цены товаров изменились, проверьте корзину",
+		"order_not_found":           "Заказ не найден",
+		"order_transition":          "Заказ нельзя перевести из статуса {from} в {to}",
+		"status_changed":            "Статус уже изменился, обновите страницу",
+		"invoice_unavailable":       "Счет к заказу в статусе {status} не выставляется",
+
+		"order_item_not_found": "Позиция заказа не найдена",
+		"return_not_found":     "Заявка на возврат не найдена",
+		"return_not_delivered": "Возврат можно оформить только по доставленному заказу",
+		"return_quantity":      "Количество к возврату больше купленного",
+		"return_transition":    "Заявку нельзя перевести из статуса {from} в {to}",
+		"return_forbidden":     "Нет прав на рассмотрение заявки",
+
+		"question_not_found": "Вопрос не найден",
+		"answer_not_found":   "Ответ не найден",
+		"answer_forbidden":   "Отвечать могут продавец товара и покупатели, которые его заказывали",
+		"vote_own_answer":    "Нельзя голосовать за свой ответ",
+
+		"notification_not_found": "Уведомление не найдено",
+		"seller_not_found":       "Продавец не найден",
+
+		"invalid_query":     "Неверный запрос GraphQL: {reason}",
+		"query_too_deep":    "Запрос слишком глубокий (макс. {max} уровней)",
+		"query_too_complex": "Запрос слишком сложный: {complexity} при пределе {max}",
+
+		"compare_owner_required": "Войдите или передайте заголовок X-Session-ID",
+		"compare_full":           "В сравнении может быть не больше {max} товаров",
+		"compare_category":       "В сравнении товары категории «{category}»; сначала очистите список",
+		"compare_item_not_found": "Товара нет в списке сравнения",
+
+		"sale_not_found":         "Акция не найдена",
+		"sale_forbidden":         "Можно управлять только своими акциями",
+		"sale_window":            "Окончание акции должно быть позже начала и еще не наступить",
+		"sale_percent":           "Скидка не может быть больше {max}%",
+		"sale_target_required":   "Укажите категорию или товары акции",
+		"sale_too_many_products": "В акции может быть не больше {max} товаров",
+		"sale_product_invalid":   "Товар {id} не найден или не принадлежит вам",
+		"sale_finished":          "Акция уже завершена или отменена ({status})",
+
+		"upload_failed":    "Ошибка загрузки файла",
+		"file_too_large":   "Файл слишком большой (макс. {max_mb}MB)",
+		"not_an_image":     "Допустимы только изображения JPEG, PNG и WebP",
+		"image_corrupt":    "Не удалось прочитать изображение",
+		"image_dimensions": "Изображение больше {max}×{max} пикселей",
+
+		"import_invalid_json":       "Неверный JSON: ожидается массив товаров",
+		"import_unsupported_format": "Неподдерживаемый формат, используйте CSV или JSON",
+		"import_csv_no_header":      "Неверный CSV: нет строки заголовка",
+		"import_csv_missing_column": "В CSV нет колонки \"{column}\"",
+		"import_csv_malformed":      "Неверный CSV: ошибка в строке {line}",
+		"import_empty":              "Файл не содержит товаров",
+		"import_too_many_rows":      "Слишком много строк (макс. {max})",
+		"export_unsupported_format": "Неподдерживаемый формат, используйте csv или json",
+		"analytics_invalid_to":      "Неверная дата окончания, ожидается ГГГГ-ММ-ДД",
+		"analytics_invalid_from":    "Неверная дата начала, ожидается ГГГГ-ММ-ДД",
+		"analytics_range_reversed":  "Дата начала позже даты окончания",
+		"analytics_range_too_long":  "Период не может превышать {max} дней",
+
+		"field.required":        "Обязательное поле",
+		"field.min":             "Не короче {param} символов",
+		"field.max":             "Не длиннее {param} символов",
+		"field.gt":              "Должно быть больше {param}",
+		"field.gte":             "Должно быть не меньше {param}",
+		"field.lt":              "Должно быть меньше {param}",
+		"field.lte":             "Должно быть не больше {param}",
+		"field.number":          "Должно быть числом",
+		"field.integer":         "Должно быть целым числом",
+		"field.email":           "Неверный адрес электронной почты",
+		"field.oneof":           "Допустимые значения: {param}",
+		"field.sku_format":      "До 64 символов из латиницы, цифр и . _ -",
+		"field.file_name":       "Неверное имя файла",
+		"field.image_owner":     "Изображение не найдено среди ваших загрузок",
+		"field.duplicate":       "Значение повторяется (строка {row})",
+		"field.save_failed":     "Ошибка сохранения строки",
+		"field.rules_start":     "Первая ступень тарифа должна начинаться с 0",
+		"field.rules_duplicate": "Порог {value} указан дважды",
+		"field.max_items":       "Не больше {param} элементов",
+		"field.boolean":         "Ожидается true или false",
+
+		"msg.cart_added":              "Товар добавлен в корзину",
+		"msg.cart_updated":            "Корзина обновлена",
+		"msg.cart_removed":            "Товар удален из корзины",
+		"msg.compare_cleared":         "Список сравнения очищен",
+		"msg.product_created":         "Товар создан",
+		"msg.product_created_pending": "Товар создан (ожидает одобрения администратора)",
+		"msg.product_updated":         "Товар обновлен",
+		"msg.product_updated_pending": "Товар обновлен (ожидает повторного одобрения)",
+		"msg.product_deleted":         "Товар удален",
+		"msg.product_hidden":          "Товар скрыт (был в корзинах пользователей)",
+		"msg.product_approved":        "Товар одобрен",
+		"msg.product_force_deleted":   "Товар принудительно удален",
+		"msg.import_done":             "Импорт выполнен",
+		"msg.import_done_pending":     "Импорт выполнен (товары ожидают одобрения администратора)",
+		"msg.import_failed":           "Импорт отменен: исправьте ошибки в строках",
+		"msg.import_dry_run":          "Проверка пройдена, изменения не сохранены",
+		"msg.role_updated_self":       "Ваша роль обновлена. Используйте новый токен.",
+		"msg.role_updated":            "Роль пользователя {username} обновлена на {role}",
+		"msg.user_blocked":            "Пользователь заблокирован",
+		"msg.user_unblocked":          "Пользователь разблокирован",
+		"msg.price_alert_set":         "Сообщим, когда цена опустится до {price}",
+		"msg.price_alert_deleted":     "Подписка на цену отменена",
+		"msg.address_deleted":         "Адрес удален",
+		"msg.delivery_method_deleted": "Способ доставки удален",
+		"msg.tax_rate_deleted":        "Ставка налога удалена",
+		"msg.moderation_published":    "Опубликовано",
+		"msg.moderation_hidden":       "Скрыто",
+		"msg.account_deleted":         "Аккаунт удален",
+		"msg.bulk_users":              "Изменено пользователей: {updated} из {total}",
+		"msg.notification_read":       "Уведомление прочитано",
+		"msg.notifications_read":      "Прочитано уведомлений: {count}",
+
+		"notification.product_approved":       "Товар «{product}» одобрен и опубликован в каталоге",
+		"notification.product_rejected":       "Товар «{product}» отклонен модератором",
+		"notification.product_pending":        "Товар «{product}» ожидает модерации",
+		"notification.products_imported":      "Товаров из импорта ожидает модерации: {count}",
+		"notification.back_in_stock":          "Товар «{product}» снова в наличии",
+		"notification.order_status.paid":      "Заказ №{order} оплачен",
+		"notification.order_status.shipped":   "Заказ №{order} отправлен",
+		"notification.order_status.delivered": "Заказ №{order} доставлен",
+		"notification.order_status.cancelled": "Заказ №{order} отменен",
+		"notification.order_status.refunded":  "Деньги за заказ №{order} возвращены",
+
+		"role.admin":    "Администратор",
+		"role.seller":   "Продавец",
+		"role.customer": "Покупатель",
+	},
+
+	EN: {
+		"internal_error":         "Internal server error",
+		"bad_request":            "Bad request",
+		"invalid_body":           "Invalid request data",
+		"invalid_id":             "Invalid ID",
+		"validation_failed":      "Please check the submitted data",
+		"unauthorized":           "Authorization required",
+		"invalid_token":          "Invalid token",
+		"forbidden":              "Insufficient permissions",
+		"route_not_found":        "Route not found",
+		"method_not_allowed":     "Method not allowed",
+		"request_too_large":      "Request is too large",
+		"unsupported_media_type": "Unsupported content type",
+
+		"required_fields":            "All fields are required",
+		"user_exists":                "User already exists",
+		"invalid_credentials":        "Invalid username or password",
+		"account_blocked":            "Account is blocked",
+		"user_not_found":             "User not found",
+		"invalid_role":               "Invalid role",
+		"protected_user_role":        "Cannot change the role of a protected user",
+		"admin_assignment_forbidden": "Only the main administrator can assign administrators",
+		"role_unchanged":             "Role is already set",
+		"protected_user_block":       "Cannot block a protected user",
+		"block_self":                 "You cannot block yourself",
+		"session_revoked":            "Session has ended, please sign in again",
+		"bulk_self":                  "You cannot change yourself with a bulk action",
+		"wrong_password":             "Current password is incorrect",
+		"email_token_invalid":        "Confirmation code is invalid or outdated",
+		"email_token_expired":        "The code has expired: save the new email again to get a new one",
+		"protected_user_rename":      "A protected user cannot be renamed",
+		"protected_user_delete":      "A protected account cannot be deleted",
+
+		"product_not_found":        "Product not found",
+		"invalid_cursor":           "Invalid page cursor",
+		"price_alert_target":       "Target price must be below the current price ({price})",
+		"price_alert_not_found":    "Price alert not found",
+		"product_unavailable":      "Product is not available for purchase",
+		"insufficient_stock":       "Not enough items in stock",
+		"invalid_quantity":         "Quantity must be greater than 0",
+		"invalid_price":            "Invalid price",
+		"invalid_stock":            "Invalid stock quantity",
+		"invalid_weight":           "Invalid weight",
+		"invalid_attributes":       "Attributes must be a JSON object of name: value pairs, at most {max}",
+		"unknown_currency":         "Currency {currency} is not supported",
+		"product_edit_forbidden":   "You are not allowed to edit this product",
+		"product_delete_forbidden": "You are not allowed to delete this product",
+
+		"address_not_found":         "Address not found",
+		"delivery_method_not_found": "Delivery method not found",
+		"tax_rate_not_found":        "Tax rate not found",
+		"tax_rate_exists":           "A tax rate for this category and region already exists",
+		"address_required":          "Please specify a delivery address",
+		"cart_empty":                "Your cart is empty",
+		"cart_changed":              "Stock or prices have changed, please review your cart",
+		"order_not_found":           "Order not found",
+		"order_transition":          "The order cannot move from {from} to {to}",
+		"status_changed":            "The status has already changed, please reload",
+		"invoice_unavailable":       "No invoice is issued for an order in status {status}",
+
+		"order_item_not_found": "Order line not found",
+		"return_not_found":     "Return request not found",
+		"return_not_delivered": "Returns are only possible for delivered orders",
+		"return_quantity":      "Return quantity exceeds the purchased quantity",
+		"return_transition":    "The return request cannot move from {from} to {to}",
+		"return_forbidden":     "You are not allowed to process this return request",
+
+		"question_not_found": "Question not found",
+		"answer_not_found":   "Answer not found",
+		"answer_forbidden":   "Only the seller and customers who ordered this product can answer",
+		"vote_own_answer":    "You cannot vote for your own answer",
+
+		"notification_not_found": "Notification not found",
+		"seller_not_found":       "Seller not found",
+
+		"invalid_query":     "Invalid GraphQL query: {reason}",
+		"query_too_deep":    "The query is too deep (max {max} levels)",
+		"query_too_complex": "The query is too complex: {complexity} with a limit of {max}",
+
+		"compare_owner_required": "Sign in or send the X-Session-ID header",
+		"compare_full":           "A comparison can hold at most {max} products",
+		"compare_category":       "The comparison holds {category} products; clear it first",
+		"compare_item_not_found": "The product is not in the comparison",
+
+		"sale_not_found":         "Sale not found",
+		"sale_forbidden":         "You can only manage your own sales",
+		"sale_window":            "The sale must end after it starts and in the future",
+		"sale_percent":           "The discount cannot exceed {max}%",
+		"sale_target_required":   "Specify a category or products for the sale",
+		"sale_too_many_products": "A sale can include at most {max} products",
+		"sale_product_invalid":   "Product {id} not found or not yours",
+		"sale_finished":          "The sale has already ended or been cancelled ({status})",
+
+		"upload_failed":    "File upload failed",
+		"file_too_large":   "File is too large (max {max_mb}MB)",
+		"not_an_image":     "Only JPEG, PNG and WebP images are allowed",
+		"image_corrupt":    "The image could not be read",
+		"image_dimensions": "The image is larger than {max}×{max} pixels",
+
+		"import_invalid_json":       "Invalid JSON: an array of products is expected",
+		"import_unsupported_format": "Unsupported format, use CSV or JSON",
+		"import_csv_no_header":      "Invalid CSV: header row is missing",
+		"import_csv_missing_column": "CSV has no \"{column}\" column",
+		"import_csv_malformed":      "Invalid CSV: error on line {line}",
+		"import_empty":              "The file contains no products",
+		"import_too_many_rows":      "Too many rows (max {max})",
+		"export_unsupported_format": "Unsupported format, use csv or json",
+		"analytics_invalid_to":      "Invalid end date, expected YYYY-MM-DD",
+		"analytics_invalid_from":    "Invalid start date, expected YYYY-MM-DD",
+		"analytics_range_reversed":  "Start date is after end date",
+		"analytics_range_too_long":  "The period cannot exceed {max} days",
+
+		"field.required":        "Required field",
+		"field.min":             "Must be at least {param} characters",
+		"field.max":             "Must be at most {param} characters",
+		"field.gt":              "Must be greater than {param}",
+		"field.gte":             "Must be at least {param}",
+		"field.lt":              "Must be less than {param}",
+		"field.lte":             "Must be at most {param}",
+		"field.number":          "Must be a number",
+		"field.integer":         "Must be an integer",
+		"field.email":           "Invalid email address",
+		"field.oneof":           "Allowed values: {param}",
+		"field.sku_format":      "Up to 64 Latin letters, digits and . _ -",
+		"field.file_name":       "Invalid file name",
+		"field.image_owner":     "Image not found among your uploads",
+		"field.duplicate":       "Duplicate value (row {row})",
+		"field.save_failed":     "Failed to save the row",
+		"field.rules_start":     "The first tariff step must start at 0",
+		"field.rules_duplicate": "Threshold {value} is listed twice",
+		"field.max_items":       "At most {param} items",
+		"field.boolean":         "Expected true or false",
+
+		"msg.cart_added":              "Product added to cart",
+		"msg.cart_updated":            "Cart updated",
+		"msg.cart_removed":            "Product removed from cart",
+		"msg.compare_cleared":         "Comparison list cleared",
+		"msg.product_created":         "Product created",
+		"msg.product_created_pending": "Product created (awaiting administrator approval)",
+		"msg.product_updated":         "Product updated",
+		"msg.product_updated_pending": "Product updated (awaiting re-approval)",
+		"msg.product_deleted":         "Product deleted",
+		"msg.product_hidden":          "Product hidden (it was in users' carts)",
+		"msg.product_approved":        "Product approved",
+		"msg.product_force_deleted":   "Product force-deleted",
+		"msg.import_done":             "Import completed",
+		"msg.import_done_pending":     "Import completed (products await administrator approval)",
+		"msg.import_failed":           "Import cancelled: fix the errors in the rows",
+		"msg.import_dry_run":          "Check passed, no changes were saved",
+		"msg.role_updated_self":       "Your role has been updated. Use the new token.",
+		"msg.role_updated":            "Role of user {username} changed to {role}",
+		"msg.user_blocked":            "User blocked",
+		"msg.user_unblocked":          "User unblocked",
+		"msg.price_alert_set":         "We will let you know when the price drops to {price}",
+		"msg.price_alert_deleted":     "Price alert removed",
+		"msg.address_deleted":         "Address deleted",
+		"msg.delivery_method_deleted": "Delivery method deleted",
+		"msg.tax_rate_deleted":        "Tax rate deleted",
+		"msg.moderation_published":    "Published",
+		"msg.moderation_hidden":       "Hidden",
+		"msg.account_deleted":         "Account deleted",
+		"msg.bulk_users":              "Users updated: {updated} of {total}",
+		"msg.notification_read":       "Notification marked as read",
+		"msg.notifications_read":      "Notifications marked as read: {count}",
+
+		"notification.product_approved":       "Product “{product}” has been approved and published",
+		"notification.product_rejected":       "Product “{product}” has been rejected by a moderator",
+		"notification.product_pending":        "Product “{product}” is awaiting moderation",
+		"notification.products_imported":      "Imported products awaiting moderation: {count}",
+		"notification.back_in_stock":          "Product “{product}” is back in stock",
+		"notification.order_status.paid":      "Order #{order} has been paid",
+		"notification.order_status.shipped":   "Order #{order} has been shipped",
+		"notification.order_status.delivered": "Order #{order} has been delivered",
+		"notification.order_status.cancelled": "Order #{order} has been cancelled",
+		"notification.order_status.refunded":  "Order #{order} has been refunded",
+
+		"role.admin":    "Administrator",
+		"role.seller":   "Seller",
+		"role.customer": "Customer",
+	},
+}
@@ -0,0 +1,222 @@
+// Package invoice строит PDF-счета к заказам. Шрифт DejaVu встроен в бинарник,
+// поэтому кириллица выводится без установленных в системе шрифтов.
+package invoice
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"catpc-backend/internal/money"
+
+	"github.com/go-pdf/fpdf"
+)
+
+var (
+	//go:embed fonts/DejaVuSans.ttf
+	regularFont []byte
+	//go:embed fonts/DejaVuSans-Bold.ttf
+	boldFont []byte
+)
+
+// Party — сторона счета: название и строки реквизитов
+type Party struct {
+	Name    string
+	Details []string
+}
+
+// Line — позиция счета
+type Line struct {
+	Name     string
+	Seller   string
+	Quantity int
+	Price    money.Amount
+	Amount   money.Amount
+}
+
+// Tax — строка налогов. Налог сверх цен выводится до итога, включенный в цены —
+// после итога, например «В том числе НДС 20%».
+type Tax struct {
+	Name     string
+	Amount   money.Amount
+	Included bool
+}
+
+// Document — все данные счета; суммы уже в валюте Currency
+type Document struct {
+	Number    string
+	IssuedAt  time.Time
+	OrderID   int
+	OrderDate time.Time
+	Issuer    Party
+	Buyer     Party
+	Lines     []Line
+	Subtotal  money.Amount
+	// Shipping — название способа доставки; пустое, если доставки в заказе нет
+	Shipping     string
+	ShippingCost money.Amount
+	Total        money.Amount
+	Taxes        []Tax
+	Currency     string
+}
+
+const (
+	fontFamily = "DejaVu"
+	lineHeight = 6.0
+)
+
+// колонки таблицы позиций: №, товар, продавец, количество, цена, сумма
+var columns = []struct {
+	title string
+	width float64
+	align string
+}{
+	{"№", 10, "C"},
+	{"Товар", 66, "L"},
+	{"Продавец", 34, "L"},
+	{"Кол-во", 18, "R"},
+	{"Цена", 26, "R"},
+	{"Сумма", 26, "R"},
+}
+
+// Render строит PDF. Даты документа берутся из IssuedAt, поэтому один и тот же
+// счет всегда дает одинаковые байты.
+func Render(doc Document) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", regularFont)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", boldFont)
+	pdf.SetCreationDate(doc.IssuedAt)
+	pdf.SetModificationDate(doc.IssuedAt)
+	pdf.SetTitle("Счет "+doc.Number, true)
+	pdf.SetCreator("CatPC", true)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(0, 10, fmt.Sprintf("Счет № %s от %s", doc.Number, formatDate(doc.IssuedAt)), "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 10)
+	pdf.CellFormat(0, lineHeight, fmt.Sprintf("К заказу № %d от %s", doc.OrderID, formatDate(doc.OrderDate)), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	party(pdf, "Продавец", doc.Issuer)
+	party(pdf, "Покупатель", doc.Buyer)
+	pdf.Ln(2)
+
+	pdf.SetFont(fontFamily, "B", 9)
+	pdf.SetFillColor(235, 235, 235)
+	for _, col := range columns {
+		pdf.CellFormat(col.width, 7, col.title, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont(fontFamily, "", 9)
+	for i, line := range doc.Lines {
+		row(pdf, []string{
+			fmt.Sprint(i + 1),
+			line.Name,
+			line.Seller,
+			fmt.Sprint(line.Quantity),
+			formatAmount(line.Price),
+			formatAmount(line.Amount),
+		})
+	}
+	pdf.Ln(2)
+
+	total(pdf, "Товары", doc.Subtotal, false)
+	if doc.Shipping != "" {
+		total(pdf, "Доставка: "+doc.Shipping, doc.ShippingCost, false)
+	}
+	for _, tax := range doc.Taxes {
+		if !tax.Included {
+			total(pdf, tax.Name, tax.Amount, false)
+		}
+	}
+	total(pdf, "Итого, "+doc.Currency, doc.Total, true)
+	for _, tax := range doc.Taxes {
+		if tax.Included {
+			total(pdf, tax.Name, tax.Amount, false)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func party(pdf *fpdf.Fpdf, title string, p Party) {
+	pdf.SetFont(fontFamily, "B", 10)
+	pdf.CellFormat(30, lineHeight, title+":", "", 0, "L", false, 0, "")
+	pdf.CellFormat(0, lineHeight, p.Name, "", 1, "L", false, 0, "")
+	pdf.SetFont(fontFamily, "", 9)
+	for _, detail := range p.Details {
+		pdf.CellFormat(30, 5, "", "", 0, "L", false, 0, "")
+		pdf.MultiCell(0, 5, detail, "", "L", false)
+	}
+	pdf.Ln(1)
+}
+
+// row выводит строку таблицы; длинные названия переносятся, высота строки
+// подбирается по самой высокой ячейке
+func row(pdf *fpdf.Fpdf, cells []string) {
+	lines := 1
+	for i, text := range cells {
+		if n := len(pdf.SplitText(text, columns[i].width-2)); n > lines {
+			lines = n
+		}
+	}
+	height := float64(lines) * 5
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+	}
+
+	x, y := pdf.GetXY()
+	for i, text := range cells {
+		pdf.Rect(x, y, columns[i].width, height, "D")
+		pdf.SetXY(x, y)
+		pdf.MultiCell(columns[i].width, 5, text, "", columns[i].align, false)
+		x += columns[i].width
+	}
+	pdf.SetXY(pdf.GetX(), y+height)
+}
+
+func total(pdf *fpdf.Fpdf, label string, amount money.Amount, bold bool) {
+	style := ""
+	if bold {
+		style = "B"
+	}
+	pdf.SetFont(fontFamily, style, 10)
+	pdf.CellFormat(154, lineHeight, label+":", "", 0, "R", false, 0, "")
+	pdf.CellFormat(26, lineHeight, formatAmount(amount), "", 1, "R", false, 0, "")
+}
+
+func formatDate(t time.Time) string {
+	return t.Format("02.01.2006")
+}
+
+// formatAmount записывает сумму по-русски: пробелы между разрядами и запятая
+// перед копейками, например 12 345,60
+func formatAmount(a money.Amount) string {
+	s := a.StringFixed(2)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	units, cents, _ := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, digit := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			b.WriteRune(' ')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + "," + cents
+}
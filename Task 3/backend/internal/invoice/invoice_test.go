@@ -0,0 +1,77 @@
+package invoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"catpc-backend/internal/money"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0", "0,00"},
+		{"999.5", "999,50"},
+		{"1000", "1 000,00"},
+		{"1234567.89", "1 234 567,89"},
+		{"-12345", "-12 345,00"},
+	}
+	for _, tt := range tests {
+		if got := formatAmount(money.MustParse(tt.in)); got != tt.want {
+			t.Errorf("formatAmount(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	issued := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	doc := Document{
+		Number:    "CP-000042",
+		IssuedAt:  issued,
+		OrderID:   42,
+		OrderDate: issued.Add(-time.Hour),
+		Issuer:    Party{Name: "ООО «КэтПиСи»", Details: []string{"ИНН 7700000000", "Москва, Тверская, 1"}},
+		Buyer:     Party{Name: "Иван", Details: []string{"ivan@example.com"}},
+		Subtotal:  money.FromInt(2000),
+		Total:     money.FromInt(2300),
+		Shipping:  "Курьер",
+		Taxes: []Tax{
+			{Name: "В том числе НДС 20%", Amount: money.MustParse("383.33"), Included: true},
+			{Name: "Налог с продаж 5%", Amount: money.FromInt(100)},
+		},
+		Currency: "RUB",
+	}
+	// Позиций больше, чем помещается на страницу, и с длинным названием
+	for i := 0; i < 60; i++ {
+		doc.Lines = append(doc.Lines, Line{
+			Name:     strings.Repeat("Видеокарта ", 1+i%4),
+			Seller:   "seller",
+			Quantity: 1,
+			Price:    money.FromInt(1000),
+			Amount:   money.FromInt(1000),
+		})
+	}
+
+	first, err := Render(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(first, []byte("%PDF-")) {
+		t.Fatalf("not a PDF: %q", first[:16])
+	}
+	if bytes.Count(first, []byte("/Type /Page\n")) < 2 {
+		t.Error("long invoice fits on one page")
+	}
+
+	second, err := Render(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("same document rendered differently")
+	}
+}
@@ -0,0 +1,37 @@
+package metrics
+
+// Default — реестр метрик приложения, который отдает /metrics
+var Default = NewRegistry()
+
+// SizeBuckets — границы гистограмм размеров в байтах: от 1 КБ до 16 МБ
+var SizeBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+var (
+	// HTTPRequestDuration — время обработки запроса; route — шаблон маршрута echo, а не сам путь
+	HTTPRequestDuration = Default.NewHistogramVec("catpc_http_request_duration_seconds",
+		"Время обработки HTTP-запроса", DefaultBuckets, "method", "route", "status")
+
+	// DBQueryDuration — время выполнения запроса к БД по типу операции (select, insert, ...)
+	DBQueryDuration = Default.NewHistogramVec("catpc_db_query_duration_seconds",
+		"Время выполнения запроса к базе данных", DefaultBuckets, "operation")
+
+	CartOperations = Default.NewCounterVec("catpc_cart_operations_total",
+		"Операции с корзиной", "action", "result")
+
+	Checkouts = Default.NewCounterVec("catpc_checkouts_total",
+		"Оформления заказов", "result")
+
+	Notifications = Default.NewCounterVec("catpc_notifications_total",
+		"Отправленные уведомления", "type")
+
+	UploadSize = Default.NewHistogramVec("catpc_upload_size_bytes",
+		"Размер загруженных файлов", SizeBuckets, "kind")
+)
+
+// Result — значение метки result по ошибке операции
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,276 @@
+// Package metrics — минимальный реестр метрик в текстовом формате Prometheus:
+// счетчики и гистограммы с метками, без внешних зависимостей.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets — границы гистограмм длительности в секундах
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry хранит метрики и выводит их в порядке регистрации
+type Registry struct {
+	mu      sync.Mutex
+	metrics []collector
+	names   map[string]bool
+}
+
+type collector interface {
+	write(w io.Writer) error
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic("metrics: метрика " + name + " уже зарегистрирована")
+	}
+	r.names[name] = true
+	r.metrics = append(r.metrics, c)
+}
+
+// Write выводит все метрики в текстовом формате Prometheus
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]collector(nil), r.metrics...)
+	r.mu.Unlock()
+
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// family — общая часть счетчиков и гистограмм: имя, описание, метки и серии
+type family[T any] struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*T
+	values map[string][]string
+}
+
+func newFamily[T any](name, help string, labels []string) family[T] {
+	return family[T]{name: name, help: help, labels: labels, series: map[string]*T{}, values: map[string][]string{}}
+}
+
+func (f *family[T]) get(values []string, create func() *T) *T {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s ожидает меток %d, передано %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = create()
+		f.series[key] = s
+		f.values[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// each обходит серии в порядке значений меток, чтобы вывод был стабильным
+func (f *family[T]) each(fn func(labels string, s *T)) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	f.mu.Unlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f.mu.Lock()
+		s, values := f.series[key], f.values[key]
+		f.mu.Unlock()
+		fn(formatLabels(f.labels, values), s)
+	}
+}
+
+func (f *family[T]) header(w io.Writer, kind string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, kind)
+	return err
+}
+
+// CounterVec — монотонно растущие счетчики с метками
+type CounterVec struct {
+	family[Counter]
+}
+
+// Counter — одна серия счетчика
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (c *Counter) Inc() { c.Add(1) }
+
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: счетчик не может уменьшаться")
+	}
+	c.mu.Lock()
+	c.value += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newFamily[Counter](name, help, labels)}
+	r.register(name, c)
+	return c
+}
+
+// With возвращает серию для значений меток в порядке их объявления
+func (c *CounterVec) With(values ...string) *Counter {
+	return c.get(values, func() *Counter { return &Counter{} })
+}
+
+func (c *CounterVec) write(w io.Writer) error {
+	if err := c.header(w, "counter"); err != nil {
+		return err
+	}
+	var err error
+	c.each(func(labels string, s *Counter) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", c.name, labels, formatFloat(s.Value()))
+		}
+	})
+	return err
+}
+
+// HistogramVec — распределения значений (длительностей, размеров) с метками
+type HistogramVec struct {
+	family[Histogram]
+	buckets []float64
+}
+
+// Histogram — одна серия гистограммы; counts хранит попадания в каждую корзину
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// ObserveDuration записывает длительность в секундах
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+func (h *Histogram) snapshot() ([]uint64, uint64, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]uint64(nil), h.counts...), h.count, h.sum
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if !sort.Float64sAreSorted(buckets) {
+		panic("metrics: границы гистограммы " + name + " должны возрастать")
+	}
+	h := &HistogramVec{family: newFamily[Histogram](name, help, labels), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+func (h *HistogramVec) With(values ...string) *Histogram {
+	return h.get(values, func() *Histogram {
+		return &Histogram{buckets: h.buckets, counts: make([]uint64, len(h.buckets))}
+	})
+}
+
+func (h *HistogramVec) write(w io.Writer) error {
+	if err := h.header(w, "histogram"); err != nil {
+		return err
+	}
+
+	var err error
+	h.each(func(labels string, s *Histogram) {
+		counts, count, sum := s.snapshot()
+		for i, bound := range h.buckets {
+			if err == nil {
+				_, err = fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(labels, "le", formatFloat(bound)), counts[i])
+			}
+		}
+		if err == nil {
+			_, err = fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+				h.name, withLabel(labels, "le", "+Inf"), count,
+				h.name, labels, formatFloat(sum),
+				h.name, labels, count)
+		}
+	})
+	return err
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + value + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWritesPrometheusText(t *testing.T) {
+	r := NewRegistry()
+	requests := r.NewCounterVec("test_requests_total", "Запросы", "method", "path")
+	sizes := r.NewHistogramVec("test_size_bytes", "Размеры", []float64{10, 100}, "kind")
+
+	requests.With("GET", `/a"b`).Inc()
+	requests.With("GET", `/a"b`).Add(2)
+	requests.With("DELETE", "/x").Inc()
+	sizes.With("image").Observe(5)
+	sizes.With("image").Observe(50)
+	sizes.With("image").Observe(500)
+
+	var out strings.Builder
+	if err := r.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP test_requests_total Запросы
+# TYPE test_requests_total counter
+test_requests_total{method="DELETE",path="/x"} 1
+test_requests_total{method="GET",path="/a\"b"} 3
+# HELP test_size_bytes Размеры
+# TYPE test_size_bytes histogram
+test_size_bytes_bucket{kind="image",le="10"} 1
+test_size_bytes_bucket{kind="image",le="100"} 2
+test_size_bytes_bucket{kind="image",le="+Inf"} 3
+test_size_bytes_sum{kind="image"} 555
+test_size_bytes_count{kind="image"} 3
+`
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestHistogramWithoutLabels(t *testing.T) {
+	r := NewRegistry()
+	r.NewHistogramVec("test_seconds", "Время", []float64{1}).With().Observe(0.5)
+
+	var out strings.Builder
+	r.Write(&out)
+	if !strings.Contains(out.String(), `test_seconds_bucket{le="1"} 1`) || !strings.Contains(out.String(), "test_seconds_count 1") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
+
+func TestRegistryRejectsDuplicates(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("dup_total", "")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate metric")
+		}
+	}()
+	r.NewCounterVec("dup_total", "")
+}
+
+func TestResult(t *testing.T) {
+	if Result(nil) != "ok" || Result(errors.New("boom")) != "error" {
+		t.Error("unexpected result labels")
+	}
+}
@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, migration := range m.migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+
+	tests := []struct {
+		name     string
+		fs       fstest.MapFS
+		versions []int64
+		err      string
+	}{
+		{
+			name: "sorted by version",
+			fs: fstest.MapFS{
+				"0010_later.up.sql":   file("up"),
+				"0010_later.down.sql": file("down"),
+				"0002_first.up.sql":   file("up"),
+				"0002_first.down.sql": file("down"),
+			},
+			versions: []int64{2, 10},
+		},
+		{
+			name: "missing down",
+			fs:   fstest.MapFS{"0001_init.up.sql": file("up")},
+			err:  "нет up- или down-файла",
+		},
+		{
+			name: "bad file name",
+			fs:   fstest.MapFS{"init.sql": file("up")},
+			err:  "неверное имя файла",
+		},
+		{
+			name: "duplicate version",
+			fs: fstest.MapFS{
+				"0001_a.up.sql":   file("up"),
+				"0001_a.down.sql": file("down"),
+				"0001_b.up.sql":   file("up"),
+			},
+			err: "используется миграциями",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.fs)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var versions []int64
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+				if m.Up != "up" || m.Down != "down" {
+					t.Errorf("migration %d: up=%q down=%q", m.Version, m.Up, m.Down)
+				}
+			}
+			if len(versions) != len(tt.versions) {
+				t.Fatalf("versions %v, want %v", versions, tt.versions)
+			}
+			for i := range versions {
+				if versions[i] != tt.versions[i] {
+					t.Fatalf("versions %v, want %v", versions, tt.versions)
+				}
+			}
+		})
+	}
+}
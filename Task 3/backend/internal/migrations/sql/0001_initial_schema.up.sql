@@ -0,0 +1,73 @@
+-- Базовая схема магазина. IF NOT EXISTS позволяет принять под управление
+-- базу, созданную ранее из дампа schema.sql.
+
+CREATE EXTENSION IF NOT EXISTS pgcrypto WITH SCHEMA public;
+
+CREATE TABLE IF NOT EXISTS users (
+    id serial PRIMARY KEY,
+    username character varying(50) NOT NULL UNIQUE,
+    email character varying(100) NOT NULL UNIQUE,
+    password_hash character varying(255) NOT NULL,
+    role character varying(20) DEFAULT 'customer'::character varying,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    is_active boolean DEFAULT true,
+    is_protected boolean DEFAULT false
+);
+
+-- В старых дампах этих колонок не было
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_active boolean DEFAULT true;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_protected boolean DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_users_email ON users USING btree (email);
+CREATE INDEX IF NOT EXISTS idx_users_username ON users USING btree (username);
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    session_token character varying(255) NOT NULL UNIQUE,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions USING btree (session_token);
+
+CREATE TABLE IF NOT EXISTS products (
+    id serial PRIMARY KEY,
+    name character varying(255) NOT NULL,
+    description text,
+    price numeric(10,2),
+    image character varying(255),
+    stock integer DEFAULT 0,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    user_id integer REFERENCES users(id) ON DELETE SET NULL,
+    is_approved boolean DEFAULT true
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_user ON products USING btree (user_id);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    product_id integer REFERENCES products(id) ON DELETE CASCADE,
+    quantity integer DEFAULT 1,
+    added_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cart_user ON cart_items USING btree (user_id);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users(id),
+    total_amount numeric(10,2),
+    status character varying(20) DEFAULT 'pending'::character varying,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id serial PRIMARY KEY,
+    order_id integer REFERENCES orders(id) ON DELETE CASCADE,
+    product_id integer REFERENCES products(id),
+    quantity integer,
+    price_at_time numeric(10,2)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_order_items_order;
+DROP INDEX IF EXISTS idx_orders_created_at;
+DROP TABLE IF EXISTS cart_additions;
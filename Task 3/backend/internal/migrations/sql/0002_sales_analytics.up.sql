@@ -0,0 +1,12 @@
+-- Журнал добавлений в корзину для расчета конверсии
+CREATE TABLE IF NOT EXISTS cart_additions (
+    id serial PRIMARY KEY,
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    product_id integer REFERENCES products(id) ON DELETE CASCADE,
+    quantity integer NOT NULL,
+    added_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cart_additions_added_at ON cart_additions USING btree (added_at);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders USING btree (created_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items USING btree (order_id);
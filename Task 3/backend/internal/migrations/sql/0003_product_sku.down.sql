@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_products_user_sku;
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
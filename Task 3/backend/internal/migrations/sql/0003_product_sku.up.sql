@@ -0,0 +1,4 @@
+-- Артикул продавца для массового импорта
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku character varying(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_user_sku ON products USING btree (user_id, sku) WHERE (sku IS NOT NULL);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_approved_id;
+DROP TRIGGER IF EXISTS trg_products_updated_at ON products;
+DROP FUNCTION IF EXISTS products_touch_updated_at();
+ALTER TABLE products DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,22 @@
+-- Время последнего изменения товара: по нему считаются ETag каталога
+ALTER TABLE products ADD COLUMN IF NOT EXISTS updated_at timestamp without time zone;
+UPDATE products SET updated_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE updated_at IS NULL;
+ALTER TABLE products
+    ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN updated_at SET NOT NULL;
+
+-- Любое изменение строки обновляет updated_at, даже если запрос забыл это сделать
+CREATE OR REPLACE FUNCTION products_touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := clock_timestamp();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_updated_at ON products;
+CREATE TRIGGER trg_products_updated_at
+    BEFORE UPDATE ON products
+    FOR EACH ROW EXECUTE FUNCTION products_touch_updated_at();
+
+-- Постраничный вывод каталога по курсору (WHERE id > $1 ORDER BY id)
+CREATE INDEX IF NOT EXISTS idx_products_approved_id ON products USING btree (id) WHERE is_approved;
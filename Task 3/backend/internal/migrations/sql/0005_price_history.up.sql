@@ -0,0 +1,33 @@
+-- История цен товаров: каждое изменение с автором и источником (create, update, import)
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id serial PRIMARY KEY,
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    old_price numeric(10,2),
+    new_price numeric(10,2) NOT NULL,
+    changed_by integer REFERENCES users(id) ON DELETE SET NULL,
+    source character varying(20) NOT NULL,
+    changed_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON product_price_history USING btree (product_id, changed_at);
+
+-- Начальная цена уже существующих товаров
+INSERT INTO product_price_history (product_id, old_price, new_price, changed_by, source, changed_at)
+SELECT p.id, NULL, p.price, p.user_id, 'create', COALESCE(p.created_at, CURRENT_TIMESTAMP)
+FROM products p
+WHERE p.price IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM product_price_history h WHERE h.product_id = p.id);
+
+-- Подписки на снижение цены: срабатывают один раз, когда цена опускается до target_price
+CREATE TABLE IF NOT EXISTS price_alerts (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    target_price numeric(10,2) NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    triggered_at timestamp without time zone,
+    triggered_price numeric(10,2),
+    UNIQUE (user_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_pending ON price_alerts USING btree (product_id) WHERE (triggered_at IS NULL);
@@ -0,0 +1,22 @@
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_product_id_fkey,
+    ADD CONSTRAINT order_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id);
+ALTER TABLE order_items DROP COLUMN IF EXISTS product_name;
+
+DROP INDEX IF EXISTS idx_orders_user;
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS shipping_address,
+    DROP COLUMN IF EXISTS weight_grams,
+    DROP COLUMN IF EXISTS shipping_cost,
+    DROP COLUMN IF EXISTS delivery_kind,
+    DROP COLUMN IF EXISTS delivery_name,
+    DROP COLUMN IF EXISTS delivery_method_id,
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS subtotal;
+
+DROP TABLE IF EXISTS delivery_rules;
+DROP TABLE IF EXISTS delivery_methods;
+DROP TABLE IF EXISTS addresses;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_weight_grams_check;
+ALTER TABLE products DROP COLUMN IF EXISTS weight_grams;
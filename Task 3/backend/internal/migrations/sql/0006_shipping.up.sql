@@ -0,0 +1,66 @@
+-- Вес товара в граммах для расчета доставки
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams integer DEFAULT 0 NOT NULL;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_weight_grams_check;
+ALTER TABLE products ADD CONSTRAINT products_weight_grams_check CHECK (weight_grams >= 0);
+
+-- Адресная книга покупателя; адрес по умолчанию у пользователя один
+CREATE TABLE IF NOT EXISTS addresses (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label character varying(50) DEFAULT '' NOT NULL,
+    recipient character varying(100) NOT NULL,
+    phone character varying(30) NOT NULL,
+    city character varying(100) NOT NULL,
+    street character varying(255) NOT NULL,
+    postal_code character varying(20) DEFAULT '' NOT NULL,
+    is_default boolean DEFAULT false NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_addresses_user ON addresses USING btree (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_default ON addresses USING btree (user_id) WHERE is_default;
+
+-- Способы доставки. Стоимость задается ступенями: действует правило с наибольшим
+-- порогом from_value, не превышающим вес заказа в граммах (pricing = 'weight')
+-- или сумму товаров (pricing = 'total').
+CREATE TABLE IF NOT EXISTS delivery_methods (
+    id serial PRIMARY KEY,
+    name character varying(100) NOT NULL,
+    kind character varying(20) NOT NULL CHECK (kind IN ('pickup', 'courier', 'post')),
+    pricing character varying(10) NOT NULL CHECK (pricing IN ('weight', 'total')),
+    is_active boolean DEFAULT true NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS delivery_rules (
+    method_id integer NOT NULL REFERENCES delivery_methods(id) ON DELETE CASCADE,
+    from_value numeric(12,2) NOT NULL CHECK (from_value >= 0),
+    cost numeric(10,2) NOT NULL CHECK (cost >= 0),
+    PRIMARY KEY (method_id, from_value)
+);
+
+-- Заказ хранит условия доставки на момент оформления: позднее изменение
+-- тарифов или адресной книги не меняет уже оформленные заказы
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS subtotal numeric(10,2),
+    ADD COLUMN IF NOT EXISTS currency character(3),
+    ADD COLUMN IF NOT EXISTS delivery_method_id integer REFERENCES delivery_methods(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS delivery_name character varying(100),
+    ADD COLUMN IF NOT EXISTS delivery_kind character varying(20),
+    ADD COLUMN IF NOT EXISTS shipping_cost numeric(10,2) DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS weight_grams integer DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS shipping_address jsonb;
+
+UPDATE orders o SET subtotal = COALESCE(o.total_amount, (
+    SELECT COALESCE(SUM(oi.quantity * oi.price_at_time), 0) FROM order_items oi WHERE oi.order_id = o.id
+)) WHERE o.subtotal IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_user ON orders USING btree (user_id, created_at);
+
+-- Название товара сохраняется в позиции, чтобы товар можно было удалить
+-- из каталога, не теряя историю заказов
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS product_name character varying(255);
+UPDATE order_items oi SET product_name = p.name FROM products p WHERE p.id = oi.product_id AND oi.product_name IS NULL;
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS order_items_product_id_fkey,
+    ADD CONSTRAINT order_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE SET NULL;
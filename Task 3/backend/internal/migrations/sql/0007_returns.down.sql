@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS returns;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded;
+ALTER TABLE order_items DROP COLUMN IF EXISTS seller_id;
@@ -0,0 +1,28 @@
+-- Продавец сохраняется в позиции заказа, чтобы возврат можно было рассмотреть
+-- и после удаления товара из каталога
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS seller_id integer REFERENCES users(id) ON DELETE SET NULL;
+UPDATE order_items oi SET seller_id = p.user_id FROM products p WHERE p.id = oi.product_id AND oi.seller_id IS NULL;
+
+-- Сумма, уже возвращенная покупателю по заказу
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded numeric(10,2) DEFAULT 0 NOT NULL;
+
+-- Заявки на возврат по позициям заказа. Сумма возврата фиксируется при создании
+-- по цене из заказа.
+CREATE TABLE IF NOT EXISTS returns (
+    id serial PRIMARY KEY,
+    order_id integer NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id integer NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quantity integer NOT NULL CHECK (quantity > 0),
+    reason character varying(1000) NOT NULL,
+    photos text[] DEFAULT '{}' NOT NULL,
+    amount numeric(10,2) NOT NULL CHECK (amount >= 0),
+    status character varying(20) DEFAULT 'requested' NOT NULL
+        CHECK (status IN ('requested', 'rejected', 'awaiting_item', 'received', 'refunded')),
+    comment character varying(1000) DEFAULT '' NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_returns_user ON returns USING btree (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_returns_item ON returns USING btree (order_item_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS answer_votes;
+DROP TABLE IF EXISTS answers;
+DROP TABLE IF EXISTS questions;
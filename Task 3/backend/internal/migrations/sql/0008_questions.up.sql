@@ -0,0 +1,35 @@
+-- Вопросы покупателей о товаре и ответы на них. status: published — виден всем,
+-- pending — ждет модерации, hidden — скрыт модератором.
+CREATE TABLE IF NOT EXISTS questions (
+    id serial PRIMARY KEY,
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body character varying(1000) NOT NULL,
+    status character varying(20) DEFAULT 'published' NOT NULL
+        CHECK (status IN ('published', 'pending', 'hidden')),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_questions_product ON questions USING btree (product_id, created_at);
+
+-- is_seller — ответ владельца товара или администратора, is_buyer — автор покупал товар
+CREATE TABLE IF NOT EXISTS answers (
+    id serial PRIMARY KEY,
+    question_id integer NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body character varying(2000) NOT NULL,
+    is_seller boolean DEFAULT false NOT NULL,
+    is_buyer boolean DEFAULT false NOT NULL,
+    status character varying(20) DEFAULT 'published' NOT NULL
+        CHECK (status IN ('published', 'pending', 'hidden')),
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_answers_question ON answers USING btree (question_id);
+
+CREATE TABLE IF NOT EXISTS answer_votes (
+    answer_id integer NOT NULL REFERENCES answers(id) ON DELETE CASCADE,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (answer_id, user_id)
+);
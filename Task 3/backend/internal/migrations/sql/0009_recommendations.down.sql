@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS product_pairs;
+DROP TABLE IF EXISTS product_views;
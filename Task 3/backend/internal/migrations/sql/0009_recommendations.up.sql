@@ -0,0 +1,18 @@
+-- Просмотренные товары. viewer — "u:<id>" для пользователя или "s:<id>" для анонимной
+-- сессии; повторный просмотр обновляет viewed_at.
+CREATE TABLE IF NOT EXISTS product_views (
+    viewer character varying(80) NOT NULL,
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    viewed_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (viewer, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_views_recent ON product_views USING btree (viewer, viewed_at DESC);
+
+-- Пары товаров из одних заказов; таблицу целиком пересчитывает периодическая задача
+CREATE TABLE IF NOT EXISTS product_pairs (
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    related_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    orders_count integer NOT NULL,
+    PRIMARY KEY (product_id, related_id)
+);
@@ -0,0 +1,8 @@
+-- token_version растет при смене пароля и удалении аккаунта: токены со старой
+-- версией перестают приниматься. Новый email хранится в pending_email, пока
+-- пользователь не подтвердит его кодом email_token.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version integer DEFAULT 0 NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email character varying(100);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_token character varying(64) UNIQUE;
+-- Удаленный аккаунт обезличивается, заказы остаются за ним
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamp without time zone;
@@ -0,0 +1,10 @@
+-- Журнал успешных входов для карточки пользователя в админке
+CREATE TABLE IF NOT EXISTS user_logins (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    ip character varying(45) DEFAULT '' NOT NULL,
+    user_agent character varying(255) DEFAULT '' NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_logins_user ON user_logins USING btree (user_id, created_at DESC);
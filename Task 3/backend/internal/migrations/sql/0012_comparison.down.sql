@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS compare_items;
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN IF EXISTS attributes;
+ALTER TABLE products DROP COLUMN IF EXISTS category;
@@ -0,0 +1,15 @@
+-- Категория и характеристики товара, по ним строится сравнение.
+-- attributes — объект «название → значение», например {"Память": "8 ГБ"}.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS category character varying(50) DEFAULT '' NOT NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS attributes jsonb DEFAULT '{}'::jsonb NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_category ON products USING btree (category);
+
+-- Списки сравнения; owner устроен так же, как viewer в product_views:
+-- "u:<id>" для пользователя или "s:<id>" для анонимной сессии
+CREATE TABLE IF NOT EXISTS compare_items (
+    owner character varying(80) NOT NULL,
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    added_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (owner, product_id)
+);
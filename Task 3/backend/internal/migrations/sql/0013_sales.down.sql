@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sale_products;
+DROP TABLE IF EXISTS sales;
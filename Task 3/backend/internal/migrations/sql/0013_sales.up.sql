@@ -0,0 +1,26 @@
+-- Акции со скидкой на время [starts_at, ends_at). Время хранится в UTC.
+-- Статус ведет планировщик: scheduled → active → ended; cancelled — отменена вручную.
+-- seller_id задан у акций продавца: они действуют только на его товары.
+CREATE TABLE IF NOT EXISTS sales (
+    id serial PRIMARY KEY,
+    name character varying(100) NOT NULL,
+    discount_type character varying(10) NOT NULL CHECK (discount_type IN ('percent', 'fixed')),
+    discount_value numeric(10,2) NOT NULL CHECK (discount_value > 0),
+    starts_at timestamp without time zone NOT NULL,
+    ends_at timestamp without time zone NOT NULL,
+    category character varying(50) DEFAULT '' NOT NULL,
+    seller_id integer REFERENCES users(id) ON DELETE CASCADE,
+    status character varying(20) DEFAULT 'scheduled' NOT NULL,
+    created_by integer REFERENCES users(id) ON DELETE SET NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_status ON sales USING btree (status, starts_at);
+
+-- Товары, выбранные в акции поштучно; категория задается в sales.category
+CREATE TABLE IF NOT EXISTS sale_products (
+    sale_id integer NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+    product_id integer NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (sale_id, product_id)
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invoices;
+DROP FUNCTION IF EXISTS invoices_immutable();
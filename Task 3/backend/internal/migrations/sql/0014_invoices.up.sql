@@ -0,0 +1,20 @@
+-- Счета к заказам. PDF сохраняется при выставлении и больше не меняется:
+-- правка реквизитов или данных пользователей не затрагивает выставленные счета.
+CREATE TABLE IF NOT EXISTS invoices (
+    order_id integer PRIMARY KEY REFERENCES orders(id) ON DELETE RESTRICT,
+    number character varying(32) NOT NULL UNIQUE,
+    issued_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    pdf bytea NOT NULL
+);
+
+-- Выставленный счет нельзя изменить или удалить даже прямым запросом
+CREATE OR REPLACE FUNCTION invoices_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'счет % изменить нельзя', OLD.number;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_invoices_immutable ON invoices;
+CREATE TRIGGER trg_invoices_immutable
+    BEFORE UPDATE OR DELETE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION invoices_immutable();
@@ -0,0 +1,6 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_inclusive;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE order_items DROP COLUMN IF EXISTS tax_name;
+ALTER TABLE addresses DROP COLUMN IF EXISTS region;
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,26 @@
+-- Ставки налогов по категории товара и региону доставки. Пустые категория и регион
+-- подходят к любым; одна ставка на пару категория — регион без учета регистра.
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id serial PRIMARY KEY,
+    name character varying(100) NOT NULL,
+    rate numeric(5,2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    category character varying(50) DEFAULT '' NOT NULL,
+    region character varying(100) DEFAULT '' NOT NULL,
+    inclusive boolean DEFAULT true NOT NULL,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rates_target ON tax_rates USING btree (lower(category), lower(region));
+
+-- Цены магазина уже включали НДС 20%: он и становится ставкой по умолчанию
+INSERT INTO tax_rates (name, rate, inclusive)
+SELECT 'НДС 20%', 20, true
+WHERE NOT EXISTS (SELECT 1 FROM tax_rates);
+
+ALTER TABLE addresses ADD COLUMN IF NOT EXISTS region character varying(100) DEFAULT '' NOT NULL;
+
+-- Налог позиции на момент оформления; у заказов, оформленных раньше, налога нет (NULL)
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_name character varying(100);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_rate numeric(5,2);
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_inclusive boolean;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS tax_amount numeric(10,2);
@@ -0,0 +1,17 @@
+-- Входящие уведомления пользователей. Товар и заказ хранятся без внешних ключей:
+-- уведомление об отклонении переживает удаление товара.
+CREATE TABLE IF NOT EXISTS notifications (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type character varying(32) NOT NULL,
+    product_id integer,
+    product_name character varying(255) DEFAULT '' NOT NULL,
+    order_id integer,
+    status character varying(20) DEFAULT '' NOT NULL,
+    count integer DEFAULT 0 NOT NULL,
+    read_at timestamp without time zone,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications USING btree (user_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications USING btree (user_id) WHERE read_at IS NULL;
@@ -0,0 +1,2 @@
+-- Товары категории выбираются без учета регистра: GraphQL загружает их пачкой по lower(category)
+CREATE INDEX IF NOT EXISTS idx_products_category_lower ON products USING btree (lower(category)) WHERE is_approved;
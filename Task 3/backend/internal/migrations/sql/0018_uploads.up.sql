@@ -0,0 +1,8 @@
+-- Кто загружал изображение. Файлы хранятся по содержимому, поэтому одну картинку
+-- могут загрузить несколько пользователей; импорт принимает только свои файлы.
+CREATE TABLE IF NOT EXISTS uploads (
+    filename character varying(255) NOT NULL,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, filename)
+);
@@ -0,0 +1,5 @@
+-- Код подтверждения нового email действует ограниченное время. Уже отправленным
+-- кодам дается сутки с момента миграции.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_token_expires_at timestamp without time zone;
+UPDATE users SET email_token_expires_at = CURRENT_TIMESTAMP + interval '24 hours'
+WHERE email_token IS NOT NULL AND email_token_expires_at IS NULL;
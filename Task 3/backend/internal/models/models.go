@@ -0,0 +1,849 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/money"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleSeller   = "seller"
+	RoleAdmin    = "admin"
+)
+
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	IsActive     bool      `json:"is_active"`
+	IsProtected  bool      `json:"-"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	// PendingEmail — новый email, который еще не подтвержден кодом EmailToken
+	PendingEmail string `json:"pending_email,omitempty" doc:"Новый email, ожидающий подтверждения"`
+	EmailToken   string `json:"-"`
+	// EmailTokenExpiresAt — до какого момента принимается EmailToken
+	EmailTokenExpiresAt time.Time `json:"-"`
+	// TokenVersion меняется при смене пароля: токены со старой версией отзываются
+	TokenVersion int `json:"-"`
+}
+
+type UserDetail struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	IsActive    bool   `json:"is_active"`
+	IsProtected bool   `json:"is_protected"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// UserFilter — поиск пользователей в админке. Query ищется в имени и email
+// без учета регистра; nil в Active и Protected — без фильтра.
+type UserFilter struct {
+	Query     string
+	Role      string
+	Active    *bool
+	Protected *bool
+	Limit     int
+	Offset    int
+}
+
+// ClientInfo — откуда пришел запрос на вход
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type LoginRecord struct {
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Product struct {
+	ID          int          `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       money.Amount `json:"price"`
+	Currency    string       `json:"currency,omitempty" doc:"Валюта цены в каталоге; в остальных ответах цена в базовой валюте"`
+	Image       string       `json:"image"`
+	Stock       int          `json:"stock"`
+	Weight      int          `json:"weight" doc:"Вес в граммах"`
+	UserID      *int         `json:"user_id,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	IsApproved  bool         `json:"is_approved"`
+	SKU         string       `json:"sku,omitempty"`
+	CreatedAt   string       `json:"created_at,omitempty"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Category    string       `json:"category,omitempty" doc:"Категория; сравнивать можно только товары одной категории"`
+	// Attributes — характеристики «название → значение», из них строится таблица сравнения
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// OriginalPrice — цена без скидки; заполняется в каталоге, карточке и корзине,
+	// где Price уже учитывает действующую акцию
+	OriginalPrice *money.Amount `json:"original_price,omitempty"`
+	Sale          *AppliedSale  `json:"sale,omitempty" doc:"Акция, которая снижает цену сейчас"`
+}
+
+// OwnedBy сообщает, принадлежит ли товар пользователю
+func (p *Product) OwnedBy(userID int) bool {
+	return p.UserID != nil && *p.UserID == userID
+}
+
+// Seller — публичные сведения о продавце: без email и статуса учетной записи
+type Seller struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// Category — категория каталога и число одобренных товаров в ней
+type Category struct {
+	Name         string `json:"name"`
+	ProductCount int    `json:"product_count"`
+}
+
+// Источники изменения цены в истории
+const (
+	PriceSourceCreate = "create"
+	PriceSourceUpdate = "update"
+	PriceSourceImport = "import"
+)
+
+// PriceChange — запись истории цены. У начальной цены OldPrice = nil.
+// Автор хранится для аудита и в публичный ответ не попадает.
+type PriceChange struct {
+	ID        int           `json:"id"`
+	ProductID int           `json:"product_id"`
+	OldPrice  *money.Amount `json:"old_price"`
+	NewPrice  money.Amount  `json:"new_price"`
+	ChangedBy *int          `json:"-"`
+	Source    string        `json:"source"`
+	ChangedAt time.Time     `json:"changed_at"`
+}
+
+// PriceAlert — подписка пользователя на снижение цены товара до TargetPrice
+type PriceAlert struct {
+	ID             int           `json:"id"`
+	UserID         int           `json:"-"`
+	ProductID      int           `json:"product_id"`
+	ProductName    string        `json:"product_name"`
+	CurrentPrice   money.Amount  `json:"current_price"`
+	TargetPrice    money.Amount  `json:"target_price"`
+	CreatedAt      time.Time     `json:"created_at"`
+	TriggeredAt    *time.Time    `json:"triggered_at"`
+	TriggeredPrice *money.Amount `json:"triggered_price"`
+}
+
+type CartItem struct {
+	ID        int          `json:"id"`
+	ProductID int          `json:"product_id"`
+	Name      string       `json:"name"`
+	Price     money.Amount `json:"price" doc:"Цена с учетом действующей акции"`
+	Quantity  int          `json:"quantity"`
+	Weight    int          `json:"weight" doc:"Вес единицы товара в граммах"`
+	Image     string       `json:"image"`
+	// OriginalPrice — цена без скидки
+	OriginalPrice money.Amount `json:"original_price"`
+	Sale          *AppliedSale `json:"sale,omitempty"`
+	Tax           *ItemTax     `json:"tax" doc:"Налог позиции; null, если товар налогом не облагается"`
+	// Продавец и категория нужны, чтобы подобрать акцию и ставку налога
+	SellerID int    `json:"-"`
+	Category string `json:"-"`
+}
+
+// Виды скидки акции
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// Статусы акции: scheduled → active → ended ведет планировщик, cancelled — отмена вручную
+const (
+	SaleScheduled = "scheduled"
+	SaleActive    = "active"
+	SaleEnded     = "ended"
+	SaleCancelled = "cancelled"
+)
+
+// Sale — акция со скидкой на время [StartsAt, EndsAt). Действует на товары из ProductIDs
+// и на товары категории Category; акция продавца — только на его товары.
+type Sale struct {
+	ID            int          `json:"id"`
+	Name          string       `json:"name"`
+	DiscountType  string       `json:"discount_type" doc:"percent — процент от цены, fixed — сумма в базовой валюте"`
+	DiscountValue money.Amount `json:"discount_value"`
+	StartsAt      time.Time    `json:"starts_at"`
+	EndsAt        time.Time    `json:"ends_at"`
+	Category      string       `json:"category" doc:"Товары этой категории (без учета регистра); пустая — только product_ids"`
+	ProductIDs    []int        `json:"product_ids"`
+	SellerID      *int         `json:"seller_id" doc:"Продавец, на чьи товары действует акция; null — акция магазина"`
+	Status        string       `json:"status" doc:"scheduled, active, ended или cancelled"`
+	CreatedBy     *int         `json:"-"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// AppliedSale — акция, по которой посчитана цена товара
+type AppliedSale struct {
+	ID     int       `json:"id"`
+	Name   string    `json:"name"`
+	EndsAt time.Time `json:"ends_at"`
+}
+
+// SaleTarget — товар глазами акции: по нему акция решает, действует ли она
+type SaleTarget struct {
+	ProductID int
+	SellerID  int
+	Category  string
+}
+
+// Covers сообщает, действует ли акция на товар
+func (s *Sale) Covers(t SaleTarget) bool {
+	if s.SellerID != nil && *s.SellerID != t.SellerID {
+		return false
+	}
+	if s.Category != "" && strings.EqualFold(s.Category, t.Category) {
+		return true
+	}
+	for _, id := range s.ProductIDs {
+		if id == t.ProductID {
+			return true
+		}
+	}
+	return false
+}
+
+var hundred = money.FromInt(100)
+
+// Apply возвращает цену со скидкой, округленную до копеек. false — скидка
+// не снижает цену или съедает ее целиком, тогда акция к товару не применяется.
+func (s *Sale) Apply(price money.Amount) (money.Amount, bool) {
+	var discounted money.Amount
+	if s.DiscountType == DiscountPercent {
+		discounted = price.Mul(hundred.Sub(s.DiscountValue)).Div(hundred).Round(2)
+	} else {
+		discounted = price.Sub(s.DiscountValue)
+	}
+	if !discounted.IsPositive() || !discounted.LessThan(price) {
+		return price, false
+	}
+	return discounted, true
+}
+
+// BestSale выбирает из действующих акций самую выгодную для покупателя.
+// Скидки разных акций не суммируются; nil — ни одна акция цену не снижает.
+func BestSale(sales []Sale, t SaleTarget, price money.Amount) (money.Amount, *Sale) {
+	best := price
+	var chosen *Sale
+	for i := range sales {
+		if !sales[i].Covers(t) {
+			continue
+		}
+		if discounted, ok := sales[i].Apply(price); ok && discounted.LessThan(best) {
+			best, chosen = discounted, &sales[i]
+		}
+	}
+	return best, chosen
+}
+
+// TaxRate — ставка налога для товаров категории Category, доставляемых в регион Region.
+// Пустые категория и регион подходят к любым; из подходящих ставок действует самая точная.
+type TaxRate struct {
+	ID        int          `json:"id"`
+	Name      string       `json:"name" doc:"Название для чеков и счетов, например «НДС 20%»"`
+	Rate      money.Amount `json:"rate" doc:"Ставка в процентах"`
+	Category  string       `json:"category" doc:"Категория товара (без учета регистра); пусто — любая"`
+	Region    string       `json:"region" doc:"Регион доставки (без учета регистра); пусто — любой"`
+	Inclusive bool         `json:"inclusive" doc:"true — цены уже включают налог, false — налог начисляется сверху"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// specificity — насколько точно ставка описывает товар: категория важнее региона
+func (r *TaxRate) specificity() int {
+	n := 0
+	if r.Category != "" {
+		n += 2
+	}
+	if r.Region != "" {
+		n++
+	}
+	return n
+}
+
+// MatchTaxRate выбирает ставку для товара категории category при доставке в регион region.
+// nil — ни одна ставка не подходит, товар налогом не облагается.
+func MatchTaxRate(rates []TaxRate, category, region string) *TaxRate {
+	var best *TaxRate
+	for i := range rates {
+		r := &rates[i]
+		if r.Category != "" && !strings.EqualFold(r.Category, strings.TrimSpace(category)) {
+			continue
+		}
+		if r.Region != "" && !strings.EqualFold(r.Region, strings.TrimSpace(region)) {
+			continue
+		}
+		if best == nil || r.specificity() > best.specificity() {
+			best = r
+		}
+	}
+	return best
+}
+
+// Tax считает налог с суммы позиции amount, округленный до копеек
+func (r *TaxRate) Tax(amount money.Amount) *ItemTax {
+	return &ItemTax{Name: r.Name, Rate: r.Rate, Inclusive: r.Inclusive, Amount: lineTax(r.Rate, r.Inclusive, amount)}
+}
+
+func lineTax(rate money.Amount, inclusive bool, amount money.Amount) money.Amount {
+	if inclusive {
+		return amount.Mul(rate).Div(hundred.Add(rate)).Round(2)
+	}
+	return amount.Mul(rate).Div(hundred).Round(2)
+}
+
+// ItemTax — налог позиции корзины или заказа: ставка и сумма на всю позицию
+type ItemTax struct {
+	Name      string       `json:"name"`
+	Rate      money.Amount `json:"rate" doc:"Ставка в процентах"`
+	Inclusive bool         `json:"inclusive" doc:"Налог уже включен в цену"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// Recalculate пересчитывает сумму налога для новой суммы позиции, например после
+// пересчета цены в другую валюту
+func (t *ItemTax) Recalculate(amount money.Amount) {
+	t.Amount = lineTax(t.Rate, t.Inclusive, amount)
+}
+
+// TaxLine — строка разбивки налогов: сколько начислено по одной ставке
+type TaxLine struct {
+	Name      string       `json:"name"`
+	Rate      money.Amount `json:"rate"`
+	Inclusive bool         `json:"inclusive"`
+	Base      money.Amount `json:"base" doc:"Сумма позиций, облагаемых по этой ставке"`
+	Amount    money.Amount `json:"amount"`
+}
+
+// TaxSummary собирает разбивку налогов корзины или заказа
+type TaxSummary struct {
+	Lines []TaxLine
+	// Total — все налоги, Extra — только начисленные сверх цен
+	Total money.Amount
+	Extra money.Amount
+}
+
+func NewTaxSummary() *TaxSummary {
+	return &TaxSummary{Lines: []TaxLine{}, Total: money.Zero, Extra: money.Zero}
+}
+
+// Add учитывает налог позиции с суммой base; позиции без налога пропускаются
+func (s *TaxSummary) Add(tax *ItemTax, base money.Amount) {
+	if tax == nil {
+		return
+	}
+	s.Total = s.Total.Add(tax.Amount)
+	if !tax.Inclusive {
+		s.Extra = s.Extra.Add(tax.Amount)
+	}
+	for i := range s.Lines {
+		line := &s.Lines[i]
+		if line.Name == tax.Name && line.Rate.Equal(tax.Rate) && line.Inclusive == tax.Inclusive {
+			line.Base = line.Base.Add(base)
+			line.Amount = line.Amount.Add(tax.Amount)
+			return
+		}
+	}
+	s.Lines = append(s.Lines, TaxLine{Name: tax.Name, Rate: tax.Rate, Inclusive: tax.Inclusive, Base: base, Amount: tax.Amount})
+}
+
+type Address struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"-"`
+	Label      string    `json:"label"`
+	Recipient  string    `json:"recipient"`
+	Phone      string    `json:"phone"`
+	Region     string    `json:"region" doc:"Регион доставки; по нему выбирается ставка налога"`
+	City       string    `json:"city"`
+	Street     string    `json:"street" doc:"Улица, дом, квартира"`
+	PostalCode string    `json:"postal_code"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Виды доставки
+const (
+	DeliveryPickup  = "pickup"
+	DeliveryCourier = "courier"
+	DeliveryPost    = "post"
+)
+
+// Способы расчета стоимости доставки
+const (
+	PricingByWeight = "weight"
+	PricingByTotal  = "total"
+)
+
+// DeliveryRule — ступень тарифа: Cost действует начиная с порога From
+type DeliveryRule struct {
+	From money.Amount `json:"from" doc:"Порог: вес в граммах (pricing=weight) или сумма товаров (pricing=total)"`
+	Cost money.Amount `json:"cost"`
+}
+
+type DeliveryMethod struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Kind     string         `json:"kind" doc:"pickup, courier или post"`
+	Pricing  string         `json:"pricing" doc:"weight — по весу, total — по сумме товаров"`
+	Rules    []DeliveryRule `json:"rules"`
+	IsActive bool           `json:"is_active"`
+}
+
+// NeedsAddress сообщает, нужен ли для доставки адрес покупателя
+func (m *DeliveryMethod) NeedsAddress() bool {
+	return m.Kind != DeliveryPickup
+}
+
+// ShippingQuote — расчет доставки для корзины; в заказе сохраняется как есть
+type ShippingQuote struct {
+	MethodID int          `json:"method_id"`
+	Name     string       `json:"name"`
+	Kind     string       `json:"kind"`
+	Weight   int          `json:"weight" doc:"Вес заказа в граммах"`
+	Cost     money.Amount `json:"cost"`
+}
+
+// Статусы заказа; допустимые переходы между ними задает сервис
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderDelivered = "delivered"
+	OrderCancelled = "cancelled"
+	OrderRefunded  = "refunded"
+)
+
+type Order struct {
+	ID       int            `json:"id"`
+	UserID   int            `json:"-"`
+	Status   string         `json:"status"`
+	Items    []OrderItem    `json:"items"`
+	Subtotal money.Amount   `json:"subtotal"`
+	Shipping *ShippingQuote `json:"shipping"`
+	Total    money.Amount   `json:"total"`
+	Tax      money.Amount   `json:"tax" doc:"Все налоги заказа, включенные в цены и начисленные сверху"`
+	Taxes    []TaxLine      `json:"taxes" doc:"Разбивка налогов по ставкам"`
+	Refunded money.Amount   `json:"refunded" doc:"Сумма, возвращенная по заявкам на возврат"`
+	Currency string         `json:"currency"`
+	// Address — снимок адреса на момент оформления; у самовывоза nil
+	Address   *Address  `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrderItem struct {
+	ID          int          `json:"id"`
+	ProductID   int          `json:"product_id" doc:"0, если товар удален из каталога"`
+	SellerID    int          `json:"-"`
+	Name        string       `json:"name"`
+	Quantity    int          `json:"quantity"`
+	PriceAtTime money.Amount `json:"price"`
+	Tax         *ItemTax     `json:"tax" doc:"Налог позиции на момент оформления; null — без налога"`
+}
+
+// SumTaxes заполняет Tax и Taxes заказа по налогам позиций
+func (o *Order) SumTaxes() *TaxSummary {
+	summary := NewTaxSummary()
+	for _, item := range o.Items {
+		summary.Add(item.Tax, money.Line(item.PriceAtTime, item.Quantity))
+	}
+	o.Tax, o.Taxes = summary.Total, summary.Lines
+	return summary
+}
+
+// ReturnAmount — сумма к возврату за quantity единиц позиции, если до этого уже
+// возвращено returned. Налог сверх цены возвращается долей нарастающим итогом,
+// чтобы после возврата всей позиции сумма сошлась с оплаченной до копейки.
+func (i OrderItem) ReturnAmount(returned, quantity int) money.Amount {
+	amount := money.Line(i.PriceAtTime, quantity)
+	if i.Tax == nil || i.Tax.Inclusive || i.Quantity == 0 {
+		return amount
+	}
+	share := func(n int) money.Amount {
+		return i.Tax.Amount.Mul(money.FromInt(int64(n))).Div(money.FromInt(int64(i.Quantity))).Round(2)
+	}
+	return amount.Add(share(returned + quantity)).Sub(share(returned))
+}
+
+// Invoice — выставленный счет к заказу. PDF сохраняется при выставлении
+// и больше не меняется, даже если изменятся реквизиты или данные пользователей.
+type Invoice struct {
+	OrderID  int
+	Number   string
+	IssuedAt time.Time
+	PDF      []byte
+}
+
+// Статусы заявки на возврат
+const (
+	ReturnRequested    = "requested"
+	ReturnRejected     = "rejected"
+	ReturnAwaitingItem = "awaiting_item"
+	ReturnReceived     = "received"
+	ReturnRefunded     = "refunded"
+)
+
+// Return — заявка на возврат части позиции заказа. Amount — сумма к возврату
+// в валюте заказа по цене на момент покупки вместе с налогом, начисленным сверху.
+type Return struct {
+	ID          int          `json:"id"`
+	OrderID     int          `json:"order_id"`
+	OrderItemID int          `json:"order_item_id"`
+	UserID      int          `json:"-"`
+	SellerID    int          `json:"-"`
+	ProductID   int          `json:"product_id" doc:"0, если товар удален из каталога"`
+	ProductName string       `json:"product_name"`
+	Quantity    int          `json:"quantity"`
+	Reason      string       `json:"reason"`
+	Photos      []string     `json:"photos" doc:"Имена файлов из /api/upload"`
+	Amount      money.Amount `json:"amount"`
+	Status      string       `json:"status" doc:"requested, rejected, awaiting_item, received или refunded"`
+	Comment     string       `json:"comment" doc:"Комментарий продавца или администратора"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// ReturnFilter отбирает заявки покупателя или продавца; нулевые поля не ограничивают выборку
+type ReturnFilter struct {
+	UserID   int
+	SellerID int
+}
+
+// Статусы вопросов и ответов о товаре
+const (
+	QAPublished = "published"
+	QAPending   = "pending"
+	QAHidden    = "hidden"
+)
+
+type Question struct {
+	ID        int       `json:"id"`
+	ProductID int       `json:"product_id"`
+	UserID    int       `json:"-"`
+	Username  string    `json:"username"`
+	Body      string    `json:"body"`
+	Status    string    `json:"status" doc:"published, pending или hidden"`
+	Answers   []Answer  `json:"answers" doc:"Опубликованные ответы: сначала ответы продавца, затем по числу голосов"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Answer struct {
+	ID         int       `json:"id"`
+	QuestionID int       `json:"question_id"`
+	UserID     int       `json:"-"`
+	Username   string    `json:"username"`
+	Body       string    `json:"body"`
+	IsSeller   bool      `json:"is_seller" doc:"Ответ продавца товара или администратора"`
+	IsBuyer    bool      `json:"is_buyer" doc:"Автор ответа покупал товар"`
+	Votes      int       `json:"votes"`
+	Status     string    `json:"status" doc:"published, pending или hidden"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Типы уведомлений
+const (
+	NotifyProductApproved = "product_approved"
+	NotifyProductRejected = "product_rejected"
+	NotifyProductPending  = "product_pending"
+	NotifyOrderStatus     = "order_status"
+	NotifyBackInStock     = "back_in_stock"
+)
+
+// Notification — событие для пользователя во входящих и в потоке уведомлений.
+// Текст не хранится: Message собирается по типу на языке клиента при выдаче.
+type Notification struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"-"`
+	Type        string    `json:"type" doc:"product_approved, product_rejected, product_pending, order_status или back_in_stock"`
+	ProductID   int       `json:"product_id,omitempty"`
+	ProductName string    `json:"product_name,omitempty" doc:"Название на момент события: отклоненный товар удаляется"`
+	OrderID     int       `json:"order_id,omitempty"`
+	Status      string    `json:"status,omitempty" doc:"Новый статус заказа"`
+	Count       int       `json:"count,omitempty" doc:"Сколько товаров ждут модерации после импорта"`
+	Message     string    `json:"message"`
+	Read        bool      `json:"read"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NotificationFilter отбирает уведомления пользователя, новые первыми
+type NotificationFilter struct {
+	UserID     int
+	UnreadOnly bool
+	// AfterID — только уведомления новее этого, например пропущенные потоком
+	AfterID int
+	// Oldest — от старых к новым: вместе с AfterID читает пропущенное страницами
+	Oldest bool
+	Limit  int
+}
+
+type JWTClaims struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	// TokenVersion сверяется с пользователем при каждом запросе
+	TokenVersion int `json:"ver"`
+	// Purpose пуст у токена сессии; токен с назначением принимается только там,
+	// для чего выдан
+	Purpose string `json:"purpose,omitempty"`
+	// SessionExpiresAt — когда истекает сессия, выдавшая токен потока
+	SessionExpiresAt *jwt.NumericDate `json:"sexp,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenPurposeStream — токен подключения к потоку уведомлений
+const TokenPurposeStream = "notifications_stream"
+
+type RegisterRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+	// bcrypt учитывает только первые 72 байта пароля
+	Password string `json:"password" validate:"required,min=6,max=72"`
+}
+
+type ProfileRequest struct {
+	Username string `json:"username" validate:"required,min=3,max=50"`
+	Email    string `json:"email" validate:"required,email,max=100"`
+}
+
+type PasswordChangeRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6,max=72"`
+}
+
+// GraphQLRequest — запрос к /api/graphql в формате GraphQL over HTTP
+type GraphQLRequest struct {
+	Query         string                 `json:"query" validate:"required,max=20000"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type EmailConfirmRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type CompareRequest struct {
+	ProductID int `json:"product_id" validate:"required,gt=0"`
+}
+
+type AddToCartRequest struct {
+	ProductID int `json:"product_id" validate:"required,gt=0"`
+	Quantity  int `json:"quantity" validate:"gt=0"`
+}
+
+type UpdateCartItemRequest struct {
+	// Количество 0 и меньше удаляет позицию
+	Quantity int `json:"quantity"`
+}
+
+type PriceAlertRequest struct {
+	TargetPrice money.Amount `json:"target_price" validate:"gt=0,lte=99999999.99"`
+}
+
+type AddressRequest struct {
+	Label      string `json:"label" validate:"max=50"`
+	Recipient  string `json:"recipient" validate:"required,max=100"`
+	Phone      string `json:"phone" validate:"required,max=30"`
+	Region     string `json:"region" validate:"max=100"`
+	City       string `json:"city" validate:"required,max=100"`
+	Street     string `json:"street" validate:"required,max=255"`
+	PostalCode string `json:"postal_code" validate:"max=20"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+// DeliveryMethodRequest — способ доставки в админке; ступени тарифа проверяет сервис
+type DeliveryMethodRequest struct {
+	Name     string         `json:"name" validate:"required,max=100"`
+	Kind     string         `json:"kind" validate:"required,oneof=pickup courier post"`
+	Pricing  string         `json:"pricing" validate:"required,oneof=weight total"`
+	Rules    []DeliveryRule `json:"rules"`
+	IsActive bool           `json:"is_active"`
+}
+
+// CheckoutRequest оформляет заказ из корзины; для самовывоза адрес не нужен
+type CheckoutRequest struct {
+	DeliveryMethodID int `json:"delivery_method_id" validate:"required,gt=0"`
+	AddressID        int `json:"address_id" validate:"gte=0"`
+}
+
+type ReturnRequest struct {
+	OrderItemID int      `json:"order_item_id" validate:"required,gt=0"`
+	Quantity    int      `json:"quantity" validate:"required,gt=0"`
+	Reason      string   `json:"reason" validate:"required,max=1000"`
+	Photos      []string `json:"photos" doc:"До 5 имен файлов из /api/upload"`
+}
+
+// ReturnStatusRequest — решение по заявке: одобрить (awaiting_item), отклонить,
+// отметить получение товара или возврат денег
+type ReturnStatusRequest struct {
+	Status  string `json:"status" validate:"required,oneof=awaiting_item rejected received refunded"`
+	Comment string `json:"comment" validate:"max=1000"`
+}
+
+type OrderStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=paid shipped delivered cancelled"`
+}
+
+type QuestionRequest struct {
+	Body string `json:"body" validate:"required,min=5,max=1000"`
+}
+
+type AnswerRequest struct {
+	Body string `json:"body" validate:"required,min=2,max=2000"`
+}
+
+type ModerationRequest struct {
+	Status string `json:"status" validate:"required,oneof=published hidden"`
+}
+
+// Массовые действия над пользователями
+const (
+	BulkBlock   = "block"
+	BulkUnblock = "unblock"
+	BulkRole    = "role"
+)
+
+type BulkUserRequest struct {
+	UserIDs []int  `json:"user_ids" validate:"required" doc:"До 100 пользователей"`
+	Action  string `json:"action" validate:"required,oneof=block unblock role"`
+	// Role обязательна для action = role
+	Role string `json:"role"`
+}
+
+// BulkUserResult — итог действия над одним пользователем; при ошибке — ее код и текст
+type BulkUserResult struct {
+	UserID  int    `json:"user_id"`
+	Success bool   `json:"success"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type SaleRequest struct {
+	Name          string       `json:"name" validate:"required,max=100"`
+	DiscountType  string       `json:"discount_type" validate:"required,oneof=percent fixed"`
+	DiscountValue money.Amount `json:"discount_value" validate:"gt=0,lte=99999999.99" doc:"Процент (до 90) или сумма в базовой валюте"`
+	StartsAt      time.Time    `json:"starts_at" validate:"required"`
+	EndsAt        time.Time    `json:"ends_at" validate:"required"`
+	Category      string       `json:"category" validate:"max=50"`
+	ProductIDs    []int        `json:"product_ids" doc:"До 500 товаров; нужна категория или хотя бы один товар"`
+}
+
+// TaxRateRequest — ставка налога в админке; одна ставка на пару категория — регион
+type TaxRateRequest struct {
+	Name      string       `json:"name" validate:"required,max=100"`
+	Rate      money.Amount `json:"rate" validate:"gte=0,lte=100" doc:"Ставка в процентах"`
+	Category  string       `json:"category" validate:"max=50"`
+	Region    string       `json:"region" validate:"max=100"`
+	Inclusive bool         `json:"inclusive"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=customer seller admin"`
+}
+
+// ProductForm — поля формы создания и редактирования товара.
+// Числа остаются строками формы и разбираются после проверки.
+type ProductForm struct {
+	Name        string `form:"name" validate:"required,max=255"`
+	Description string `form:"description"`
+	Price       string `form:"price" validate:"required,number,gt=0,lte=99999999.99"`
+	Stock       string `form:"stock" validate:"required,integer,gte=0"`
+	Weight      string `form:"weight" validate:"integer,gte=0,lte=1000000"` // граммы; пустое при правке — не менять
+	Image       string `form:"image" validate:"max=255"`                    // имя файла из скрытого поля
+	Category    string `form:"category" validate:"max=50"`                  // пустое при правке — не менять
+	Attributes  string `form:"attributes"`                                  // JSON-объект; пустое при правке — не менять
+}
+
+// AnalyticsFilter задает период [From, To) и продавца; SellerID = 0 — весь магазин
+type AnalyticsFilter struct {
+	SellerID int
+	From     time.Time
+	To       time.Time
+	Top      int
+}
+
+type AnalyticsSummary struct {
+	Revenue        money.Amount `json:"revenue"`
+	UnitsSold      int          `json:"units_sold"`
+	Orders         int          `json:"orders"`
+	CartAdds       int          `json:"cart_adds"`
+	Purchases      int          `json:"purchases"`
+	ConversionRate float64      `json:"conversion_rate"`
+}
+
+type AnalyticsTopProduct struct {
+	ProductID int          `json:"product_id"`
+	Name      string       `json:"name"`
+	UnitsSold int          `json:"units_sold"`
+	Revenue   money.Amount `json:"revenue"`
+}
+
+type AnalyticsDay struct {
+	Date      string       `json:"date"`
+	Revenue   money.Amount `json:"revenue"`
+	UnitsSold int          `json:"units_sold"`
+	Orders    int          `json:"orders"`
+}
+
+type AnalyticsReport struct {
+	From        string                `json:"from"`
+	To          string                `json:"to"`
+	Summary     AnalyticsSummary      `json:"summary"`
+	TopProducts []AnalyticsTopProduct `json:"top_products"`
+	Daily       []AnalyticsDay        `json:"daily"`
+}
+
+type ImportRow struct {
+	SKU         string       `json:"sku"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       money.Amount `json:"price"`
+	Stock       int          `json:"stock"`
+	Image       string       `json:"image"`
+}
+
+// ImportOutcome — результат записи одной строки импорта в БД
+type ImportOutcome struct {
+	ProductID int
+	Action    string
+	Err       error
+}
+
+type ImportRowResult struct {
+	Row       int                 `json:"row"`
+	SKU       string              `json:"sku"`
+	Action    string              `json:"action"`
+	ProductID int                 `json:"product_id,omitempty"`
+	Errors    []apperr.FieldError `json:"errors,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Committed bool              `json:"committed"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
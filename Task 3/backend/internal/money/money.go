@@ -0,0 +1,55 @@
+// Package money описывает денежные суммы и пересчет цен в другие валюты.
+// Суммы хранятся в decimal, чтобы итоги корзины и заказов не теряли копейки.
+package money
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Amount — денежная сумма. В JSON кодируется числом, в БД — как numeric.
+type Amount = decimal.Decimal
+
+func init() {
+	// Клиенты получают суммы числами, как до перехода на decimal
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
+// NullAmount — сумма, которая может быть NULL в БД
+type NullAmount = decimal.NullDecimal
+
+// Zero — нулевая сумма
+var Zero = decimal.Zero
+
+// FromInt — сумма в целых единицах валюты
+func FromInt(units int64) Amount {
+	return decimal.NewFromInt(units)
+}
+
+// MaxPrice — наибольшая цена, которая помещается в numeric(10,2)
+var MaxPrice = decimal.RequireFromString("99999999.99")
+
+// Parse разбирает сумму из формы или файла импорта и округляет до копеек.
+// Допускается запятая вместо точки.
+func Parse(s string) (Amount, error) {
+	amount, err := decimal.NewFromString(strings.Replace(strings.TrimSpace(s), ",", ".", 1))
+	if err != nil {
+		return Zero, err
+	}
+	return amount.Round(2), nil
+}
+
+// MustParse — Parse для констант и тестов; паникует на неверной строке
+func MustParse(s string) Amount {
+	amount, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// Line — стоимость позиции: цена, умноженная на количество
+func Line(price Amount, quantity int) Amount {
+	return price.Mul(decimal.NewFromInt(int64(quantity)))
+}
@@ -0,0 +1,116 @@
+package money
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"1499.99", "1499.99", true},
+		{" 1499,99 ", "1499.99", true},
+		{"0.105", "0.11", true},
+		{"10", "10", true},
+		{"abc", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("Parse(%q) error = %v", tt.in, err)
+			continue
+		}
+		if tt.ok && got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLineHasNoFloatError(t *testing.T) {
+	// 0.1 + 0.2 во float64 дает 0.30000000000000004
+	total := Zero
+	for _, price := range []string{"0.1", "0.2"} {
+		total = total.Add(Line(MustParse(price), 1))
+	}
+	total = total.Add(Line(MustParse("19.99"), 3))
+
+	if total.String() != "60.27" {
+		t.Errorf("total = %s, want 60.27", total)
+	}
+
+	data, _ := json.Marshal(struct {
+		Total Amount `json:"total"`
+	}{total})
+	if string(data) != `{"total":60.27}` {
+		t.Errorf("json = %s", data)
+	}
+}
+
+func TestConverter(t *testing.T) {
+	rates := NewStaticRates("RUB", map[string]Amount{"USD": MustParse("92.5")})
+
+	base, err := NewConverter(rates, "")
+	if err != nil || base.Currency != "RUB" || !base.Convert(MustParse("1000")).Equal(MustParse("1000")) {
+		t.Errorf("base converter %+v, %v", base, err)
+	}
+
+	usd, err := NewConverter(rates, "USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := usd.Convert(MustParse("1000")); got.String() != "10.81" {
+		t.Errorf("1000 RUB = %s USD, want 10.81", got)
+	}
+
+	if _, err := NewConverter(rates, "EUR"); err != ErrUnknownCurrency {
+		t.Errorf("unknown currency error = %v", err)
+	}
+}
+
+func TestLoadStaticRates(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "rates.yaml")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	rates, err := LoadStaticRates(write("base: RUB\nrates:\n  USD: 92.50\n  EUR: \"100.1\"\n"), "RUB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usd, _ := rates.Rate("USD"); usd.String() != "92.5" {
+		t.Errorf("USD rate = %s", usd)
+	}
+	if len(rates.Rates()) != 3 {
+		t.Errorf("rates %v, want RUB, USD and EUR", rates.Rates())
+	}
+
+	failures := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"other base", "base: USD\nrates: {}\n", "базовая валюта"},
+		{"bad code", "base: RUB\nrates:\n  usd: 92\n", "код валюты"},
+		{"zero rate", "base: RUB\nrates:\n  USD: 0\n", "неверный курс"},
+		{"not a number", "base: RUB\nrates:\n  USD: много\n", "неверный курс"},
+	}
+	for _, tt := range failures {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadStaticRates(write(tt.content), "RUB")
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error = %v, want %q", err, tt.message)
+			}
+		})
+	}
+}
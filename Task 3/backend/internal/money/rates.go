@@ -0,0 +1,134 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownCurrency возвращается, если курс валюты не задан
+var ErrUnknownCurrency = errors.New("money: неизвестная валюта")
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCurrency проверяет формат кода валюты ISO 4217 (три заглавные буквы)
+func ValidCurrency(code string) bool {
+	return currencyPattern.MatchString(code)
+}
+
+// ExchangeRateProvider сообщает курсы валют к базовой валюте магазина.
+// Цены хранятся в базовой валюте и пересчитываются только при выдаче.
+type ExchangeRateProvider interface {
+	Base() string
+	// Rate — сколько базовой валюты стоит единица currency; у базовой валюты курс 1
+	Rate(currency string) (Amount, error)
+	// Rates — курсы всех доступных валют, включая базовую
+	Rates() map[string]Amount
+}
+
+// Converter пересчитывает суммы из базовой валюты в одну выбранную
+type Converter struct {
+	Currency string
+	rate     Amount
+}
+
+// NewConverter готовит пересчет в currency; пустая строка — базовая валюта
+func NewConverter(p ExchangeRateProvider, currency string) (Converter, error) {
+	if currency == "" || currency == p.Base() {
+		return Converter{Currency: p.Base()}, nil
+	}
+
+	rate, err := p.Rate(currency)
+	if err != nil {
+		return Converter{}, err
+	}
+	return Converter{Currency: currency, rate: rate}, nil
+}
+
+// Convert переводит сумму в валюту конвертера с округлением до копеек
+func (c Converter) Convert(amount Amount) Amount {
+	if c.rate.IsZero() {
+		return amount
+	}
+	return amount.DivRound(c.rate, 2)
+}
+
+// StaticRates — курсы из файла, загруженные при старте
+type StaticRates struct {
+	base  string
+	rates map[string]Amount
+}
+
+// NewStaticRates создает набор курсов; без rates доступна только базовая валюта
+func NewStaticRates(base string, rates map[string]Amount) *StaticRates {
+	all := map[string]Amount{base: FromInt(1)}
+	for code, rate := range rates {
+		all[code] = rate
+	}
+	return &StaticRates{base: base, rates: all}
+}
+
+// ratesFile — формат файла курсов:
+//
+//	base: RUB
+//	rates:
+//	  USD: 92.50
+type ratesFile struct {
+	Base  string            `yaml:"base"`
+	Rates map[string]string `yaml:"rates"`
+}
+
+// LoadStaticRates читает курсы из YAML-файла. Базовая валюта файла должна
+// совпадать с валютой магазина, иначе все цены пересчитались бы неверно.
+func LoadStaticRates(path, base string) (*StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("чтение файла курсов: %w", err)
+	}
+
+	var file ratesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("разбор файла курсов %s: %w", path, err)
+	}
+	if file.Base != base {
+		return nil, fmt.Errorf("файл курсов %s: базовая валюта %q, а у магазина %q", path, file.Base, base)
+	}
+
+	rates := make(map[string]Amount, len(file.Rates))
+	for code, value := range file.Rates {
+		if !ValidCurrency(code) {
+			return nil, fmt.Errorf("файл курсов %s: неверный код валюты %q", path, code)
+		}
+		rate, err := decimal.NewFromString(value)
+		if err != nil || !rate.IsPositive() {
+			return nil, fmt.Errorf("файл курсов %s: неверный курс %s %q", path, code, value)
+		}
+		rates[code] = rate
+	}
+
+	return NewStaticRates(base, rates), nil
+}
+
+func (r *StaticRates) Base() string {
+	return r.base
+}
+
+func (r *StaticRates) Rate(currency string) (Amount, error) {
+	rate, ok := r.rates[currency]
+	if !ok {
+		return Zero, ErrUnknownCurrency
+	}
+	return rate, nil
+}
+
+func (r *StaticRates) Rates() map[string]Amount {
+	rates := make(map[string]Amount, len(r.rates))
+	for code, rate := range r.rates {
+		rates[code] = rate
+	}
+	return rates
+}
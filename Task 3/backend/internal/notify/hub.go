@@ -0,0 +1,106 @@
+// Package notify рассылает уведомления открытым потокам пользователей.
+// Hub живет в памяти процесса: уведомления сохраняются во входящих до публикации,
+// поэтому поток, который что-то пропустил, дочитывает их из базы.
+package notify
+
+import (
+	"sync"
+
+	"catpc-backend/internal/models"
+)
+
+// Hub — подписки пользователей на их уведомления. У одного пользователя
+// может быть несколько подписок, например по вкладке браузера на каждую.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[int]map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// Subscription — подписка одного потока. Канал C закрывается, когда подписчик
+// отстал и его буфер переполнен, при Close или при остановке Hub.
+type Subscription struct {
+	C      <-chan models.Notification
+	c      chan models.Notification
+	userID int
+	hub    *Hub
+}
+
+// NewHub создает Hub, в котором у каждой подписки буфер на buffer уведомлений
+func NewHub(buffer int) *Hub {
+	return &Hub{subs: map[int]map[*Subscription]struct{}{}, buffer: buffer}
+}
+
+// Subscribe подписывает поток на уведомления пользователя. После остановки Hub
+// возвращается уже закрытая подписка.
+func (h *Hub) Subscribe(userID int) *Subscription {
+	c := make(chan models.Notification, h.buffer)
+	sub := &Subscription{C: c, c: c, userID: userID, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(c)
+		return sub
+	}
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[*Subscription]struct{}{}
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// Publish отправляет уведомление всем подпискам получателя и не ждет их.
+// Отставший подписчик отключается: переподключившись, он дочитает пропущенное.
+func (h *Hub) Publish(n models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs[n.UserID] {
+		select {
+		case sub.c <- n:
+		default:
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Subscribers возвращает число открытых подписок пользователя
+func (h *Hub) Subscribers(userID int) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs[userID])
+}
+
+// Close отключает все подписки; новые подписки сразу закрыты
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for sub := range subs {
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Close отписывает поток; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	subs := h.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.userID)
+	}
+	close(sub.c)
+}
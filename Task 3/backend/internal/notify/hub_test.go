@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"testing"
+
+	"catpc-backend/internal/models"
+)
+
+func TestPublish(t *testing.T) {
+	hub := NewHub(4)
+	first, second := hub.Subscribe(1), hub.Subscribe(1)
+	other := hub.Subscribe(2)
+
+	hub.Publish(models.Notification{ID: 10, UserID: 1})
+	for i, sub := range []*Subscription{first, second} {
+		if n := <-sub.C; n.ID != 10 {
+			t.Errorf("subscription %d got %d", i, n.ID)
+		}
+	}
+	select {
+	case n := <-other.C:
+		t.Errorf("other user got %d", n.ID)
+	default:
+	}
+
+	first.Close()
+	first.Close()
+	if _, ok := <-first.C; ok {
+		t.Error("closed subscription is open")
+	}
+	if got := hub.Subscribers(1); got != 1 {
+		t.Errorf("subscribers %d, want 1", got)
+	}
+}
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	hub := NewHub(2)
+	slow := hub.Subscribe(1)
+	for id := 1; id <= 3; id++ {
+		hub.Publish(models.Notification{ID: id, UserID: 1})
+	}
+
+	// Поместившиеся уведомления дочитываются, затем канал закрыт
+	var got []int
+	for n := range slow.C {
+		got = append(got, n.ID)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("got %v", got)
+	}
+	if hub.Subscribers(1) != 0 {
+		t.Error("slow subscriber kept")
+	}
+}
+
+func TestClose(t *testing.T) {
+	hub := NewHub(1)
+	sub := hub.Subscribe(1)
+	hub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription open after Close")
+	}
+	if _, ok := <-hub.Subscribe(1).C; ok {
+		t.Error("subscribed after Close")
+	}
+	hub.Publish(models.Notification{UserID: 1})
+	sub.Close()
+}
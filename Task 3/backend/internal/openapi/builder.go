@@ -0,0 +1,397 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Route описывает одну операцию API. Путь записывается в формате echo (/products/:id),
+// параметры пути считаются целыми числами.
+type Route struct {
+	Method    string
+	Path      string
+	ID        string
+	Summary   string
+	Tag       string
+	Auth      bool
+	Query     []Param
+	Body      *Body
+	Responses []Reply
+	// StreamToken — operationId, который выдает токен для подключения к потоку событий
+	StreamToken string
+}
+
+type Param struct {
+	Name        string
+	In          string // query (по умолчанию) или header
+	Type        string // integer, number, string, boolean
+	Description string
+	Enum        []interface{}
+}
+
+// Body — тело запроса: по типу Go для каждого вида содержимого
+type Body struct {
+	Content map[string]*Schema
+}
+
+// Reply — ответ со статусом; несколько типов дают oneOf
+type Reply struct {
+	Status      int
+	ContentType string
+	Types       []interface{}
+	Schema      *Schema
+}
+
+// JSON описывает ответ application/json одним или несколькими типами Go
+func JSON(status int, types ...interface{}) Reply {
+	return Reply{Status: status, ContentType: "application/json", Types: types}
+}
+
+// Empty описывает ответ без тела, например 304 Not Modified
+func Empty(status int) Reply {
+	return Reply{Status: status}
+}
+
+// Raw описывает ответ с произвольной схемой и типом содержимого
+func Raw(status int, contentType string, schema *Schema) Reply {
+	return Reply{Status: status, ContentType: contentType, Schema: schema}
+}
+
+type Builder struct {
+	doc   *Document
+	types map[string]reflect.Type
+	// scalars — типы со своей JSON-кодировкой, которые нельзя описать по полям
+	scalars map[reflect.Type]Schema
+	// errorType — тип тела ответа с ошибкой для статусов из Errors
+	errorType interface{}
+}
+
+func NewBuilder(info Info, errorType interface{}) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   map[string]PathItem{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{
+					"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				},
+			},
+		},
+		types:     map[string]reflect.Type{},
+		scalars:   map[reflect.Type]Schema{},
+		errorType: errorType,
+	}
+}
+
+// Scalar задает схему типа, который кодируется в JSON не по своим полям
+// (например, десятичная сумма, которая отдается числом)
+func (b *Builder) Scalar(v interface{}, schema Schema) {
+	b.scalars[reflect.TypeOf(v)] = schema
+}
+
+// Document возвращает собранный документ
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// JSONBody — тело запроса application/json
+func (b *Builder) JSONBody(v interface{}) *Body {
+	return &Body{Content: map[string]*Schema{"application/json": b.Schema(v)}}
+}
+
+// FormBody — тело multipart/form-data и application/x-www-form-urlencoded по тегам form;
+// files добавляет поля с файлами, которые есть только в multipart
+func (b *Builder) FormBody(v interface{}, files ...string) *Body {
+	form := b.inlineStruct(reflect.TypeOf(v), "form")
+	multipart := &Schema{Type: "object", Properties: map[string]*Schema{}, Required: form.Required}
+	for name, prop := range form.Properties {
+		multipart.Properties[name] = prop
+	}
+	for _, file := range files {
+		multipart.Properties[file] = &Schema{Type: "string", Format: "binary"}
+	}
+
+	return &Body{Content: map[string]*Schema{
+		"multipart/form-data":               multipart,
+		"application/x-www-form-urlencoded": form,
+	}}
+}
+
+// Add регистрирует операцию; повторная регистрация пути и метода — ошибка описания
+func (b *Builder) Add(r Route) {
+	path := EchoPathToOpenAPI(r.Path)
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = PathItem{}
+		b.doc.Paths[path] = item
+	}
+
+	method := strings.ToLower(r.Method)
+	if _, exists := item[method]; exists {
+		panic(fmt.Sprintf("openapi: операция %s %s описана дважды", r.Method, r.Path))
+	}
+
+	op := &Operation{
+		OperationID: r.ID,
+		Summary:     r.Summary,
+		Responses:   map[string]*Response{},
+		StreamToken: r.StreamToken,
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Auth {
+		op.Security = []SecurityRequirement{{"bearerAuth": {}}}
+	}
+
+	for _, segment := range strings.Split(r.Path, "/") {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: name, In: "path", Required: true, Schema: &Schema{Type: "integer"},
+			})
+		}
+	}
+	for _, q := range r.Query {
+		in := q.In
+		if in == "" {
+			in = "query"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name: q.Name, In: in, Description: q.Description,
+			Schema: &Schema{Type: q.Type, Enum: q.Enum},
+		})
+	}
+
+	if r.Body != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+		for contentType, schema := range r.Body.Content {
+			op.RequestBody.Content[contentType] = MediaType{Schema: schema}
+		}
+	}
+
+	for _, reply := range r.Responses {
+		b.addResponse(op, reply)
+	}
+
+	item[method] = op
+}
+
+// Errors добавляет ответы с ошибкой для перечисленных статусов
+func (b *Builder) Errors(statuses ...int) []Reply {
+	replies := make([]Reply, len(statuses))
+	for i, status := range statuses {
+		replies[i] = JSON(status, b.errorType)
+	}
+	return replies
+}
+
+func (b *Builder) addResponse(op *Operation, reply Reply) {
+	key := strconv.Itoa(reply.Status)
+	resp, ok := op.Responses[key]
+	if !ok {
+		resp = &Response{Description: http.StatusText(reply.Status)}
+		op.Responses[key] = resp
+	}
+	if reply.ContentType == "" {
+		return
+	}
+
+	schema := reply.Schema
+	if schema == nil {
+		var variants []*Schema
+		for _, t := range reply.Types {
+			variants = append(variants, b.Schema(t))
+		}
+		schema = variants[0]
+		if len(variants) > 1 {
+			schema = &Schema{OneOf: variants}
+		}
+	}
+
+	if resp.Content == nil {
+		resp.Content = map[string]MediaType{}
+	}
+	if existing, ok := resp.Content[reply.ContentType]; ok {
+		if existing.Schema.Ref != "" && existing.Schema.Ref == schema.Ref {
+			return
+		}
+		// Тот же статус у разных вариантов ответа (например, два формата данных)
+		existing.Schema = &Schema{OneOf: append(oneOfVariants(existing.Schema), schema)}
+		resp.Content[reply.ContentType] = existing
+		return
+	}
+	resp.Content[reply.ContentType] = MediaType{Schema: schema}
+}
+
+func oneOfVariants(s *Schema) []*Schema {
+	if len(s.OneOf) > 0 && s.Ref == "" && s.Type == "" {
+		return s.OneOf
+	}
+	return []*Schema{s}
+}
+
+// Schema строит схему для значения Go; именованные структуры становятся компонентами
+func (b *Builder) Schema(v interface{}) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return b.schemaOf(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *Builder) schemaOf(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if schema, ok := b.scalars[t]; ok {
+		return &schema
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		inner := b.schemaOf(t.Elem())
+		if inner.Ref != "" {
+			return &Schema{Nullable: true, AllOf: []*Schema{inner}}
+		}
+		inner.Nullable = true
+		return inner
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// Пустой срез nil кодируется как null
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.inlineStruct(t, "json")
+		}
+		return b.component(t)
+	}
+
+	panic(fmt.Sprintf("openapi: тип %s не поддерживается", t))
+}
+
+func (b *Builder) component(t reflect.Type) *Schema {
+	name := t.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if existing, ok := b.types[name]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: имя схемы %s у типов %s и %s", name, existing, t))
+		}
+		return ref
+	}
+
+	// Регистрируем до обхода полей, чтобы поддержать рекурсивные типы
+	b.types[name] = t
+	b.doc.Components.Schemas[name] = &Schema{}
+	*b.doc.Components.Schemas[name] = *b.inlineStruct(t, "json")
+	return ref
+}
+
+// inlineStruct описывает поля структуры по тегу json или form
+func (b *Builder) inlineStruct(t reflect.Type, tagKey string) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	b.collectFields(t, tagKey, s)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (b *Builder) collectFields(t reflect.Type, tagKey string, s *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get(tagKey)
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.collectFields(ft, tagKey, s)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			if tagKey != "json" {
+				continue
+			}
+			name = f.Name
+		}
+
+		prop := b.schemaOf(f.Type)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			prop = withDescription(prop, doc)
+		}
+		s.Properties[name] = prop
+
+		optional := strings.Contains(opts, "omitempty")
+		if tagKey != "json" {
+			optional = !strings.Contains(f.Tag.Get("validate"), "required")
+		}
+		if !optional {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func withDescription(s *Schema, description string) *Schema {
+	if s.Ref != "" {
+		return &Schema{AllOf: []*Schema{s}, Description: description}
+	}
+	c := *s
+	c.Description = description
+	return &c
+}
+
+// EchoPathToOpenAPI переводит /products/:id в /products/{id}
+func EchoPathToOpenAPI(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// OpenAPIPathToEcho — обратное преобразование для сверки с маршрутами echo
+func OpenAPIPathToEcho(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + segment[1:len(segment)-1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,414 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// JSClient генерирует модуль для фронтенда: JSDoc-типы из components.schemas
+// и по функции на каждую операцию поверх экземпляра axios из ./api.
+// Функции возвращают ответ axios, тело ответа — в поле data; функции потоков
+// событий открывают EventSource и возвращают функцию, которая его закрывает.
+func (d *Document) JSClient(command string) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Код сгенерирован командой `%s` по спецификации OpenAPI.\n", command)
+	buf.WriteString("// Не редактируйте вручную: изменения вносятся в ответы обработчиков.\n")
+	buf.WriteString("import api from './api'\n")
+
+	ops := d.sortedOperations()
+	for _, ref := range ops {
+		if eventStream(ref.op) {
+			buf.WriteString(eventSourceJS)
+			break
+		}
+	}
+
+	names := make([]string, 0, len(d.Components.Schemas))
+	for name := range d.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		d.writeTypedef(&buf, name, d.Components.Schemas[name])
+	}
+
+	for _, op := range ops {
+		d.writeFunction(&buf, op)
+	}
+
+	return buf.Bytes()
+}
+
+func (d *Document) writeTypedef(buf *bytes.Buffer, name string, s *Schema) {
+	buf.WriteString("\n/**\n")
+	if s.Type != "object" || s.Properties == nil {
+		fmt.Fprintf(buf, " * @typedef {%s} %s\n */\n", jsType(s), name)
+		return
+	}
+
+	fmt.Fprintf(buf, " * @typedef {Object} %s\n", name)
+	for _, prop := range sortedKeys(s.Properties) {
+		field := prop
+		if !contains(s.Required, prop) {
+			field = "[" + prop + "]"
+		}
+		line := fmt.Sprintf(" * @property {%s} %s", jsType(s.Properties[prop]), field)
+		if description := s.Properties[prop].Description; description != "" {
+			line += " - " + description
+		}
+		buf.WriteString(line + "\n")
+	}
+	buf.WriteString(" */\n")
+}
+
+type operationRef struct {
+	method string
+	path   string
+	op     *Operation
+}
+
+func (d *Document) sortedOperations() []operationRef {
+	var ops []operationRef
+	for path, item := range d.Paths {
+		for method, op := range item {
+			ops = append(ops, operationRef{method, path, op})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].op.OperationID < ops[j].op.OperationID
+	})
+	return ops
+}
+
+func (d *Document) writeFunction(buf *bytes.Buffer, ref operationRef) {
+	op := ref.op
+
+	var args, docs []string
+	var hasQuery bool
+	for _, p := range op.Parameters {
+		switch {
+		case p.In == "path":
+			args = append(args, p.Name)
+			docs = append(docs, fmt.Sprintf(" * @param {%s} %s", jsType(p.Schema), p.Name))
+		// Токен потока подставляет eventSource
+		case p.In == "query" && !(eventStream(op) && p.Name == "token"):
+			hasQuery = true
+		}
+	}
+
+	if eventStream(op) {
+		d.writeEventStream(buf, ref, args, docs, hasQuery)
+		return
+	}
+
+	var body string
+	if op.RequestBody != nil {
+		args = append(args, "body")
+		body = ", body"
+		docs = append(docs, fmt.Sprintf(" * @param {%s} body", bodyType(op.RequestBody)))
+	}
+
+	var config string
+	if hasQuery {
+		args = append(args, "params = {}")
+		config = ", { params }"
+		var fields []string
+		for _, p := range op.Parameters {
+			if p.In == "query" {
+				fields = append(fields, fmt.Sprintf("%s?: %s", p.Name, jsType(p.Schema)))
+			}
+		}
+		docs = append(docs, fmt.Sprintf(" * @param {{%s}} [params]", strings.Join(fields, ", ")))
+	}
+	// Файлы (например, PDF) читаются как Blob, иначе axios испортит их, декодируя как текст
+	if binaryResponse(op) {
+		if config == "" {
+			config = ", { responseType: 'blob' }"
+		} else {
+			config = ", { params, responseType: 'blob' }"
+		}
+	}
+	if body == "" && config != "" && (ref.method == "post" || ref.method == "put") {
+		body = ", undefined"
+	}
+	// У axios.delete нет аргумента для тела: оно передается в поле data настроек
+	if body != "" && ref.method == "delete" {
+		body = ""
+		if hasQuery {
+			config = ", { params, data: body }"
+		} else {
+			config = ", { data: body }"
+		}
+	}
+
+	buf.WriteString("\n/**\n")
+	if op.Summary != "" {
+		fmt.Fprintf(buf, " * %s\n", op.Summary)
+	}
+	for _, line := range docs {
+		buf.WriteString(line + "\n")
+	}
+	fmt.Fprintf(buf, " * @returns {Promise<import('axios').AxiosResponse<%s>>}\n */\n", successType(op))
+
+	url := "'" + ref.path + "'"
+	if strings.Contains(ref.path, "{") {
+		url = "`" + strings.ReplaceAll(ref.path, "{", "${") + "`"
+	}
+
+	fmt.Fprintf(buf, "export function %s(%s) {\n", op.OperationID, strings.Join(args, ", "))
+	fmt.Fprintf(buf, "  return api.%s(%s%s%s)\n}\n", ref.method, url, body, config)
+}
+
+// writeEventStream пишет функцию потока Server-Sent Events: axios не отдает ответ,
+// пока поток не закрыт, поэтому поток читает EventSource через eventSource
+func (d *Document) writeEventStream(buf *bytes.Buffer, ref operationRef, args, docs []string, hasQuery bool) {
+	op := ref.op
+
+	args = append(args, "handlers")
+	docs = append(docs, " * @param {Object<string, function(*, MessageEvent): void>} handlers - обработчики по типу события, получают разобранный JSON")
+	params := "{}"
+	if hasQuery {
+		args = append(args, "params = {}")
+		params = "params"
+		var fields []string
+		for _, p := range op.Parameters {
+			if p.In == "query" && p.Name != "token" {
+				fields = append(fields, fmt.Sprintf("%s?: %s", p.Name, jsType(p.Schema)))
+			}
+		}
+		docs = append(docs, fmt.Sprintf(" * @param {{%s}} [params]", strings.Join(fields, ", ")))
+	}
+	issueToken := "null"
+	if op.StreamToken != "" {
+		issueToken = op.StreamToken
+	}
+
+	buf.WriteString("\n/**\n")
+	if op.Summary != "" {
+		fmt.Fprintf(buf, " * %s\n", op.Summary)
+	}
+	for _, line := range docs {
+		buf.WriteString(line + "\n")
+	}
+	buf.WriteString(" * @returns {function(): void} закрывает поток\n */\n")
+
+	url := "'" + ref.path + "'"
+	if strings.Contains(ref.path, "{") {
+		url = "`" + strings.ReplaceAll(ref.path, "{", "${") + "`"
+	}
+
+	fmt.Fprintf(buf, "export function %s(%s) {\n", op.OperationID, strings.Join(args, ", "))
+	fmt.Fprintf(buf, "  return eventSource(%s, %s, %s, handlers)\n}\n", url, params, issueToken)
+}
+
+// eventSourceJS — общая часть функций потоков. EventSource не передает заголовок
+// Authorization, поэтому перед каждым подключением запрашивается короткий токен
+// для параметра token. Собственное переподключение EventSource пошло бы по URL
+// с истекшим токеном, поэтому при обрыве поток открывается заново с новым токеном
+// и id последнего полученного события.
+const eventSourceJS = `
+const streamRetryDelay = 5000
+
+// eventSource открывает поток событий и возвращает функцию, которая его закрывает
+function eventSource(path, params, issueToken, handlers) {
+  let source = null
+  let timer = null
+  let closed = false
+  let lastEventId = params.last_event_id
+
+  const retry = () => {
+    source?.close()
+    if (!closed) {
+      timer = setTimeout(open, streamRetryDelay)
+    }
+  }
+  const open = async () => {
+    const query = new URLSearchParams(params)
+    if (issueToken) {
+      try {
+        const { data } = await issueToken()
+        query.set('token', data.data.token)
+      } catch (error) {
+        retry()
+        return
+      }
+    }
+    if (closed) {
+      return
+    }
+    if (lastEventId) {
+      query.set('last_event_id', lastEventId)
+    }
+
+    source = new EventSource(` + "`${api.defaults.baseURL}${path}?${query}`" + `)
+    for (const [type, handle] of Object.entries(handlers)) {
+      source.addEventListener(type, (event) => {
+        lastEventId = event.lastEventId || lastEventId
+        handle(JSON.parse(event.data), event)
+      })
+    }
+    source.onerror = retry
+  }
+
+  open()
+  return () => {
+    closed = true
+    clearTimeout(timer)
+    source?.close()
+  }
+}
+`
+
+// bodyType перечисляет, чем можно передать тело запроса в axios
+func bodyType(body *RequestBody) string {
+	var types []string
+	for _, contentType := range sortedKeys(body.Content) {
+		switch contentType {
+		case "application/json":
+			types = append(types, jsBaseType(body.Content[contentType].Schema))
+		case "multipart/form-data":
+			types = append(types, "FormData")
+		case "application/x-www-form-urlencoded":
+			types = append(types, "URLSearchParams")
+		default:
+			types = append(types, "string")
+		}
+	}
+	return strings.Join(types, "|")
+}
+
+// successType — тип тела первого успешного ответа
+func successType(op *Operation) string {
+	for _, status := range sortedKeys(op.Responses) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+
+		var types []string
+		for _, contentType := range sortedKeys(op.Responses[status].Content) {
+			schema := op.Responses[status].Content[contentType].Schema
+			if contentType == "application/json" {
+				types = append(types, jsType(schema))
+			} else if schema != nil && schema.Format == "binary" {
+				types = append(types, "Blob")
+			} else {
+				types = append(types, "string")
+			}
+		}
+		if len(types) > 0 {
+			return strings.Join(types, "|")
+		}
+	}
+	return "*"
+}
+
+// binaryResponse сообщает, что успешный ответ — файл, а не JSON или текст
+func binaryResponse(op *Operation) bool {
+	for status, resp := range op.Responses {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		for _, media := range resp.Content {
+			if media.Schema != nil && media.Schema.Format == "binary" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// eventStream сообщает, что успешный ответ — поток Server-Sent Events
+func eventStream(op *Operation) bool {
+	for status, resp := range op.Responses {
+		if _, ok := resp.Content["text/event-stream"]; ok && strings.HasPrefix(status, "2") {
+			return true
+		}
+	}
+	return false
+}
+
+func jsType(s *Schema) string {
+	t := jsBaseType(s)
+	if s.Nullable {
+		return "(" + t + "|null)"
+	}
+	return t
+}
+
+func jsBaseType(s *Schema) string {
+	switch {
+	case s.Ref != "":
+		return s.RefName()
+	case len(s.OneOf) > 0:
+		return union(s.OneOf)
+	case len(s.AllOf) == 1:
+		return jsType(s.AllOf[0])
+	case len(s.Enum) > 0:
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = fmt.Sprintf("%q", fmt.Sprint(v))
+		}
+		return strings.Join(values, "|")
+	}
+
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if s.Items == nil {
+			return "Array<*>"
+		}
+		return "Array<" + jsType(s.Items) + ">"
+	case "object":
+		if extra, ok := s.AdditionalProperties.(*Schema); ok {
+			return "Object<string, " + jsType(extra) + ">"
+		}
+		if len(s.Properties) == 0 {
+			return "Object"
+		}
+		fields := make([]string, 0, len(s.Properties))
+		for _, name := range sortedKeys(s.Properties) {
+			optional := ""
+			if !contains(s.Required, name) {
+				optional = "?"
+			}
+			fields = append(fields, fmt.Sprintf("%s%s: %s", name, optional, jsType(s.Properties[name])))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return "*"
+}
+
+func union(schemas []*Schema) string {
+	types := make([]string, len(schemas))
+	for i, s := range schemas {
+		types[i] = jsType(s)
+	}
+	return "(" + strings.Join(types, "|") + ")"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+)
+
+type item struct {
+	ID    int     `json:"id"`
+	Price float64 `json:"price"`
+}
+
+type sample struct {
+	Name    string            `json:"name"`
+	Note    string            `json:"note,omitempty"`
+	Items   []item            `json:"items"`
+	Parent  *item             `json:"parent"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	Created time.Time         `json:"created"`
+	Secret  string            `json:"-"`
+}
+
+func TestValidate(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"}, struct{}{})
+	schema := b.Schema(sample{})
+	doc := b.Document()
+
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{"full", `{"name":"a","note":"b","items":[{"id":1,"price":2.5}],"parent":{"id":2,"price":1},"tags":{"k":"v"},"created":"2024-01-01T00:00:00Z"}`, true},
+		{"optional and null omitted", `{"name":"a","items":null,"parent":null,"created":"2024-01-01T00:00:00Z"}`, true},
+		{"missing required", `{"name":"a","items":[],"created":"2024-01-01T00:00:00Z"}`, false},
+		{"unknown field", `{"name":"a","items":[],"parent":null,"created":"","Secret":"x"}`, false},
+		{"fractional integer", `{"name":"a","items":[{"id":1.5,"price":1}],"parent":null,"created":""}`, false},
+		{"wrong map value", `{"name":"a","items":[],"parent":null,"created":"","tags":{"k":1}}`, false},
+		{"null not allowed", `{"name":null,"items":[],"parent":null,"created":""}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := doc.Validate(schema, []byte(tt.body))
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	type first struct {
+		A int `json:"a"`
+	}
+	type second struct {
+		B int `json:"b"`
+	}
+
+	b := NewBuilder(Info{}, struct{}{})
+	b.Add(Route{Method: "GET", Path: "/things/:id", ID: "getThing", Responses: []Reply{JSON(200, first{}, second{})}})
+	doc := b.Document()
+
+	op := doc.Operation("GET", "/things/{id}")
+	if op == nil || len(op.Parameters) != 1 || op.Parameters[0].In != "path" {
+		t.Fatalf("operation %+v", op)
+	}
+
+	schema, ok := op.ResponseSchema(200, "application/json")
+	if !ok {
+		t.Fatal("no response schema")
+	}
+	for _, body := range []string{`{"a":1}`, `{"b":1}`} {
+		if err := doc.Validate(schema, []byte(body)); err != nil {
+			t.Errorf("%s: %v", body, err)
+		}
+	}
+	if err := doc.Validate(schema, []byte(`{"a":1,"b":1}`)); err == nil {
+		t.Error("body matching no variant accepted")
+	}
+}
@@ -0,0 +1,187 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Validate проверяет JSON-значение по схеме документа. Поддерживается то же
+// подмножество OpenAPI, которое порождает Builder: $ref, allOf, oneOf, nullable,
+// типы, обязательные и лишние свойства, enum.
+func (d *Document) Validate(schema *Schema, data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("неверный JSON: %w", err)
+	}
+	return d.validate(schema, value, "$")
+}
+
+// Operation находит операцию по методу и пути в формате OpenAPI
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+// ResponseSchema возвращает схему ответа операции для статуса и типа содержимого
+func (op *Operation) ResponseSchema(status int, contentType string) (*Schema, bool) {
+	resp, ok := op.Responses[fmt.Sprint(status)]
+	if !ok {
+		return nil, false
+	}
+	media, ok := resp.Content[contentType]
+	return media.Schema, ok
+}
+
+func (d *Document) resolve(s *Schema) (*Schema, error) {
+	for s.Ref != "" {
+		target, ok := d.Components.Schemas[s.RefName()]
+		if !ok {
+			return nil, fmt.Errorf("нет схемы %s", s.Ref)
+		}
+		s = target
+	}
+	return s, nil
+}
+
+func (d *Document) validate(s *Schema, value interface{}, path string) error {
+	s, err := d.resolve(s)
+	if err != nil {
+		return err
+	}
+
+	if value == nil {
+		if s.Nullable || isAny(s) {
+			return nil
+		}
+		return fmt.Errorf("%s: null не допускается", path)
+	}
+
+	for _, part := range s.AllOf {
+		if err := d.validate(part, value, path); err != nil {
+			return err
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		var matched int
+		var errs []string
+		for _, variant := range s.OneOf {
+			if err := d.validate(variant, value, path); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			matched++
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: подходит вариантов oneOf: %d (%s)", path, matched, strings.Join(errs, "; "))
+		}
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return fmt.Errorf("%s: значение %v не из %v", path, value, s.Enum)
+	}
+
+	switch s.Type {
+	case "":
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return typeError(path, s.Type, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(path, s.Type, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return typeError(path, s.Type, value)
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return typeError(path, s.Type, value)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return typeError(path, s.Type, value)
+		}
+		return d.validateObject(s, object, path)
+	default:
+		return fmt.Errorf("%s: неизвестный тип схемы %q", path, s.Type)
+	}
+
+	return nil
+}
+
+func (d *Document) validateObject(s *Schema, object map[string]interface{}, path string) error {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Errorf("%s: нет обязательного поля %q", path, name)
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fieldPath := path + "." + name
+		if prop, ok := s.Properties[name]; ok {
+			if err := d.validate(prop, object[name], fieldPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch extra := s.AdditionalProperties.(type) {
+		case bool:
+			if !extra {
+				return fmt.Errorf("%s: поле не описано в схеме", fieldPath)
+			}
+		case *Schema:
+			if err := d.validate(extra, object[name], fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isAny(s *Schema) bool {
+	return s.Type == "" && len(s.AllOf) == 0 && len(s.OneOf) == 0 && len(s.Enum) == 0
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeError(path, want string, value interface{}) error {
+	return fmt.Errorf("%s: ожидается %s, получено %T", path, want, value)
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+
+	"catpc-backend/internal/models"
+)
+
+const addressColumns = `id, user_id, label, recipient, phone, region, city, street, postal_code, is_default, created_at`
+
+func scanAddress(row rowScanner) (*models.Address, error) {
+	var a models.Address
+	err := row.Scan(&a.ID, &a.UserID, &a.Label, &a.Recipient, &a.Phone, &a.Region, &a.City,
+		&a.Street, &a.PostalCode, &a.IsDefault, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresRepository) ListAddresses(userID int) ([]models.Address, error) {
+	rows, err := r.db.Query(`
+		SELECT `+addressColumns+`
+		FROM addresses
+		WHERE user_id = $1
+		ORDER BY is_default DESC, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []models.Address{}
+	for rows.Next() {
+		a, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, *a)
+	}
+	return addresses, rows.Err()
+}
+
+func (r *PostgresRepository) GetAddress(userID, id int) (*models.Address, error) {
+	a, err := scanAddress(r.db.QueryRow(`
+		SELECT `+addressColumns+`
+		FROM addresses
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+// clearDefaultAddress снимает отметку по умолчанию перед назначением нового адреса:
+// уникальный частичный индекс не допускает двух адресов по умолчанию
+func clearDefaultAddress(tx *sql.Tx, userID int) error {
+	_, err := tx.Exec("UPDATE addresses SET is_default = false WHERE user_id = $1 AND is_default", userID)
+	return err
+}
+
+func (r *PostgresRepository) CreateAddress(address *models.Address) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокировка пользователя упорядочивает параллельные изменения его адресной книги
+	if _, err := tx.Exec("SELECT 1 FROM users WHERE id = $1 FOR UPDATE", address.UserID); err != nil {
+		return err
+	}
+
+	var first bool
+	if err := tx.QueryRow("SELECT NOT EXISTS(SELECT 1 FROM addresses WHERE user_id = $1)", address.UserID).Scan(&first); err != nil {
+		return err
+	}
+	address.IsDefault = address.IsDefault || first
+	if address.IsDefault {
+		if err := clearDefaultAddress(tx, address.UserID); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO addresses (user_id, label, recipient, phone, region, city, street, postal_code, is_default)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, address.UserID, address.Label, address.Recipient, address.Phone, address.Region, address.City,
+		address.Street, address.PostalCode, address.IsDefault).Scan(&address.ID, &address.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) UpdateAddress(address *models.Address) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT 1 FROM users WHERE id = $1 FOR UPDATE", address.UserID); err != nil {
+		return err
+	}
+	if address.IsDefault {
+		if err := clearDefaultAddress(tx, address.UserID); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRow(`
+		UPDATE addresses
+		SET label = $1, recipient = $2, phone = $3, city = $4, street = $5, postal_code = $6,
+		    is_default = is_default OR $7, region = $10
+		WHERE id = $8 AND user_id = $9
+		RETURNING is_default, created_at
+	`, address.Label, address.Recipient, address.Phone, address.City, address.Street,
+		address.PostalCode, address.IsDefault, address.ID, address.UserID, address.Region).Scan(&address.IsDefault, &address.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) DeleteAddress(userID, id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("SELECT 1 FROM users WHERE id = $1 FOR UPDATE", userID); err != nil {
+		return err
+	}
+
+	var wasDefault bool
+	err = tx.QueryRow(`
+		DELETE FROM addresses WHERE id = $1 AND user_id = $2 RETURNING is_default
+	`, id, userID).Scan(&wasDefault)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if wasDefault {
+		_, err = tx.Exec(`
+			UPDATE addresses SET is_default = true
+			WHERE id = (SELECT id FROM addresses WHERE user_id = $1 ORDER BY id DESC LIMIT 1)
+		`, userID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
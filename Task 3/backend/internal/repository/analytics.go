@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"catpc-backend/internal/models"
+)
+
+const dateLayout = "2006-01-02"
+
+func (r *PostgresRepository) SalesReport(filter models.AnalyticsFilter) (*models.AnalyticsReport, error) {
+	from, to := filter.From, filter.To
+
+	// NULL в $3 снимает фильтр по продавцу
+	var seller interface{}
+	if filter.SellerID != 0 {
+		seller = filter.SellerID
+	}
+
+	report := &models.AnalyticsReport{
+		From:        from.Format(dateLayout),
+		To:          to.AddDate(0, 0, -1).Format(dateLayout),
+		TopProducts: []models.AnalyticsTopProduct{},
+		Daily:       []models.AnalyticsDay{},
+	}
+
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(oi.quantity * oi.price_at_time), 0),
+		       COALESCE(SUM(oi.quantity), 0),
+		       COUNT(DISTINCT o.id)
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		JOIN products p ON p.id = oi.product_id
+		WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp
+		  AND o.status <> 'cancelled'
+		  AND ($3::integer IS NULL OR p.user_id = $3::integer)
+	`, from, to, seller).Scan(&report.Summary.Revenue, &report.Summary.UnitsSold, &report.Summary.Orders)
+	if err != nil {
+		return nil, fmt.Errorf("summary: %w", err)
+	}
+
+	// Конверсия: доля пар (покупатель, товар), добавленных в корзину и затем купленных
+	err = r.db.QueryRow(`
+		WITH adds AS (
+			SELECT DISTINCT ca.user_id, ca.product_id
+			FROM cart_additions ca
+			JOIN products p ON p.id = ca.product_id
+			WHERE ca.added_at >= $1::timestamp AND ca.added_at < $2::timestamp
+			  AND ($3::integer IS NULL OR p.user_id = $3::integer)
+		),
+		purchases AS (
+			SELECT DISTINCT o.user_id, oi.product_id
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp
+			  AND o.status <> 'cancelled'
+		)
+		SELECT (SELECT COUNT(*) FROM adds),
+		       (SELECT COUNT(*) FROM adds a JOIN purchases pu
+		            ON pu.user_id = a.user_id AND pu.product_id = a.product_id)
+	`, from, to, seller).Scan(&report.Summary.CartAdds, &report.Summary.Purchases)
+	if err != nil {
+		return nil, fmt.Errorf("conversion: %w", err)
+	}
+
+	if report.Summary.CartAdds > 0 {
+		report.Summary.ConversionRate = float64(report.Summary.Purchases) / float64(report.Summary.CartAdds)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, SUM(oi.quantity), SUM(oi.quantity * oi.price_at_time) AS revenue
+		FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		JOIN products p ON p.id = oi.product_id
+		WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp
+		  AND o.status <> 'cancelled'
+		  AND ($3::integer IS NULL OR p.user_id = $3::integer)
+		GROUP BY p.id, p.name
+		ORDER BY revenue DESC, p.id
+		LIMIT $4
+	`, from, to, seller, filter.Top)
+	if err != nil {
+		return nil, fmt.Errorf("top products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p models.AnalyticsTopProduct
+		if err := rows.Scan(&p.ProductID, &p.Name, &p.UnitsSold, &p.Revenue); err != nil {
+			return nil, fmt.Errorf("top products: %w", err)
+		}
+		report.TopProducts = append(report.TopProducts, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("top products: %w", err)
+	}
+
+	dayRows, err := r.db.Query(`
+		SELECT d.day::date,
+		       COALESCE(s.revenue, 0),
+		       COALESCE(s.units, 0),
+		       COALESCE(s.orders, 0)
+		FROM generate_series($1::timestamp, $2::timestamp - interval '1 day', interval '1 day') AS d(day)
+		LEFT JOIN (
+			SELECT date_trunc('day', o.created_at) AS day,
+			       SUM(oi.quantity * oi.price_at_time) AS revenue,
+			       SUM(oi.quantity) AS units,
+			       COUNT(DISTINCT o.id) AS orders
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			JOIN products p ON p.id = oi.product_id
+			WHERE o.created_at >= $1::timestamp AND o.created_at < $2::timestamp
+			  AND o.status <> 'cancelled'
+			  AND ($3::integer IS NULL OR p.user_id = $3::integer)
+			GROUP BY 1
+		) s ON s.day = d.day
+		ORDER BY d.day
+	`, from, to, seller)
+	if err != nil {
+		return nil, fmt.Errorf("daily: %w", err)
+	}
+	defer dayRows.Close()
+
+	for dayRows.Next() {
+		var d models.AnalyticsDay
+		var day sql.NullTime
+		if err := dayRows.Scan(&day, &d.Revenue, &d.UnitsSold, &d.Orders); err != nil {
+			return nil, fmt.Errorf("daily: %w", err)
+		}
+		d.Date = day.Time.Format(dateLayout)
+		report.Daily = append(report.Daily, d)
+	}
+	if err := dayRows.Err(); err != nil {
+		return nil, fmt.Errorf("daily: %w", err)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"log/slog"
+
+	"catpc-backend/internal/models"
+)
+
+func (r *PostgresRepository) GetCartItems(userID int) ([]models.CartItem, error) {
+	rows, err := r.db.Query(`
+		SELECT ci.id, ci.product_id, p.name, p.price, ci.quantity, p.weight_grams, p.image,
+		       COALESCE(p.user_id, 0), p.category
+		FROM cart_items ci
+		JOIN products p ON ci.product_id = p.id
+		WHERE ci.user_id = $1 AND p.stock > 0 AND p.is_approved = true
+		ORDER BY ci.added_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cart []models.CartItem
+	for rows.Next() {
+		var item models.CartItem
+		err := rows.Scan(&item.ID, &item.ProductID, &item.Name, &item.Price, &item.Quantity, &item.Weight, &item.Image,
+			&item.SellerID, &item.Category)
+		if err != nil {
+			continue
+		}
+		cart = append(cart, item)
+	}
+
+	return cart, nil
+}
+
+func (r *PostgresRepository) AddCartItem(userID, productID, quantity int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO cart_items (user_id, product_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, product_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+	`, userID, productID, quantity)
+	if err != nil {
+		return err
+	}
+
+	// Фиксируем добавление для расчета конверсии в аналитике
+	if _, err := r.db.Exec(`
+		INSERT INTO cart_additions (user_id, product_id, quantity)
+		VALUES ($1, $2, $3)
+	`, userID, productID, quantity); err != nil {
+		slog.Error("Ошибка записи события корзины", "user_id", userID, "product_id", productID, "error", err)
+	}
+
+	return nil
+}
+
+func (r *PostgresRepository) UpdateCartItem(userID, itemID, quantity int) error {
+	_, err := r.db.Exec(`
+		UPDATE cart_items
+		SET quantity = $1
+		WHERE id = $2 AND user_id = $3
+	`, quantity, itemID, userID)
+	return err
+}
+
+func (r *PostgresRepository) RemoveCartItem(userID, itemID int) error {
+	_, err := r.db.Exec(`
+		DELETE FROM cart_items
+		WHERE id = $1 AND user_id = $2
+	`, itemID, userID)
+	return err
+}
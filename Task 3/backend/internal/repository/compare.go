@@ -0,0 +1,38 @@
+package repository
+
+import "catpc-backend/internal/models"
+
+func (r *PostgresRepository) ListCompareItems(owner string) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM compare_items c
+		JOIN products p ON p.id = c.product_id
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE c.owner = $1 AND p.is_approved = true
+		ORDER BY c.added_at, p.id
+	`, owner)
+}
+
+func (r *PostgresRepository) AddCompareItem(owner string, productID int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO compare_items (owner, product_id) VALUES ($1, $2)
+		ON CONFLICT (owner, product_id) DO NOTHING
+	`, owner, productID)
+	return err
+}
+
+func (r *PostgresRepository) RemoveCompareItem(owner string, productID int) error {
+	result, err := r.db.Exec("DELETE FROM compare_items WHERE owner = $1 AND product_id = $2", owner, productID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ClearCompareItems(owner string) error {
+	_, err := r.db.Exec("DELETE FROM compare_items WHERE owner = $1", owner)
+	return err
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"database/sql"
+
+	"catpc-backend/internal/models"
+)
+
+func (r *PostgresRepository) ListDeliveryMethods(activeOnly bool) ([]models.DeliveryMethod, error) {
+	rows, err := r.db.Query(`
+		SELECT id, name, kind, pricing, is_active
+		FROM delivery_methods
+		WHERE is_active OR NOT $1
+		ORDER BY id
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	methods := []models.DeliveryMethod{}
+	index := make(map[int]int)
+	for rows.Next() {
+		var m models.DeliveryMethod
+		if err := rows.Scan(&m.ID, &m.Name, &m.Kind, &m.Pricing, &m.IsActive); err != nil {
+			return nil, err
+		}
+		m.Rules = []models.DeliveryRule{}
+		index[m.ID] = len(methods)
+		methods = append(methods, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ruleRows, err := r.db.Query(`
+		SELECT dr.method_id, dr.from_value, dr.cost
+		FROM delivery_rules dr
+		JOIN delivery_methods m ON m.id = dr.method_id
+		WHERE m.is_active OR NOT $1
+		ORDER BY dr.method_id, dr.from_value
+	`, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer ruleRows.Close()
+
+	for ruleRows.Next() {
+		var methodID int
+		var rule models.DeliveryRule
+		if err := ruleRows.Scan(&methodID, &rule.From, &rule.Cost); err != nil {
+			return nil, err
+		}
+		// Способ мог появиться между двумя запросами
+		if i, ok := index[methodID]; ok {
+			methods[i].Rules = append(methods[i].Rules, rule)
+		}
+	}
+	return methods, ruleRows.Err()
+}
+
+func (r *PostgresRepository) GetDeliveryMethod(id int) (*models.DeliveryMethod, error) {
+	var m models.DeliveryMethod
+	err := r.db.QueryRow(`
+		SELECT id, name, kind, pricing, is_active FROM delivery_methods WHERE id = $1
+	`, id).Scan(&m.ID, &m.Name, &m.Kind, &m.Pricing, &m.IsActive)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT from_value, cost FROM delivery_rules WHERE method_id = $1 ORDER BY from_value
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	m.Rules = []models.DeliveryRule{}
+	for rows.Next() {
+		var rule models.DeliveryRule
+		if err := rows.Scan(&rule.From, &rule.Cost); err != nil {
+			return nil, err
+		}
+		m.Rules = append(m.Rules, rule)
+	}
+	return &m, rows.Err()
+}
+
+func insertDeliveryRules(tx *sql.Tx, methodID int, rules []models.DeliveryRule) error {
+	for _, rule := range rules {
+		if _, err := tx.Exec(`
+			INSERT INTO delivery_rules (method_id, from_value, cost) VALUES ($1, $2, $3)
+		`, methodID, rule.From, rule.Cost); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresRepository) CreateDeliveryMethod(method *models.DeliveryMethod) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO delivery_methods (name, kind, pricing, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, method.Name, method.Kind, method.Pricing, method.IsActive).Scan(&method.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertDeliveryRules(tx, method.ID, method.Rules); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) UpdateDeliveryMethod(method *models.DeliveryMethod) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE delivery_methods SET name = $1, kind = $2, pricing = $3, is_active = $4
+		WHERE id = $5
+	`, method.Name, method.Kind, method.Pricing, method.IsActive, method.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM delivery_rules WHERE method_id = $1", method.ID); err != nil {
+		return err
+	}
+	if err := insertDeliveryRules(tx, method.ID, method.Rules); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteDeliveryMethod удаляет способ доставки; оформленные заказы сохраняют его название и стоимость
+func (r *PostgresRepository) DeleteDeliveryMethod(id int) error {
+	result, err := r.db.Exec("DELETE FROM delivery_methods WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
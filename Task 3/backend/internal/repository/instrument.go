@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// QueryObserver получает тип операции (select, insert, ...) и длительность запроса
+type QueryObserver func(operation string, d time.Duration)
+
+// OpenInstrumented открывает пул соединений PostgreSQL, в котором каждый запрос —
+// и в транзакциях тоже — замеряется и передается в observe.
+func OpenInstrumented(dsn string, observe QueryObserver) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(&timedConnector{Connector: connector, observe: observe}), nil
+}
+
+type timedConnector struct {
+	driver.Connector
+	observe QueryObserver
+}
+
+func (c *timedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{conn: conn, observe: c.observe}, nil
+}
+
+// timedConn оборачивает соединение pq; остальные интерфейсы драйвера
+// пробрасываются как есть, чтобы database/sql работал без изменений.
+type timedConn struct {
+	conn    driver.Conn
+	observe QueryObserver
+}
+
+func (c *timedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.conn.Prepare(query)
+}
+
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c *timedConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *timedConn) Begin() (driver.Tx, error) {
+	return c.conn.Begin()
+}
+
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.observe(queryOperation(query), time.Since(start))
+	}
+	return rows, err
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := c.conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.observe(queryOperation(query), time.Since(start))
+	}
+	return result, err
+}
+
+func (c *timedConn) Ping(ctx context.Context) error {
+	return c.conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *timedConn) ResetSession(ctx context.Context) error {
+	return c.conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *timedConn) IsValid() bool {
+	return c.conn.(driver.Validator).IsValid()
+}
+
+// queryOperation — первое слово запроса в нижнем регистре: у метрики
+// должно быть немного значений метки, поэтому текст запроса не используется.
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with", "begin", "commit", "rollback", "savepoint", "release":
+		return op
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"database/sql"
+
+	"catpc-backend/internal/models"
+)
+
+func (r *PostgresRepository) GetInvoice(orderID int) (*models.Invoice, error) {
+	var inv models.Invoice
+	err := r.db.QueryRow(`
+		SELECT order_id, number, issued_at, pdf FROM invoices WHERE order_id = $1
+	`, orderID).Scan(&inv.OrderID, &inv.Number, &inv.IssuedAt, &inv.PDF)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *PostgresRepository) CreateInvoice(invoice *models.Invoice) (*models.Invoice, error) {
+	if _, err := r.db.Exec(`
+		INSERT INTO invoices (order_id, number, issued_at, pdf)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (order_id) DO NOTHING
+	`, invoice.OrderID, invoice.Number, invoice.IssuedAt.UTC(), invoice.PDF); err != nil {
+		return nil, err
+	}
+	return r.GetInvoice(invoice.OrderID)
+}
@@ -0,0 +1,2232 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+)
+
+// MemoryRepository хранит данные в памяти процесса. Используется в тестах
+// и повторяет поведение SQL-запросов PostgresRepository.
+type MemoryRepository struct {
+	mu sync.RWMutex
+
+	users         map[int]models.User
+	products      map[int]models.Product
+	cart          []memoryCartItem
+	cartAdditions []memoryCartAddition
+	orders        []models.Order
+	invoices      map[int]models.Invoice
+	priceHistory  []models.PriceChange
+	priceAlerts   []models.PriceAlert
+	addresses     []models.Address
+	delivery      map[int]models.DeliveryMethod
+	taxRates      []models.TaxRate
+	notifications []models.Notification
+	returns       []models.Return
+	questions     []models.Question
+	answers       []models.Answer
+	answerVotes   map[[2]int]bool
+	views         map[string][]int
+	compare       map[string][]int
+	sales         []models.Sale
+	deletedUsers  map[int]bool
+	logins        map[int][]models.LoginRecord
+	uploads       map[int]map[string]bool
+	productPairs  map[[2]int]int
+
+	nextUserID     int
+	nextProductID  int
+	nextCartID     int
+	nextPriceID    int
+	nextAlertID    int
+	nextAddressID  int
+	nextDeliveryID int
+	nextTaxRateID  int
+	nextNotifyID   int
+	nextItemID     int
+	nextReturnID   int
+	nextQuestionID int
+	nextAnswerID   int
+	nextSaleID     int
+	cartSeq        int
+}
+
+type memoryCartItem struct {
+	ID        int
+	UserID    int
+	ProductID int
+	Quantity  int
+	addedSeq  int
+}
+
+type memoryCartAddition struct {
+	UserID    int
+	ProductID int
+	AddedAt   time.Time
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		users:       make(map[int]models.User),
+		products:    make(map[int]models.Product),
+		delivery:    make(map[int]models.DeliveryMethod),
+		invoices:    make(map[int]models.Invoice),
+		answerVotes: make(map[[2]int]bool),
+		views:       make(map[string][]int),
+		compare:     make(map[string][]int),
+
+		deletedUsers: make(map[int]bool),
+		logins:       make(map[int][]models.LoginRecord),
+		uploads:      make(map[int]map[string]bool),
+	}
+}
+
+func (r *MemoryRepository) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (r *MemoryRepository) CreateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Username == user.Username || u.Email == user.Email {
+			return ErrConflict
+		}
+	}
+
+	r.nextUserID++
+	user.ID = r.nextUserID
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	if user.Role == "" {
+		user.Role = models.RoleCustomer
+	}
+	user.IsActive = true
+	r.users[user.ID] = *user
+	return nil
+}
+
+// SetUserProtected помечает пользователя защищенным (в БД это делается вручную)
+func (r *MemoryRepository) SetUserProtected(id int, protected bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id]; ok {
+		u.IsProtected = protected
+		r.users[id] = u
+	}
+}
+
+func (r *MemoryRepository) GetUserByID(id int) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *MemoryRepository) GetUserByLogin(login string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == login || u.Email == login {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) UserExists(username, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if u.Username == username || u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) ListUsers(filter models.UserFilter) ([]models.UserDetail, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query := strings.ToLower(filter.Query)
+	users := make([]models.User, 0, len(r.users))
+	for _, u := range r.users {
+		switch {
+		case query != "" && !strings.Contains(strings.ToLower(u.Username), query) && !strings.Contains(strings.ToLower(u.Email), query):
+		case filter.Role != "" && u.Role != filter.Role:
+		case filter.Active != nil && u.IsActive != *filter.Active:
+		case filter.Protected != nil && u.IsProtected != *filter.Protected:
+		default:
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].ID > users[j].ID
+		}
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+
+	total := len(users)
+	if filter.Offset >= total {
+		users = nil
+	} else {
+		users = users[filter.Offset:]
+	}
+	if len(users) > filter.Limit {
+		users = users[:filter.Limit]
+	}
+
+	details := make([]models.UserDetail, 0, len(users))
+	for _, u := range users {
+		details = append(details, models.UserDetail{
+			ID:          u.ID,
+			Username:    u.Username,
+			Email:       u.Email,
+			Role:        u.Role,
+			IsActive:    u.IsActive,
+			IsProtected: u.IsProtected,
+			CreatedAt:   u.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	return details, total, nil
+}
+
+func (r *MemoryRepository) UpdateUserRole(id int, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id]; ok {
+		u.Role = role
+		r.users[id] = u
+	}
+	return nil
+}
+
+func (r *MemoryRepository) SetUserActive(id int, active bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id]; ok {
+		u.IsActive = active
+		r.users[id] = u
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RecordLogin(userID int, client models.ClientInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logins[userID] = append(r.logins[userID], models.LoginRecord{
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (r *MemoryRepository) ListLogins(userID, limit int) ([]models.LoginRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	logins := []models.LoginRecord{}
+	all := r.logins[userID]
+	for i := len(all) - 1; i >= 0 && len(logins) < limit; i-- {
+		logins = append(logins, all[i])
+	}
+	return logins, nil
+}
+
+func (r *MemoryRepository) ToggleUserActive(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if u, ok := r.users[id]; ok {
+		u.IsActive = !u.IsActive
+		r.users[id] = u
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetUserByEmailToken(token string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, u := range r.users {
+		if token != "" && u.EmailToken == token {
+			return &u, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) UpdateUser(user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[user.ID]; !ok || r.deletedUsers[user.ID] {
+		return ErrNotFound
+	}
+	for _, u := range r.users {
+		if u.ID == user.ID {
+			continue
+		}
+		if u.Username == user.Username || u.Email == user.Email || (user.EmailToken != "" && u.EmailToken == user.EmailToken) {
+			return ErrConflict
+		}
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *MemoryRepository) DeleteUser(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok || r.deletedUsers[id] {
+		return ErrNotFound
+	}
+	u.Username = fmt.Sprintf("deleted-%d", id)
+	u.Email = fmt.Sprintf("deleted-%d@deleted.invalid", id)
+	u.PasswordHash = ""
+	u.PendingEmail, u.EmailToken, u.EmailTokenExpiresAt = "", "", time.Time{}
+	u.IsActive = false
+	u.TokenVersion++
+	r.users[id] = u
+	r.deletedUsers[id] = true
+
+	cart := r.cart[:0]
+	for _, item := range r.cart {
+		if item.UserID != id {
+			cart = append(cart, item)
+		}
+	}
+	r.cart = cart
+
+	addresses := r.addresses[:0]
+	for _, a := range r.addresses {
+		if a.UserID != id {
+			addresses = append(addresses, a)
+		}
+	}
+	r.addresses = addresses
+
+	alerts := r.priceAlerts[:0]
+	for _, alert := range r.priceAlerts {
+		if alert.UserID != id {
+			alerts = append(alerts, alert)
+		}
+	}
+	r.priceAlerts = alerts
+
+	notifications := r.notifications[:0]
+	for _, n := range r.notifications {
+		if n.UserID != id {
+			notifications = append(notifications, n)
+		}
+	}
+	r.notifications = notifications
+
+	delete(r.logins, id)
+	delete(r.views, fmt.Sprintf("u:%d", id))
+	delete(r.compare, fmt.Sprintf("u:%d", id))
+	for pid, p := range r.products {
+		if p.UserID != nil && *p.UserID == id {
+			p.IsApproved = false
+			r.products[pid] = p
+		}
+	}
+	return nil
+}
+
+// withOwner дополняет товар именем владельца, как LEFT JOIN users
+func (r *MemoryRepository) withOwner(p models.Product) models.Product {
+	p.Attributes = copyAttributes(p.Attributes)
+	p.Username = ""
+	if p.UserID != nil {
+		id := *p.UserID
+		p.UserID = &id
+		if u, ok := r.users[id]; ok {
+			p.Username = u.Username
+		}
+	}
+	return p
+}
+
+func (r *MemoryRepository) sortedProducts(match func(p models.Product) bool) []models.Product {
+	products := []models.Product{}
+	for _, p := range r.products {
+		if match(p) {
+			products = append(products, r.withOwner(p))
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products
+}
+
+func (r *MemoryRepository) ListApprovedProducts(limit, offset int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := r.sortedProducts(func(p models.Product) bool { return p.IsApproved })
+	if offset >= len(products) {
+		return []models.Product{}, nil
+	}
+	end := offset + limit
+	if end > len(products) {
+		end = len(products)
+	}
+	return products[offset:end], nil
+}
+
+func (r *MemoryRepository) ListApprovedProductsAfter(afterID, limit int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := r.sortedProducts(func(p models.Product) bool { return p.IsApproved && p.ID > afterID })
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+func (r *MemoryRepository) ListApprovedProductsByIDs(ids []int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedProducts(func(p models.Product) bool { return p.IsApproved && containsID(ids, p.ID) }), nil
+}
+
+func (r *MemoryRepository) ListApprovedProductsBySellers(sellerIDs []int, limit int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := r.sortedProducts(func(p models.Product) bool {
+		return p.IsApproved && p.UserID != nil && containsID(sellerIDs, *p.UserID)
+	})
+	return firstPerGroup(products, limit, func(p models.Product) string { return fmt.Sprint(*p.UserID) }), nil
+}
+
+func (r *MemoryRepository) ListApprovedProductsByCategories(categories []string, limit int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := r.sortedProducts(func(p models.Product) bool {
+		if !p.IsApproved {
+			return false
+		}
+		for _, c := range categories {
+			if strings.EqualFold(p.Category, c) {
+				return true
+			}
+		}
+		return false
+	})
+	return firstPerGroup(products, limit, func(p models.Product) string { return strings.ToLower(p.Category) }), nil
+}
+
+// firstPerGroup оставляет первые limit товаров каждой группы, сохраняя порядок
+func firstPerGroup(products []models.Product, limit int, group func(models.Product) string) []models.Product {
+	taken := map[string]int{}
+	kept := products[:0]
+	for _, p := range products {
+		if key := group(p); taken[key] < limit {
+			taken[key]++
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func (r *MemoryRepository) ListCategories() ([]models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byKey := map[string]*models.Category{}
+	for _, p := range r.products {
+		if !p.IsApproved || p.Category == "" {
+			continue
+		}
+		key := strings.ToLower(p.Category)
+		c, ok := byKey[key]
+		if !ok {
+			c = &models.Category{Name: p.Category}
+			byKey[key] = c
+		} else if p.Category < c.Name {
+			c.Name = p.Category
+		}
+		c.ProductCount++
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	categories := []models.Category{}
+	for _, key := range keys {
+		categories = append(categories, *byKey[key])
+	}
+	return categories, nil
+}
+
+func (r *MemoryRepository) CountApprovedProducts() (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, p := range r.products {
+		if p.IsApproved {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *MemoryRepository) GetProductByID(id int) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	p = r.withOwner(p)
+	return &p, nil
+}
+
+func (r *MemoryRepository) ListProductsByUser(userID int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedProducts(func(p models.Product) bool { return p.OwnedBy(userID) }), nil
+}
+
+func (r *MemoryRepository) ListPendingProducts() ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sortedProducts(func(p models.Product) bool {
+		if p.IsApproved || p.UserID == nil {
+			return false
+		}
+		_, ok := r.users[*p.UserID]
+		return ok
+	}), nil
+}
+
+func (r *MemoryRepository) CreateProduct(product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextProductID++
+	product.ID = r.nextProductID
+	if product.CreatedAt == "" {
+		product.CreatedAt = time.Now().Format("2006-01-02 15:04:05")
+	}
+	product.UpdatedAt = time.Now().UTC()
+	stored := *product
+	stored.Attributes = copyAttributes(product.Attributes)
+	r.products[product.ID] = stored
+	r.recordPriceLocked(product.ID, nil, product.Price, product.UserID, models.PriceSourceCreate)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateProduct(product *models.Product, resetApproval bool, actorID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.products[product.ID]
+	if !ok {
+		return nil
+	}
+
+	oldPrice := p.Price
+	p.Name = product.Name
+	p.Description = product.Description
+	p.Price = product.Price
+	p.Image = product.Image
+	p.Stock = product.Stock
+	p.Weight = product.Weight
+	p.Category = product.Category
+	p.Attributes = copyAttributes(product.Attributes)
+	if resetApproval {
+		p.IsApproved = false
+	}
+	p.UpdatedAt = time.Now().UTC()
+	r.products[p.ID] = p
+	r.recordPriceLocked(p.ID, &oldPrice, p.Price, &actorID, models.PriceSourceUpdate)
+	return nil
+}
+
+func (r *MemoryRepository) SetProductApproved(id int, approved bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p, ok := r.products[id]; ok {
+		p.IsApproved = approved
+		p.UpdatedAt = time.Now().UTC()
+		r.products[id] = p
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ProductInCarts(id int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, item := range r.cart {
+		if item.ProductID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) DeleteProduct(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteProductLocked(id)
+	return nil
+}
+
+func (r *MemoryRepository) ForceDeleteProduct(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.deleteProductLocked(id)
+	return nil
+}
+
+// deleteProductLocked повторяет ON DELETE CASCADE для позиций корзины, истории цен и подписок
+// и ON DELETE SET NULL для позиций заказов
+func (r *MemoryRepository) deleteProductLocked(id int) {
+	delete(r.products, id)
+
+	for _, order := range r.orders {
+		for i := range order.Items {
+			if order.Items[i].ProductID == id {
+				order.Items[i].ProductID = 0
+			}
+		}
+	}
+
+	history := r.priceHistory[:0]
+	for _, change := range r.priceHistory {
+		if change.ProductID != id {
+			history = append(history, change)
+		}
+	}
+	r.priceHistory = history
+
+	alerts := r.priceAlerts[:0]
+	for _, alert := range r.priceAlerts {
+		if alert.ProductID != id {
+			alerts = append(alerts, alert)
+		}
+	}
+	r.priceAlerts = alerts
+
+	cart := r.cart[:0]
+	for _, item := range r.cart {
+		if item.ProductID != id {
+			cart = append(cart, item)
+		}
+	}
+	r.cart = cart
+}
+
+func (r *MemoryRepository) RecordUpload(userID int, filename string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.uploads[userID] == nil {
+		r.uploads[userID] = map[string]bool{}
+	}
+	r.uploads[userID][filename] = true
+	return nil
+}
+
+func (r *MemoryRepository) OwnedImages(userID int, filenames []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	own := map[string]bool{}
+	for filename := range r.uploads[userID] {
+		own[filename] = true
+	}
+	for _, p := range r.products {
+		if p.OwnedBy(userID) {
+			own[p.Image] = true
+		}
+	}
+
+	var owned []string
+	for _, filename := range filenames {
+		if own[filename] {
+			owned = append(owned, filename)
+		}
+	}
+	return owned, nil
+}
+
+func (r *MemoryRepository) ImportProducts(sellerID int, approved bool, rows []models.ImportRow, commit bool) ([]models.ImportOutcome, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Работаем с копией, чтобы откат «транзакции» был бесплатным
+	products := make(map[int]models.Product, len(r.products))
+	for id, p := range r.products {
+		products[id] = p
+	}
+	nextID := r.nextProductID
+
+	type priceChange struct {
+		productID int
+		oldPrice  *money.Amount
+		newPrice  money.Amount
+	}
+	var changes []priceChange
+
+	outcomes := make([]models.ImportOutcome, len(rows))
+	for i, row := range rows {
+		existing := 0
+		for id, p := range products {
+			if p.OwnedBy(sellerID) && p.SKU == row.SKU {
+				existing = id
+				break
+			}
+		}
+
+		if existing == 0 {
+			nextID++
+			image := row.Image
+			if image == "" {
+				image = "default.png"
+			}
+			owner := sellerID
+			products[nextID] = models.Product{
+				ID:          nextID,
+				Name:        row.Name,
+				Description: row.Description,
+				Price:       row.Price,
+				Image:       image,
+				Stock:       row.Stock,
+				UserID:      &owner,
+				IsApproved:  approved,
+				SKU:         row.SKU,
+				CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+				UpdatedAt:   time.Now().UTC(),
+			}
+			outcomes[i] = models.ImportOutcome{ProductID: nextID, Action: "create"}
+			changes = append(changes, priceChange{nextID, nil, row.Price})
+			continue
+		}
+
+		p := products[existing]
+		oldPrice := p.Price
+		changes = append(changes, priceChange{existing, &oldPrice, row.Price})
+		p.Name = row.Name
+		p.Description = row.Description
+		p.Price = row.Price
+		p.Stock = row.Stock
+		if row.Image != "" {
+			p.Image = row.Image
+		}
+		if !approved {
+			p.IsApproved = false
+		}
+		p.UpdatedAt = time.Now().UTC()
+		products[existing] = p
+		outcomes[i] = models.ImportOutcome{ProductID: existing, Action: "update"}
+	}
+
+	if !commit {
+		return outcomes, false, nil
+	}
+
+	r.products = products
+	r.nextProductID = nextID
+	for _, change := range changes {
+		r.recordPriceLocked(change.productID, change.oldPrice, change.newPrice, &sellerID, models.PriceSourceImport)
+	}
+	return outcomes, true, nil
+}
+
+func (r *MemoryRepository) GetCartItems(userID int) ([]models.CartItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := make([]memoryCartItem, 0)
+	for _, item := range r.cart {
+		p, ok := r.products[item.ProductID]
+		if item.UserID == userID && ok && p.Stock > 0 && p.IsApproved {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].addedSeq > items[j].addedSeq })
+
+	var cart []models.CartItem
+	for _, item := range items {
+		p := r.products[item.ProductID]
+		cart = append(cart, models.CartItem{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			Name:      p.Name,
+			Price:     p.Price,
+			Quantity:  item.Quantity,
+			Weight:    p.Weight,
+			Image:     p.Image,
+			SellerID:  sellerOf(p),
+			Category:  p.Category,
+		})
+	}
+	return cart, nil
+}
+
+func (r *MemoryRepository) AddCartItem(userID, productID, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cartAdditions = append(r.cartAdditions, memoryCartAddition{
+		UserID:    userID,
+		ProductID: productID,
+		AddedAt:   time.Now(),
+	})
+
+	// ON CONFLICT (user_id, product_id) DO UPDATE SET quantity = quantity + EXCLUDED.quantity
+	for i, item := range r.cart {
+		if item.UserID == userID && item.ProductID == productID {
+			r.cart[i].Quantity += quantity
+			return nil
+		}
+	}
+
+	r.nextCartID++
+	r.cartSeq++
+	r.cart = append(r.cart, memoryCartItem{
+		ID:        r.nextCartID,
+		UserID:    userID,
+		ProductID: productID,
+		Quantity:  quantity,
+		addedSeq:  r.cartSeq,
+	})
+	return nil
+}
+
+func (r *MemoryRepository) UpdateCartItem(userID, itemID, quantity int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, item := range r.cart {
+		if item.ID == itemID && item.UserID == userID {
+			r.cart[i].Quantity = quantity
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RemoveCartItem(userID, itemID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cart := r.cart[:0]
+	for _, item := range r.cart {
+		if item.ID != itemID || item.UserID != userID {
+			cart = append(cart, item)
+		}
+	}
+	r.cart = cart
+	return nil
+}
+
+// AddOrder сохраняет готовый заказ без проверок и списания остатков (для отчетов в тестах)
+func (r *MemoryRepository) AddOrder(order models.Order) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order.ID = len(r.orders) + 1
+	if order.Status == "" {
+		order.Status = models.OrderPending
+	}
+	r.numberItemsLocked(order.Items)
+	// Разбивка налогов выводится из позиций, как при чтении заказа из БД
+	order.SumTaxes()
+	r.orders = append(r.orders, copyOrder(order))
+}
+
+// numberItemsLocked выдает позициям заказа id и запоминает продавца, как INSERT в order_items
+func (r *MemoryRepository) numberItemsLocked(items []models.OrderItem) {
+	for i := range items {
+		r.nextItemID++
+		items[i].ID = r.nextItemID
+		if p, ok := r.products[items[i].ProductID]; ok && p.UserID != nil {
+			items[i].SellerID = *p.UserID
+		}
+	}
+}
+
+func (r *MemoryRepository) SalesReport(filter models.AnalyticsFilter) (*models.AnalyticsReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inRange := func(t time.Time) bool {
+		return !t.Before(filter.From) && t.Before(filter.To)
+	}
+	sellerMatches := func(productID int) bool {
+		p, ok := r.products[productID]
+		return ok && (filter.SellerID == 0 || p.OwnedBy(filter.SellerID))
+	}
+
+	report := &models.AnalyticsReport{
+		From:        filter.From.Format(dateLayout),
+		To:          filter.To.AddDate(0, 0, -1).Format(dateLayout),
+		TopProducts: []models.AnalyticsTopProduct{},
+		Daily:       []models.AnalyticsDay{},
+	}
+
+	type pair struct{ userID, productID int }
+	days := make(map[string]*models.AnalyticsDay)
+	dayOrders := make(map[string]map[int]bool)
+	orders := make(map[int]bool)
+	top := make(map[int]*models.AnalyticsTopProduct)
+	purchased := make(map[pair]bool)
+
+	for _, order := range r.orders {
+		if order.Status == "cancelled" || !inRange(order.CreatedAt) {
+			continue
+		}
+
+		day := order.CreatedAt.Format(dateLayout)
+		for _, item := range order.Items {
+			purchased[pair{order.UserID, item.ProductID}] = true
+			if !sellerMatches(item.ProductID) {
+				continue
+			}
+
+			revenue := money.Line(item.PriceAtTime, item.Quantity)
+			report.Summary.Revenue = report.Summary.Revenue.Add(revenue)
+			report.Summary.UnitsSold += item.Quantity
+			orders[order.ID] = true
+
+			if days[day] == nil {
+				days[day] = &models.AnalyticsDay{Date: day}
+				dayOrders[day] = make(map[int]bool)
+			}
+			days[day].Revenue = days[day].Revenue.Add(revenue)
+			days[day].UnitsSold += item.Quantity
+			dayOrders[day][order.ID] = true
+
+			if top[item.ProductID] == nil {
+				top[item.ProductID] = &models.AnalyticsTopProduct{
+					ProductID: item.ProductID,
+					Name:      r.products[item.ProductID].Name,
+				}
+			}
+			top[item.ProductID].UnitsSold += item.Quantity
+			top[item.ProductID].Revenue = top[item.ProductID].Revenue.Add(revenue)
+		}
+	}
+	report.Summary.Orders = len(orders)
+
+	adds := make(map[pair]bool)
+	for _, addition := range r.cartAdditions {
+		if inRange(addition.AddedAt) && sellerMatches(addition.ProductID) {
+			adds[pair{addition.UserID, addition.ProductID}] = true
+		}
+	}
+	report.Summary.CartAdds = len(adds)
+	for p := range adds {
+		if purchased[p] {
+			report.Summary.Purchases++
+		}
+	}
+	if report.Summary.CartAdds > 0 {
+		report.Summary.ConversionRate = float64(report.Summary.Purchases) / float64(report.Summary.CartAdds)
+	}
+
+	for _, p := range top {
+		report.TopProducts = append(report.TopProducts, *p)
+	}
+	sort.Slice(report.TopProducts, func(i, j int) bool {
+		a, b := report.TopProducts[i], report.TopProducts[j]
+		if a.Revenue.Equal(b.Revenue) {
+			return a.ProductID < b.ProductID
+		}
+		return a.Revenue.GreaterThan(b.Revenue)
+	})
+	if len(report.TopProducts) > filter.Top {
+		report.TopProducts = report.TopProducts[:filter.Top]
+	}
+
+	for d := filter.From; d.Before(filter.To); d = d.AddDate(0, 0, 1) {
+		key := d.Format(dateLayout)
+		day := models.AnalyticsDay{Date: key}
+		if days[key] != nil {
+			day = *days[key]
+			day.Orders = len(dayOrders[key])
+		}
+		report.Daily = append(report.Daily, day)
+	}
+
+	return report, nil
+}
+
+// recordPriceLocked повторяет recordPriceChange: запись появляется, только если цена
+// в копейках изменилась
+func (r *MemoryRepository) recordPriceLocked(productID int, oldPrice *money.Amount, newPrice money.Amount, actorID *int, source string) {
+	newPrice = newPrice.Round(2)
+	if oldPrice != nil && oldPrice.Round(2).Equal(newPrice) {
+		return
+	}
+
+	var changedBy *int
+	if actorID != nil {
+		id := *actorID
+		changedBy = &id
+	}
+
+	r.nextPriceID++
+	r.priceHistory = append(r.priceHistory, models.PriceChange{
+		ID:        r.nextPriceID,
+		ProductID: productID,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		ChangedBy: changedBy,
+		Source:    source,
+		ChangedAt: time.Now().UTC(),
+	})
+}
+
+func (r *MemoryRepository) ListPriceHistory(productID int) ([]models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history := []models.PriceChange{}
+	for _, change := range r.priceHistory {
+		if change.ProductID == productID {
+			history = append(history, change)
+		}
+	}
+	return history, nil
+}
+
+// withProduct дополняет подписку названием и текущей ценой товара, как JOIN products
+func (r *MemoryRepository) withProduct(alert models.PriceAlert) models.PriceAlert {
+	p := r.products[alert.ProductID]
+	alert.ProductName = p.Name
+	alert.CurrentPrice = p.Price
+	return alert
+}
+
+func (r *MemoryRepository) ListPriceAlerts(userID int) ([]models.PriceAlert, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	alerts := []models.PriceAlert{}
+	for i := len(r.priceAlerts) - 1; i >= 0; i-- {
+		if alert := r.priceAlerts[i]; alert.UserID == userID {
+			alerts = append(alerts, r.withProduct(alert))
+		}
+	}
+	return alerts, nil
+}
+
+func (r *MemoryRepository) UpsertPriceAlert(userID, productID int, target money.Amount) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, alert := range r.priceAlerts {
+		if alert.UserID == userID && alert.ProductID == productID {
+			r.priceAlerts = append(r.priceAlerts[:i], r.priceAlerts[i+1:]...)
+			break
+		}
+	}
+
+	r.nextAlertID++
+	r.priceAlerts = append(r.priceAlerts, models.PriceAlert{
+		ID:          r.nextAlertID,
+		UserID:      userID,
+		ProductID:   productID,
+		TargetPrice: target,
+		CreatedAt:   time.Now().UTC(),
+	})
+	return nil
+}
+
+func (r *MemoryRepository) DeletePriceAlert(userID, productID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, alert := range r.priceAlerts {
+		if alert.UserID == userID && alert.ProductID == productID {
+			r.priceAlerts = append(r.priceAlerts[:i], r.priceAlerts[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) TriggerPriceAlerts(productID int, price money.Amount) ([]models.PriceAlert, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.products[productID]; !ok {
+		return []models.PriceAlert{}, nil
+	}
+
+	triggered := []models.PriceAlert{}
+	for i, alert := range r.priceAlerts {
+		if alert.ProductID != productID || alert.TriggeredAt != nil || price.GreaterThan(alert.TargetPrice) {
+			continue
+		}
+		now := time.Now().UTC()
+		alert.TriggeredAt = &now
+		alert.TriggeredPrice = &price
+		r.priceAlerts[i] = alert
+		triggered = append(triggered, r.withProduct(alert))
+	}
+	return triggered, nil
+}
+
+func (r *MemoryRepository) ListAddresses(userID int) ([]models.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	addresses := []models.Address{}
+	for _, a := range r.addresses {
+		if a.UserID == userID {
+			addresses = append(addresses, a)
+		}
+	}
+	// ORDER BY is_default DESC, id
+	sort.SliceStable(addresses, func(i, j int) bool { return addresses[i].IsDefault && !addresses[j].IsDefault })
+	return addresses, nil
+}
+
+func (r *MemoryRepository) GetAddress(userID, id int) (*models.Address, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, a := range r.addresses {
+		if a.ID == id && a.UserID == userID {
+			return &a, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) setDefaultAddressLocked(userID, id int) {
+	for i := range r.addresses {
+		if r.addresses[i].UserID == userID {
+			r.addresses[i].IsDefault = r.addresses[i].ID == id
+		}
+	}
+}
+
+func (r *MemoryRepository) CreateAddress(address *models.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first := true
+	for _, a := range r.addresses {
+		if a.UserID == address.UserID {
+			first = false
+		}
+	}
+
+	r.nextAddressID++
+	address.ID = r.nextAddressID
+	address.IsDefault = address.IsDefault || first
+	address.CreatedAt = time.Now().UTC()
+	r.addresses = append(r.addresses, *address)
+	if address.IsDefault {
+		r.setDefaultAddressLocked(address.UserID, address.ID)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) UpdateAddress(address *models.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, a := range r.addresses {
+		if a.ID != address.ID || a.UserID != address.UserID {
+			continue
+		}
+		address.IsDefault = address.IsDefault || a.IsDefault
+		address.CreatedAt = a.CreatedAt
+		r.addresses[i] = *address
+		if address.IsDefault {
+			r.setDefaultAddressLocked(address.UserID, address.ID)
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) DeleteAddress(userID, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, a := range r.addresses {
+		if a.ID != id || a.UserID != userID {
+			continue
+		}
+		r.addresses = append(r.addresses[:i], r.addresses[i+1:]...)
+
+		if a.IsDefault {
+			newest := 0
+			for _, other := range r.addresses {
+				if other.UserID == userID && other.ID > newest {
+					newest = other.ID
+				}
+			}
+			r.setDefaultAddressLocked(userID, newest)
+		}
+		return nil
+	}
+	return ErrNotFound
+}
+
+func copyDeliveryMethod(m models.DeliveryMethod) models.DeliveryMethod {
+	m.Rules = append([]models.DeliveryRule{}, m.Rules...)
+	return m
+}
+
+func (r *MemoryRepository) ListDeliveryMethods(activeOnly bool) ([]models.DeliveryMethod, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	methods := []models.DeliveryMethod{}
+	for _, m := range r.delivery {
+		if m.IsActive || !activeOnly {
+			methods = append(methods, copyDeliveryMethod(m))
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].ID < methods[j].ID })
+	return methods, nil
+}
+
+func (r *MemoryRepository) GetDeliveryMethod(id int) (*models.DeliveryMethod, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.delivery[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m = copyDeliveryMethod(m)
+	return &m, nil
+}
+
+func (r *MemoryRepository) CreateDeliveryMethod(method *models.DeliveryMethod) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextDeliveryID++
+	method.ID = r.nextDeliveryID
+	r.delivery[method.ID] = copyDeliveryMethod(*method)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateDeliveryMethod(method *models.DeliveryMethod) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.delivery[method.ID]; !ok {
+		return ErrNotFound
+	}
+	r.delivery[method.ID] = copyDeliveryMethod(*method)
+	return nil
+}
+
+func (r *MemoryRepository) DeleteDeliveryMethod(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.delivery[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.delivery, id)
+	return nil
+}
+
+func (r *MemoryRepository) ListTaxRates() ([]models.TaxRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rates := append([]models.TaxRate{}, r.taxRates...)
+	sort.SliceStable(rates, func(i, j int) bool {
+		if rates[i].Category != rates[j].Category {
+			return rates[i].Category < rates[j].Category
+		}
+		return rates[i].Region < rates[j].Region
+	})
+	return rates, nil
+}
+
+func (r *MemoryRepository) GetTaxRate(id int) (*models.TaxRate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.taxRates {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// taxTargetTakenLocked повторяет уникальный индекс по lower(category), lower(region)
+func (r *MemoryRepository) taxTargetTakenLocked(rate *models.TaxRate) bool {
+	for _, t := range r.taxRates {
+		if t.ID != rate.ID && strings.EqualFold(t.Category, rate.Category) && strings.EqualFold(t.Region, rate.Region) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) CreateTaxRate(rate *models.TaxRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.taxTargetTakenLocked(rate) {
+		return ErrConflict
+	}
+	r.nextTaxRateID++
+	rate.ID = r.nextTaxRateID
+	rate.CreatedAt = time.Now().UTC()
+	r.taxRates = append(r.taxRates, *rate)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateTaxRate(rate *models.TaxRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.taxRates {
+		if t.ID != rate.ID {
+			continue
+		}
+		if r.taxTargetTakenLocked(rate) {
+			return ErrConflict
+		}
+		rate.CreatedAt = t.CreatedAt
+		r.taxRates[i] = *rate
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) DeleteTaxRate(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, t := range r.taxRates {
+		if t.ID == id {
+			r.taxRates = append(r.taxRates[:i], r.taxRates[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) CreateOrder(order *models.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sales := r.activeSalesLocked(time.Now())
+	for _, item := range order.Items {
+		p, ok := r.products[item.ProductID]
+		if !ok {
+			return ErrCartChanged
+		}
+		target := models.SaleTarget{ProductID: p.ID, SellerID: sellerOf(p), Category: p.Category}
+		current, _ := models.BestSale(sales, target, p.Price)
+		if !p.IsApproved || p.Stock < item.Quantity || !current.Equal(item.PriceAtTime) {
+			return ErrCartChanged
+		}
+	}
+
+	for _, item := range order.Items {
+		p := r.products[item.ProductID]
+		p.Stock -= item.Quantity
+		p.UpdatedAt = time.Now().UTC()
+		r.products[p.ID] = p
+
+		cart := r.cart[:0]
+		for _, c := range r.cart {
+			if c.UserID != order.UserID || c.ProductID != item.ProductID {
+				cart = append(cart, c)
+			}
+		}
+		r.cart = cart
+	}
+
+	order.ID = len(r.orders) + 1
+	order.CreatedAt = time.Now().UTC()
+	r.numberItemsLocked(order.Items)
+	r.orders = append(r.orders, copyOrder(*order))
+	return nil
+}
+
+// copyOrder копирует заказ вместе с позициями и их налогами, расчетом доставки и адресом,
+// чтобы пересчет валюты в сервисе не менял сохраненный заказ
+func copyOrder(o models.Order) models.Order {
+	o.Items = append([]models.OrderItem{}, o.Items...)
+	for i := range o.Items {
+		if tax := o.Items[i].Tax; tax != nil {
+			copied := *tax
+			o.Items[i].Tax = &copied
+		}
+	}
+	o.Taxes = append([]models.TaxLine{}, o.Taxes...)
+	if o.Shipping != nil {
+		shipping := *o.Shipping
+		o.Shipping = &shipping
+	}
+	if o.Address != nil {
+		address := *o.Address
+		o.Address = &address
+	}
+	return o
+}
+
+func (r *MemoryRepository) ListOrders(userID int) ([]models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := []models.Order{}
+	for i := len(r.orders) - 1; i >= 0; i-- {
+		if o := r.orders[i]; o.UserID == userID {
+			orders = append(orders, copyOrder(o))
+		}
+	}
+	return orders, nil
+}
+
+func (r *MemoryRepository) GetOrder(userID, id int) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, o := range r.orders {
+		if o.ID == id && o.UserID == userID {
+			o = copyOrder(o)
+			return &o, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) GetOrderByID(id int) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, o := range r.orders {
+		if o.ID == id {
+			o = copyOrder(o)
+			return &o, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) UpdateOrderStatus(id int, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.orders {
+		o := &r.orders[i]
+		if o.ID != id {
+			continue
+		}
+		if o.Status != from {
+			return ErrStatusChanged
+		}
+		o.Status = to
+		if to == models.OrderCancelled {
+			for _, item := range o.Items {
+				r.restockLocked(item.ProductID, item.Quantity)
+			}
+		}
+		return nil
+	}
+	return ErrStatusChanged
+}
+
+func (r *MemoryRepository) restockLocked(productID, quantity int) {
+	if p, ok := r.products[productID]; ok {
+		p.Stock += quantity
+		p.UpdatedAt = time.Now().UTC()
+		r.products[productID] = p
+	}
+}
+
+func (r *MemoryRepository) CreateReturn(ret *models.Return) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var item *models.OrderItem
+	for i := range r.orders {
+		if r.orders[i].ID != ret.OrderID {
+			continue
+		}
+		for j := range r.orders[i].Items {
+			if r.orders[i].Items[j].ID == ret.OrderItemID {
+				item = &r.orders[i].Items[j]
+			}
+		}
+	}
+	if item == nil {
+		return ErrNotFound
+	}
+
+	returned := 0
+	for _, existing := range r.returns {
+		if existing.OrderItemID == ret.OrderItemID && existing.Status != models.ReturnRejected {
+			returned += existing.Quantity
+		}
+	}
+	if returned+ret.Quantity > item.Quantity {
+		return ErrReturnQuantity
+	}
+
+	r.nextReturnID++
+	ret.ID = r.nextReturnID
+	ret.ProductID = item.ProductID
+	ret.SellerID = item.SellerID
+	ret.ProductName = item.Name
+	ret.Amount = item.ReturnAmount(returned, ret.Quantity)
+	ret.Status = models.ReturnRequested
+	ret.Photos = append([]string{}, ret.Photos...)
+	ret.CreatedAt = time.Now().UTC()
+	ret.UpdatedAt = ret.CreatedAt
+	r.returns = append(r.returns, *ret)
+	return nil
+}
+
+func (r *MemoryRepository) ListReturns(filter models.ReturnFilter) ([]models.Return, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	returns := []models.Return{}
+	for i := len(r.returns) - 1; i >= 0; i-- {
+		ret := r.returns[i]
+		if (filter.UserID == 0 || ret.UserID == filter.UserID) && (filter.SellerID == 0 || ret.SellerID == filter.SellerID) {
+			ret.Photos = append([]string{}, ret.Photos...)
+			returns = append(returns, ret)
+		}
+	}
+	return returns, nil
+}
+
+func (r *MemoryRepository) GetReturn(id int) (*models.Return, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, ret := range r.returns {
+		if ret.ID == id {
+			ret.Photos = append([]string{}, ret.Photos...)
+			return &ret, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) setReturnStatusLocked(id int, from, to, comment string) (*models.Return, error) {
+	for i := range r.returns {
+		ret := &r.returns[i]
+		if ret.ID != id {
+			continue
+		}
+		if ret.Status != from {
+			return nil, ErrStatusChanged
+		}
+		ret.Status = to
+		ret.Comment = comment
+		ret.UpdatedAt = time.Now().UTC()
+		return ret, nil
+	}
+	return nil, ErrStatusChanged
+}
+
+func (r *MemoryRepository) UpdateReturnStatus(id int, from, to, comment string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ret, err := r.setReturnStatusLocked(id, from, to, comment)
+	if err != nil {
+		return err
+	}
+	if to == models.ReturnReceived {
+		r.restockLocked(ret.ProductID, ret.Quantity)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RefundReturn(id int, comment string) (*models.Order, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ret, err := r.setReturnStatusLocked(id, models.ReturnReceived, models.ReturnRefunded, comment)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range r.orders {
+		o := &r.orders[i]
+		if o.ID != ret.OrderID {
+			continue
+		}
+		goods := o.Total
+		if o.Shipping != nil {
+			goods = goods.Sub(o.Shipping.Cost)
+		}
+		o.Refunded = o.Refunded.Add(ret.Amount)
+		fullyRefunded := o.Status == models.OrderDelivered && o.Refunded.GreaterThanOrEqual(goods)
+		if fullyRefunded {
+			o.Status = models.OrderRefunded
+		}
+		order := *o
+		return &order, fullyRefunded, nil
+	}
+	return nil, false, ErrNotFound
+}
+
+func (r *MemoryRepository) HasPurchased(userID, productID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, o := range r.orders {
+		if o.UserID != userID || o.Status == models.OrderCancelled {
+			continue
+		}
+		for _, item := range o.Items {
+			if item.ProductID == productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) GetInvoice(orderID int) (*models.Invoice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	inv, ok := r.invoices[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyInvoice(inv), nil
+}
+
+func (r *MemoryRepository) CreateInvoice(invoice *models.Invoice) (*models.Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if inv, ok := r.invoices[invoice.OrderID]; ok {
+		return copyInvoice(inv), nil
+	}
+	r.invoices[invoice.OrderID] = *copyInvoice(*invoice)
+	return copyInvoice(*invoice), nil
+}
+
+// copyInvoice не дает вызывающему коду изменить сохраненный PDF
+func copyInvoice(inv models.Invoice) *models.Invoice {
+	inv.PDF = append([]byte(nil), inv.PDF...)
+	return &inv
+}
+
+func (r *MemoryRepository) CreateNotifications(notifications []models.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range notifications {
+		r.nextNotifyID++
+		notifications[i].ID = r.nextNotifyID
+		notifications[i].CreatedAt = now
+		notifications[i].Read = false
+		notifications[i].Message = ""
+		r.notifications = append(r.notifications, notifications[i])
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ListNotifications(filter models.NotificationFilter) ([]models.Notification, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	notifications := []models.Notification{}
+	for k := range r.notifications {
+		i := len(r.notifications) - 1 - k
+		if filter.Oldest {
+			i = k
+		}
+		n := r.notifications[i]
+		if n.UserID != filter.UserID || (filter.UnreadOnly && n.Read) || n.ID <= filter.AfterID {
+			continue
+		}
+		if filter.Limit > 0 && len(notifications) == filter.Limit {
+			break
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, nil
+}
+
+func (r *MemoryRepository) CountUnreadNotifications(userID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, n := range r.notifications {
+		if n.UserID == userID && !n.Read {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) MarkNotificationRead(userID, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.notifications {
+		if n := &r.notifications[i]; n.ID == id && n.UserID == userID {
+			n.Read = true
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) MarkAllNotificationsRead(userID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for i := range r.notifications {
+		if n := &r.notifications[i]; n.UserID == userID && !n.Read {
+			n.Read = true
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) ActiveUserIDsByRole(role string) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int
+	for id, u := range r.users {
+		if u.Role == role && u.IsActive {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (r *MemoryRepository) ProductWatchers(productID int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := map[int]bool{}
+	for _, item := range r.cart {
+		if item.ProductID == productID {
+			seen[item.UserID] = true
+		}
+	}
+	for _, alert := range r.priceAlerts {
+		if alert.ProductID == productID {
+			seen[alert.UserID] = true
+		}
+	}
+
+	var ids []int
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (r *MemoryRepository) username(userID int) string {
+	return r.users[userID].Username
+}
+
+func (r *MemoryRepository) CreateQuestion(q *models.Question) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextQuestionID++
+	q.ID = r.nextQuestionID
+	q.Username = r.username(q.UserID)
+	q.CreatedAt = time.Now().UTC()
+	q.Answers = []models.Answer{}
+	r.questions = append(r.questions, *q)
+	return nil
+}
+
+func (r *MemoryRepository) GetQuestion(id int) (*models.Question, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, q := range r.questions {
+		if q.ID == id {
+			q.Answers = []models.Answer{}
+			return &q, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// answerLocked дополняет ответ числом голосов
+func (r *MemoryRepository) answerLocked(a models.Answer) models.Answer {
+	a.Votes = 0
+	for key := range r.answerVotes {
+		if key[0] == a.ID {
+			a.Votes++
+		}
+	}
+	return a
+}
+
+func (r *MemoryRepository) ListQuestions(productID int, answeredOnly bool, limit int) ([]models.Question, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	questions := []models.Question{}
+	for i := len(r.questions) - 1; i >= 0; i-- {
+		q := r.questions[i]
+		if q.ProductID != productID || q.Status != models.QAPublished {
+			continue
+		}
+
+		q.Answers = []models.Answer{}
+		for _, a := range r.answers {
+			if a.QuestionID == q.ID && a.Status == models.QAPublished {
+				q.Answers = append(q.Answers, r.answerLocked(a))
+			}
+		}
+		if answeredOnly && len(q.Answers) == 0 {
+			continue
+		}
+		sort.SliceStable(q.Answers, func(i, j int) bool {
+			a, b := q.Answers[i], q.Answers[j]
+			if a.IsSeller != b.IsSeller {
+				return a.IsSeller
+			}
+			return a.Votes > b.Votes
+		})
+
+		questions = append(questions, q)
+		if limit > 0 && len(questions) == limit {
+			break
+		}
+	}
+	return questions, nil
+}
+
+func (r *MemoryRepository) ListQuestionsByStatus(status string) ([]models.Question, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	questions := []models.Question{}
+	for _, q := range r.questions {
+		if q.Status == status {
+			q.Answers = []models.Answer{}
+			questions = append(questions, q)
+		}
+	}
+	return questions, nil
+}
+
+func (r *MemoryRepository) SetQuestionStatus(id int, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.questions {
+		if r.questions[i].ID == id {
+			r.questions[i].Status = status
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) CreateAnswer(a *models.Answer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextAnswerID++
+	a.ID = r.nextAnswerID
+	a.Username = r.username(a.UserID)
+	a.CreatedAt = time.Now().UTC()
+	r.answers = append(r.answers, *a)
+	return nil
+}
+
+func (r *MemoryRepository) GetAnswer(id int) (*models.Answer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, a := range r.answers {
+		if a.ID == id {
+			a = r.answerLocked(a)
+			return &a, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) ListAnswersByStatus(status string) ([]models.Answer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	answers := []models.Answer{}
+	for _, a := range r.answers {
+		if a.Status == status {
+			answers = append(answers, r.answerLocked(a))
+		}
+	}
+	return answers, nil
+}
+
+func (r *MemoryRepository) SetAnswerStatus(id int, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.answers {
+		if r.answers[i].ID == id {
+			r.answers[i].Status = status
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) VoteAnswer(answerID, userID int, up bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]int{answerID, userID}
+	if up {
+		r.answerVotes[key] = true
+	} else {
+		delete(r.answerVotes, key)
+	}
+	return nil
+}
+
+// recommendable — товар можно советовать: он одобрен и есть в наличии
+func (r *MemoryRepository) recommendable(id int) (models.Product, bool) {
+	p, ok := r.products[id]
+	return r.withOwner(p), ok && p.IsApproved && p.Stock > 0
+}
+
+func (r *MemoryRepository) RecordView(viewer string, productID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Последний просмотренный товар хранится в конце списка
+	viewed := r.views[viewer]
+	for i, id := range viewed {
+		if id == productID {
+			viewed = append(viewed[:i], viewed[i+1:]...)
+			break
+		}
+	}
+	r.views[viewer] = append(viewed, productID)
+	return nil
+}
+
+func (r *MemoryRepository) ListViewed(viewer string, exclude []int, limit int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := []models.Product{}
+	viewed := r.views[viewer]
+	for i := len(viewed) - 1; i >= 0 && len(products) < limit; i-- {
+		if containsID(exclude, viewed[i]) {
+			continue
+		}
+		if p, ok := r.recommendable(viewed[i]); ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (r *MemoryRepository) ListBoughtTogether(productIDs []int, limit int) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := map[int]int{}
+	for pair, count := range r.productPairs {
+		if containsID(productIDs, pair[0]) && !containsID(productIDs, pair[1]) {
+			totals[pair[1]] += count
+		}
+	}
+
+	products := []models.Product{}
+	for id := range totals {
+		if p, ok := r.recommendable(id); ok {
+			products = append(products, p)
+		}
+	}
+	sort.Slice(products, func(i, j int) bool {
+		a, b := totals[products[i].ID], totals[products[j].ID]
+		if a != b {
+			return a > b
+		}
+		return products[i].ID < products[j].ID
+	})
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return products, nil
+}
+
+func (r *MemoryRepository) RecomputeProductPairs() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pairs := map[[2]int]int{}
+	for _, o := range r.orders {
+		if o.Status == models.OrderCancelled {
+			continue
+		}
+		seen := map[[2]int]bool{}
+		for _, a := range o.Items {
+			for _, b := range o.Items {
+				key := [2]int{a.ProductID, b.ProductID}
+				if a.ProductID != b.ProductID && !seen[key] {
+					seen[key] = true
+					pairs[key]++
+				}
+			}
+		}
+	}
+	r.productPairs = pairs
+	return nil
+}
+
+func containsID(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func copyAttributes(attributes map[string]string) map[string]string {
+	if len(attributes) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		copied[name] = value
+	}
+	return copied
+}
+
+func (r *MemoryRepository) ListCompareItems(owner string) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := []models.Product{}
+	for _, id := range r.compare[owner] {
+		if p, ok := r.products[id]; ok && p.IsApproved {
+			products = append(products, r.withOwner(p))
+		}
+	}
+	return products, nil
+}
+
+func (r *MemoryRepository) AddCompareItem(owner string, productID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !containsID(r.compare[owner], productID) {
+		r.compare[owner] = append(r.compare[owner], productID)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RemoveCompareItem(owner string, productID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := r.compare[owner]
+	for i, id := range items {
+		if id == productID {
+			r.compare[owner] = append(items[:i:i], items[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) ClearCompareItems(owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.compare, owner)
+	return nil
+}
+
+func sellerOf(p models.Product) int {
+	if p.UserID == nil {
+		return 0
+	}
+	return *p.UserID
+}
+
+// copySale копирует акцию вместе со списком товаров и продавцом
+func copySale(s models.Sale) models.Sale {
+	s.ProductIDs = append([]int{}, s.ProductIDs...)
+	if s.SellerID != nil {
+		id := *s.SellerID
+		s.SellerID = &id
+	}
+	return s
+}
+
+func (r *MemoryRepository) CreateSale(sale *models.Sale) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextSaleID++
+	sale.ID = r.nextSaleID
+	sale.CreatedAt = time.Now().UTC()
+	ids := append([]int{}, sale.ProductIDs...)
+	sort.Ints(ids)
+	sale.ProductIDs = ids
+	r.sales = append(r.sales, copySale(*sale))
+	return nil
+}
+
+func (r *MemoryRepository) GetSale(id int) (*models.Sale, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, s := range r.sales {
+		if s.ID == id {
+			sale := copySale(s)
+			return &sale, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryRepository) ListSales(sellerID int) ([]models.Sale, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sales := []models.Sale{}
+	for _, s := range r.sales {
+		if sellerID == 0 || (s.SellerID != nil && *s.SellerID == sellerID) {
+			sales = append(sales, copySale(s))
+		}
+	}
+	sort.SliceStable(sales, func(i, j int) bool {
+		if !sales[i].StartsAt.Equal(sales[j].StartsAt) {
+			return sales[i].StartsAt.After(sales[j].StartsAt)
+		}
+		return sales[i].ID > sales[j].ID
+	})
+	return sales, nil
+}
+
+func (r *MemoryRepository) ListActiveSales(at time.Time) ([]models.Sale, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.activeSalesLocked(at), nil
+}
+
+func (r *MemoryRepository) activeSalesLocked(at time.Time) []models.Sale {
+	sales := []models.Sale{}
+	for _, s := range r.sales {
+		if s.Status == models.SaleActive && !s.StartsAt.After(at) && s.EndsAt.After(at) {
+			sales = append(sales, copySale(s))
+		}
+	}
+	return sales
+}
+
+func (r *MemoryRepository) AdvanceSales(at time.Time) ([]models.Sale, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	started := []models.Sale{}
+	for i, s := range r.sales {
+		switch {
+		case (s.Status == models.SaleScheduled || s.Status == models.SaleActive) && !s.EndsAt.After(at):
+			r.sales[i].Status = models.SaleEnded
+		case s.Status == models.SaleScheduled && !s.StartsAt.After(at):
+			r.sales[i].Status = models.SaleActive
+			started = append(started, copySale(r.sales[i]))
+		}
+	}
+	return started, nil
+}
+
+func (r *MemoryRepository) CancelSale(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, s := range r.sales {
+		if s.ID != id {
+			continue
+		}
+		if s.Status != models.SaleScheduled && s.Status != models.SaleActive {
+			return ErrStatusChanged
+		}
+		r.sales[i].Status = models.SaleCancelled
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (r *MemoryRepository) ListSaleProducts(sale models.Sale) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := []models.Product{}
+	for _, p := range r.products {
+		target := models.SaleTarget{ProductID: p.ID, SellerID: sellerOf(p), Category: p.Category}
+		if p.IsApproved && sale.Covers(target) {
+			products = append(products, r.withOwner(p))
+		}
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	return products, nil
+}
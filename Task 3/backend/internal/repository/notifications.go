@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+
+	"catpc-backend/internal/models"
+)
+
+const notificationColumns = `id, user_id, type, COALESCE(product_id, 0), product_name,
+	COALESCE(order_id, 0), status, count, read_at IS NOT NULL, created_at`
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	var n models.Notification
+	err := row.Scan(&n.ID, &n.UserID, &n.Type, &n.ProductID, &n.ProductName,
+		&n.OrderID, &n.Status, &n.Count, &n.Read, &n.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// nullID сохраняет отсутствующий товар или заказ как NULL
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+func (r *PostgresRepository) CreateNotifications(notifications []models.Notification) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i := range notifications {
+		n := &notifications[i]
+		if err := tx.QueryRow(`
+			INSERT INTO notifications (user_id, type, product_id, product_name, order_id, status, count)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at
+		`, n.UserID, n.Type, nullID(n.ProductID), n.ProductName, nullID(n.OrderID), n.Status, n.Count).Scan(&n.ID, &n.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) ListNotifications(filter models.NotificationFilter) ([]models.Notification, error) {
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE user_id = $1`
+	args := []interface{}{filter.UserID}
+	if filter.UnreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	if filter.AfterID != 0 {
+		args = append(args, filter.AfterID)
+		query += " AND id > $" + strconv.Itoa(len(args))
+	}
+	if filter.Oldest {
+		query += " ORDER BY id"
+	} else {
+		query += " ORDER BY id DESC"
+	}
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += " LIMIT $" + strconv.Itoa(len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *PostgresRepository) CountUnreadNotifications(userID int) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresRepository) MarkNotificationRead(userID, id int) error {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) MarkAllNotificationsRead(userID int) (int, error) {
+	result, err := r.db.Exec(`
+		UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND read_at IS NULL
+	`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func (r *PostgresRepository) ActiveUserIDsByRole(role string) ([]int, error) {
+	return r.userIDs(`SELECT id FROM users WHERE role = $1 AND COALESCE(is_active, true) ORDER BY id`, role)
+}
+
+func (r *PostgresRepository) ProductWatchers(productID int) ([]int, error) {
+	return r.userIDs(`
+		SELECT user_id FROM cart_items WHERE product_id = $1
+		UNION
+		SELECT user_id FROM price_alerts WHERE product_id = $1
+		ORDER BY user_id
+	`, productID)
+}
+
+func (r *PostgresRepository) userIDs(query string, args ...interface{}) ([]int, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
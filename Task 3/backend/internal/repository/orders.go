@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateOrder(order *models.Order) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Цены пересчитываются по акциям, действующим в момент фиксации заказа
+	sales, err := querySales(tx, activeSalesQuery, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	// Блокируем товары в порядке id, чтобы параллельные заказы не взаимоблокировались
+	items := append([]models.OrderItem(nil), order.Items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
+
+	for _, item := range items {
+		var price money.NullAmount
+		var stock, sellerID int
+		var approved bool
+		var category string
+		err := tx.QueryRow(`
+			SELECT price, stock, is_approved, COALESCE(user_id, 0), category
+			FROM products WHERE id = $1 FOR UPDATE
+		`, item.ProductID).Scan(&price, &stock, &approved, &sellerID, &category)
+		if err == sql.ErrNoRows {
+			return ErrCartChanged
+		}
+		if err != nil {
+			return err
+		}
+		target := models.SaleTarget{ProductID: item.ProductID, SellerID: sellerID, Category: category}
+		current, _ := models.BestSale(sales, target, price.Decimal)
+		if !approved || stock < item.Quantity || !current.Equal(item.PriceAtTime) {
+			return ErrCartChanged
+		}
+
+		if _, err := tx.Exec("UPDATE products SET stock = stock - $1 WHERE id = $2", item.Quantity, item.ProductID); err != nil {
+			return err
+		}
+	}
+
+	var address []byte
+	if order.Address != nil {
+		if address, err = json.Marshal(order.Address); err != nil {
+			return err
+		}
+	}
+
+	// Без доставки поля расчета остаются NULL
+	var methodID, deliveryName, deliveryKind interface{}
+	shipping := models.ShippingQuote{Cost: money.Zero}
+	if order.Shipping != nil {
+		shipping = *order.Shipping
+		methodID, deliveryName, deliveryKind = shipping.MethodID, shipping.Name, shipping.Kind
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO orders (user_id, total_amount, subtotal, currency, status, delivery_method_id,
+		                    delivery_name, delivery_kind, shipping_cost, weight_grams, shipping_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, order.UserID, order.Total, order.Subtotal, order.Currency, order.Status,
+		methodID, deliveryName, deliveryKind, shipping.Cost, shipping.Weight,
+		address).Scan(&order.ID, &order.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		var sellerID sql.NullInt64
+		var taxName, taxRate, taxInclusive, taxAmount interface{}
+		if item.Tax != nil {
+			taxName, taxRate, taxInclusive, taxAmount = item.Tax.Name, item.Tax.Rate, item.Tax.Inclusive, item.Tax.Amount
+		}
+		if err := tx.QueryRow(`
+			INSERT INTO order_items (order_id, product_id, product_name, quantity, price_at_time, seller_id,
+			                         tax_name, tax_rate, tax_inclusive, tax_amount)
+			VALUES ($1, $2, $3, $4, $5, (SELECT user_id FROM products WHERE id = $2), $6, $7, $8, $9)
+			RETURNING id, seller_id
+		`, order.ID, item.ProductID, item.Name, item.Quantity, item.PriceAtTime,
+			taxName, taxRate, taxInclusive, taxAmount).Scan(&item.ID, &sellerID); err != nil {
+			return err
+		}
+		item.SellerID = int(sellerID.Int64)
+		if _, err := tx.Exec(`
+			DELETE FROM cart_items WHERE user_id = $1 AND product_id = $2
+		`, order.UserID, item.ProductID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+const orderColumns = `
+	o.id, o.user_id, COALESCE(o.status, 'pending'), COALESCE(o.subtotal, o.total_amount, 0),
+	COALESCE(o.total_amount, 0), o.refunded, COALESCE(o.currency, ''), o.delivery_method_id, o.delivery_name,
+	COALESCE(o.delivery_kind, ''), o.shipping_cost, o.weight_grams, o.shipping_address, o.created_at
+`
+
+func scanOrder(row rowScanner) (*models.Order, error) {
+	var o models.Order
+	var methodID sql.NullInt64
+	var deliveryName sql.NullString
+	var shipping models.ShippingQuote
+	var address []byte
+	var createdAt sql.NullTime
+
+	err := row.Scan(&o.ID, &o.UserID, &o.Status, &o.Subtotal, &o.Total, &o.Refunded, &o.Currency,
+		&methodID, &deliveryName, &shipping.Kind, &shipping.Cost, &shipping.Weight,
+		&address, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// У заказов, оформленных до появления доставки, расчета нет
+	if deliveryName.Valid {
+		shipping.MethodID = int(methodID.Int64)
+		shipping.Name = deliveryName.String
+		o.Shipping = &shipping
+	}
+	if address != nil {
+		o.Address = &models.Address{}
+		if err := json.Unmarshal(address, o.Address); err != nil {
+			return nil, err
+		}
+	}
+	o.CreatedAt = createdAt.Time
+	o.Items = []models.OrderItem{}
+	return &o, nil
+}
+
+// loadOrderItems дополняет заказы позициями одним запросом
+func (r *PostgresRepository) loadOrderItems(orders []models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(orders))
+	index := make(map[int]int, len(orders))
+	for i, o := range orders {
+		ids[i] = int64(o.ID)
+		index[o.ID] = i
+	}
+
+	rows, err := r.db.Query(`
+		SELECT oi.order_id, oi.id, COALESCE(oi.product_id, 0), COALESCE(oi.seller_id, 0),
+		       COALESCE(oi.product_name, p.name, ''),
+		       oi.quantity, oi.price_at_time, oi.tax_name, oi.tax_rate, COALESCE(oi.tax_inclusive, false), oi.tax_amount
+		FROM order_items oi
+		LEFT JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = ANY($1)
+		ORDER BY oi.id
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var orderID int
+		var item models.OrderItem
+		var taxName sql.NullString
+		var taxRate, taxAmount money.NullAmount
+		var tax models.ItemTax
+		if err := rows.Scan(&orderID, &item.ID, &item.ProductID, &item.SellerID, &item.Name, &item.Quantity, &item.PriceAtTime,
+			&taxName, &taxRate, &tax.Inclusive, &taxAmount); err != nil {
+			return err
+		}
+		if taxName.Valid {
+			tax.Name, tax.Rate, tax.Amount = taxName.String, taxRate.Decimal, taxAmount.Decimal
+			item.Tax = &tax
+		}
+		o := &orders[index[orderID]]
+		o.Items = append(o.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range orders {
+		orders[i].SumTaxes()
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListOrders(userID int) ([]models.Order, error) {
+	rows, err := r.db.Query(`
+		SELECT `+orderColumns+`
+		FROM orders o
+		WHERE o.user_id = $1
+		ORDER BY o.created_at DESC, o.id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, r.loadOrderItems(orders)
+}
+
+func (r *PostgresRepository) GetOrder(userID, id int) (*models.Order, error) {
+	return r.getOrder("o.id = $1 AND o.user_id = $2", id, userID)
+}
+
+func (r *PostgresRepository) GetOrderByID(id int) (*models.Order, error) {
+	return r.getOrder("o.id = $1", id)
+}
+
+func (r *PostgresRepository) getOrder(where string, args ...interface{}) (*models.Order, error) {
+	o, err := scanOrder(r.db.QueryRow(`
+		SELECT `+orderColumns+`
+		FROM orders o
+		WHERE `+where, args...))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []models.Order{*o}
+	if err := r.loadOrderItems(orders); err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+func (r *PostgresRepository) UpdateOrderStatus(id int, from, to string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE orders SET status = $1 WHERE id = $2 AND COALESCE(status, 'pending') = $3
+	`, to, id, from)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrStatusChanged
+	}
+
+	if to == models.OrderCancelled {
+		rows, err := tx.Query(`
+			SELECT product_id, quantity FROM order_items
+			WHERE order_id = $1 AND product_id IS NOT NULL
+			ORDER BY product_id
+		`, id)
+		if err != nil {
+			return err
+		}
+		var items []models.OrderItem
+		for rows.Next() {
+			var item models.OrderItem
+			if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+				rows.Close()
+				return err
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err := restock(tx, item.ProductID, item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// restock возвращает товар на склад при отмене заказа или получении возврата.
+// Удаленные из каталога товары пропускаются.
+func restock(tx *sql.Tx, productID, quantity int) error {
+	_, err := tx.Exec("UPDATE products SET stock = stock + $1 WHERE id = $2", quantity, productID)
+	return err
+}
+
+func (r *PostgresRepository) HasPurchased(userID, productID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.user_id = $1 AND oi.product_id = $2 AND COALESCE(o.status, 'pending') <> $3
+		)
+	`, userID, productID, models.OrderCancelled).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"database/sql"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+)
+
+// recordPriceChange пишет в историю текущую цену товара, если она отличается от oldPrice
+// (у нового товара oldPrice пуст). Сравнение идет в numeric, поэтому округление
+// до копеек не создает лишних записей. Вызывается в транзакции изменения товара.
+func recordPriceChange(tx *sql.Tx, productID int, oldPrice money.NullAmount, actorID *int, source string) error {
+	_, err := tx.Exec(`
+		INSERT INTO product_price_history (product_id, old_price, new_price, changed_by, source)
+		SELECT id, $2::numeric, price, $3::integer, $4
+		FROM products
+		WHERE id = $1 AND price IS DISTINCT FROM $2::numeric
+	`, productID, oldPrice, actorID, source)
+	return err
+}
+
+func (r *PostgresRepository) ListPriceHistory(productID int) ([]models.PriceChange, error) {
+	rows, err := r.db.Query(`
+		SELECT id, product_id, old_price, new_price, changed_by, source, changed_at
+		FROM product_price_history
+		WHERE product_id = $1
+		ORDER BY changed_at, id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.PriceChange{}
+	for rows.Next() {
+		var change models.PriceChange
+		var oldPrice money.NullAmount
+		var changedBy sql.NullInt64
+		if err := rows.Scan(&change.ID, &change.ProductID, &oldPrice, &change.NewPrice,
+			&changedBy, &change.Source, &change.ChangedAt); err != nil {
+			return nil, err
+		}
+		if oldPrice.Valid {
+			change.OldPrice = &oldPrice.Decimal
+		}
+		if changedBy.Valid {
+			id := int(changedBy.Int64)
+			change.ChangedBy = &id
+		}
+		history = append(history, change)
+	}
+	return history, rows.Err()
+}
+
+const priceAlertColumns = `
+	a.id, a.user_id, a.product_id, p.name, p.price, a.target_price,
+	a.created_at, a.triggered_at, a.triggered_price
+`
+
+func scanPriceAlerts(rows *sql.Rows) ([]models.PriceAlert, error) {
+	defer rows.Close()
+
+	alerts := []models.PriceAlert{}
+	for rows.Next() {
+		var a models.PriceAlert
+		var triggeredAt sql.NullTime
+		var triggeredPrice money.NullAmount
+		if err := rows.Scan(&a.ID, &a.UserID, &a.ProductID, &a.ProductName, &a.CurrentPrice,
+			&a.TargetPrice, &a.CreatedAt, &triggeredAt, &triggeredPrice); err != nil {
+			return nil, err
+		}
+		if triggeredAt.Valid {
+			a.TriggeredAt = &triggeredAt.Time
+		}
+		if triggeredPrice.Valid {
+			a.TriggeredPrice = &triggeredPrice.Decimal
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+func (r *PostgresRepository) ListPriceAlerts(userID int) ([]models.PriceAlert, error) {
+	rows, err := r.db.Query(`
+		SELECT `+priceAlertColumns+`
+		FROM price_alerts a
+		JOIN products p ON p.id = a.product_id
+		WHERE a.user_id = $1
+		ORDER BY a.created_at DESC, a.id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanPriceAlerts(rows)
+}
+
+func (r *PostgresRepository) UpsertPriceAlert(userID, productID int, target money.Amount) error {
+	_, err := r.db.Exec(`
+		INSERT INTO price_alerts (user_id, product_id, target_price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, product_id) DO UPDATE
+		SET target_price = EXCLUDED.target_price,
+		    created_at = CURRENT_TIMESTAMP,
+		    triggered_at = NULL,
+		    triggered_price = NULL
+	`, userID, productID, target)
+	return err
+}
+
+func (r *PostgresRepository) DeletePriceAlert(userID, productID int) error {
+	result, err := r.db.Exec("DELETE FROM price_alerts WHERE user_id = $1 AND product_id = $2", userID, productID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) TriggerPriceAlerts(productID int, price money.Amount) ([]models.PriceAlert, error) {
+	rows, err := r.db.Query(`
+		WITH triggered AS (
+			UPDATE price_alerts a
+			SET triggered_at = CURRENT_TIMESTAMP, triggered_price = $2::numeric
+			WHERE a.product_id = $1 AND a.triggered_at IS NULL AND $2::numeric <= a.target_price
+			RETURNING a.*
+		)
+		SELECT `+priceAlertColumns+`
+		FROM triggered a
+		JOIN products p ON p.id = a.product_id
+		ORDER BY a.id
+	`, productID, price)
+	if err != nil {
+		return nil, err
+	}
+	return scanPriceAlerts(rows)
+}
@@ -0,0 +1,422 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+
+	"github.com/lib/pq"
+)
+
+const productColumns = `
+	p.id, p.name, COALESCE(p.description, ''), p.price, COALESCE(p.image, ''), p.stock,
+	p.weight_grams, p.user_id, u.username, p.is_approved, COALESCE(p.sku, ''), p.created_at,
+	p.updated_at, p.category, p.attributes
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduct(row rowScanner) (*models.Product, error) {
+	var p models.Product
+	var userID sql.NullInt64
+	var username sql.NullString
+	var createdAt sql.NullTime
+	var attributes []byte
+
+	err := row.Scan(&p.ID, &p.Name, &p.Description, &p.Price, &p.Image, &p.Stock, &p.Weight,
+		&userID, &username, &p.IsApproved, &p.SKU, &createdAt, &p.UpdatedAt, &p.Category, &attributes)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(attributes, &p.Attributes); err != nil {
+		return nil, err
+	}
+	if len(p.Attributes) == 0 {
+		p.Attributes = nil
+	}
+
+	if userID.Valid {
+		id := int(userID.Int64)
+		p.UserID = &id
+		p.Username = username.String
+	}
+
+	if createdAt.Valid {
+		p.CreatedAt = createdAt.Time.Format("2006-01-02 15:04:05")
+	}
+
+	return &p, nil
+}
+
+// attributesJSON кодирует характеристики для колонки jsonb; nil хранится как {}
+func attributesJSON(attributes map[string]string) string {
+	if len(attributes) == 0 {
+		return "{}"
+	}
+	data, _ := json.Marshal(attributes)
+	return string(data)
+}
+
+func (r *PostgresRepository) queryProducts(query string, args ...interface{}) ([]models.Product, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []models.Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			continue
+		}
+		products = append(products, *p)
+	}
+
+	return products, nil
+}
+
+func (r *PostgresRepository) ListApprovedProducts(limit, offset int) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.is_approved = true
+		ORDER BY p.id
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+}
+
+func (r *PostgresRepository) ListApprovedProductsAfter(afterID, limit int) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.is_approved = true AND p.id > $1
+		ORDER BY p.id
+		LIMIT $2
+	`, afterID, limit)
+}
+
+func (r *PostgresRepository) ListApprovedProductsByIDs(ids []int) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.is_approved = true AND p.id = ANY($1)
+		ORDER BY p.id
+	`, pq.Array(ids))
+}
+
+func (r *PostgresRepository) ListApprovedProductsBySellers(sellerIDs []int, limit int) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY id) AS n
+				FROM products
+				WHERE is_approved = true AND user_id = ANY($1)
+			) ranked
+			WHERE n <= $2
+		)
+		ORDER BY p.id
+	`, pq.Array(sellerIDs), limit)
+}
+
+func (r *PostgresRepository) ListApprovedProductsByCategories(categories []string, limit int) ([]models.Product, error) {
+	lower := make([]string, len(categories))
+	for i, c := range categories {
+		lower[i] = strings.ToLower(c)
+	}
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY lower(category) ORDER BY id) AS n
+				FROM products
+				WHERE is_approved = true AND lower(category) = ANY($1)
+			) ranked
+			WHERE n <= $2
+		)
+		ORDER BY p.id
+	`, pq.Array(lower), limit)
+}
+
+func (r *PostgresRepository) ListCategories() ([]models.Category, error) {
+	rows, err := r.db.Query(`
+		SELECT MIN(category), COUNT(*)
+		FROM products
+		WHERE is_approved = true AND category <> ''
+		GROUP BY lower(category)
+		ORDER BY lower(category)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []models.Category{}
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.Name, &c.ProductCount); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (r *PostgresRepository) CountApprovedProducts() (int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM products WHERE is_approved = true").Scan(&total)
+	return total, err
+}
+
+func (r *PostgresRepository) GetProductByID(id int) (*models.Product, error) {
+	p, err := scanProduct(r.db.QueryRow(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return p, err
+}
+
+func (r *PostgresRepository) ListProductsByUser(userID int) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.user_id = $1
+		ORDER BY p.id
+	`, userID)
+}
+
+func (r *PostgresRepository) ListPendingProducts() ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT ` + productColumns + `
+		FROM products p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.is_approved = false
+		ORDER BY p.id
+	`)
+}
+
+func (r *PostgresRepository) CreateProduct(product *models.Product) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO products (name, description, price, image, stock, weight_grams, user_id, is_approved,
+			category, attributes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10::jsonb)
+		RETURNING id
+	`, product.Name, product.Description, product.Price, product.Image, product.Stock,
+		product.Weight, product.UserID, product.IsApproved, product.Category, attributesJSON(product.Attributes)).Scan(&product.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordPriceChange(tx, product.ID, money.NullAmount{}, product.UserID, models.PriceSourceCreate); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) UpdateProduct(product *models.Product, resetApproval bool, actorID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldPrice money.NullAmount
+	err = tx.QueryRow("SELECT price FROM products WHERE id = $1 FOR UPDATE", product.ID).Scan(&oldPrice)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products
+		SET name = $1, description = $2, price = $3, image = $4, stock = $5, weight_grams = $6,
+		    category = $7, attributes = $8::jsonb,
+		    is_approved = CASE WHEN $9::boolean THEN false ELSE is_approved END
+		WHERE id = $10
+	`, product.Name, product.Description, product.Price, product.Image, product.Stock, product.Weight,
+		product.Category, attributesJSON(product.Attributes), resetApproval, product.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := recordPriceChange(tx, product.ID, oldPrice, &actorID, models.PriceSourceUpdate); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) SetProductApproved(id int, approved bool) error {
+	_, err := r.db.Exec("UPDATE products SET is_approved = $1 WHERE id = $2", approved, id)
+	return err
+}
+
+func (r *PostgresRepository) ProductInCarts(id int) (bool, error) {
+	var inCart bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM cart_items WHERE product_id = $1)", id).Scan(&inCart)
+	return inCart, err
+}
+
+func (r *PostgresRepository) DeleteProduct(id int) error {
+	_, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
+	return err
+}
+
+func (r *PostgresRepository) ForceDeleteProduct(id int) error {
+	if _, err := r.db.Exec("DELETE FROM cart_items WHERE product_id = $1", id); err != nil {
+		return err
+	}
+	_, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
+	return err
+}
+
+func (r *PostgresRepository) ImportProducts(sellerID int, approved bool, rows []models.ImportRow, commit bool) ([]models.ImportOutcome, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	outcomes := make([]models.ImportOutcome, len(rows))
+	failed := false
+	for i, row := range rows {
+		// Точка сохранения не дает ошибке одной строки прервать всю транзакцию
+		if _, err := tx.Exec("SAVEPOINT import_row"); err != nil {
+			return nil, false, err
+		}
+
+		productID, action, err := importRow(tx, sellerID, approved, row)
+		if err != nil {
+			tx.Exec("ROLLBACK TO SAVEPOINT import_row")
+			slog.Warn("Ошибка импорта строки", "line", i+1, "sku", row.SKU, "error", err)
+			outcomes[i] = models.ImportOutcome{Action: "error", Err: err}
+			failed = true
+			continue
+		}
+
+		tx.Exec("RELEASE SAVEPOINT import_row")
+		outcomes[i] = models.ImportOutcome{ProductID: productID, Action: action}
+	}
+
+	if !commit || failed {
+		return outcomes, false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	return outcomes, true, nil
+}
+
+// importRow создает товар или обновляет товар продавца с тем же SKU
+func importRow(tx *sql.Tx, sellerID int, approved bool, row models.ImportRow) (int, string, error) {
+	image := row.Image
+
+	var productID int
+	var currentImage string
+	var oldPrice money.NullAmount
+	err := tx.QueryRow(`
+		SELECT id, COALESCE(image, ''), price FROM products
+		WHERE user_id = $1 AND sku = $2
+		FOR UPDATE
+	`, sellerID, row.SKU).Scan(&productID, &currentImage, &oldPrice)
+
+	switch {
+	case err == sql.ErrNoRows:
+		if image == "" {
+			image = "default.png"
+		}
+		err = tx.QueryRow(`
+			INSERT INTO products (name, description, price, image, stock, user_id, is_approved, sku)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`, row.Name, row.Description, row.Price, image, row.Stock, sellerID, approved, row.SKU).Scan(&productID)
+		if err == nil {
+			err = recordPriceChange(tx, productID, money.NullAmount{}, &sellerID, models.PriceSourceImport)
+		}
+		return productID, "create", err
+
+	case err != nil:
+		return 0, "", err
+	}
+
+	if image == "" {
+		image = currentImage
+	}
+
+	// Как и в UpdateProduct: изменения продавца снова отправляются на модерацию
+	if approved {
+		_, err = tx.Exec(`
+			UPDATE products
+			SET name = $1, description = $2, price = $3, image = $4, stock = $5
+			WHERE id = $6
+		`, row.Name, row.Description, row.Price, image, row.Stock, productID)
+	} else {
+		_, err = tx.Exec(`
+			UPDATE products
+			SET name = $1, description = $2, price = $3, image = $4, stock = $5, is_approved = false
+			WHERE id = $6
+		`, row.Name, row.Description, row.Price, image, row.Stock, productID)
+	}
+	if err == nil {
+		err = recordPriceChange(tx, productID, oldPrice, &sellerID, models.PriceSourceImport)
+	}
+	return productID, "update", err
+}
+
+func (r *PostgresRepository) RecordUpload(userID int, filename string) error {
+	_, err := r.db.Exec(`
+		INSERT INTO uploads (user_id, filename) VALUES ($1, $2)
+		ON CONFLICT (user_id, filename) DO NOTHING
+	`, userID, filename)
+	return err
+}
+
+func (r *PostgresRepository) OwnedImages(userID int, filenames []string) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT filename FROM uploads WHERE user_id = $1 AND filename = ANY($2)
+		UNION
+		SELECT image FROM products WHERE user_id = $1 AND image = ANY($2)
+	`, userID, pq.Array(filenames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owned []string
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err != nil {
+			return nil, err
+		}
+		owned = append(owned, filename)
+	}
+	return owned, rows.Err()
+}
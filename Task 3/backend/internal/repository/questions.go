@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+
+	"catpc-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateQuestion(q *models.Question) error {
+	return r.db.QueryRow(`
+		INSERT INTO questions (product_id, user_id, body, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, (SELECT username FROM users WHERE id = $2)
+	`, q.ProductID, q.UserID, q.Body, q.Status).Scan(&q.ID, &q.CreatedAt, &q.Username)
+}
+
+const questionColumns = `q.id, q.product_id, q.user_id, u.username, q.body, q.status, q.created_at`
+
+func scanQuestion(row rowScanner) (*models.Question, error) {
+	var q models.Question
+	if err := row.Scan(&q.ID, &q.ProductID, &q.UserID, &q.Username, &q.Body, &q.Status, &q.CreatedAt); err != nil {
+		return nil, err
+	}
+	q.Answers = []models.Answer{}
+	return &q, nil
+}
+
+func (r *PostgresRepository) queryQuestions(query string, args ...interface{}) ([]models.Question, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	questions := []models.Question{}
+	for rows.Next() {
+		q, err := scanQuestion(rows)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, *q)
+	}
+	return questions, rows.Err()
+}
+
+func (r *PostgresRepository) GetQuestion(id int) (*models.Question, error) {
+	q, err := scanQuestion(r.db.QueryRow(`
+		SELECT `+questionColumns+`
+		FROM questions q
+		JOIN users u ON u.id = q.user_id
+		WHERE q.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return q, err
+}
+
+func (r *PostgresRepository) ListQuestions(productID int, answeredOnly bool, limit int) ([]models.Question, error) {
+	query := `
+		SELECT ` + questionColumns + `
+		FROM questions q
+		JOIN users u ON u.id = q.user_id
+		WHERE q.product_id = $1 AND q.status = $2`
+	if answeredOnly {
+		query += `
+		  AND EXISTS (SELECT 1 FROM answers a WHERE a.question_id = q.id AND a.status = $2)`
+	}
+	query += `
+		ORDER BY q.created_at DESC, q.id DESC`
+	if limit > 0 {
+		query += " LIMIT " + strconv.Itoa(limit)
+	}
+
+	questions, err := r.queryQuestions(query, productID, models.QAPublished)
+	if err != nil {
+		return nil, err
+	}
+	return questions, r.loadAnswers(questions)
+}
+
+func (r *PostgresRepository) ListQuestionsByStatus(status string) ([]models.Question, error) {
+	return r.queryQuestions(`
+		SELECT `+questionColumns+`
+		FROM questions q
+		JOIN users u ON u.id = q.user_id
+		WHERE q.status = $1
+		ORDER BY q.created_at, q.id
+	`, status)
+}
+
+func (r *PostgresRepository) SetQuestionStatus(id int, status string) error {
+	res, err := r.db.Exec("UPDATE questions SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+const answerColumns = `
+	a.id, a.question_id, a.user_id, u.username, a.body, a.is_seller, a.is_buyer,
+	(SELECT COUNT(*) FROM answer_votes v WHERE v.answer_id = a.id) AS votes, a.status, a.created_at
+`
+
+func scanAnswer(row rowScanner) (*models.Answer, error) {
+	var a models.Answer
+	err := row.Scan(&a.ID, &a.QuestionID, &a.UserID, &a.Username, &a.Body, &a.IsSeller, &a.IsBuyer,
+		&a.Votes, &a.Status, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *PostgresRepository) queryAnswers(query string, args ...interface{}) ([]models.Answer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	answers := []models.Answer{}
+	for rows.Next() {
+		a, err := scanAnswer(rows)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, *a)
+	}
+	return answers, rows.Err()
+}
+
+// loadAnswers дополняет вопросы опубликованными ответами одним запросом
+func (r *PostgresRepository) loadAnswers(questions []models.Question) error {
+	if len(questions) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(questions))
+	index := make(map[int]int, len(questions))
+	for i, q := range questions {
+		ids[i] = int64(q.ID)
+		index[q.ID] = i
+	}
+
+	answers, err := r.queryAnswers(`
+		SELECT `+answerColumns+`
+		FROM answers a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.question_id = ANY($1) AND a.status = $2
+		ORDER BY a.is_seller DESC, votes DESC, a.created_at, a.id
+	`, pq.Array(ids), models.QAPublished)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range answers {
+		q := &questions[index[a.QuestionID]]
+		q.Answers = append(q.Answers, a)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListAnswersByStatus(status string) ([]models.Answer, error) {
+	return r.queryAnswers(`
+		SELECT `+answerColumns+`
+		FROM answers a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.status = $1
+		ORDER BY a.created_at, a.id
+	`, status)
+}
+
+func (r *PostgresRepository) CreateAnswer(a *models.Answer) error {
+	return r.db.QueryRow(`
+		INSERT INTO answers (question_id, user_id, body, is_seller, is_buyer, status)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, (SELECT username FROM users WHERE id = $2)
+	`, a.QuestionID, a.UserID, a.Body, a.IsSeller, a.IsBuyer, a.Status).Scan(&a.ID, &a.CreatedAt, &a.Username)
+}
+
+func (r *PostgresRepository) GetAnswer(id int) (*models.Answer, error) {
+	a, err := scanAnswer(r.db.QueryRow(`
+		SELECT `+answerColumns+`
+		FROM answers a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return a, err
+}
+
+func (r *PostgresRepository) SetAnswerStatus(id int, status string) error {
+	res, err := r.db.Exec("UPDATE answers SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgresRepository) VoteAnswer(answerID, userID int, up bool) error {
+	var err error
+	if up {
+		_, err = r.db.Exec(`
+			INSERT INTO answer_votes (answer_id, user_id) VALUES ($1, $2)
+			ON CONFLICT (answer_id, user_id) DO NOTHING
+		`, answerID, userID)
+	} else {
+		_, err = r.db.Exec("DELETE FROM answer_votes WHERE answer_id = $1 AND user_id = $2", answerID, userID)
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"catpc-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) RecordView(viewer string, productID int) error {
+	_, err := r.db.Exec(`
+		INSERT INTO product_views (viewer, product_id) VALUES ($1, $2)
+		ON CONFLICT (viewer, product_id) DO UPDATE SET viewed_at = CURRENT_TIMESTAMP
+	`, viewer, productID)
+	return err
+}
+
+func (r *PostgresRepository) ListViewed(viewer string, exclude []int, limit int) ([]models.Product, error) {
+	if exclude == nil {
+		exclude = []int{}
+	}
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM product_views v
+		JOIN products p ON p.id = v.product_id
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE v.viewer = $1 AND NOT (v.product_id = ANY($2::int[]))
+			AND p.is_approved = true AND p.stock > 0
+		ORDER BY v.viewed_at DESC, p.id
+		LIMIT $3
+	`, viewer, pq.Array(exclude), limit)
+}
+
+func (r *PostgresRepository) ListBoughtTogether(productIDs []int, limit int) ([]models.Product, error) {
+	if len(productIDs) == 0 {
+		return []models.Product{}, nil
+	}
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM (
+			SELECT related_id, SUM(orders_count) AS total
+			FROM product_pairs
+			WHERE product_id = ANY($1::int[]) AND NOT (related_id = ANY($1::int[]))
+			GROUP BY related_id
+		) pair
+		JOIN products p ON p.id = pair.related_id
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.is_approved = true AND p.stock > 0
+		ORDER BY pair.total DESC, p.id
+		LIMIT $2
+	`, pq.Array(productIDs), limit)
+}
+
+func (r *PostgresRepository) RecomputeProductPairs() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_pairs`); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO product_pairs (product_id, related_id, orders_count)
+		SELECT a.product_id, b.product_id, COUNT(DISTINCT a.order_id)
+		FROM order_items a
+		JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+		JOIN orders o ON o.id = a.order_id
+		WHERE o.status <> $1 AND a.product_id IS NOT NULL AND b.product_id IS NOT NULL
+		GROUP BY a.product_id, b.product_id
+	`, models.OrderCancelled)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
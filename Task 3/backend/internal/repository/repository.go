@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+)
+
+var (
+	ErrNotFound = errors.New("запись не найдена")
+	ErrConflict = errors.New("запись уже существует")
+	// ErrCartChanged — остаток, цена или доступность товара изменились после чтения корзины
+	ErrCartChanged = errors.New("корзина изменилась")
+	// ErrStatusChanged — статус заказа или заявки успел измениться в другом запросе
+	ErrStatusChanged = errors.New("статус изменился")
+	// ErrReturnQuantity — к возврату заявлено больше, чем куплено в позиции
+	ErrReturnQuantity = errors.New("количество к возврату больше купленного")
+)
+
+type UserRepository interface {
+	CreateUser(user *models.User) error
+	GetUserByID(id int) (*models.User, error)
+	// GetUserByLogin ищет пользователя по имени или email
+	GetUserByLogin(login string) (*models.User, error)
+	UserExists(username, email string) (bool, error)
+	// ListUsers возвращает страницу пользователей по фильтру, новые первыми, и общее число найденных
+	ListUsers(filter models.UserFilter) ([]models.UserDetail, int, error)
+	UpdateUserRole(id int, role string) error
+	ToggleUserActive(id int) error
+	SetUserActive(id int, active bool) error
+	RecordLogin(userID int, client models.ClientInfo) error
+	// ListLogins возвращает последние входы пользователя, новые первыми
+	ListLogins(userID, limit int) ([]models.LoginRecord, error)
+	// GetUserByEmailToken ищет пользователя по коду подтверждения нового email
+	GetUserByEmailToken(token string) (*models.User, error)
+	// UpdateUser сохраняет имя, email, пароль и версию токенов. ErrConflict — имя
+	// или email заняты
+	UpdateUser(user *models.User) error
+	// DeleteUser обезличивает пользователя, сохраняя его заказы
+	DeleteUser(id int) error
+}
+
+type ProductRepository interface {
+	ListApprovedProducts(limit, offset int) ([]models.Product, error)
+	// ListApprovedProductsAfter — выборка по ключу: товары с id больше afterID
+	ListApprovedProductsAfter(afterID, limit int) ([]models.Product, error)
+	CountApprovedProducts() (int, error)
+	GetProductByID(id int) (*models.Product, error)
+	ListProductsByUser(userID int) ([]models.Product, error)
+	ListPendingProducts() ([]models.Product, error)
+	// Выборки пачкой для GraphQL: одобренные товары по id, продавцам и категориям
+	// (категории сравниваются без учета регистра), в порядке id. У каждого продавца
+	// и каждой категории берутся первые limit товаров.
+	ListApprovedProductsByIDs(ids []int) ([]models.Product, error)
+	ListApprovedProductsBySellers(sellerIDs []int, limit int) ([]models.Product, error)
+	ListApprovedProductsByCategories(categories []string, limit int) ([]models.Product, error)
+	// ListCategories возвращает категории одобренных товаров с числом товаров, по алфавиту.
+	// Написания одной категории в разном регистре считаются одной категорией.
+	ListCategories() ([]models.Category, error)
+	// CreateProduct создает товар и записывает начальную цену в историю
+	CreateProduct(product *models.Product) error
+	// UpdateProduct сохраняет изменения; resetApproval снова отправляет товар на модерацию.
+	// Изменение цены записывается в историю с автором actorID.
+	UpdateProduct(product *models.Product, resetApproval bool, actorID int) error
+	SetProductApproved(id int, approved bool) error
+	ProductInCarts(id int) (bool, error)
+	DeleteProduct(id int) error
+	// ForceDeleteProduct удаляет товар вместе с позициями корзин
+	ForceDeleteProduct(id int) error
+	// ImportProducts создает или обновляет товары продавца по SKU в одной транзакции.
+	// Транзакция фиксируется, только если commit = true и все строки записаны без ошибок.
+	ImportProducts(sellerID int, approved bool, rows []models.ImportRow, commit bool) ([]models.ImportOutcome, bool, error)
+	// RecordUpload запоминает, что пользователь загрузил файл изображения
+	RecordUpload(userID int, filename string) error
+	// OwnedImages возвращает из filenames те, что пользователь загружал сам
+	// или что уже стоят у его товаров
+	OwnedImages(userID int, filenames []string) ([]string, error)
+}
+
+type PriceRepository interface {
+	ListPriceHistory(productID int) ([]models.PriceChange, error)
+	ListPriceAlerts(userID int) ([]models.PriceAlert, error)
+	// UpsertPriceAlert создает подписку или заново взводит существующую с новой целью
+	UpsertPriceAlert(userID, productID int, target money.Amount) error
+	DeletePriceAlert(userID, productID int) error
+	// TriggerPriceAlerts отмечает сработавшими подписки, цель которых не ниже цены price —
+	// текущей цены товара с учетом действующей акции
+	TriggerPriceAlerts(productID int, price money.Amount) ([]models.PriceAlert, error)
+}
+
+type CartRepository interface {
+	GetCartItems(userID int) ([]models.CartItem, error)
+	// AddCartItem добавляет товар или увеличивает количество уже лежащего в корзине
+	AddCartItem(userID, productID, quantity int) error
+	UpdateCartItem(userID, itemID, quantity int) error
+	RemoveCartItem(userID, itemID int) error
+}
+
+type AddressRepository interface {
+	ListAddresses(userID int) ([]models.Address, error)
+	GetAddress(userID, id int) (*models.Address, error)
+	// CreateAddress сохраняет адрес; первый адрес пользователя становится адресом по умолчанию
+	CreateAddress(address *models.Address) error
+	// UpdateAddress сохраняет адрес; IsDefault = true переносит на него отметку по умолчанию,
+	// false отметку не снимает
+	UpdateAddress(address *models.Address) error
+	// DeleteAddress удаляет адрес; если он был по умолчанию, отметка переходит на самый новый
+	DeleteAddress(userID, id int) error
+}
+
+type DeliveryRepository interface {
+	ListDeliveryMethods(activeOnly bool) ([]models.DeliveryMethod, error)
+	GetDeliveryMethod(id int) (*models.DeliveryMethod, error)
+	CreateDeliveryMethod(method *models.DeliveryMethod) error
+	// UpdateDeliveryMethod заменяет поля и все ступени тарифа
+	UpdateDeliveryMethod(method *models.DeliveryMethod) error
+	DeleteDeliveryMethod(id int) error
+}
+
+type TaxRepository interface {
+	ListTaxRates() ([]models.TaxRate, error)
+	GetTaxRate(id int) (*models.TaxRate, error)
+	// CreateTaxRate и UpdateTaxRate возвращают ErrConflict, если ставка для той же
+	// пары категория — регион уже есть
+	CreateTaxRate(rate *models.TaxRate) error
+	UpdateTaxRate(rate *models.TaxRate) error
+	DeleteTaxRate(id int) error
+}
+
+type OrderRepository interface {
+	// CreateOrder в одной транзакции проверяет остатки и цены, списывает остатки,
+	// сохраняет заказ и убирает купленные товары из корзины. Цена позиции сверяется
+	// с ценой на момент фиксации с учетом действующих акций. ErrCartChanged — если
+	// товар успел закончиться, подорожать или подешеветь, в том числе из-за акции.
+	CreateOrder(order *models.Order) error
+	ListOrders(userID int) ([]models.Order, error)
+	GetOrder(userID, id int) (*models.Order, error)
+	GetOrderByID(id int) (*models.Order, error)
+	// UpdateOrderStatus меняет статус, если заказ все еще в статусе from, иначе ErrStatusChanged.
+	// Отмена возвращает товары заказа на склад.
+	UpdateOrderStatus(id int, from, to string) error
+	// HasPurchased сообщает, есть ли у пользователя неотмененный заказ с товаром
+	HasPurchased(userID, productID int) (bool, error)
+}
+
+type InvoiceRepository interface {
+	// GetInvoice возвращает счет заказа или ErrNotFound, если он еще не выставлен
+	GetInvoice(orderID int) (*models.Invoice, error)
+	// CreateInvoice сохраняет счет. Если к заказу уже выставлен счет (например,
+	// параллельным запросом), возвращается он, а новый отбрасывается.
+	CreateInvoice(invoice *models.Invoice) (*models.Invoice, error)
+}
+
+type ReturnRepository interface {
+	// CreateReturn сохраняет заявку, заполняя товар и сумму по позиции заказа.
+	// ErrReturnQuantity — если вместе с неотклоненными заявками по позиции
+	// возвращается больше, чем куплено.
+	CreateReturn(ret *models.Return) error
+	ListReturns(filter models.ReturnFilter) ([]models.Return, error)
+	GetReturn(id int) (*models.Return, error)
+	// UpdateReturnStatus меняет статус, если заявка все еще в статусе from, иначе ErrStatusChanged.
+	// Переход в received возвращает товар на склад.
+	UpdateReturnStatus(id int, from, to, comment string) error
+	// RefundReturn переводит полученный (received) возврат в refunded и добавляет его сумму
+	// к возвращенной по заказу. Заказ блокируется до конца транзакции; когда возвращена
+	// вся сумма товаров (доставка не возвращается), доставленный заказ переходит в refunded.
+	// Возвращает заказ после изменения и признак того, что он перешел в refunded.
+	RefundReturn(id int, comment string) (order *models.Order, refunded bool, err error)
+}
+
+type NotificationRepository interface {
+	// CreateNotifications сохраняет уведомления, заполняя id и время создания
+	CreateNotifications(notifications []models.Notification) error
+	ListNotifications(filter models.NotificationFilter) ([]models.Notification, error)
+	CountUnreadNotifications(userID int) (int, error)
+	// MarkNotificationRead отмечает уведомление прочитанным; ErrNotFound — если
+	// у пользователя нет такого уведомления
+	MarkNotificationRead(userID, id int) error
+	// MarkAllNotificationsRead отмечает прочитанными все уведомления пользователя
+	// и возвращает, сколько было непрочитанных
+	MarkAllNotificationsRead(userID int) (int, error)
+	// ActiveUserIDsByRole возвращает активных пользователей с ролью role
+	ActiveUserIDsByRole(role string) ([]int, error)
+	// ProductWatchers возвращает пользователей, которые ждут товар: он лежит
+	// у них в корзине или на него оформлена подписка на цену
+	ProductWatchers(productID int) ([]int, error)
+}
+
+type QuestionRepository interface {
+	CreateQuestion(q *models.Question) error
+	GetQuestion(id int) (*models.Question, error)
+	// ListQuestions возвращает опубликованные вопросы о товаре с опубликованными ответами,
+	// новые первыми. answeredOnly оставляет вопросы с ответами; limit = 0 — без ограничения.
+	ListQuestions(productID int, answeredOnly bool, limit int) ([]models.Question, error)
+	// ListQuestionsByStatus и ListAnswersByStatus — очередь модерации, старые первыми
+	ListQuestionsByStatus(status string) ([]models.Question, error)
+	ListAnswersByStatus(status string) ([]models.Answer, error)
+	SetQuestionStatus(id int, status string) error
+	CreateAnswer(a *models.Answer) error
+	GetAnswer(id int) (*models.Answer, error)
+	SetAnswerStatus(id int, status string) error
+	// VoteAnswer ставит (up = true) или снимает голос пользователя; повтор ничего не меняет
+	VoteAnswer(answerID, userID int, up bool) error
+}
+
+// RecommendationRepository хранит просмотры и пары товаров, купленных вместе.
+// Выборки возвращают только одобренные товары в наличии.
+type RecommendationRepository interface {
+	// RecordView запоминает просмотр товара; viewer — пользователь или анонимная сессия
+	RecordView(viewer string, productID int) error
+	// ListViewed возвращает последние просмотренные товары, кроме exclude
+	ListViewed(viewer string, exclude []int, limit int) ([]models.Product, error)
+	// ListBoughtTogether возвращает товары, которые чаще всего заказывали вместе
+	// с productIDs, кроме самих productIDs
+	ListBoughtTogether(productIDs []int, limit int) ([]models.Product, error)
+	// RecomputeProductPairs пересчитывает пары по всем неотмененным заказам
+	RecomputeProductPairs() error
+}
+
+// CompareRepository хранит списки сравнения; owner устроен как viewer в RecommendationRepository
+type CompareRepository interface {
+	// ListCompareItems возвращает одобренные товары из списка в порядке добавления
+	ListCompareItems(owner string) ([]models.Product, error)
+	// AddCompareItem добавляет товар; повторное добавление ничего не меняет
+	AddCompareItem(owner string, productID int) error
+	// RemoveCompareItem убирает товар из списка или возвращает ErrNotFound
+	RemoveCompareItem(owner string, productID int) error
+	ClearCompareItems(owner string) error
+}
+
+// SaleRepository хранит акции. Действует акция в статусе active, если момент
+// времени попадает в [starts_at, ends_at).
+type SaleRepository interface {
+	CreateSale(sale *models.Sale) error
+	GetSale(id int) (*models.Sale, error)
+	// ListSales возвращает акции продавца или, при sellerID = 0, все акции; поздние первыми
+	ListSales(sellerID int) ([]models.Sale, error)
+	// ListActiveSales возвращает акции, действующие в момент at
+	ListActiveSales(at time.Time) ([]models.Sale, error)
+	// AdvanceSales завершает прошедшие акции и запускает наступившие; возвращает запущенные
+	AdvanceSales(at time.Time) ([]models.Sale, error)
+	// CancelSale отменяет запланированную или идущую акцию, иначе ErrStatusChanged
+	CancelSale(id int) error
+	// ListSaleProducts возвращает одобренные товары, на которые действует акция
+	ListSaleProducts(sale models.Sale) ([]models.Product, error)
+}
+
+type AnalyticsRepository interface {
+	SalesReport(filter models.AnalyticsFilter) (*models.AnalyticsReport, error)
+}
+
+type Repository interface {
+	UserRepository
+	ProductRepository
+	PriceRepository
+	CartRepository
+	AddressRepository
+	DeliveryRepository
+	TaxRepository
+	OrderRepository
+	InvoiceRepository
+	ReturnRepository
+	NotificationRepository
+	QuestionRepository
+	RecommendationRepository
+	CompareRepository
+	SaleRepository
+	AnalyticsRepository
+	// Ping проверяет, что хранилище доступно и отвечает на запросы
+	Ping(ctx context.Context) error
+}
+
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+func (r *PostgresRepository) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
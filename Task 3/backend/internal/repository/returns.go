@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateReturn(ret *models.Return) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Блокировка позиции не дает двум заявкам одновременно превысить купленное количество
+	var item models.OrderItem
+	var productID, sellerID sql.NullInt64
+	var taxAmount money.NullAmount
+	var taxInclusive bool
+	err = tx.QueryRow(`
+		SELECT oi.quantity, oi.product_id, oi.seller_id, COALESCE(oi.product_name, ''),
+		       oi.price_at_time, COALESCE(oi.tax_inclusive, false), oi.tax_amount
+		FROM order_items oi
+		WHERE oi.id = $1 AND oi.order_id = $2
+		FOR UPDATE
+	`, ret.OrderItemID, ret.OrderID).Scan(&item.Quantity, &productID, &sellerID, &ret.ProductName,
+		&item.PriceAtTime, &taxInclusive, &taxAmount)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var returned int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(quantity), 0) FROM returns WHERE order_item_id = $1 AND status <> $2
+	`, ret.OrderItemID, models.ReturnRejected).Scan(&returned)
+	if err != nil {
+		return err
+	}
+	if returned+ret.Quantity > item.Quantity {
+		return ErrReturnQuantity
+	}
+	if taxAmount.Valid {
+		item.Tax = &models.ItemTax{Inclusive: taxInclusive, Amount: taxAmount.Decimal}
+	}
+	ret.Amount = item.ReturnAmount(returned, ret.Quantity)
+
+	ret.ProductID = int(productID.Int64)
+	ret.SellerID = int(sellerID.Int64)
+	ret.Status = models.ReturnRequested
+	if ret.Photos == nil {
+		ret.Photos = []string{}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO returns (order_id, order_item_id, user_id, quantity, reason, photos, amount, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`, ret.OrderID, ret.OrderItemID, ret.UserID, ret.Quantity, ret.Reason, pq.Array(ret.Photos),
+		ret.Amount, ret.Status).Scan(&ret.ID, &ret.CreatedAt, &ret.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const returnColumns = `
+	rt.id, rt.order_id, rt.order_item_id, rt.user_id, COALESCE(oi.seller_id, 0),
+	COALESCE(oi.product_id, 0), COALESCE(oi.product_name, ''), rt.quantity, rt.reason,
+	rt.photos, rt.amount, rt.status, rt.comment, rt.created_at, rt.updated_at
+`
+
+func scanReturn(row rowScanner) (*models.Return, error) {
+	var ret models.Return
+	err := row.Scan(&ret.ID, &ret.OrderID, &ret.OrderItemID, &ret.UserID, &ret.SellerID,
+		&ret.ProductID, &ret.ProductName, &ret.Quantity, &ret.Reason,
+		pq.Array(&ret.Photos), &ret.Amount, &ret.Status, &ret.Comment, &ret.CreatedAt, &ret.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Photos == nil {
+		ret.Photos = []string{}
+	}
+	return &ret, nil
+}
+
+func (r *PostgresRepository) ListReturns(filter models.ReturnFilter) ([]models.Return, error) {
+	query := `
+		SELECT ` + returnColumns + `
+		FROM returns rt
+		JOIN order_items oi ON oi.id = rt.order_item_id
+		WHERE true`
+	var args []interface{}
+	if filter.UserID != 0 {
+		args = append(args, filter.UserID)
+		query += " AND rt.user_id = $" + strconv.Itoa(len(args))
+	}
+	if filter.SellerID != 0 {
+		args = append(args, filter.SellerID)
+		query += " AND oi.seller_id = $" + strconv.Itoa(len(args))
+	}
+	query += " ORDER BY rt.created_at DESC, rt.id DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	returns := []models.Return{}
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, *ret)
+	}
+	return returns, rows.Err()
+}
+
+func (r *PostgresRepository) GetReturn(id int) (*models.Return, error) {
+	ret, err := scanReturn(r.db.QueryRow(`
+		SELECT `+returnColumns+`
+		FROM returns rt
+		JOIN order_items oi ON oi.id = rt.order_item_id
+		WHERE rt.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return ret, err
+}
+
+// setReturnStatus меняет статус заявки, если она все еще в статусе from
+func setReturnStatus(tx *sql.Tx, id int, from, to, comment string) (orderID, productID, quantity int, err error) {
+	var product sql.NullInt64
+	err = tx.QueryRow(`
+		UPDATE returns rt
+		SET status = $1, comment = $2, updated_at = CURRENT_TIMESTAMP
+		FROM order_items oi
+		WHERE rt.id = $3 AND rt.status = $4 AND oi.id = rt.order_item_id
+		RETURNING rt.order_id, oi.product_id, rt.quantity
+	`, to, comment, id, from).Scan(&orderID, &product, &quantity)
+	if err == sql.ErrNoRows {
+		err = ErrStatusChanged
+	}
+	return orderID, int(product.Int64), quantity, err
+}
+
+func (r *PostgresRepository) UpdateReturnStatus(id int, from, to, comment string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, productID, quantity, err := setReturnStatus(tx, id, from, to, comment)
+	if err != nil {
+		return err
+	}
+	if to == models.ReturnReceived && productID != 0 {
+		if err := restock(tx, productID, quantity); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) RefundReturn(id int, comment string) (*models.Order, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	orderID, _, _, err := setReturnStatus(tx, id, models.ReturnReceived, models.ReturnRefunded, comment)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Блокировка заказа не дает одновременным возвратам посчитать сумму и статус
+	// по устаревшим данным
+	var status string
+	var refunded, goods, amount money.Amount
+	err = tx.QueryRow(`
+		SELECT o.status, o.refunded, o.total - o.shipping_cost, rt.amount
+		FROM orders o
+		JOIN returns rt ON rt.order_id = o.id
+		WHERE o.id = $1 AND rt.id = $2
+		FOR UPDATE OF o
+	`, orderID, id).Scan(&status, &refunded, &goods, &amount)
+	if err != nil {
+		return nil, false, err
+	}
+
+	refunded = refunded.Add(amount)
+	fullyRefunded := status == models.OrderDelivered && refunded.GreaterThanOrEqual(goods)
+	if fullyRefunded {
+		status = models.OrderRefunded
+	}
+	if _, err := tx.Exec("UPDATE orders SET refunded = $1, status = $2 WHERE id = $3", refunded, status, orderID); err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, err
+	}
+	order, err := r.GetOrderByID(orderID)
+	if err != nil {
+		return nil, false, err
+	}
+	return order, fullyRefunded, nil
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"catpc-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const saleColumns = `
+	s.id, s.name, s.discount_type, s.discount_value, s.starts_at, s.ends_at, s.category,
+	s.seller_id, s.status, s.created_by, s.created_at,
+	ARRAY(SELECT sp.product_id FROM sale_products sp WHERE sp.sale_id = s.id ORDER BY sp.product_id)
+`
+
+// activeSalesQuery — акции, действующие в момент $1. Окончание проверяется по времени,
+// а не только по статусу, поэтому акция заканчивается вовремя, даже если планировщик опоздал.
+const activeSalesQuery = `
+	SELECT ` + saleColumns + `
+	FROM sales s
+	WHERE s.status = 'active' AND s.starts_at <= $1 AND s.ends_at > $1
+	ORDER BY s.id
+`
+
+func scanSale(row rowScanner) (*models.Sale, error) {
+	var s models.Sale
+	var sellerID, createdBy sql.NullInt64
+	var productIDs []int64
+	err := row.Scan(&s.ID, &s.Name, &s.DiscountType, &s.DiscountValue, &s.StartsAt, &s.EndsAt, &s.Category,
+		&sellerID, &s.Status, &createdBy, &s.CreatedAt, pq.Array(&productIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	if sellerID.Valid {
+		id := int(sellerID.Int64)
+		s.SellerID = &id
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		s.CreatedBy = &id
+	}
+	s.ProductIDs = make([]int, len(productIDs))
+	for i, id := range productIDs {
+		s.ProductIDs[i] = int(id)
+	}
+	return &s, nil
+}
+
+// queryer — общее у *sql.DB и *sql.Tx: акции читаются и внутри транзакции заказа
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func querySales(q queryer, query string, args ...interface{}) ([]models.Sale, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []models.Sale{}
+	for rows.Next() {
+		s, err := scanSale(rows)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, *s)
+	}
+	return sales, rows.Err()
+}
+
+func (r *PostgresRepository) CreateSale(sale *models.Sale) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO sales (name, discount_type, discount_value, starts_at, ends_at, category, seller_id, status, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`, sale.Name, sale.DiscountType, sale.DiscountValue, sale.StartsAt.UTC(), sale.EndsAt.UTC(),
+		sale.Category, sale.SellerID, sale.Status, sale.CreatedBy).Scan(&sale.ID, &sale.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	if len(sale.ProductIDs) > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO sale_products (sale_id, product_id)
+			SELECT $1, unnest($2::int[])
+		`, sale.ID, pq.Array(sale.ProductIDs)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresRepository) GetSale(id int) (*models.Sale, error) {
+	sale, err := scanSale(r.db.QueryRow(`SELECT `+saleColumns+` FROM sales s WHERE s.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return sale, err
+}
+
+func (r *PostgresRepository) ListSales(sellerID int) ([]models.Sale, error) {
+	return querySales(r.db, `
+		SELECT `+saleColumns+`
+		FROM sales s
+		WHERE $1 = 0 OR s.seller_id = $1
+		ORDER BY s.starts_at DESC, s.id DESC
+	`, sellerID)
+}
+
+func (r *PostgresRepository) ListActiveSales(at time.Time) ([]models.Sale, error) {
+	return querySales(r.db, activeSalesQuery, at.UTC())
+}
+
+// AdvanceSales меняет статусы условным UPDATE: если планировщик запущен на нескольких
+// экземплярах, каждая акция запускается только одним из них
+func (r *PostgresRepository) AdvanceSales(at time.Time) ([]models.Sale, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE sales SET status = 'ended'
+		WHERE status IN ('scheduled', 'active') AND ends_at <= $1
+	`, at.UTC()); err != nil {
+		return nil, err
+	}
+
+	started, err := querySales(tx, `
+		WITH s AS (
+			UPDATE sales SET status = 'active'
+			WHERE status = 'scheduled' AND starts_at <= $1
+			RETURNING *
+		)
+		SELECT `+saleColumns+` FROM s ORDER BY s.id
+	`, at.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return started, tx.Commit()
+}
+
+func (r *PostgresRepository) CancelSale(id int) error {
+	result, err := r.db.Exec(`
+		UPDATE sales SET status = 'cancelled'
+		WHERE id = $1 AND status IN ('scheduled', 'active')
+	`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		if _, err := r.GetSale(id); err != nil {
+			return err
+		}
+		return ErrStatusChanged
+	}
+	return nil
+}
+
+func (r *PostgresRepository) ListSaleProducts(sale models.Sale) ([]models.Product, error) {
+	return r.queryProducts(`
+		SELECT `+productColumns+`
+		FROM products p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE p.is_approved = true
+		  AND ($2::int IS NULL OR p.user_id = $2)
+		  AND (p.id IN (SELECT product_id FROM sale_products WHERE sale_id = $1)
+		       OR ($3::text <> '' AND lower(p.category) = lower($3::text)))
+		ORDER BY p.id
+	`, sale.ID, sale.SellerID, sale.Category)
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"database/sql"
+
+	"catpc-backend/internal/models"
+)
+
+const taxRateColumns = `id, name, rate, category, region, inclusive, created_at`
+
+func scanTaxRate(row rowScanner) (*models.TaxRate, error) {
+	var t models.TaxRate
+	err := row.Scan(&t.ID, &t.Name, &t.Rate, &t.Category, &t.Region, &t.Inclusive, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PostgresRepository) ListTaxRates() ([]models.TaxRate, error) {
+	rows, err := r.db.Query(`SELECT ` + taxRateColumns + ` FROM tax_rates ORDER BY category, region, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := []models.TaxRate{}
+	for rows.Next() {
+		t, err := scanTaxRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *t)
+	}
+	return rates, rows.Err()
+}
+
+func (r *PostgresRepository) GetTaxRate(id int) (*models.TaxRate, error) {
+	t, err := scanTaxRate(r.db.QueryRow(`SELECT `+taxRateColumns+` FROM tax_rates WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return t, err
+}
+
+func (r *PostgresRepository) CreateTaxRate(rate *models.TaxRate) error {
+	err := r.db.QueryRow(`
+		INSERT INTO tax_rates (name, rate, category, region, inclusive)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, rate.Name, rate.Rate, rate.Category, rate.Region, rate.Inclusive).Scan(&rate.ID, &rate.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *PostgresRepository) UpdateTaxRate(rate *models.TaxRate) error {
+	err := r.db.QueryRow(`
+		UPDATE tax_rates SET name = $1, rate = $2, category = $3, region = $4, inclusive = $5
+		WHERE id = $6
+		RETURNING created_at
+	`, rate.Name, rate.Rate, rate.Category, rate.Region, rate.Inclusive, rate.ID).Scan(&rate.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	return err
+}
+
+func (r *PostgresRepository) DeleteTaxRate(id int) error {
+	result, err := r.db.Exec(`DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
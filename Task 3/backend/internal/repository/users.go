@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"catpc-backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (r *PostgresRepository) CreateUser(user *models.User) error {
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+
+	return r.db.QueryRow(`
+		INSERT INTO users (username, email, password_hash, role, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, is_active
+	`, user.Username, user.Email, user.PasswordHash, user.Role, user.CreatedAt).Scan(&user.ID, &user.IsActive)
+}
+
+// is_active и is_protected допускают NULL в старых строках: значения по умолчанию
+// подставляются так же, как в фильтрах ListUsers
+const userColumns = `
+	id, username, email, role, COALESCE(is_active, true), COALESCE(is_protected, false), password_hash, created_at,
+	COALESCE(pending_email, ''), COALESCE(email_token, ''), email_token_expires_at, token_version
+`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var emailTokenExpiresAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.IsActive,
+		&user.IsProtected, &user.PasswordHash, &user.CreatedAt,
+		&user.PendingEmail, &user.EmailToken, &emailTokenExpiresAt, &user.TokenVersion)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.EmailTokenExpiresAt = emailTokenExpiresAt.Time
+	return &user, nil
+}
+
+func (r *PostgresRepository) GetUserByID(id int) (*models.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+}
+
+func (r *PostgresRepository) GetUserByLogin(login string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE username = $1 OR email = $1`, login))
+}
+
+func (r *PostgresRepository) GetUserByEmailToken(token string) (*models.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email_token = $1`, token))
+}
+
+func (r *PostgresRepository) UserExists(username, email string) (bool, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE username = $1 OR email = $2",
+		username, email).Scan(&count)
+	return count > 0, err
+}
+
+// ListUsers возвращает страницу пользователей по фильтру и общее число найденных
+func (r *PostgresRepository) ListUsers(filter models.UserFilter) ([]models.UserDetail, int, error) {
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Query != "" {
+		pattern := arg("%" + likeEscaper.Replace(filter.Query) + "%")
+		conds = append(conds, "(username ILIKE "+pattern+" OR email ILIKE "+pattern+")")
+	}
+	if filter.Role != "" {
+		conds = append(conds, "role = "+arg(filter.Role))
+	}
+	if filter.Active != nil {
+		conds = append(conds, "COALESCE(is_active, true) = "+arg(*filter.Active))
+	}
+	if filter.Protected != nil {
+		conds = append(conds, "COALESCE(is_protected, false) = "+arg(*filter.Protected))
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM users `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, username, email, role, COALESCE(is_active, true), COALESCE(is_protected, false), created_at
+		FROM users `+where+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT `+arg(filter.Limit)+` OFFSET `+arg(filter.Offset), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.UserDetail{}
+	for rows.Next() {
+		var u models.UserDetail
+		var createdAt time.Time
+		err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.IsActive, &u.IsProtected, &createdAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		u.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		users = append(users, u)
+	}
+
+	return users, total, rows.Err()
+}
+
+func (r *PostgresRepository) UpdateUserRole(id int, role string) error {
+	_, err := r.db.Exec("UPDATE users SET role = $1 WHERE id = $2", role, id)
+	return err
+}
+
+func (r *PostgresRepository) SetUserActive(id int, active bool) error {
+	_, err := r.db.Exec("UPDATE users SET is_active = $1 WHERE id = $2", active, id)
+	return err
+}
+
+func (r *PostgresRepository) RecordLogin(userID int, client models.ClientInfo) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_logins (user_id, ip, user_agent) VALUES ($1, LEFT($2, 45), LEFT($3, 255))
+	`, userID, client.IP, client.UserAgent)
+	return err
+}
+
+func (r *PostgresRepository) ListLogins(userID, limit int) ([]models.LoginRecord, error) {
+	rows, err := r.db.Query(`
+		SELECT ip, user_agent, created_at FROM user_logins
+		WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logins := []models.LoginRecord{}
+	for rows.Next() {
+		var l models.LoginRecord
+		if err := rows.Scan(&l.IP, &l.UserAgent, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		logins = append(logins, l)
+	}
+	return logins, rows.Err()
+}
+
+func (r *PostgresRepository) ToggleUserActive(id int) error {
+	_, err := r.db.Exec("UPDATE users SET is_active = NOT is_active WHERE id = $1", id)
+	return err
+}
+
+func (r *PostgresRepository) UpdateUser(user *models.User) error {
+	var emailTokenExpiresAt interface{}
+	if user.EmailToken != "" {
+		emailTokenExpiresAt = user.EmailTokenExpiresAt
+	}
+
+	res, err := r.db.Exec(`
+		UPDATE users
+		SET username = $1, email = $2, pending_email = NULLIF($3, ''), email_token = NULLIF($4, ''),
+			email_token_expires_at = $5, password_hash = $6, token_version = $7
+		WHERE id = $8 AND deleted_at IS NULL
+	`, user.Username, user.Email, user.PendingEmail, user.EmailToken, emailTokenExpiresAt,
+		user.PasswordHash, user.TokenVersion, user.ID)
+	if isUniqueViolation(err) {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteUser обезличивает пользователя: имя и email заменяются заглушками, пароль
+// стирается, все токены отзываются. Заказы, возвраты и отзывы остаются, а корзина,
+// адреса, подписки и история просмотров удаляются; товары продавца снимаются с продажи.
+func (r *PostgresRepository) DeleteUser(id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE users
+		SET username = 'deleted-' || id, email = 'deleted-' || id || '@deleted.invalid',
+			password_hash = '', pending_email = NULL, email_token = NULL, email_token_expires_at = NULL, is_active = false,
+			token_version = token_version + 1, deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	for _, query := range []string{
+		`DELETE FROM cart_items WHERE user_id = $1`,
+		`DELETE FROM addresses WHERE user_id = $1`,
+		`DELETE FROM price_alerts WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE user_id = $1`,
+		`DELETE FROM sessions WHERE user_id = $1`,
+		`DELETE FROM user_logins WHERE user_id = $1`,
+		`DELETE FROM product_views WHERE viewer = 'u:' || $1::text`,
+		`DELETE FROM compare_items WHERE owner = 'u:' || $1::text`,
+		`UPDATE products SET is_approved = false WHERE user_id = $1`,
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// likeEscaper экранирует спецсимволы LIKE, чтобы поиск шел по буквальной подстроке
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
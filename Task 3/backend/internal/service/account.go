@@ -0,0 +1,187 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Mailer отправляет письма пользователям. По умолчанию письма только пишутся в лог.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// LogMailer пишет письма в лог вместо отправки
+type LogMailer struct{}
+
+func (LogMailer) Send(to, subject, body string) error {
+	slog.Info("Письмо", "to", to, "subject", subject, "body", body)
+	return nil
+}
+
+// emailTokenTTL — сколько действует код подтверждения нового email
+const emailTokenTTL = 24 * time.Hour
+
+// CheckSession отклоняет токены удаленных пользователей и выданные до смены пароля
+func (s *Service) CheckSession(claims *models.JWTClaims) error {
+	user, err := s.Repo.GetUserByID(claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// UpdateProfile меняет имя и email. Новый email вступает в силу только после
+// подтверждения кодом из письма, до этого он хранится в pending_email.
+// Возвращает новый токен: в нем указано имя пользователя.
+func (s *Service) UpdateProfile(userID int, req models.ProfileRequest) (*models.User, string, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	username := strings.TrimSpace(req.Username)
+	email := strings.TrimSpace(req.Email)
+	if username != user.Username && user.IsProtected {
+		return nil, "", ErrProtectedRename
+	}
+	user.Username = username
+
+	sendConfirmation := false
+	switch {
+	case email == user.Email:
+		user.PendingEmail, user.EmailToken, user.EmailTokenExpiresAt = "", "", time.Time{}
+	case email == user.PendingEmail && time.Now().Before(user.EmailTokenExpiresAt):
+		// Тот же адрес уже ждет подтверждения — код не меняется, пока действует
+	default:
+		if other, err := s.Repo.GetUserByLogin(email); err == nil && other.ID != user.ID {
+			return nil, "", ErrUserExists
+		} else if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, "", err
+		}
+		if user.EmailToken, err = newEmailToken(); err != nil {
+			return nil, "", apperr.Internal(err)
+		}
+		user.PendingEmail = email
+		user.EmailTokenExpiresAt = time.Now().Add(emailTokenTTL)
+		sendConfirmation = true
+	}
+
+	if err := s.Repo.UpdateUser(user); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, "", ErrUserExists
+		}
+		return nil, "", err
+	}
+
+	if sendConfirmation {
+		body := fmt.Sprintf("Код подтверждения нового адреса: %s", user.EmailToken)
+		if err := s.Mailer.Send(user.PendingEmail, "Подтверждение email в CatPC", body); err != nil {
+			slog.Error("Ошибка отправки письма", "user_id", user.ID, "error", err)
+		}
+	}
+
+	token, err := s.GenerateJWT(user)
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+	return user, token, nil
+}
+
+// ConfirmEmail заменяет email пользователя подтвержденным адресом. Просроченный код
+// не принимается: новый приходит, если снова сохранить профиль с тем же адресом.
+func (s *Service) ConfirmEmail(req models.EmailConfirmRequest) (*models.User, error) {
+	user, err := s.Repo.GetUserByEmailToken(req.Token)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrEmailTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(user.EmailTokenExpiresAt) {
+		return nil, ErrEmailTokenExpired
+	}
+
+	user.Email = user.PendingEmail
+	user.PendingEmail, user.EmailToken, user.EmailTokenExpiresAt = "", "", time.Time{}
+	if err := s.Repo.UpdateUser(user); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, ErrUserExists
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangePassword меняет пароль и отзывает все выданные токены.
+// Возвращает новый токен, чтобы текущая сессия продолжила работать.
+func (s *Service) ChangePassword(userID int, req models.PasswordChangeRequest) (*models.User, string, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
+		return nil, "", ErrWrongPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+	user.PasswordHash = string(hash)
+	user.TokenVersion++
+	if err := s.Repo.UpdateUser(user); err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.GenerateJWT(user)
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+	return user, token, nil
+}
+
+// DeleteAccount обезличивает аккаунт после проверки пароля. Заказы сохраняются
+// для учета, защищенные аккаунты удалить нельзя.
+func (s *Service) DeleteAccount(userID int, req models.DeleteAccountRequest) error {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsProtected {
+		return ErrProtectedDelete
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return ErrWrongPassword
+	}
+
+	err = s.Repo.DeleteUser(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func newEmailToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+)
+
+func (s *Service) GetAddresses(userID int) ([]models.Address, error) {
+	return s.Repo.ListAddresses(userID)
+}
+
+func addressFromRequest(userID int, req models.AddressRequest) *models.Address {
+	return &models.Address{
+		UserID:     userID,
+		Label:      strings.TrimSpace(req.Label),
+		Recipient:  strings.TrimSpace(req.Recipient),
+		Phone:      strings.TrimSpace(req.Phone),
+		Region:     strings.TrimSpace(req.Region),
+		City:       strings.TrimSpace(req.City),
+		Street:     strings.TrimSpace(req.Street),
+		PostalCode: strings.TrimSpace(req.PostalCode),
+		IsDefault:  req.IsDefault,
+	}
+}
+
+// CreateAddress добавляет адрес; первый адрес становится адресом по умолчанию
+func (s *Service) CreateAddress(userID int, req models.AddressRequest) (*models.Address, error) {
+	address := addressFromRequest(userID, req)
+	if err := s.Repo.CreateAddress(address); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *Service) UpdateAddress(userID, id int, req models.AddressRequest) (*models.Address, error) {
+	address := addressFromRequest(userID, req)
+	address.ID = id
+	err := s.Repo.UpdateAddress(address)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+func (s *Service) DeleteAddress(userID, id int) error {
+	err := s.Repo.DeleteAddress(userID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAddressNotFound
+	}
+	return err
+}
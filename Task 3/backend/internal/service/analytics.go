@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+)
+
+const (
+	analyticsDateLayout  = "2006-01-02"
+	analyticsDefaultDays = 30
+	analyticsMaxDays     = 366
+	analyticsDefaultTop  = 5
+	analyticsMaxTop      = 50
+)
+
+// reportCache хранит готовые отчеты, чтобы не пересчитывать агрегаты на каждый запрос
+type reportCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]reportCacheEntry
+}
+
+type reportCacheEntry struct {
+	report    *models.AnalyticsReport
+	expiresAt time.Time
+}
+
+func newReportCache(ttl time.Duration) *reportCache {
+	return &reportCache{
+		ttl:     ttl,
+		entries: make(map[string]reportCacheEntry),
+	}
+}
+
+func (rc *reportCache) Get(key string) (*models.AnalyticsReport, bool) {
+	if rc.ttl == 0 {
+		return nil, false
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(rc.entries, key)
+		return nil, false
+	}
+	return entry.report, true
+}
+
+func (rc *reportCache) Set(key string, report *models.AnalyticsReport) {
+	if rc.ttl == 0 {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range rc.entries {
+		if now.After(entry.expiresAt) {
+			delete(rc.entries, k)
+		}
+	}
+
+	rc.entries[key] = reportCacheEntry{
+		report:    report,
+		expiresAt: now.Add(rc.ttl),
+	}
+}
+
+// GetAnalytics строит отчет за период; sellerID = 0 означает отчет по всему магазину
+func (s *Service) GetAnalytics(sellerID int, fromStr, toStr string, top int) (*models.AnalyticsReport, error) {
+	from, to, err := parseAnalyticsRange(fromStr, toStr)
+	if err != nil {
+		return nil, err
+	}
+
+	if top < 1 || top > analyticsMaxTop {
+		top = analyticsDefaultTop
+	}
+
+	key := fmt.Sprintf("%d:%s:%s:%d", sellerID, from.Format(analyticsDateLayout), to.Format(analyticsDateLayout), top)
+	if report, ok := s.reports.Get(key); ok {
+		return report, nil
+	}
+
+	report, err := s.Repo.SalesReport(models.AnalyticsFilter{
+		SellerID: sellerID,
+		From:     from,
+		To:       to,
+		Top:      top,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.reports.Set(key, report)
+	return report, nil
+}
+
+// parseAnalyticsRange возвращает полуинтервал [from, to) по дням; to включается в отчет целиком
+func parseAnalyticsRange(fromStr, toStr string) (time.Time, time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to := today
+	if toStr != "" {
+		parsed, err := time.Parse(analyticsDateLayout, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperr.Invalid("analytics_invalid_to")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if fromStr != "" {
+		parsed, err := time.Parse(analyticsDateLayout, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, apperr.Invalid("analytics_invalid_from")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, apperr.Invalid("analytics_range_reversed")
+	}
+
+	to = to.AddDate(0, 0, 1)
+	if to.Sub(from) > analyticsMaxDays*24*time.Hour {
+		return time.Time{}, time.Time{}, apperr.Invalid("analytics_range_too_long").With("max", analyticsMaxDays)
+	}
+
+	return from, to, nil
+}
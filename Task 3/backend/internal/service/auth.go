@@ -0,0 +1,179 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (s *Service) GenerateJWT(user *models.User) (string, error) {
+	claims := models.JWTClaims{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.jwtSecret)
+}
+
+// streamTokenTTL — сколько действует токен подключения к потоку: он передается
+// в URL и может попасть в журналы прокси, поэтому живет недолго
+const streamTokenTTL = time.Minute
+
+// StreamToken — токен для подключения к потоку уведомлений
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GenerateStreamToken выдает по токену сессии короткий токен потока уведомлений:
+// EventSource не передает заголовок Authorization, и токен уходит в URL.
+// Открытый поток живет, пока действует сессия.
+func (s *Service) GenerateStreamToken(session *models.JWTClaims) (*StreamToken, error) {
+	expiresAt := time.Now().Add(streamTokenTTL)
+	if session.ExpiresAt != nil && session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt.Time
+	}
+	claims := models.JWTClaims{
+		UserID:           session.UserID,
+		Username:         session.Username,
+		Role:             session.Role,
+		TokenVersion:     session.TokenVersion,
+		Purpose:          models.TokenPurposeStream,
+		SessionExpiresAt: session.ExpiresAt,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamToken{Token: token, ExpiresAt: claims.ExpiresAt.Time}, nil
+}
+
+// ValidateJWT проверяет токен сессии; токены с назначением здесь не принимаются
+func (s *Service) ValidateJWT(tokenString string) (*models.JWTClaims, error) {
+	return s.parseJWT(tokenString, "")
+}
+
+// ValidateStreamToken проверяет токен подключения к потоку уведомлений
+func (s *Service) ValidateStreamToken(tokenString string) (*models.JWTClaims, error) {
+	return s.parseJWT(tokenString, models.TokenPurposeStream)
+}
+
+func (s *Service) parseJWT(tokenString, purpose string) (*models.JWTClaims, error) {
+	if tokenString == "" {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &models.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("неожиданный метод подписи: %v", token.Header["alg"])
+		}
+		return s.jwtSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*models.JWTClaims); ok && token.Valid && claims.Purpose == purpose {
+		return claims, nil
+	}
+
+	return nil, jwt.ErrSignatureInvalid
+}
+
+// Register создает покупателя и возвращает его вместе с токеном
+func (s *Service) Register(req models.RegisterRequest) (*models.User, string, error) {
+	if req.Username == "" || req.Email == "" || req.Password == "" {
+		return nil, "", ErrRequiredFields
+	}
+
+	exists, err := s.Repo.UserExists(req.Username, req.Email)
+	if err != nil {
+		return nil, "", err
+	}
+	if exists {
+		return nil, "", ErrUserExists
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+
+	user := &models.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		Role:         models.RoleCustomer,
+		PasswordHash: string(hashedPassword),
+	}
+	if err := s.Repo.CreateUser(user); err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.GenerateJWT(user)
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+
+	return user, token, nil
+}
+
+// Login проверяет пароль, выдает токен и записывает вход в журнал пользователя
+func (s *Service) Login(req models.LoginRequest, client models.ClientInfo) (*models.User, string, error) {
+	user, err := s.Repo.GetUserByLogin(req.Username)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !user.IsActive {
+		return nil, "", ErrAccountBlocked
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, "", ErrInvalidCredentials
+	}
+
+	token, err := s.GenerateJWT(user)
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+
+	if err := s.Repo.RecordLogin(user.ID, client); err != nil {
+		slog.Error("Ошибка записи входа", "user_id", user.ID, "error", err)
+	}
+
+	return user, token, nil
+}
+
+func (s *Service) GetProfile(userID int) (*models.User, error) {
+	user, err := s.Repo.GetUserByID(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
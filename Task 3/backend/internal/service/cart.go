@@ -0,0 +1,135 @@
+package service
+
+import (
+	"catpc-backend/internal/metrics"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+)
+
+type Cart struct {
+	Items    []models.CartItem     `json:"items"`
+	Subtotal money.Amount          `json:"subtotal" doc:"Сумма товаров"`
+	Tax      money.Amount          `json:"tax" doc:"Все налоги корзины, включенные в цены и начисленные сверху"`
+	Taxes    []models.TaxLine      `json:"taxes" doc:"Разбивка налогов по ставкам"`
+	Shipping *models.ShippingQuote `json:"shipping" doc:"Расчет доставки, если передан delivery_method"`
+	Total    money.Amount          `json:"total" doc:"Сумма товаров, налогов сверх цен и доставки"`
+	Currency string                `json:"currency"`
+	Weight   int                   `json:"weight" doc:"Вес корзины в граммах"`
+	Count    int                   `json:"count"`
+	// Рекомендации к корзине: без товаров, которые уже в ней лежат
+	Recommendations Recommendations `json:"recommendations"`
+}
+
+// GetCart возвращает корзину с ценами в валюте currency и с учетом действующих акций.
+// Итог складывается из уже пересчитанных цен, чтобы совпадать с суммой позиций на экране.
+// Налоги считаются по ставкам региона адреса addressID (0 — ставки без региона)
+// с сумм позиций на экране; налог сверх цен добавляется к итогу.
+// Если передан deliveryMethodID, к итогу добавляется стоимость доставки:
+// тариф считается в базовой валюте и затем пересчитывается.
+func (s *Service) GetCart(userID int, currency string, deliveryMethodID, addressID int) (*Cart, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+	converter := prices.converter
+
+	region, err := s.addressRegion(userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+	taxes, err := s.taxes(region)
+	if err != nil {
+		return nil, err
+	}
+
+	var method *models.DeliveryMethod
+	if deliveryMethodID > 0 {
+		if method, err = s.deliveryMethod(deliveryMethodID); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := s.Repo.GetCartItems(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := &Cart{
+		Items:    items,
+		Subtotal: money.Zero,
+		Currency: converter.Currency,
+		Weight:   cartWeight(items),
+		Count:    len(items),
+	}
+	baseSubtotal := money.Zero
+	summary := models.NewTaxSummary()
+	for i := range cart.Items {
+		item := &cart.Items[i]
+		applySale(prices.sales, item)
+		baseSubtotal = baseSubtotal.Add(money.Line(item.Price, item.Quantity))
+		item.Price = converter.Convert(item.Price)
+		item.OriginalPrice = converter.Convert(item.OriginalPrice)
+
+		line := money.Line(item.Price, item.Quantity)
+		item.Tax = taxes.item(item.Category, line)
+		summary.Add(item.Tax, line)
+		cart.Subtotal = cart.Subtotal.Add(line)
+	}
+
+	cart.Tax, cart.Taxes = summary.Total, summary.Lines
+	cart.Total = cart.Subtotal.Add(summary.Extra)
+	if method != nil {
+		cart.Shipping = shippingQuote(method, baseSubtotal, cart.Weight)
+		cart.Shipping.Cost = converter.Convert(cart.Shipping.Cost)
+		cart.Total = cart.Total.Add(cart.Shipping.Cost)
+	}
+
+	productIDs := make([]int, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	if cart.Recommendations, err = s.recommendations(UserViewer(userID), productIDs, prices); err != nil {
+		return nil, err
+	}
+
+	return cart, nil
+}
+
+func (s *Service) AddToCart(userID int, req models.AddToCartRequest) (err error) {
+	defer func() { metrics.CartOperations.With("add", metrics.Result(err)).Inc() }()
+
+	if req.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	product, err := s.Repo.GetProductByID(req.ProductID)
+	if err != nil {
+		return ErrProductNotFound
+	}
+
+	if !product.IsApproved {
+		return ErrProductUnavailable
+	}
+
+	if product.Stock < req.Quantity {
+		return ErrInsufficientStock
+	}
+
+	return s.Repo.AddCartItem(userID, req.ProductID, req.Quantity)
+}
+
+// UpdateCartItem меняет количество; количество 0 и меньше удаляет позицию
+func (s *Service) UpdateCartItem(userID, itemID, quantity int) (err error) {
+	defer func() { metrics.CartOperations.With("update", metrics.Result(err)).Inc() }()
+
+	if quantity <= 0 {
+		return s.Repo.RemoveCartItem(userID, itemID)
+	}
+	return s.Repo.UpdateCartItem(userID, itemID, quantity)
+}
+
+func (s *Service) RemoveFromCart(userID, itemID int) (err error) {
+	defer func() { metrics.CartOperations.With("remove", metrics.Result(err)).Inc() }()
+
+	return s.Repo.RemoveCartItem(userID, itemID)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+)
+
+// Выборки пачкой для GraphQL: резолверы одного уровня запроса собирают ключи,
+// и товары для них загружаются одним запросом к базе. Цены, как в каталоге,
+// учитывают действующие акции и переводятся в валюту currency.
+
+// ProductsByIDs возвращает одобренные товары по id; скрытых и удаленных в ответе нет
+func (s *Service) ProductsByIDs(ids []int, currency string) (map[int]models.Product, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.Repo.ListApprovedProductsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]models.Product, len(products))
+	for _, p := range products {
+		prices.product(&p)
+		byID[p.ID] = p
+	}
+	return byID, nil
+}
+
+// SellerProducts возвращает первые limit одобренных товаров каждого продавца
+func (s *Service) SellerProducts(sellerIDs []int, limit int, currency string) (map[int][]models.Product, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.Repo.ListApprovedProductsBySellers(sellerIDs, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	bySeller := map[int][]models.Product{}
+	for _, p := range products {
+		prices.product(&p)
+		bySeller[*p.UserID] = append(bySeller[*p.UserID], p)
+	}
+	return bySeller, nil
+}
+
+// CategoryProducts возвращает первые limit одобренных товаров каждой категории.
+// Ключ результата — название категории в нижнем регистре.
+func (s *Service) CategoryProducts(categories []string, limit int, currency string) (map[string][]models.Product, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.Repo.ListApprovedProductsByCategories(categories, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	byCategory := map[string][]models.Product{}
+	for _, p := range products {
+		prices.product(&p)
+		key := strings.ToLower(p.Category)
+		byCategory[key] = append(byCategory[key], p)
+	}
+	return byCategory, nil
+}
+
+func (s *Service) GetCategories() ([]models.Category, error) {
+	return s.Repo.ListCategories()
+}
+
+// GetSeller возвращает публичные сведения о продавце. Товары выставляют
+// продавцы и администраторы; покупатель продавцом не считается.
+func (s *Service) GetSeller(id int) (*models.Seller, error) {
+	user, err := s.Repo.GetUserByID(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSellerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Role != models.RoleSeller && user.Role != models.RoleAdmin {
+		return nil, ErrSellerNotFound
+	}
+	return &models.Seller{ID: user.ID, Username: user.Username}, nil
+}
+
+// GraphQLLimits возвращает наибольшую глубину и сложность запроса GraphQL
+func (s *Service) GraphQLLimits() (depth, complexity int) {
+	return s.config.GraphQLMaxDepth, s.config.GraphQLMaxComplexity
+}
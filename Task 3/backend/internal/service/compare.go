@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+)
+
+const (
+	// MaxCompareItems — сколько товаров можно сравнивать одновременно
+	MaxCompareItems = 4
+	// maxAttributes — сколько характеристик можно задать товару
+	maxAttributes = 50
+)
+
+// CompareRow — строка таблицы сравнения: одна характеристика у всех товаров
+type CompareRow struct {
+	Name    string   `json:"name"`
+	Values  []string `json:"values" doc:"Значения в порядке products; пустая строка — характеристика не указана"`
+	Differs bool     `json:"differs" doc:"Значения у товаров различаются"`
+}
+
+// Comparison — список сравнения с таблицей характеристик
+type Comparison struct {
+	Category string           `json:"category" doc:"Категория товаров в списке; пустая у пустого списка или товаров без категории"`
+	Products []models.Product `json:"products"`
+	Rows     []CompareRow     `json:"rows"`
+	Limit    int              `json:"limit" doc:"Сколько товаров можно добавить"`
+}
+
+// GetComparison возвращает список сравнения владельца. owner устроен так же, как
+// viewer у рекомендаций: пользователь или анонимная сессия.
+func (s *Service) GetComparison(owner, currency string) (*Comparison, error) {
+	if owner == "" {
+		return nil, ErrCompareOwner
+	}
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.Repo.ListCompareItems(owner)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		prices.product(&products[i])
+	}
+	return buildComparison(products), nil
+}
+
+// AddToComparison добавляет одобренный товар. Сравнивать можно только товары
+// одной категории; повторное добавление ничего не меняет.
+func (s *Service) AddToComparison(owner string, productID int, currency string) (*Comparison, error) {
+	if owner == "" {
+		return nil, ErrCompareOwner
+	}
+
+	product, err := s.Repo.GetProductByID(productID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+	if !product.IsApproved {
+		return nil, ErrProductUnavailable
+	}
+
+	items, err := s.Repo.ListCompareItems(owner)
+	if err != nil {
+		return nil, err
+	}
+	if !containsProduct(items, productID) {
+		if len(items) >= MaxCompareItems {
+			return nil, ErrCompareFull.With("max", MaxCompareItems)
+		}
+		if len(items) > 0 && !strings.EqualFold(items[0].Category, product.Category) {
+			return nil, ErrCompareCategory.With("category", items[0].Category)
+		}
+		if err := s.Repo.AddCompareItem(owner, productID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetComparison(owner, currency)
+}
+
+func (s *Service) RemoveFromComparison(owner string, productID int, currency string) (*Comparison, error) {
+	if owner == "" {
+		return nil, ErrCompareOwner
+	}
+
+	err := s.Repo.RemoveCompareItem(owner, productID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrCompareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetComparison(owner, currency)
+}
+
+func (s *Service) ClearComparison(owner string) error {
+	if owner == "" {
+		return ErrCompareOwner
+	}
+	return s.Repo.ClearCompareItems(owner)
+}
+
+// buildComparison сводит характеристики товаров в таблицу. Названия и значения
+// сравниваются без учета регистра и лишних пробелов: «8 ГБ» и «8  гб» совпадают.
+func buildComparison(products []models.Product) *Comparison {
+	c := &Comparison{Products: products, Rows: []CompareRow{}, Limit: MaxCompareItems}
+	if len(products) > 0 {
+		c.Category = products[0].Category
+	}
+
+	rows := map[string]*CompareRow{}
+	for i, p := range products {
+		for name, value := range p.Attributes {
+			key := attributeKey(name)
+			row, ok := rows[key]
+			if !ok {
+				row = &CompareRow{Name: name, Values: make([]string, len(products))}
+				rows[key] = row
+			}
+			row.Values[i] = value
+		}
+	}
+
+	for _, row := range rows {
+		for _, value := range row.Values[1:] {
+			if attributeKey(value) != attributeKey(row.Values[0]) {
+				row.Differs = true
+				break
+			}
+		}
+		c.Rows = append(c.Rows, *row)
+	}
+	sort.Slice(c.Rows, func(i, j int) bool {
+		return attributeKey(c.Rows[i].Name) < attributeKey(c.Rows[j].Name)
+	})
+	return c
+}
+
+// attributeKey нормализует название или значение характеристики для сравнения
+func attributeKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func containsProduct(products []models.Product, id int) bool {
+	for _, p := range products {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"catpc-backend/internal/money"
+)
+
+// Currencies — базовая валюта магазина и курсы доступных валют
+type Currencies struct {
+	Base  string                  `json:"base"`
+	Rates map[string]money.Amount `json:"rates" doc:"Сколько базовой валюты стоит единица валюты"`
+}
+
+func (s *Service) GetCurrencies() Currencies {
+	return Currencies{Base: s.Rates.Base(), Rates: s.Rates.Rates()}
+}
+
+// converter готовит пересчет цен в валюту из параметра запроса; пустая строка — базовая валюта
+func (s *Service) converter(currency string) (money.Converter, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	converter, err := money.NewConverter(s.Rates, currency)
+	if errors.Is(err, money.ErrUnknownCurrency) {
+		return money.Converter{}, ErrUnknownCurrency.With("currency", currency)
+	}
+	return converter, err
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+// maxRuleFrom — наибольший порог ступени, numeric(12,2)
+var maxRuleFrom = money.MustParse("9999999999.99")
+
+// GetDeliveryMethods возвращает способы доставки; покупателям — только включенные
+func (s *Service) GetDeliveryMethods(activeOnly bool) ([]models.DeliveryMethod, error) {
+	return s.Repo.ListDeliveryMethods(activeOnly)
+}
+
+// deliveryMethod возвращает включенный способ доставки для расчета и оформления
+func (s *Service) deliveryMethod(id int) (*models.DeliveryMethod, error) {
+	method, err := s.Repo.GetDeliveryMethod(id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !method.IsActive) {
+		return nil, ErrDeliveryMethodNotFound
+	}
+	return method, err
+}
+
+// deliveryFromRequest проверяет ступени тарифа: первая начинается с 0, пороги не повторяются.
+// Ступени сортируются по порогу, суммы округляются до копеек.
+func deliveryFromRequest(req models.DeliveryMethodRequest) (*models.DeliveryMethod, error) {
+	rules := make([]models.DeliveryRule, len(req.Rules))
+	for i, rule := range req.Rules {
+		rules[i] = models.DeliveryRule{From: rule.From.Round(2), Cost: rule.Cost.Round(2)}
+	}
+	sort.SliceStable(rules, func(i, j int) bool { return rules[i].From.LessThan(rules[j].From) })
+
+	var fields []apperr.FieldError
+	fail := func(code string, params apperr.Params) {
+		fields = append(fields, apperr.FieldError{Field: "rules", Code: code, Params: params})
+	}
+
+	switch {
+	case len(rules) == 0:
+		fail("required", nil)
+	case !rules[0].From.IsZero():
+		fail("rules_start", nil)
+	}
+	for i, rule := range rules {
+		if i > 0 && rule.From.Equal(rules[i-1].From) {
+			fail("rules_duplicate", apperr.Params{"value": rule.From})
+		}
+		if rule.From.GreaterThan(maxRuleFrom) {
+			fail("lte", apperr.Params{"param": maxRuleFrom})
+		}
+		if rule.Cost.IsNegative() {
+			fail("gte", apperr.Params{"param": 0})
+		} else if rule.Cost.GreaterThan(money.MaxPrice) {
+			fail("lte", apperr.Params{"param": money.MaxPrice})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, apperr.Validation(fields)
+	}
+
+	return &models.DeliveryMethod{
+		Name:     strings.TrimSpace(req.Name),
+		Kind:     req.Kind,
+		Pricing:  req.Pricing,
+		Rules:    rules,
+		IsActive: req.IsActive,
+	}, nil
+}
+
+func (s *Service) CreateDeliveryMethod(req models.DeliveryMethodRequest) (*models.DeliveryMethod, error) {
+	method, err := deliveryFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateDeliveryMethod(method); err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+func (s *Service) UpdateDeliveryMethod(id int, req models.DeliveryMethodRequest) (*models.DeliveryMethod, error) {
+	method, err := deliveryFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	method.ID = id
+
+	err = s.Repo.UpdateDeliveryMethod(method)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDeliveryMethodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return method, nil
+}
+
+func (s *Service) DeleteDeliveryMethod(id int) error {
+	err := s.Repo.DeleteDeliveryMethod(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeliveryMethodNotFound
+	}
+	return err
+}
+
+// cartWeight — вес позиций корзины в граммах
+func cartWeight(items []models.CartItem) int {
+	weight := 0
+	for _, item := range items {
+		weight += item.Weight * item.Quantity
+	}
+	return weight
+}
+
+// shippingQuote считает доставку по ступени с наибольшим порогом, не превышающим
+// вес (в граммах) или сумму товаров в базовой валюте
+func shippingQuote(method *models.DeliveryMethod, subtotal money.Amount, weight int) *models.ShippingQuote {
+	measure := subtotal
+	if method.Pricing == models.PricingByWeight {
+		measure = money.FromInt(int64(weight))
+	}
+
+	cost := money.Zero
+	for _, rule := range method.Rules {
+		if rule.From.GreaterThan(measure) {
+			break
+		}
+		cost = rule.Cost
+	}
+
+	return &models.ShippingQuote{
+		MethodID: method.ID,
+		Name:     method.Name,
+		Kind:     method.Kind,
+		Weight:   weight,
+		Cost:     cost,
+	}
+}
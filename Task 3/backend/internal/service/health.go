@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"os"
+)
+
+// Названия проверок готовности в ответе /readyz
+const (
+	CheckDatabase = "database"
+	CheckUploads  = "uploads"
+)
+
+// Readiness проверяет зависимости, без которых сервис не может обслуживать запросы.
+// Ключ — название проверки, значение — ошибка или nil, если проверка прошла.
+func (s *Service) Readiness(ctx context.Context) map[string]error {
+	return map[string]error{
+		CheckDatabase: s.Repo.Ping(ctx),
+		CheckUploads:  checkWritable(s.config.UploadDir),
+	}
+}
+
+// checkWritable создает и сразу удаляет временный файл в dir
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+)
+
+const (
+	ImportMaxBytes = 5 << 20 // 5 MB
+	importMaxRows  = 1000
+)
+
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// exportColumns задает порядок колонок CSV; импорт принимает тот же файл обратно
+var exportColumns = []string{"id", "sku", "name", "description", "price", "stock", "image", "is_approved"}
+
+// ParseImport разбирает файл импорта в формате csv или json.
+// Ошибки разбора отдельных строк возвращаются построчно, чтобы попасть в отчет.
+func ParseImport(format string, data []byte) ([]models.ImportRow, [][]apperr.FieldError, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	switch format {
+	case "json":
+		var rows []models.ImportRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, nil, apperr.Invalid("import_invalid_json")
+		}
+		// Цена в numeric(10,2) хранится с точностью до копеек
+		for i := range rows {
+			rows[i].Price = rows[i].Price.Round(2)
+		}
+		return rows, make([][]apperr.FieldError, len(rows)), nil
+	case "csv":
+		return ParseImportCSV(data)
+	default:
+		return nil, nil, apperr.Invalid("import_unsupported_format")
+	}
+}
+
+func ParseImportCSV(data []byte) ([]models.ImportRow, [][]apperr.FieldError, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Excel в русской локали сохраняет CSV через точку с запятой
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, apperr.Invalid("import_csv_no_header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"sku", "name", "price", "stock"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, apperr.Invalid("import_csv_missing_column").With("column", required)
+		}
+	}
+
+	var rows []models.ImportRow
+	var lineErrors [][]apperr.FieldError
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line := 0
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			}
+			return nil, nil, apperr.Invalid("import_csv_malformed").With("line", line)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		var errs []apperr.FieldError
+		row := models.ImportRow{
+			SKU:         field("sku"),
+			Name:        field("name"),
+			Description: field("description"),
+			Image:       field("image"),
+		}
+
+		price, err := money.Parse(field("price"))
+		if err != nil {
+			errs = append(errs, apperr.FieldError{Field: "price", Code: "number"})
+		}
+		row.Price = price
+
+		stock, err := strconv.Atoi(field("stock"))
+		if err != nil {
+			errs = append(errs, apperr.FieldError{Field: "stock", Code: "integer"})
+		}
+		row.Stock = stock
+
+		rows = append(rows, row)
+		lineErrors = append(lineErrors, errs)
+	}
+
+	return rows, lineErrors, nil
+}
+
+// ImportProducts проверяет все строки и записывает их одной транзакцией.
+// При dryRun или ошибке хотя бы в одной строке изменения не сохраняются.
+func (s *Service) ImportProducts(userID int, role string, rows []models.ImportRow, lineErrors [][]apperr.FieldError, dryRun bool) (*models.ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, apperr.Invalid("import_empty")
+	}
+
+	if len(rows) > importMaxRows {
+		return nil, apperr.Invalid("import_too_many_rows").With("max", importMaxRows)
+	}
+
+	report := &models.ImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]models.ImportRowResult, len(rows)),
+	}
+
+	foreign, err := s.foreignImages(userID, role, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Первый проход: проверяем все строки без записи в БД
+	var valid []models.ImportRow
+	var validIndex []int
+	seen := make(map[string]int)
+	for i, row := range rows {
+		result := models.ImportRowResult{Row: i + 1, SKU: row.SKU}
+		if i < len(lineErrors) {
+			result.Errors = lineErrors[i]
+		}
+		result.Errors = append(result.Errors, validateImportRow(row)...)
+		if foreign[row.Image] {
+			result.Errors = append(result.Errors, apperr.FieldError{Field: "image", Code: "image_owner"})
+		}
+
+		if prev, ok := seen[row.SKU]; ok && row.SKU != "" {
+			result.Errors = append(result.Errors, apperr.FieldError{
+				Field:  "sku",
+				Code:   "duplicate",
+				Params: apperr.Params{"row": prev},
+			})
+		} else {
+			seen[row.SKU] = i + 1
+		}
+
+		if len(result.Errors) > 0 {
+			result.Action = "error"
+		} else {
+			valid = append(valid, row)
+			validIndex = append(validIndex, i)
+		}
+		report.Rows[i] = result
+	}
+
+	// Второй проход в транзакции; фиксируется, только если ошибок нет и это не пробный запуск
+	commit := !dryRun && len(valid) == len(rows)
+	if len(valid) > 0 {
+		outcomes, committed, err := s.Repo.ImportProducts(userID, role == models.RoleAdmin, valid, commit)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, outcome := range outcomes {
+			result := &report.Rows[validIndex[i]]
+			result.Action = outcome.Action
+			result.ProductID = outcome.ProductID
+			if outcome.Err != nil {
+				result.Errors = append(result.Errors, apperr.FieldError{Code: "save_failed"})
+			}
+		}
+		report.Committed = committed
+
+		if committed && role == models.RoleAdmin {
+			for _, outcome := range outcomes {
+				s.checkPriceAlerts(outcome.ProductID)
+			}
+		} else if committed {
+			// Импорт продавца отправляет все товары на модерацию: одно уведомление на импорт
+			s.notifyPending(nil, len(outcomes))
+		}
+	}
+
+	for _, result := range report.Rows {
+		switch result.Action {
+		case "create":
+			report.Created++
+		case "update":
+			report.Updated++
+		default:
+			report.Failed++
+		}
+	}
+
+	return report, nil
+}
+
+func validateImportRow(row models.ImportRow) []apperr.FieldError {
+	var errs []apperr.FieldError
+	fail := func(field, code string, param interface{}) {
+		fe := apperr.FieldError{Field: field, Code: code}
+		if param != nil {
+			fe.Params = apperr.Params{"param": param}
+		}
+		errs = append(errs, fe)
+	}
+
+	switch {
+	case row.SKU == "":
+		fail("sku", "required", nil)
+	case !skuPattern.MatchString(row.SKU):
+		fail("sku", "sku_format", nil)
+	}
+	switch {
+	case row.Name == "":
+		fail("name", "required", nil)
+	case len([]rune(row.Name)) > 255:
+		fail("name", "max", 255)
+	}
+	if !row.Price.IsPositive() {
+		fail("price", "gt", 0)
+	} else if row.Price.GreaterThan(money.MaxPrice) {
+		fail("price", "lte", money.MaxPrice)
+	}
+	if row.Stock < 0 {
+		fail("stock", "gte", 0)
+	}
+	if row.Image != "" && !imageFileName(row.Image) {
+		fail("image", "file_name", nil)
+	}
+
+	return errs
+}
+
+// imageFileName сообщает, что имя указывает на файл прямо в папке загрузок
+func imageFileName(name string) bool {
+	return filepath.Base(name) == name && !strings.HasPrefix(name, ".")
+}
+
+// foreignImages возвращает изображения строк, которые продавец не загружал и которых
+// нет у его товаров: иначе импорт позволил бы поставить товару чужую картинку из папки
+// загрузок. Администратор, как и при правке товаров, может ставить любые изображения.
+func (s *Service) foreignImages(userID int, role string, rows []models.ImportRow) (map[string]bool, error) {
+	foreign := map[string]bool{}
+	if role == models.RoleAdmin {
+		return foreign, nil
+	}
+
+	var images []string
+	for _, row := range rows {
+		if row.Image != "" && row.Image != "default.png" && imageFileName(row.Image) && !foreign[row.Image] {
+			foreign[row.Image] = true
+			images = append(images, row.Image)
+		}
+	}
+	if len(images) == 0 {
+		return foreign, nil
+	}
+
+	owned, err := s.Repo.OwnedImages(userID, images)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range owned {
+		delete(foreign, image)
+	}
+	return foreign, nil
+}
+
+func (s *Service) ExportProducts(userID int) ([]models.Product, error) {
+	return s.Repo.ListProductsByUser(userID)
+}
+
+// WriteProductsCSV записывает товары в формате, который принимает импорт
+func WriteProductsCSV(w io.Writer, products []models.Product) error {
+	writer := csv.NewWriter(w)
+	writer.Write(exportColumns)
+	for _, p := range products {
+		writer.Write([]string{
+			strconv.Itoa(p.ID),
+			p.SKU,
+			p.Name,
+			p.Description,
+			p.Price.StringFixed(2),
+			strconv.Itoa(p.Stock),
+			p.Image,
+			strconv.FormatBool(p.IsApproved),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
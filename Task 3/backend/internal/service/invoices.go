@@ -0,0 +1,176 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"catpc-backend/internal/invoice"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+// GetInvoice возвращает PDF-счет к заказу. Счет доступен покупателю, продавцам позиций
+// заказа и администраторам; остальным заказ «не найден», как в GetOrder. Первый запрос
+// выставляет счет, дальше отдается сохраненный документ без изменений.
+func (s *Service) GetInvoice(actor Actor, orderID int) (*models.Invoice, error) {
+	order, err := s.Repo.GetOrderByID(orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !canViewInvoice(actor, order) {
+		return nil, ErrOrderNotFound
+	}
+
+	inv, err := s.Repo.GetInvoice(order.ID)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return inv, err
+	}
+	if order.Status == models.OrderCancelled {
+		return nil, ErrInvoiceUnavailable.With("status", order.Status)
+	}
+	return s.issueInvoice(order)
+}
+
+func canViewInvoice(actor Actor, order *models.Order) bool {
+	if actor.Role == models.RoleAdmin || order.UserID == actor.ID {
+		return true
+	}
+	for _, item := range order.Items {
+		if item.SellerID == actor.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) issueInvoice(order *models.Order) (*models.Invoice, error) {
+	// В БД время хранится с точностью до микросекунд, а в PDF попадает до секунд
+	issued := time.Now().UTC().Truncate(time.Second)
+	doc, err := s.invoiceDocument(order, issued)
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := invoice.Render(*doc)
+	if err != nil {
+		return nil, fmt.Errorf("счет к заказу %d: %w", order.ID, err)
+	}
+
+	inv, err := s.Repo.CreateInvoice(&models.Invoice{OrderID: order.ID, Number: doc.Number, IssuedAt: issued, PDF: pdf})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("Выставлен счет", "order_id", order.ID, "number", inv.Number)
+	return inv, nil
+}
+
+// invoiceNumber — номер счета; у заказа один счет, поэтому номер строится из id заказа
+func invoiceNumber(orderID int) string {
+	return fmt.Sprintf("CP-%06d", orderID)
+}
+
+// invoiceDocument собирает данные счета. Суммы берутся из заказа как есть,
+// в валюте оформления: цены позиций — price_at_time, налоги — сохраненные при оформлении.
+func (s *Service) invoiceDocument(order *models.Order, issued time.Time) (*invoice.Document, error) {
+	names := map[int]string{}
+	user := func(id int) (*models.User, error) {
+		u, err := s.Repo.GetUserByID(id)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil
+		}
+		return u, err
+	}
+	sellerName := func(id int) (string, error) {
+		if name, ok := names[id]; ok || id == 0 {
+			return name, nil
+		}
+		u, err := user(id)
+		if err != nil {
+			return "", err
+		}
+		if u != nil {
+			names[id] = u.Username
+		}
+		return names[id], nil
+	}
+
+	doc := &invoice.Document{
+		Number:    invoiceNumber(order.ID),
+		IssuedAt:  issued,
+		OrderID:   order.ID,
+		OrderDate: order.CreatedAt,
+		Issuer:    invoice.Party{Name: s.config.InvoiceCompany},
+		Subtotal:  order.Subtotal,
+		Total:     order.Total,
+		Currency:  order.Currency,
+	}
+	if doc.Currency == "" {
+		doc.Currency = s.Rates.Base()
+	}
+	if s.config.InvoiceTaxID != "" {
+		doc.Issuer.Details = append(doc.Issuer.Details, "ИНН "+s.config.InvoiceTaxID)
+	}
+	if s.config.InvoiceAddress != "" {
+		doc.Issuer.Details = append(doc.Issuer.Details, s.config.InvoiceAddress)
+	}
+
+	buyer, err := user(order.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if buyer != nil {
+		doc.Buyer.Name = buyer.Username
+		doc.Buyer.Details = append(doc.Buyer.Details, buyer.Email)
+	}
+	if a := order.Address; a != nil {
+		doc.Buyer.Details = append(doc.Buyer.Details,
+			strings.Join(nonEmpty(a.Recipient, a.Phone), ", "),
+			strings.Join(nonEmpty(a.PostalCode, a.City, a.Street), ", "))
+	}
+
+	for _, item := range order.Items {
+		seller, err := sellerName(item.SellerID)
+		if err != nil {
+			return nil, err
+		}
+		doc.Lines = append(doc.Lines, invoice.Line{
+			Name:     item.Name,
+			Seller:   seller,
+			Quantity: item.Quantity,
+			Price:    item.PriceAtTime,
+			Amount:   money.Line(item.PriceAtTime, item.Quantity),
+		})
+	}
+	if order.Shipping != nil {
+		doc.Shipping = order.Shipping.Name
+		doc.ShippingCost = order.Shipping.Cost
+	}
+	for _, tax := range order.Taxes {
+		if tax.Inclusive {
+			doc.Taxes = append(doc.Taxes, invoice.Tax{Name: "В том числе " + tax.Name, Amount: tax.Amount, Included: true})
+		} else {
+			doc.Taxes = append(doc.Taxes, invoice.Tax{Name: tax.Name, Amount: tax.Amount})
+		}
+	}
+	// Заказы, оформленные до появления ставок, и товары без ставки налогом не облагаются
+	if len(doc.Taxes) == 0 {
+		doc.Taxes = []invoice.Tax{{Name: "Без налога", Amount: money.Zero, Included: true}}
+	}
+	return doc, nil
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+
+	"catpc-backend/internal/metrics"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/notify"
+	"catpc-backend/internal/repository"
+)
+
+const (
+	// notificationsPageSize — сколько уведомлений отдается за раз по умолчанию, notificationsMaxPage — наибольшее
+	notificationsPageSize = 50
+	notificationsMaxPage  = 100
+	// notificationsBuffer покрывает всплеск событий у одного потока; отставший
+	// поток отключается, переподключается и дочитывает входящие
+	notificationsBuffer = 32
+)
+
+// NotificationInbox — входящие пользователя: последние уведомления и число непрочитанных
+type NotificationInbox struct {
+	Notifications []models.Notification `json:"notifications"`
+	Unread        int                   `json:"unread"`
+}
+
+func (s *Service) GetNotifications(userID int, unreadOnly bool, limit int) (*NotificationInbox, error) {
+	if limit < 1 || limit > notificationsMaxPage {
+		limit = notificationsPageSize
+	}
+	list, err := s.Repo.ListNotifications(models.NotificationFilter{UserID: userID, UnreadOnly: unreadOnly, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.Repo.CountUnreadNotifications(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &NotificationInbox{Notifications: list, Unread: unread}, nil
+}
+
+// MissedNotifications возвращает уведомления новее afterID от старых к новым —
+// то, что поток пропустил, пока был отключен. Читает страницами, пока не дочитает все.
+func (s *Service) MissedNotifications(userID, afterID int) ([]models.Notification, error) {
+	var missed []models.Notification
+	for {
+		page, err := s.Repo.ListNotifications(models.NotificationFilter{UserID: userID, AfterID: afterID, Oldest: true, Limit: notificationsMaxPage})
+		if err != nil {
+			return nil, err
+		}
+		missed = append(missed, page...)
+		if len(page) < notificationsMaxPage {
+			return missed, nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+func (s *Service) MarkNotificationRead(userID, id int) error {
+	err := s.Repo.MarkNotificationRead(userID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrNotificationNotFound
+	}
+	return err
+}
+
+func (s *Service) MarkAllNotificationsRead(userID int) (int, error) {
+	return s.Repo.MarkAllNotificationsRead(userID)
+}
+
+// SubscribeNotifications открывает подписку потока на новые уведомления пользователя
+func (s *Service) SubscribeNotifications(userID int) *notify.Subscription {
+	return s.Notifications.Subscribe(userID)
+}
+
+// notify сохраняет уведомления во входящие и рассылает открытым потокам.
+// Уведомление сопровождает уже сохраненное изменение и не должно его отменять,
+// поэтому ошибка только пишется в журнал.
+func (s *Service) notify(notifications ...models.Notification) {
+	if len(notifications) == 0 {
+		return
+	}
+	if err := s.Repo.CreateNotifications(notifications); err != nil {
+		slog.Error("Ошибка сохранения уведомлений", "type", notifications[0].Type, "error", err)
+		return
+	}
+	for _, n := range notifications {
+		metrics.Notifications.With(n.Type).Inc()
+		s.Notifications.Publish(n)
+	}
+}
+
+// notifyUsers отправляет одинаковое уведомление каждому из пользователей
+func (s *Service) notifyUsers(userIDs []int, n models.Notification) {
+	notifications := make([]models.Notification, 0, len(userIDs))
+	for _, id := range userIDs {
+		n.UserID = id
+		notifications = append(notifications, n)
+	}
+	s.notify(notifications...)
+}
+
+// notifyOwner сообщает продавцу о решении модератора по его товару
+func (s *Service) notifyOwner(product *models.Product, kind string) {
+	if product.UserID == nil {
+		return
+	}
+	s.notify(models.Notification{UserID: *product.UserID, Type: kind, ProductID: product.ID, ProductName: product.Name})
+}
+
+// notifyPending сообщает администраторам о товарах, ждущих модерации: об одном
+// товаре или, после импорта, о числе товаров count
+func (s *Service) notifyPending(product *models.Product, count int) {
+	admins, err := s.Repo.ActiveUserIDsByRole(models.RoleAdmin)
+	if err != nil {
+		slog.Error("Ошибка поиска администраторов для уведомления", "error", err)
+		return
+	}
+	n := models.Notification{Type: models.NotifyProductPending, Count: count}
+	if product != nil {
+		n.ProductID, n.ProductName = product.ID, product.Name
+	}
+	s.notifyUsers(admins, n)
+}
+
+// notifyOrderStatus сообщает покупателю о новом статусе заказа
+func (s *Service) notifyOrderStatus(order *models.Order, status string) {
+	s.notify(models.Notification{UserID: order.UserID, Type: models.NotifyOrderStatus, OrderID: order.ID, Status: status})
+}
+
+// restocked вызывается после того, как к остатку товара добавилось added штук.
+// Если до этого товара не было, а в каталоге он виден, ожидающие его покупатели
+// получают уведомление.
+func (s *Service) restocked(productID, added int) {
+	if added <= 0 {
+		return
+	}
+	product, err := s.Repo.GetProductByID(productID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			slog.Error("Ошибка проверки поступления товара", "product_id", productID, "error", err)
+		}
+		return
+	}
+	if !product.IsApproved || product.Stock <= 0 || product.Stock-added > 0 {
+		return
+	}
+
+	watchers, err := s.Repo.ProductWatchers(productID)
+	if err != nil {
+		slog.Error("Ошибка проверки поступления товара", "product_id", productID, "error", err)
+		return
+	}
+	s.notifyUsers(watchers, models.Notification{Type: models.NotifyBackInStock, ProductID: product.ID, ProductName: product.Name})
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"catpc-backend/internal/metrics"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+// Checkout оформляет заказ из корзины по ценам с учетом действующих акций.
+// Репозиторий сверяет цены еще раз при фиксации: если акция успела закончиться
+// или начаться, заказ не оформляется (ErrCartChanged). Налоги считаются по ставкам
+// региона адреса доставки. Расчет доставки, адрес и налоги позиций сохраняются в заказе
+// как есть: последующая правка тарифа, адреса или ставок заказ не меняет.
+func (s *Service) Checkout(userID int, req models.CheckoutRequest) (order *models.Order, err error) {
+	defer func() { metrics.Checkouts.With(metrics.Result(err)).Inc() }()
+
+	method, err := s.deliveryMethod(req.DeliveryMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	var address *models.Address
+	var region string
+	if method.NeedsAddress() {
+		if req.AddressID == 0 {
+			return nil, ErrAddressRequired
+		}
+		address, err = s.Repo.GetAddress(userID, req.AddressID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAddressNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		region = address.Region
+	}
+	taxes, err := s.taxes(region)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.Repo.GetCartItems(userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrCartEmpty
+	}
+	sales, err := s.Repo.ListActiveSales(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		applySale(sales, &items[i])
+	}
+
+	order = &models.Order{
+		UserID:   userID,
+		Status:   models.OrderPending,
+		Items:    make([]models.OrderItem, len(items)),
+		Subtotal: money.Zero,
+		Refunded: money.Zero,
+		Currency: s.Rates.Base(),
+		Address:  address,
+	}
+	for i, item := range items {
+		line := money.Line(item.Price, item.Quantity)
+		order.Items[i] = models.OrderItem{
+			ProductID:   item.ProductID,
+			Name:        item.Name,
+			Quantity:    item.Quantity,
+			PriceAtTime: item.Price,
+			Tax:         taxes.item(item.Category, line),
+		}
+		order.Subtotal = order.Subtotal.Add(line)
+	}
+	summary := order.SumTaxes()
+	order.Shipping = shippingQuote(method, order.Subtotal, cartWeight(items))
+	order.Total = order.Subtotal.Add(summary.Extra).Add(order.Shipping.Cost)
+
+	err = s.Repo.CreateOrder(order)
+	if errors.Is(err, repository.ErrCartChanged) {
+		return nil, ErrCartChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *Service) GetOrders(userID int, currency string) ([]models.Order, error) {
+	converter, err := s.converter(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := s.Repo.ListOrders(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		s.convertOrder(converter, &orders[i])
+	}
+	return orders, nil
+}
+
+func (s *Service) GetOrder(userID, id int, currency string) (*models.Order, error) {
+	converter, err := s.converter(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := s.Repo.GetOrder(userID, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.convertOrder(converter, order)
+	return order, nil
+}
+
+// convertOrder переводит суммы заказа из базовой валюты. Заказы в другой валюте
+// (оформленные до смены базовой) возвращаются как есть.
+func (s *Service) convertOrder(c money.Converter, o *models.Order) {
+	if o.Currency == "" {
+		o.Currency = s.Rates.Base()
+	}
+	if o.Currency != s.Rates.Base() || c.Currency == o.Currency {
+		return
+	}
+
+	o.Subtotal = money.Zero
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.PriceAtTime = c.Convert(item.PriceAtTime)
+		line := money.Line(item.PriceAtTime, item.Quantity)
+		if item.Tax != nil {
+			item.Tax.Recalculate(line)
+		}
+		o.Subtotal = o.Subtotal.Add(line)
+	}
+	o.Total = o.Subtotal.Add(o.SumTaxes().Extra)
+	o.Refunded = c.Convert(o.Refunded)
+	if o.Shipping != nil {
+		o.Shipping.Cost = c.Convert(o.Shipping.Cost)
+		o.Total = o.Total.Add(o.Shipping.Cost)
+	}
+	o.Currency = c.Currency
+}
+
+// orderTransitions — допустимые переходы статуса заказа. В refunded заказ
+// переходит только через возврат денег по заявкам, см. RefundReturn.
+var orderTransitions = map[string][]string{
+	models.OrderPending:   {models.OrderPaid, models.OrderCancelled},
+	models.OrderPaid:      {models.OrderShipped, models.OrderCancelled},
+	models.OrderShipped:   {models.OrderDelivered},
+	models.OrderDelivered: {models.OrderRefunded},
+}
+
+func canTransition(transitions map[string][]string, from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateOrderStatus ведет заказ по статусам; отмена возвращает товары на склад
+func (s *Service) UpdateOrderStatus(id int, status string) (*models.Order, error) {
+	order, err := s.Repo.GetOrderByID(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status == models.OrderRefunded || !canTransition(orderTransitions, order.Status, status) {
+		return nil, ErrOrderTransition.With("from", order.Status).With("to", status)
+	}
+
+	err = s.Repo.UpdateOrderStatus(id, order.Status, status)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return nil, ErrStatusChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status == models.OrderCancelled {
+		for _, item := range order.Items {
+			s.restocked(item.ProductID, item.Quantity)
+		}
+	}
+	s.notifyOrderStatus(order, status)
+
+	order.Status = status
+	base, _ := s.converter("")
+	s.convertOrder(base, order)
+	return order, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+// GetPriceHistory возвращает изменения цены товара от старых к новым
+func (s *Service) GetPriceHistory(productID int) ([]models.PriceChange, error) {
+	if _, err := s.product(productID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListPriceHistory(productID)
+}
+
+func (s *Service) GetPriceAlerts(userID int) ([]models.PriceAlert, error) {
+	return s.Repo.ListPriceAlerts(userID)
+}
+
+// SetPriceAlert подписывает пользователя на снижение цены одобренного товара.
+// Цель должна быть ниже текущей цены, иначе подписка сработала бы сразу.
+func (s *Service) SetPriceAlert(userID, productID int, target money.Amount) error {
+	product, err := s.product(productID)
+	if err != nil {
+		return err
+	}
+	if !product.IsApproved {
+		return ErrProductUnavailable
+	}
+	// Сравниваем с ценой, которую покупатель видит сейчас, то есть со скидкой
+	base, err := s.pricing("")
+	if err != nil {
+		return err
+	}
+	base.product(product)
+	target = target.Round(2)
+	if target.GreaterThanOrEqual(product.Price) {
+		return ErrPriceAlertTarget.With("price", product.Price)
+	}
+
+	return s.Repo.UpsertPriceAlert(userID, productID, target)
+}
+
+func (s *Service) DeletePriceAlert(userID, productID int) error {
+	err := s.Repo.DeletePriceAlert(userID, productID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrPriceAlertNotFound
+	}
+	return err
+}
+
+// checkPriceAlerts отмечает сработавшие подписки на снижение цены товара с учетом
+// действующих акций. Ошибка не отменяет уже сохраненное изменение товара,
+// поэтому только пишется в журнал.
+func (s *Service) checkPriceAlerts(productID int) {
+	product, err := s.product(productID)
+	if err != nil {
+		slog.Error("Ошибка проверки подписок на цену", "product_id", productID, "error", err)
+		return
+	}
+	sales, err := s.Repo.ListActiveSales(time.Now())
+	if err != nil {
+		slog.Error("Ошибка проверки подписок на цену", "product_id", productID, "error", err)
+		return
+	}
+	price, _ := models.BestSale(sales, productTarget(product), product.Price)
+	s.triggerPriceAlerts(productID, price)
+}
+
+func (s *Service) triggerPriceAlerts(productID int, price money.Amount) {
+	triggered, err := s.Repo.TriggerPriceAlerts(productID, price)
+	if err != nil {
+		slog.Error("Ошибка проверки подписок на цену", "product_id", productID, "error", err)
+		return
+	}
+	if len(triggered) > 0 {
+		slog.Info("Цена товара снизилась, сработали подписки", "product_id", productID,
+			"price", triggered[0].CurrentPrice.StringFixed(2), "alerts", len(triggered))
+	}
+}
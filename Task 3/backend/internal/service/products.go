@@ -0,0 +1,437 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+type ProductPage struct {
+	Products   []models.Product `json:"products"`
+	Page       int              `json:"page"`
+	Limit      int              `json:"limit"`
+	TotalPages int              `json:"totalPages"`
+	Total      int              `json:"total"`
+}
+
+// ProductCursorPage — страница каталога при выборке по курсору. Общее число товаров
+// не считается: следующая страница запрашивается по next_cursor, пока has_more = true.
+type ProductCursorPage struct {
+	Products   []models.Product `json:"products"`
+	Limit      int              `json:"limit"`
+	HasMore    bool             `json:"has_more"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func normalizeLimit(limit int) int {
+	if limit < 1 || limit > 100 {
+		return 10
+	}
+	return limit
+}
+
+func (s *Service) GetProducts(page, limit int, currency string) (*ProductPage, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	limit = normalizeLimit(limit)
+	offset := (page - 1) * limit
+
+	products, err := s.Repo.ListApprovedProducts(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.Repo.CountApprovedProducts()
+	if err != nil {
+		total = len(products)
+	}
+
+	totalPages := 1
+	if limit > 0 {
+		totalPages = (total + limit - 1) / limit
+	}
+
+	if len(products) == 0 {
+		products = nil
+	}
+	for i := range products {
+		prices.product(&products[i])
+	}
+
+	return &ProductPage{
+		Products:   products,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		Total:      total,
+	}, nil
+}
+
+// GetProductsAfter возвращает страницу каталога после курсора; пустой курсор — первая страница
+func (s *Service) GetProductsAfter(cursor string, limit int, currency string) (*ProductCursorPage, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	afterID, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit = normalizeLimit(limit)
+
+	// Лишний товар показывает, есть ли следующая страница
+	products, err := s.Repo.ListApprovedProductsAfter(afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProductCursorPage{Limit: limit}
+	if len(products) > limit {
+		products = products[:limit]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(products[limit-1].ID)
+	}
+	if len(products) > 0 {
+		page.Products = products
+	}
+	for i := range page.Products {
+		prices.product(&page.Products[i])
+	}
+	return page, nil
+}
+
+// Курсор непрозрачен для клиента: это id последнего товара страницы в base64
+func encodeCursor(lastID int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(lastID)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// ProductsETag — слабый ETag выборки товаров: максимальный updated_at и состав выборки.
+// Состав учитывается, чтобы удаление или снятие товара с продажи тоже меняло ETag,
+// а цена с валютой — чтобы ответы в разных валютах и после смены курса различались;
+// extra — прочие поля ответа, зависящие от данных (например, общее число товаров).
+func ProductsETag(products []models.Product, extra ...interface{}) string {
+	var latest time.Time
+	h := fnv.New64a()
+	for _, p := range products {
+		if p.UpdatedAt.After(latest) {
+			latest = p.UpdatedAt
+		}
+		fmt.Fprintf(h, "%d:%s%s,", p.ID, p.Price, p.Currency)
+	}
+	fmt.Fprint(h, extra...)
+
+	return fmt.Sprintf(`W/"%x-%x"`, latest.UnixNano(), h.Sum64())
+}
+
+// GetProduct возвращает карточку товара с ценой в валюте currency
+func (s *Service) GetProduct(id int, currency string) (*models.Product, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.product(id)
+	if err != nil {
+		return nil, err
+	}
+
+	prices.product(product)
+	return product, nil
+}
+
+// product загружает товар с ценой в базовой валюте
+func (s *Service) product(id int) (*models.Product, error) {
+	product, err := s.Repo.GetProductByID(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+func (s *Service) GetMyProducts(userID int) ([]models.Product, error) {
+	products, err := s.Repo.ListProductsByUser(userID)
+	if len(products) == 0 {
+		products = nil
+	}
+	return products, err
+}
+
+// CreateProduct создает товар; товары не-администраторов ждут модерации
+func (s *Service) CreateProduct(userID int, role string, form models.ProductForm, file *multipart.FileHeader) (*models.Product, error) {
+	price, stock, err := parseProductForm(form)
+	if err != nil {
+		return nil, err
+	}
+	weight, err := parseWeight(form.Weight, 0)
+	if err != nil {
+		return nil, err
+	}
+	attributes, err := parseAttributes(form.Attributes, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	image := form.Image
+	if file != nil {
+		if image, err = s.saveImage(file); err != nil {
+			return nil, err
+		}
+	}
+
+	if image == "" {
+		image = "default.png"
+	}
+
+	product := &models.Product{
+		Name:        form.Name,
+		Description: form.Description,
+		Price:       price,
+		Image:       image,
+		Stock:       stock,
+		Weight:      weight,
+		UserID:      &userID,
+		IsApproved:  role == models.RoleAdmin,
+		Category:    strings.TrimSpace(form.Category),
+		Attributes:  attributes,
+	}
+
+	if err := s.Repo.CreateProduct(product); err != nil {
+		return nil, err
+	}
+
+	if !product.IsApproved {
+		s.notifyPending(product, 0)
+	}
+	return product, nil
+}
+
+// parseProductForm разбирает цену и остаток из полей формы; цена допускает запятую
+func parseProductForm(form models.ProductForm) (money.Amount, int, error) {
+	price, err := money.Parse(form.Price)
+	if err != nil || !price.IsPositive() || price.GreaterThan(money.MaxPrice) {
+		return money.Zero, 0, ErrInvalidPrice
+	}
+
+	stock, err := strconv.Atoi(strings.TrimSpace(form.Stock))
+	if err != nil || stock < 0 {
+		return money.Zero, 0, ErrInvalidStock
+	}
+
+	return price, stock, nil
+}
+
+// parseWeight разбирает вес в граммах; пустое поле оставляет текущий вес
+func parseWeight(value string, current int) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return current, nil
+	}
+
+	weight, err := strconv.Atoi(value)
+	if err != nil || weight < 0 || weight > maxWeight {
+		return 0, ErrInvalidWeight
+	}
+	return weight, nil
+}
+
+// parseAttributes разбирает характеристики из JSON-объекта «название → значение».
+// Пустое поле оставляет текущие; строки с пустым значением отбрасываются, а названия,
+// совпадающие без учета регистра, считаются повтором.
+func parseAttributes(value string, current map[string]string) (map[string]string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return current, nil
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(value), &raw); err != nil || len(raw) > maxAttributes {
+		return nil, ErrInvalidAttributes.With("max", maxAttributes)
+	}
+
+	attributes := map[string]string{}
+	seen := map[string]bool{}
+	for name, v := range raw {
+		name, v = strings.TrimSpace(name), strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		key := attributeKey(name)
+		if name == "" || seen[key] || utf8.RuneCountInString(name) > 64 || utf8.RuneCountInString(v) > 255 {
+			return nil, ErrInvalidAttributes.With("max", maxAttributes)
+		}
+		seen[key] = true
+		attributes[name] = v
+	}
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	return attributes, nil
+}
+
+// authorizeProduct загружает товар и проверяет, что пользователь — владелец или администратор
+func (s *Service) authorizeProduct(productID, userID int, role string, forbidden error) (*models.Product, error) {
+	product, err := s.Repo.GetProductByID(productID)
+	if err != nil {
+		return nil, ErrProductNotFound
+	}
+
+	if !canManageProduct(product, userID, role) {
+		return nil, forbidden
+	}
+
+	return product, nil
+}
+
+// canManageProduct — товар может править и представлять его владелец или администратор
+func canManageProduct(product *models.Product, userID int, role string) bool {
+	return role == models.RoleAdmin || product.OwnedBy(userID)
+}
+
+// UpdateProduct сохраняет изменения; после правки продавцом товар снова ждет одобрения
+func (s *Service) UpdateProduct(productID, userID int, role string, form models.ProductForm, file *multipart.FileHeader) error {
+	product, err := s.authorizeProduct(productID, userID, role, ErrEditForbidden)
+	if err != nil {
+		return err
+	}
+
+	price, stock, err := parseProductForm(form)
+	if err != nil {
+		return err
+	}
+	weight, err := parseWeight(form.Weight, product.Weight)
+	if err != nil {
+		return err
+	}
+	attributes, err := parseAttributes(form.Attributes, product.Attributes)
+	if err != nil {
+		return err
+	}
+
+	newImage := form.Image
+	if file != nil {
+		if newImage, err = s.saveImage(file); err != nil {
+			return err
+		}
+	}
+
+	// Если новое изображение не установлено, оставляем старое
+	if newImage == "" {
+		newImage = product.Image
+	}
+
+	wasApproved, oldStock := product.IsApproved, product.Stock
+	product.Name = form.Name
+	product.Description = form.Description
+	product.Price = price
+	product.Image = newImage
+	product.Stock = stock
+	product.Weight = weight
+	product.Attributes = attributes
+	if category := strings.TrimSpace(form.Category); category != "" {
+		product.Category = category
+	}
+
+	resetApproval := role != models.RoleAdmin
+	if err := s.Repo.UpdateProduct(product, resetApproval, userID); err != nil {
+		return err
+	}
+
+	// Подписчики узнают о снижении цены и поступлении, только когда товар виден в каталоге
+	if product.IsApproved && !resetApproval {
+		s.checkPriceAlerts(productID)
+		s.restocked(productID, stock-oldStock)
+	}
+	// Правка уже ждущего модерации товара не добавляет администраторам работы
+	if wasApproved && resetApproval {
+		s.notifyPending(product, 0)
+	}
+	return nil
+}
+
+// DeleteProduct удаляет товар; если он лежит в корзинах, товар только скрывается
+func (s *Service) DeleteProduct(productID, userID int, role string) (hidden bool, err error) {
+	if _, err := s.authorizeProduct(productID, userID, role, ErrDeleteForbidden); err != nil {
+		return false, err
+	}
+
+	inCart, _ := s.Repo.ProductInCarts(productID)
+	if inCart {
+		return true, s.Repo.SetProductApproved(productID, false)
+	}
+
+	return false, s.Repo.DeleteProduct(productID)
+}
+
+func (s *Service) GetPendingProducts() ([]models.Product, error) {
+	return s.Repo.ListPendingProducts()
+}
+
+func (s *Service) ApproveProduct(productID int) error {
+	product, err := s.product(productID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.SetProductApproved(productID, true); err != nil {
+		return err
+	}
+
+	// Цену могли снизить, пока товар был на модерации
+	s.checkPriceAlerts(productID)
+	if !product.IsApproved {
+		s.notifyOwner(product, models.NotifyProductApproved)
+		// Пока товар был скрыт, купить его было нельзя: для ждущих покупателей
+		// весь остаток поступил только сейчас
+		s.restocked(productID, product.Stock)
+	}
+	return nil
+}
+
+// ForceDeleteProduct удаляет товар вместе с позициями корзин. Удаление товара
+// с модерации — это отказ в публикации, о нем сообщается продавцу.
+func (s *Service) ForceDeleteProduct(productID int) error {
+	product, err := s.product(productID)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.ForceDeleteProduct(productID); err != nil {
+		return err
+	}
+
+	if !product.IsApproved {
+		s.notifyOwner(product, models.NotifyProductRejected)
+	}
+	return nil
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+)
+
+// detailQuestions — сколько вопросов с ответами показывать в карточке товара
+const detailQuestions = 5
+
+// ContentModerator — точка подключения модерации вопросов и ответов: решает,
+// опубликовать текст сразу (models.QAPublished) или отправить на проверку (models.QAPending)
+type ContentModerator interface {
+	Review(authorID int, text string) string
+}
+
+// PublishAll публикует все без проверки
+type PublishAll struct{}
+
+func (PublishAll) Review(int, string) string { return models.QAPublished }
+
+// PremoderateAll отправляет все на проверку администратору
+type PremoderateAll struct{}
+
+func (PremoderateAll) Review(int, string) string { return models.QAPending }
+
+// ProductDetail — карточка товара с вопросами, на которые уже ответили, и рекомендациями
+type ProductDetail struct {
+	models.Product
+	Questions       []models.Question `json:"questions" doc:"Последние вопросы с ответами"`
+	Recommendations Recommendations   `json:"recommendations"`
+}
+
+// GetProductDetail возвращает карточку товара с ценой в валюте currency, ответами на вопросы
+// и рекомендациями. viewer — пользователь или анонимная сессия (см. UserViewer, SessionViewer),
+// просмотр записывается в его историю; пустой viewer ничего не записывает.
+func (s *Service) GetProductDetail(id int, currency, viewer string) (*ProductDetail, error) {
+	prices, err := s.pricing(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.product(id)
+	if err != nil {
+		return nil, err
+	}
+	s.recordView(viewer, product)
+	prices.product(product)
+
+	questions, err := s.Repo.ListQuestions(id, true, detailQuestions)
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := s.recommendations(viewer, []int{id}, prices)
+	if err != nil {
+		return nil, err
+	}
+	return &ProductDetail{Product: *product, Questions: questions, Recommendations: rec}, nil
+}
+
+// ProductDetailETag учитывает в ETag карточки вопросы, ответы, голоса и рекомендации
+func ProductDetailETag(d *ProductDetail) string {
+	h := fnv.New64a()
+	for _, q := range d.Questions {
+		fmt.Fprintf(h, "q%d:", q.ID)
+		for _, a := range q.Answers {
+			fmt.Fprintf(h, "%d/%d,", a.ID, a.Votes)
+		}
+	}
+
+	products := []models.Product{d.Product}
+	products = append(products, d.Recommendations.RecentlyViewed...)
+	products = append(products, d.Recommendations.BoughtTogether...)
+	return ProductsETag(products, len(d.Recommendations.RecentlyViewed), h.Sum64())
+}
+
+// GetQuestions возвращает все опубликованные вопросы о товаре, новые первыми
+func (s *Service) GetQuestions(productID int) ([]models.Question, error) {
+	if _, err := s.product(productID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListQuestions(productID, false, 0)
+}
+
+func (s *Service) AskQuestion(userID, productID int, req models.QuestionRequest) (*models.Question, error) {
+	product, err := s.product(productID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.IsApproved {
+		return nil, ErrProductUnavailable
+	}
+
+	body := strings.TrimSpace(req.Body)
+	q := &models.Question{
+		ProductID: productID,
+		UserID:    userID,
+		Body:      body,
+		Status:    s.Moderator.Review(userID, body),
+	}
+	if err := s.Repo.CreateQuestion(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// AnswerQuestion принимает ответ от владельца товара (та же проверка, что при правке
+// товара) или от покупателя, который уже заказывал этот товар
+func (s *Service) AnswerQuestion(actor Actor, questionID int, req models.AnswerRequest) (*models.Answer, error) {
+	q, err := s.Repo.GetQuestion(questionID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && q.Status != models.QAPublished) {
+		return nil, ErrQuestionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.product(q.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &models.Answer{
+		QuestionID: questionID,
+		UserID:     actor.ID,
+		Body:       strings.TrimSpace(req.Body),
+		IsSeller:   canManageProduct(product, actor.ID, actor.Role),
+	}
+	if answer.IsBuyer, err = s.Repo.HasPurchased(actor.ID, q.ProductID); err != nil {
+		return nil, err
+	}
+	if !answer.IsSeller && !answer.IsBuyer {
+		return nil, ErrAnswerForbidden
+	}
+
+	answer.Status = s.Moderator.Review(actor.ID, answer.Body)
+	if err := s.Repo.CreateAnswer(answer); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// VoteAnswer ставит или снимает голос «полезный ответ»; за свой ответ голосовать нельзя
+func (s *Service) VoteAnswer(userID, answerID int, up bool) (*models.Answer, error) {
+	answer, err := s.Repo.GetAnswer(answerID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && answer.Status != models.QAPublished) {
+		return nil, ErrAnswerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if answer.UserID == userID {
+		return nil, ErrVoteOwnAnswer
+	}
+
+	if err := s.Repo.VoteAnswer(answerID, userID, up); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetAnswer(answerID)
+}
+
+// ModerationQueue — вопросы и ответы, ожидающие проверки
+type ModerationQueue struct {
+	Questions []models.Question `json:"questions"`
+	Answers   []models.Answer   `json:"answers"`
+}
+
+func (s *Service) GetModerationQueue() (*ModerationQueue, error) {
+	questions, err := s.Repo.ListQuestionsByStatus(models.QAPending)
+	if err != nil {
+		return nil, err
+	}
+	answers, err := s.Repo.ListAnswersByStatus(models.QAPending)
+	if err != nil {
+		return nil, err
+	}
+	return &ModerationQueue{Questions: questions, Answers: answers}, nil
+}
+
+func (s *Service) ModerateQuestion(id int, status string) error {
+	err := s.Repo.SetQuestionStatus(id, status)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrQuestionNotFound
+	}
+	return err
+}
+
+func (s *Service) ModerateAnswer(id int, status string) error {
+	err := s.Repo.SetAnswerStatus(id, status)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAnswerNotFound
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"catpc-backend/internal/models"
+)
+
+// recommendationLimit — сколько товаров показывать в каждом списке рекомендаций
+const recommendationLimit = 6
+
+// Recommendations — недавно просмотренные товары и товары, которые покупают вместе.
+// В списки попадают только одобренные товары в наличии.
+type Recommendations struct {
+	RecentlyViewed []models.Product `json:"recently_viewed" doc:"Недавно просмотренные товары"`
+	BoughtTogether []models.Product `json:"bought_together" doc:"С этим товаром покупают"`
+}
+
+// UserViewer — ключ истории просмотров авторизованного пользователя
+func UserViewer(userID int) string {
+	return fmt.Sprintf("u:%d", userID)
+}
+
+// SessionViewer — ключ истории просмотров анонимной сессии
+func SessionViewer(sessionID string) string {
+	return "s:" + sessionID
+}
+
+// recommendations собирает рекомендации к товарам productIDs, не повторяя их самих.
+// viewer может быть пустым — тогда недавно просмотренных нет.
+func (s *Service) recommendations(viewer string, productIDs []int, prices pricing) (Recommendations, error) {
+	rec := Recommendations{RecentlyViewed: []models.Product{}}
+
+	var err error
+	if viewer != "" {
+		if rec.RecentlyViewed, err = s.Repo.ListViewed(viewer, productIDs, recommendationLimit); err != nil {
+			return rec, err
+		}
+	}
+	if rec.BoughtTogether, err = s.Repo.ListBoughtTogether(productIDs, recommendationLimit); err != nil {
+		return rec, err
+	}
+
+	for i := range rec.RecentlyViewed {
+		prices.product(&rec.RecentlyViewed[i])
+	}
+	for i := range rec.BoughtTogether {
+		prices.product(&rec.BoughtTogether[i])
+	}
+	return rec, nil
+}
+
+// recordView запоминает просмотр; ошибка только пишется в лог, чтобы не ломать карточку товара
+func (s *Service) recordView(viewer string, product *models.Product) {
+	if viewer == "" || !product.IsApproved {
+		return
+	}
+	if err := s.Repo.RecordView(viewer, product.ID); err != nil {
+		slog.Error("Ошибка записи просмотра товара", "product_id", product.ID, "error", err)
+	}
+}
+
+// RefreshProductPairs пересчитывает пары «покупают вместе» по заказам
+func (s *Service) RefreshProductPairs() error {
+	return s.Repo.RecomputeProductPairs()
+}
+
+// RunProductPairsJob пересчитывает пары сразу и затем каждые interval, пока не отменен ctx
+func (s *Service) RunProductPairsJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.RefreshProductPairs(); err != nil {
+			slog.Error("Ошибка пересчета рекомендаций", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/repository"
+)
+
+// maxReturnPhotos — сколько фотографий можно приложить к заявке на возврат
+const maxReturnPhotos = 5
+
+// returnTransitions — допустимые переходы заявки на возврат: продавец одобряет
+// заявку и ждет товар, отмечает получение (товар возвращается на склад) и возврат денег
+var returnTransitions = map[string][]string{
+	models.ReturnRequested:    {models.ReturnAwaitingItem, models.ReturnRejected},
+	models.ReturnAwaitingItem: {models.ReturnReceived, models.ReturnRejected},
+	models.ReturnReceived:     {models.ReturnRefunded},
+}
+
+// CreateReturn открывает заявку на возврат по позиции доставленного заказа
+func (s *Service) CreateReturn(userID, orderID int, req models.ReturnRequest) (*models.Return, error) {
+	order, err := s.Repo.GetOrder(userID, orderID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderDelivered {
+		return nil, ErrReturnNotDelivered
+	}
+
+	if err := s.checkReturnPhotos(userID, req.Photos); err != nil {
+		return nil, err
+	}
+
+	ret := &models.Return{
+		OrderID:     orderID,
+		OrderItemID: req.OrderItemID,
+		UserID:      userID,
+		Quantity:    req.Quantity,
+		Reason:      strings.TrimSpace(req.Reason),
+		Photos:      req.Photos,
+	}
+	err = s.Repo.CreateReturn(ret)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, ErrOrderItemNotFound
+	case errors.Is(err, repository.ErrReturnQuantity):
+		return nil, ErrReturnQuantity
+	case err != nil:
+		return nil, err
+	}
+	return ret, nil
+}
+
+// checkReturnPhotos проверяет, что фотографии — файлы, которые покупатель сам
+// загрузил через /api/upload: чужую загрузку к заявке приложить нельзя
+func (s *Service) checkReturnPhotos(userID int, photos []string) error {
+	if len(photos) > maxReturnPhotos {
+		return apperr.Validation([]apperr.FieldError{{Field: "photos", Code: "max_items", Params: apperr.Params{"param": maxReturnPhotos}}})
+	}
+
+	for _, name := range photos {
+		valid := name != "" && imageFileName(name)
+		if valid {
+			info, err := os.Stat(filepath.Join(s.config.UploadDir, name))
+			valid = err == nil && info.Mode().IsRegular()
+		}
+		if !valid {
+			return apperr.Validation([]apperr.FieldError{{Field: "photos", Code: "file_name"}})
+		}
+	}
+	if len(photos) == 0 {
+		return nil
+	}
+
+	owned, err := s.Repo.OwnedImages(userID, photos)
+	if err != nil {
+		return err
+	}
+	mine := make(map[string]bool, len(owned))
+	for _, name := range owned {
+		mine[name] = true
+	}
+	for _, name := range photos {
+		if !mine[name] {
+			return apperr.Validation([]apperr.FieldError{{Field: "photos", Code: "image_owner"}})
+		}
+	}
+	return nil
+}
+
+func (s *Service) GetReturns(userID int) ([]models.Return, error) {
+	return s.Repo.ListReturns(models.ReturnFilter{UserID: userID})
+}
+
+// GetSellerReturns возвращает заявки по товарам продавца; администратору — все заявки
+func (s *Service) GetSellerReturns(actor Actor) ([]models.Return, error) {
+	filter := models.ReturnFilter{SellerID: actor.ID}
+	if actor.Role == models.RoleAdmin {
+		filter.SellerID = 0
+	}
+	return s.Repo.ListReturns(filter)
+}
+
+// UpdateReturnStatus продвигает заявку по статусам. Возврат денег проходит
+// через статусы заказа: когда возвращена вся сумма товаров, заказ становится refunded.
+func (s *Service) UpdateReturnStatus(actor Actor, id int, req models.ReturnStatusRequest) (*models.Return, error) {
+	ret, err := s.Repo.GetReturn(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrReturnNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.Role != models.RoleAdmin && ret.SellerID != actor.ID {
+		return nil, ErrReturnForbidden
+	}
+	if !canTransition(returnTransitions, ret.Status, req.Status) {
+		return nil, ErrReturnTransition.With("from", ret.Status).With("to", req.Status)
+	}
+
+	comment := strings.TrimSpace(req.Comment)
+	if req.Status == models.ReturnRefunded {
+		err = s.refundReturn(ret, comment)
+	} else {
+		err = s.Repo.UpdateReturnStatus(id, ret.Status, req.Status, comment)
+	}
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return nil, ErrStatusChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status == models.ReturnReceived {
+		s.restocked(ret.ProductID, ret.Quantity)
+	}
+	return s.Repo.GetReturn(id)
+}
+
+// refundReturn возвращает деньги по заявке. Сумма и статус заказа считаются
+// в транзакции репозитория: доставка не возвращается, и заказ возвращен целиком,
+// когда возвращены товары с налогами.
+func (s *Service) refundReturn(ret *models.Return, comment string) error {
+	order, refunded, err := s.Repo.RefundReturn(ret.ID, comment)
+	if err != nil {
+		return err
+	}
+	if refunded {
+		s.notifyOrderStatus(order, models.OrderRefunded)
+	}
+	return nil
+}
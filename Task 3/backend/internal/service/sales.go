@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+const (
+	// maxSalePercent — наибольшая скидка в процентах
+	maxSalePercent = 90
+	// maxSaleProducts — сколько товаров можно выбрать в акции поштучно
+	maxSaleProducts = 500
+)
+
+// pricing пересчитывает цены одного ответа: применяет акции, действующие
+// в момент запроса, и переводит цены из базовой валюты
+type pricing struct {
+	converter money.Converter
+	sales     []models.Sale
+}
+
+func (s *Service) pricing(currency string) (pricing, error) {
+	converter, err := s.converter(currency)
+	if err != nil {
+		return pricing{}, err
+	}
+	sales, err := s.Repo.ListActiveSales(time.Now())
+	if err != nil {
+		return pricing{}, err
+	}
+	return pricing{converter: converter, sales: sales}, nil
+}
+
+// product ставит в Price цену со скидкой, в OriginalPrice — цену без нее
+func (p pricing) product(product *models.Product) {
+	price, sale := models.BestSale(p.sales, productTarget(product), product.Price)
+
+	original := p.converter.Convert(product.Price)
+	product.OriginalPrice = &original
+	product.Price = p.converter.Convert(price)
+	product.Currency = p.converter.Currency
+	product.Sale = appliedSale(sale)
+}
+
+// applySale ставит позиции корзины цену со скидкой в базовой валюте
+func applySale(sales []models.Sale, item *models.CartItem) {
+	target := models.SaleTarget{ProductID: item.ProductID, SellerID: item.SellerID, Category: item.Category}
+	price, sale := models.BestSale(sales, target, item.Price)
+	item.OriginalPrice = item.Price
+	item.Price = price
+	item.Sale = appliedSale(sale)
+}
+
+func productTarget(p *models.Product) models.SaleTarget {
+	target := models.SaleTarget{ProductID: p.ID, Category: p.Category}
+	if p.UserID != nil {
+		target.SellerID = *p.UserID
+	}
+	return target
+}
+
+func appliedSale(sale *models.Sale) *models.AppliedSale {
+	if sale == nil {
+		return nil
+	}
+	return &models.AppliedSale{ID: sale.ID, Name: sale.Name, EndsAt: sale.EndsAt}
+}
+
+// GetSales возвращает администратору все акции, продавцу — его собственные
+func (s *Service) GetSales(actor Actor) ([]models.Sale, error) {
+	sellerID := actor.ID
+	if actor.Role == models.RoleAdmin {
+		sellerID = 0
+	}
+	return s.Repo.ListSales(sellerID)
+}
+
+// CreateSale планирует акцию. Акция администратора действует на товары всего магазина,
+// акция продавца — только на его товары. Если время начала уже наступило, акция
+// запускается сразу, не дожидаясь планировщика.
+func (s *Service) CreateSale(actor Actor, req models.SaleRequest) (*models.Sale, error) {
+	now := time.Now()
+	if req.DiscountType == models.DiscountPercent && req.DiscountValue.GreaterThan(money.FromInt(maxSalePercent)) {
+		return nil, ErrSalePercent.With("max", maxSalePercent)
+	}
+	if !req.EndsAt.After(req.StartsAt) || !req.EndsAt.After(now) {
+		return nil, ErrSaleWindow
+	}
+
+	sale := &models.Sale{
+		Name:          strings.TrimSpace(req.Name),
+		DiscountType:  req.DiscountType,
+		DiscountValue: req.DiscountValue.Round(2),
+		StartsAt:      req.StartsAt.UTC(),
+		EndsAt:        req.EndsAt.UTC(),
+		Category:      strings.TrimSpace(req.Category),
+		ProductIDs:    []int{},
+		Status:        models.SaleScheduled,
+		CreatedBy:     &actor.ID,
+	}
+	if actor.Role != models.RoleAdmin {
+		sale.SellerID = &actor.ID
+	}
+
+	seen := map[int]bool{}
+	for _, id := range req.ProductIDs {
+		if !seen[id] {
+			seen[id] = true
+			sale.ProductIDs = append(sale.ProductIDs, id)
+		}
+	}
+	if sale.Category == "" && len(sale.ProductIDs) == 0 {
+		return nil, ErrSaleTarget
+	}
+	if len(sale.ProductIDs) > maxSaleProducts {
+		return nil, ErrSaleProducts.With("max", maxSaleProducts)
+	}
+
+	// Продавец выбирает только свои товары; о чужих сообщаем так же, как о несуществующих
+	for _, id := range sale.ProductIDs {
+		product, err := s.product(id)
+		if errors.Is(err, ErrProductNotFound) || (err == nil && sale.SellerID != nil && !product.OwnedBy(actor.ID)) {
+			return nil, ErrSaleProduct.With("id", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.Repo.CreateSale(sale); err != nil {
+		return nil, err
+	}
+	if !sale.StartsAt.After(now) {
+		if err := s.AdvanceSales(now); err != nil {
+			return nil, err
+		}
+	}
+	return s.sale(sale.ID)
+}
+
+// CancelSale отменяет запланированную или идущую акцию; цены сразу возвращаются к обычным
+func (s *Service) CancelSale(actor Actor, id int) (*models.Sale, error) {
+	sale, err := s.sale(id)
+	if err != nil {
+		return nil, err
+	}
+	if actor.Role != models.RoleAdmin && (sale.SellerID == nil || *sale.SellerID != actor.ID) {
+		return nil, ErrSaleForbidden
+	}
+
+	err = s.Repo.CancelSale(id)
+	if errors.Is(err, repository.ErrStatusChanged) {
+		return nil, ErrSaleFinished.With("status", sale.Status)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sale.Status = models.SaleCancelled
+	return sale, nil
+}
+
+func (s *Service) sale(id int) (*models.Sale, error) {
+	sale, err := s.Repo.GetSale(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrSaleNotFound
+	}
+	return sale, err
+}
+
+// AdvanceSales запускает наступившие акции и завершает прошедшие. Со стартом акции
+// ее товары дешевеют, поэтому для них проверяются подписки на снижение цены.
+func (s *Service) AdvanceSales(now time.Time) error {
+	started, err := s.Repo.AdvanceSales(now)
+	if err != nil || len(started) == 0 {
+		return err
+	}
+
+	active, err := s.Repo.ListActiveSales(now)
+	if err != nil {
+		return err
+	}
+	for _, sale := range started {
+		products, err := s.Repo.ListSaleProducts(sale)
+		if err != nil {
+			return err
+		}
+		slog.Info("Акция началась", "sale_id", sale.ID, "products", len(products))
+
+		for _, p := range products {
+			price, _ := models.BestSale(active, productTarget(&p), p.Price)
+			s.triggerPriceAlerts(p.ID, price)
+		}
+	}
+	return nil
+}
+
+// RunSalesJob проверяет расписание акций сразу и затем каждые interval, пока не отменен ctx
+func (s *Service) RunSalesJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.AdvanceSales(time.Now()); err != nil {
+			slog.Error("Ошибка планировщика акций", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/config"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/notify"
+	"catpc-backend/internal/repository"
+)
+
+// Ошибки сервиса с машиночитаемыми кодами; текст для клиента берется из каталога i18n
+var (
+	ErrRequiredFields     = apperr.Invalid("required_fields")
+	ErrUserExists         = apperr.New(apperr.KindConflict, "user_exists")
+	ErrInvalidCredentials = apperr.New(apperr.KindUnauthorized, "invalid_credentials")
+	ErrAccountBlocked     = apperr.New(apperr.KindForbidden, "account_blocked")
+	ErrUserNotFound       = apperr.New(apperr.KindNotFound, "user_not_found")
+	ErrInvalidRole        = apperr.Invalid("invalid_role")
+	ErrProtectedRole      = apperr.New(apperr.KindForbidden, "protected_user_role")
+	ErrAdminAssignment    = apperr.New(apperr.KindForbidden, "admin_assignment_forbidden")
+	ErrRoleUnchanged      = apperr.Invalid("role_unchanged")
+	ErrProtectedBlock     = apperr.New(apperr.KindForbidden, "protected_user_block")
+	ErrBlockSelf          = apperr.New(apperr.KindForbidden, "block_self")
+	ErrBulkSelf           = apperr.New(apperr.KindForbidden, "bulk_self")
+	ErrSessionRevoked     = apperr.New(apperr.KindUnauthorized, "session_revoked")
+	ErrWrongPassword      = apperr.Invalid("wrong_password")
+	ErrEmailTokenInvalid  = apperr.Invalid("email_token_invalid")
+	ErrEmailTokenExpired  = apperr.Invalid("email_token_expired")
+	ErrProtectedRename    = apperr.New(apperr.KindForbidden, "protected_user_rename")
+	ErrProtectedDelete    = apperr.New(apperr.KindForbidden, "protected_user_delete")
+
+	ErrProductNotFound    = apperr.New(apperr.KindNotFound, "product_not_found")
+	ErrSellerNotFound     = apperr.New(apperr.KindNotFound, "seller_not_found")
+	ErrInvalidCursor      = apperr.Invalid("invalid_cursor")
+	ErrPriceAlertTarget   = apperr.Invalid("price_alert_target")
+	ErrPriceAlertNotFound = apperr.New(apperr.KindNotFound, "price_alert_not_found")
+	ErrProductUnavailable = apperr.Invalid("product_unavailable")
+	ErrInsufficientStock  = apperr.Invalid("insufficient_stock")
+	ErrInvalidQuantity    = apperr.Invalid("invalid_quantity")
+	ErrInvalidPrice       = apperr.Invalid("invalid_price")
+	ErrInvalidStock       = apperr.Invalid("invalid_stock")
+	ErrInvalidWeight      = apperr.Invalid("invalid_weight")
+	ErrInvalidAttributes  = apperr.Invalid("invalid_attributes")
+	ErrUnknownCurrency    = apperr.Invalid("unknown_currency")
+	ErrEditForbidden      = apperr.New(apperr.KindForbidden, "product_edit_forbidden")
+	ErrDeleteForbidden    = apperr.New(apperr.KindForbidden, "product_delete_forbidden")
+
+	ErrAddressNotFound        = apperr.New(apperr.KindNotFound, "address_not_found")
+	ErrDeliveryMethodNotFound = apperr.New(apperr.KindNotFound, "delivery_method_not_found")
+	ErrTaxRateNotFound        = apperr.New(apperr.KindNotFound, "tax_rate_not_found")
+	ErrTaxRateExists          = apperr.New(apperr.KindConflict, "tax_rate_exists")
+	ErrAddressRequired        = apperr.Invalid("address_required")
+	ErrCartEmpty              = apperr.Invalid("cart_empty")
+	ErrCartChanged            = apperr.New(apperr.KindConflict, "cart_changed")
+	ErrOrderNotFound          = apperr.New(apperr.KindNotFound, "order_not_found")
+	ErrOrderTransition        = apperr.Invalid("order_transition")
+	ErrStatusChanged          = apperr.New(apperr.KindConflict, "status_changed")
+	ErrInvoiceUnavailable     = apperr.Invalid("invoice_unavailable")
+
+	ErrOrderItemNotFound  = apperr.New(apperr.KindNotFound, "order_item_not_found")
+	ErrReturnNotFound     = apperr.New(apperr.KindNotFound, "return_not_found")
+	ErrReturnNotDelivered = apperr.Invalid("return_not_delivered")
+	ErrReturnQuantity     = apperr.Invalid("return_quantity")
+	ErrReturnTransition   = apperr.Invalid("return_transition")
+	ErrReturnForbidden    = apperr.New(apperr.KindForbidden, "return_forbidden")
+
+	ErrNotificationNotFound = apperr.New(apperr.KindNotFound, "notification_not_found")
+
+	ErrQuestionNotFound = apperr.New(apperr.KindNotFound, "question_not_found")
+	ErrAnswerNotFound   = apperr.New(apperr.KindNotFound, "answer_not_found")
+	ErrAnswerForbidden  = apperr.New(apperr.KindForbidden, "answer_forbidden")
+	ErrVoteOwnAnswer    = apperr.Invalid("vote_own_answer")
+
+	ErrCompareOwner    = apperr.Invalid("compare_owner_required")
+	ErrCompareFull     = apperr.Invalid("compare_full")
+	ErrCompareCategory = apperr.Invalid("compare_category")
+	ErrCompareNotFound = apperr.New(apperr.KindNotFound, "compare_item_not_found")
+
+	ErrSaleNotFound  = apperr.New(apperr.KindNotFound, "sale_not_found")
+	ErrSaleForbidden = apperr.New(apperr.KindForbidden, "sale_forbidden")
+	ErrSaleWindow    = apperr.Invalid("sale_window")
+	ErrSalePercent   = apperr.Invalid("sale_percent")
+	ErrSaleTarget    = apperr.Invalid("sale_target_required")
+	ErrSaleProducts  = apperr.Invalid("sale_too_many_products")
+	ErrSaleProduct   = apperr.Invalid("sale_product_invalid")
+	ErrSaleFinished  = apperr.Invalid("sale_finished")
+
+	ErrFileTooLarge    = apperr.Invalid("file_too_large")
+	ErrNotImage        = apperr.Invalid("not_an_image")
+	ErrImageCorrupt    = apperr.Invalid("image_corrupt")
+	ErrImageDimensions = apperr.Invalid("image_dimensions")
+)
+
+// maxWeight — наибольший вес товара в граммах
+const maxWeight = 1000000
+
+// MainAdminUsername — единственный пользователь, который может назначать администраторов
+const MainAdminUsername = "CatPC"
+
+type Service struct {
+	Repo repository.Repository
+	// Rates — курсы валют; по умолчанию доступна только базовая валюта
+	Rates money.ExchangeRateProvider
+	// Moderator решает, публиковать ли вопросы и ответы о товарах сразу
+	Moderator ContentModerator
+	// Mailer отправляет письма, например код подтверждения нового email
+	Mailer Mailer
+	// Notifications рассылает новые уведомления открытым потокам пользователей
+	Notifications *notify.Hub
+	config        *config.Config
+	jwtSecret     []byte
+	reports       *reportCache
+}
+
+func NewService(repo repository.Repository, cfg *config.Config) *Service {
+	var moderator ContentModerator = PublishAll{}
+	if cfg.QAPremoderation {
+		moderator = PremoderateAll{}
+	}
+
+	return &Service{
+		Repo:          repo,
+		Rates:         money.NewStaticRates(cfg.BaseCurrency, nil),
+		Moderator:     moderator,
+		Mailer:        LogMailer{},
+		Notifications: notify.NewHub(notificationsBuffer),
+		config:        cfg,
+		jwtSecret:     []byte(cfg.JWTSecret),
+		reports:       newReportCache(cfg.AnalyticsCacheTTL),
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"catpc-backend/internal/models"
+	"catpc-backend/internal/money"
+	"catpc-backend/internal/repository"
+)
+
+// taxes подбирает ставки налогов для позиций одной корзины или заказа
+type taxes struct {
+	rates  []models.TaxRate
+	region string
+}
+
+// taxes загружает ставки для доставки в регион region; пустой регион —
+// самовывоз или адрес еще не выбран, тогда действуют ставки без региона
+func (s *Service) taxes(region string) (taxes, error) {
+	rates, err := s.Repo.ListTaxRates()
+	if err != nil {
+		return taxes{}, err
+	}
+	return taxes{rates: rates, region: region}, nil
+}
+
+// item считает налог позиции категории category с суммой amount; nil — без налога
+func (t taxes) item(category string, amount money.Amount) *models.ItemTax {
+	rate := models.MatchTaxRate(t.rates, category, t.region)
+	if rate == nil {
+		return nil
+	}
+	return rate.Tax(amount)
+}
+
+// addressRegion возвращает регион адреса покупателя; 0 — адрес не выбран
+func (s *Service) addressRegion(userID, addressID int) (string, error) {
+	if addressID == 0 {
+		return "", nil
+	}
+	address, err := s.Repo.GetAddress(userID, addressID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", ErrAddressNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return address.Region, nil
+}
+
+func (s *Service) GetTaxRates() ([]models.TaxRate, error) {
+	return s.Repo.ListTaxRates()
+}
+
+func taxRateFromRequest(req models.TaxRateRequest) *models.TaxRate {
+	return &models.TaxRate{
+		Name:      strings.TrimSpace(req.Name),
+		Rate:      req.Rate.Round(2),
+		Category:  strings.TrimSpace(req.Category),
+		Region:    strings.TrimSpace(req.Region),
+		Inclusive: req.Inclusive,
+	}
+}
+
+// CreateTaxRate добавляет ставку. Новые ставки действуют на корзины сразу,
+// а на оформленные заказы не влияют: налог позиции сохраняется при оформлении.
+func (s *Service) CreateTaxRate(req models.TaxRateRequest) (*models.TaxRate, error) {
+	rate := taxRateFromRequest(req)
+	err := s.Repo.CreateTaxRate(rate)
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, ErrTaxRateExists
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *Service) UpdateTaxRate(id int, req models.TaxRateRequest) (*models.TaxRate, error) {
+	rate := taxRateFromRequest(req)
+	rate.ID = id
+
+	err := s.Repo.UpdateTaxRate(rate)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return nil, ErrTaxRateNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return nil, ErrTaxRateExists
+	case err != nil:
+		return nil, err
+	}
+	return rate, nil
+}
+
+func (s *Service) DeleteTaxRate(id int) error {
+	err := s.Repo.DeleteTaxRate(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrTaxRateNotFound
+	}
+	return err
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/metrics"
+
+	_ "golang.org/x/image/webp"
+)
+
+// jpegQuality — качество перекодированных JPEG: артефакты незаметны, размер умеренный
+const jpegQuality = 90
+
+// imageTypes — разрешенные форматы загрузок; остальное, включая SVG, отклоняется
+var imageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// UploadImage сохраняет изображение в папку загрузок и возвращает имя файла.
+// Загрузка запоминается за пользователем: импорт принимает только свои изображения.
+func (s *Service) UploadImage(userID int, file *multipart.FileHeader) (string, error) {
+	filename, err := s.saveImage(file)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Repo.RecordUpload(userID, filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// saveImage проверяет и сохраняет загруженное изображение. Исходный файл на диск
+// не попадает: картинка декодируется и кодируется заново, поэтому метаданные и
+// приклеенные к файлу данные отбрасываются, а расширение определяется по
+// результату, а не по имени файла. Одинаковые картинки хранятся одним файлом.
+func (s *Service) saveImage(file *multipart.FileHeader) (string, error) {
+	metrics.UploadSize.With("image").Observe(float64(file.Size))
+
+	if file.Size > s.config.MaxFileSize {
+		return "", ErrFileTooLarge.With("max_mb", s.config.MaxFileSize>>20)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", apperr.Internal(err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, s.config.MaxFileSize+1))
+	if err != nil {
+		return "", apperr.Internal(err)
+	}
+	if int64(len(data)) > s.config.MaxFileSize {
+		return "", ErrFileTooLarge.With("max_mb", s.config.MaxFileSize>>20)
+	}
+
+	encoded, ext, err := s.reencodeImage(data)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(encoded)
+	filename := hex.EncodeToString(sum[:16]) + ext
+	if err := writeFileOnce(filepath.Join(s.config.UploadDir, filename), encoded); err != nil {
+		return "", apperr.Internal(err)
+	}
+	return filename, nil
+}
+
+// reencodeImage декодирует JPEG, PNG или WebP и кодирует заново.
+// Для WebP в стандартной библиотеке нет кодировщика, поэтому непрозрачные
+// картинки сохраняются как JPEG, а с прозрачностью — как PNG.
+func (s *Service) reencodeImage(data []byte) ([]byte, string, error) {
+	contentType := http.DetectContentType(data)
+	if !imageTypes[contentType] {
+		return nil, "", ErrNotImage
+	}
+
+	// Размеры читаются из заголовка до декодирования, чтобы маленький файл
+	// с огромными заявленными размерами не занял всю память
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrImageCorrupt
+	}
+	limit := s.config.ImageMaxDimension
+	if cfg.Width > limit || cfg.Height > limit {
+		return nil, "", ErrImageDimensions.With("max", limit)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrImageCorrupt
+	}
+
+	var buf bytes.Buffer
+	ext := ".jpg"
+	if contentType == "image/png" || contentType == "image/webp" && !opaque(img) {
+		ext = ".png"
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return nil, "", apperr.Internal(err)
+	}
+	return buf.Bytes(), ext, nil
+}
+
+func opaque(img image.Image) bool {
+	o, ok := img.(interface{ Opaque() bool })
+	return ok && o.Opaque()
+}
+
+// writeFileOnce записывает файл через временный и переименование, чтобы
+// параллельная загрузка не увидела его наполовину. Имя файла — хэш содержимого,
+// поэтому существующий файл уже содержит те же байты и не перезаписывается.
+func writeFileOnce(path string, data []byte) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"catpc-backend/internal/apperr"
+	"catpc-backend/internal/models"
+)
+
+// recentLogins — сколько последних входов показывать в карточке пользователя
+const recentLogins = 10
+
+// maxBulkUsers — наибольшее число пользователей в одном массовом действии
+const maxBulkUsers = 100
+
+// Actor — пользователь, выполняющий действие (из JWT)
+type Actor struct {
+	ID       int
+	Username string
+	Role     string
+}
+
+type RoleChange struct {
+	User        *models.User
+	ChangedSelf bool
+	// NewToken выдается, когда пользователь меняет роль самому себе
+	NewToken string
+}
+
+var validRoles = map[string]bool{
+	models.RoleCustomer: true,
+	models.RoleSeller:   true,
+	models.RoleAdmin:    true,
+}
+
+// UserPage — страница списка пользователей в админке
+type UserPage struct {
+	Users      []models.UserDetail `json:"users"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	TotalPages int                 `json:"totalPages"`
+	Total      int                 `json:"total"`
+}
+
+// GetAllUsers ищет пользователей по фильтру и возвращает страницу page
+func (s *Service) GetAllUsers(filter models.UserFilter, page, limit int) (*UserPage, error) {
+	if filter.Role != "" && !validRoles[filter.Role] {
+		return nil, ErrInvalidRole
+	}
+	if page < 1 {
+		page = 1
+	}
+	filter.Limit = normalizeLimit(limit)
+	filter.Offset = (page - 1) * filter.Limit
+
+	users, total, err := s.Repo.ListUsers(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &UserPage{
+		Users:      users,
+		Page:       page,
+		Limit:      filter.Limit,
+		TotalPages: (total + filter.Limit - 1) / filter.Limit,
+		Total:      total,
+	}, nil
+}
+
+// AdminUserDetail — карточка пользователя для администратора
+type AdminUserDetail struct {
+	User     models.UserDetail    `json:"user"`
+	Products []models.Product     `json:"products"`
+	Orders   []models.Order       `json:"orders"`
+	Logins   []models.LoginRecord `json:"logins" doc:"Последние входы, новые первыми"`
+}
+
+func (s *Service) GetUserDetail(id int) (*AdminUserDetail, error) {
+	user, err := s.Repo.GetUserByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	detail := &AdminUserDetail{
+		User: models.UserDetail{
+			ID:          user.ID,
+			Username:    user.Username,
+			Email:       user.Email,
+			Role:        user.Role,
+			IsActive:    user.IsActive,
+			IsProtected: user.IsProtected,
+			CreatedAt:   user.CreatedAt.Format("2006-01-02 15:04:05"),
+		},
+	}
+	if detail.Products, err = s.Repo.ListProductsByUser(id); err != nil {
+		return nil, err
+	}
+	if detail.Orders, err = s.GetOrders(id, ""); err != nil {
+		return nil, err
+	}
+	if detail.Logins, err = s.Repo.ListLogins(id, recentLogins); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+// UpdateUserRole применяет правила смены роли:
+// защищенным пользователям роль не меняется, администраторов назначает только главный администратор.
+func (s *Service) UpdateUserRole(actor Actor, targetID int, role string) (*RoleChange, error) {
+	if !validRoles[role] {
+		return nil, ErrInvalidRole
+	}
+
+	target, err := s.Repo.GetUserByID(targetID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if target.IsProtected {
+		return nil, ErrProtectedRole
+	}
+
+	if role == models.RoleAdmin && actor.Username != MainAdminUsername {
+		return nil, ErrAdminAssignment
+	}
+
+	changingSelf := targetID == actor.ID
+	if changingSelf && target.Role == role {
+		return nil, ErrRoleUnchanged
+	}
+
+	if err := s.Repo.UpdateUserRole(targetID, role); err != nil {
+		return nil, err
+	}
+	target.Role = role
+
+	change := &RoleChange{User: target, ChangedSelf: changingSelf}
+	if changingSelf {
+		change.NewToken, err = s.GenerateJWT(target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return change, nil
+}
+
+// ToggleUserActive блокирует или разблокирует пользователя и возвращает новое состояние
+func (s *Service) ToggleUserActive(actor Actor, targetID int) (bool, error) {
+	target, err := s.blockTarget(actor, targetID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.Repo.ToggleUserActive(targetID); err != nil {
+		return false, err
+	}
+
+	return !target.IsActive, nil
+}
+
+// SetUserActive блокирует или разблокирует пользователя по тем же правилам, что ToggleUserActive
+func (s *Service) SetUserActive(actor Actor, targetID int, active bool) error {
+	if _, err := s.blockTarget(actor, targetID); err != nil {
+		return err
+	}
+	return s.Repo.SetUserActive(targetID, active)
+}
+
+// blockTarget загружает пользователя и проверяет, что его можно блокировать:
+// защищенных пользователей и самого себя заблокировать нельзя
+func (s *Service) blockTarget(actor Actor, targetID int) (*models.User, error) {
+	target, err := s.Repo.GetUserByID(targetID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if target.IsProtected {
+		return nil, ErrProtectedBlock
+	}
+
+	if targetID == actor.ID {
+		return nil, ErrBlockSelf
+	}
+
+	return target, nil
+}
+
+// BulkOutcome — итог массового действия над одним пользователем
+type BulkOutcome struct {
+	UserID int
+	Err    error
+}
+
+// BulkUpdateUsers блокирует, разблокирует или меняет роль нескольким пользователям.
+// Каждый проходит те же проверки, что и при одиночном действии; ошибка по одному
+// пользователю не останавливает остальных. Себя массовым действием менять нельзя.
+func (s *Service) BulkUpdateUsers(actor Actor, req models.BulkUserRequest) ([]BulkOutcome, error) {
+	switch {
+	case len(req.UserIDs) == 0:
+		return nil, apperr.Validation([]apperr.FieldError{{Field: "user_ids", Code: "required"}})
+	case len(req.UserIDs) > maxBulkUsers:
+		return nil, apperr.Validation([]apperr.FieldError{{Field: "user_ids", Code: "max_items", Params: apperr.Params{"param": maxBulkUsers}}})
+	case req.Action == models.BulkRole && !validRoles[req.Role]:
+		return nil, ErrInvalidRole
+	}
+
+	outcomes := make([]BulkOutcome, 0, len(req.UserIDs))
+	seen := map[int]bool{}
+	for _, id := range req.UserIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		var err error
+		switch {
+		case id == actor.ID:
+			err = ErrBulkSelf
+		case req.Action == models.BulkRole:
+			_, err = s.UpdateUserRole(actor, id, req.Role)
+		default:
+			err = s.SetUserActive(actor, id, req.Action == models.BulkUnblock)
+		}
+		outcomes = append(outcomes, BulkOutcome{UserID: id, Err: err})
+	}
+	return outcomes, nil
+}
@@ -0,0 +1,187 @@
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"catpc-backend/internal/apperr"
+)
+
+// Struct проверяет поля структуры по тегу validate и возвращает
+// apperr с кодом validation_failed, если хотя бы одно правило нарушено.
+//
+// Правила перечисляются через запятую:
+//
+//	required      значение не пустое (для чисел — не ноль)
+//	min=N, max=N  длина строки в символах
+//	number        строка содержит число (допускается запятая)
+//	integer       строка содержит целое число
+//	gt, gte, lt, lte=N  сравнение числа; у строки — значения, разобранного
+//	              стоящим раньше правилом number или integer
+//	email         строка — адрес электронной почты
+//	oneof=a b c   значение из списка
+//
+// Необязательные пустые строки остальными правилами не проверяются.
+func Struct(v interface{}) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic("validate: ожидается структура, получено " + rv.Kind().String())
+	}
+
+	var fields []apperr.FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag := sf.Tag.Get("validate")
+		if tag == "" || !sf.IsExported() {
+			continue
+		}
+
+		if fe := checkField(fieldName(sf), rv.Field(i), tag); fe != nil {
+			fields = append(fields, *fe)
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperr.Validation(fields)
+	}
+	return nil
+}
+
+// fieldName возвращает имя поля так, как его видит клиент: из тега json или form
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "form", "query"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(sf.Name)
+}
+
+func checkField(name string, value reflect.Value, tag string) *apperr.FieldError {
+	rules := strings.Split(tag, ",")
+
+	required := false
+	for _, rule := range rules {
+		if rule == "required" {
+			required = true
+		}
+	}
+
+	if value.IsZero() {
+		if required {
+			return &apperr.FieldError{Field: name, Code: "required"}
+		}
+		// Ноль у числового поля — тоже значение, его проверяют gt/gte
+		if value.Kind() == reflect.String {
+			return nil
+		}
+	}
+
+	// Число из строки (значение формы) сравнивается после разбора
+	var parsed *float64
+	for _, rule := range rules {
+		rule, param, _ := strings.Cut(rule, "=")
+		fail := &apperr.FieldError{Field: name, Code: rule}
+		if param != "" {
+			fail.Params = apperr.Params{"param": param}
+		}
+
+		switch rule {
+		case "required", "":
+			continue
+
+		case "min", "max":
+			limit, err := strconv.Atoi(param)
+			if err != nil || value.Kind() != reflect.String {
+				panic(fmt.Sprintf("validate: правило %s неприменимо к полю %s", rule, name))
+			}
+			length := utf8.RuneCountInString(value.String())
+			if (rule == "min" && length < limit) || (rule == "max" && length > limit) {
+				return fail
+			}
+
+		case "gt", "gte", "lt", "lte":
+			limit, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validate: неверный параметр правила %s у поля %s", rule, name))
+			}
+			n, ok := numeric(value, parsed)
+			if !ok {
+				continue
+			}
+			if (rule == "gt" && !(n > limit)) || (rule == "gte" && !(n >= limit)) ||
+				(rule == "lt" && !(n < limit)) || (rule == "lte" && !(n <= limit)) {
+				return fail
+			}
+
+		case "number":
+			n, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(value.String()), ",", ".", 1), 64)
+			if err != nil {
+				return fail
+			}
+			parsed = &n
+
+		case "integer":
+			n, err := strconv.Atoi(strings.TrimSpace(value.String()))
+			if err != nil {
+				return fail
+			}
+			f := float64(n)
+			parsed = &f
+
+		case "email":
+			addr, err := mail.ParseAddress(value.String())
+			if err != nil || addr.Address != value.String() {
+				return fail
+			}
+
+		case "oneof":
+			found := false
+			for _, option := range strings.Fields(param) {
+				if fmt.Sprint(value.Interface()) == option {
+					found = true
+					break
+				}
+			}
+			if !found {
+				fail.Params = apperr.Params{"param": strings.Join(strings.Fields(param), ", ")}
+				return fail
+			}
+
+		default:
+			panic(fmt.Sprintf("validate: неизвестное правило %q у поля %s", rule, name))
+		}
+	}
+
+	return nil
+}
+
+// decimal — десятичные суммы (money.Amount) сравниваются с пределами правил как числа
+type decimal interface {
+	InexactFloat64() float64
+}
+
+func numeric(value reflect.Value, parsed *float64) (float64, bool) {
+	if d, ok := value.Interface().(decimal); ok {
+		return d.InexactFloat64(), true
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.String:
+		if parsed != nil {
+			return *parsed, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"catpc-backend/internal/apperr"
+)
+
+type sample struct {
+	Name     string `json:"name" validate:"required,min=2,max=5"`
+	Email    string `json:"email" validate:"email"`
+	Role     string `json:"role" validate:"oneof=a b"`
+	Quantity int    `json:"quantity" validate:"gt=0"`
+	Price    string `form:"price" validate:"required,number,gt=0,lte=100"`
+	Stock    string `form:"stock" validate:"integer,gte=0"`
+}
+
+func valid() sample {
+	return sample{Name: "ok", Quantity: 1, Price: "10,5"}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(s *sample)
+		want   map[string]string
+	}{
+		{"valid", func(s *sample) {}, nil},
+		{"missing required", func(s *sample) { s.Name = ""; s.Price = "" }, map[string]string{"name": "required", "price": "required"}},
+		{"string too short", func(s *sample) { s.Name = "я" }, map[string]string{"name": "min"}},
+		{"length counts runes", func(s *sample) { s.Name = "котик" }, nil},
+		{"string too long", func(s *sample) { s.Name = "котики" }, map[string]string{"name": "max"}},
+		{"bad email", func(s *sample) { s.Email = "cat@" }, map[string]string{"email": "email"}},
+		{"email with display name", func(s *sample) { s.Email = "Cat <cat@catpc.ru>" }, map[string]string{"email": "email"}},
+		{"oneof", func(s *sample) { s.Role = "c" }, map[string]string{"role": "oneof"}},
+		{"zero number is checked", func(s *sample) { s.Quantity = 0 }, map[string]string{"quantity": "gt"}},
+		{"not a number", func(s *sample) { s.Price = "abc" }, map[string]string{"price": "number"}},
+		{"parsed number compared", func(s *sample) { s.Price = "100.01" }, map[string]string{"price": "lte"}},
+		{"not an integer", func(s *sample) { s.Stock = "1.5" }, map[string]string{"stock": "integer"}},
+		{"negative integer", func(s *sample) { s.Stock = "-1" }, map[string]string{"stock": "gte"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid()
+			tt.modify(&s)
+
+			err := Struct(&s)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || appErr.Code != "validation_failed" {
+				t.Fatalf("error %v, want validation_failed", err)
+			}
+
+			got := map[string]string{}
+			for _, fe := range appErr.Fields {
+				got[fe.Field] = fe.Code
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("fields %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+
+	"catpc-backend/internal/migrations"
+)
+
+const migrateUsage = `Использование: catpc-backend migrate <команда>
+
+Команды:
+  up [N]      применить N новых миграций (по умолчанию все)
+  down [N]    откатить N последних миграций (по умолчанию 1)
+  status      показать состояние миграций`
+
+// runMigrate выполняет подкоманду migrate и возвращает код завершения
+func runMigrate(db *sql.DB, args []string) int {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	steps := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			fmt.Fprintf(os.Stderr, "Неверное число миграций: %s\n", args[1])
+			return 2
+		}
+		steps = n
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка загрузки миграций: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx, steps)
+		for _, m := range applied {
+			fmt.Printf("✅ Применена %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка миграции: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("Новых миграций нет")
+		}
+
+	case "down":
+		if steps == 0 {
+			steps = 1
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			fmt.Printf("↩️  Откачена %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка отката: %v\n", err)
+			return 1
+		}
+		if len(reverted) == 0 {
+			fmt.Println("Нет примененных миграций")
+		}
+
+	case "status":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка получения статуса: %v\n", err)
+			return 1
+		}
+		for _, s := range statuses {
+			switch {
+			case s.Missing:
+				fmt.Printf("%04d  ????  применена %s, файлов нет в сборке\n", s.Version, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			case s.AppliedAt != nil:
+				fmt.Printf("%04d  %-24s применена %s\n", s.Version, s.Name, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			default:
+				fmt.Printf("%04d  %-24s ожидает\n", s.Version, s.Name)
+			}
+		}
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
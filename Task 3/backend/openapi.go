@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"catpc-backend/internal/handler"
+)
+
+// runOpenAPI печатает спецификацию API или записывает JS-клиент в файл.
+// Базе данных и конфигурации не нужна, поэтому запускается до их загрузки.
+func runOpenAPI(args []string) int {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	clientPath := flags.String("client", "", "записать JS-клиент для фронтенда в файл")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	doc := handler.OpenAPIDocument()
+
+	if *clientPath != "" {
+		if err := os.WriteFile(*clientPath, doc.JSClient(handler.ClientCommand), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка записи клиента: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		fmt.Fprintf(os.Stderr, "Ошибка вывода спецификации: %v\n", err)
+		return 1
+	}
+	return 0
+}
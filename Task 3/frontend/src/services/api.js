@@ -8,12 +8,23 @@ const api = axios.create({
   }
 })
 
+// sessionId связывает просмотры товаров анонимного посетителя
+function sessionId() {
+  let id = localStorage.getItem('sessionId')
+  if (!id) {
+    id = crypto.randomUUID()
+    localStorage.setItem('sessionId', id)
+  }
+  return id
+}
+
 api.interceptors.request.use(
   (config) => {
     const token = localStorage.getItem('token')
     if (token) {
       config.headers.Authorization = `Bearer ${token}`
     }
+    config.headers['X-Session-ID'] = sessionId()
     return config
   },
   (error) => {
@@ -0,0 +1,1698 @@
+// Код сгенерирован командой `go run . openapi -client ../frontend/src/services/client.js` по спецификации OpenAPI.
+// Не редактируйте вручную: изменения вносятся в ответы обработчиков.
+import api from './api'
+
+const streamRetryDelay = 5000
+
+// eventSource открывает поток событий и возвращает функцию, которая его закрывает
+function eventSource(path, params, issueToken, handlers) {
+  let source = null
+  let timer = null
+  let closed = false
+  let lastEventId = params.last_event_id
+
+  const retry = () => {
+    source?.close()
+    if (!closed) {
+      timer = setTimeout(open, streamRetryDelay)
+    }
+  }
+  const open = async () => {
+    const query = new URLSearchParams(params)
+    if (issueToken) {
+      try {
+        const { data } = await issueToken()
+        query.set('token', data.data.token)
+      } catch (error) {
+        retry()
+        return
+      }
+    }
+    if (closed) {
+      return
+    }
+    if (lastEventId) {
+      query.set('last_event_id', lastEventId)
+    }
+
+    source = new EventSource(`${api.defaults.baseURL}${path}?${query}`)
+    for (const [type, handle] of Object.entries(handlers)) {
+      source.addEventListener(type, (event) => {
+        lastEventId = event.lastEventId || lastEventId
+        handle(JSON.parse(event.data), event)
+      })
+    }
+    source.onerror = retry
+  }
+
+  open()
+  return () => {
+    closed = true
+    clearTimeout(timer)
+    source?.close()
+  }
+}
+
+/**
+ * @typedef {Object} AddToCartRequest
+ * @property {number} product_id
+ * @property {number} quantity
+ */
+
+/**
+ * @typedef {Object} Address
+ * @property {string} city
+ * @property {string} created_at
+ * @property {number} id
+ * @property {boolean} is_default
+ * @property {string} label
+ * @property {string} phone
+ * @property {string} postal_code
+ * @property {string} recipient
+ * @property {string} region - Регион доставки; по нему выбирается ставка налога
+ * @property {string} street - Улица, дом, квартира
+ */
+
+/**
+ * @typedef {Object} AddressListResponse
+ * @property {(Array<Address>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} AddressRequest
+ * @property {string} city
+ * @property {boolean} is_default
+ * @property {string} label
+ * @property {string} phone
+ * @property {string} postal_code
+ * @property {string} recipient
+ * @property {string} region
+ * @property {string} street
+ */
+
+/**
+ * @typedef {Object} AddressResponse
+ * @property {Address} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} AdminUserDetail
+ * @property {(Array<LoginRecord>|null)} logins - Последние входы, новые первыми
+ * @property {(Array<Order>|null)} orders
+ * @property {(Array<Product>|null)} products
+ * @property {UserDetail} user
+ */
+
+/**
+ * @typedef {Object} AnalyticsDay
+ * @property {string} date
+ * @property {number} orders
+ * @property {number} revenue
+ * @property {number} units_sold
+ */
+
+/**
+ * @typedef {Object} AnalyticsReport
+ * @property {(Array<AnalyticsDay>|null)} daily
+ * @property {string} from
+ * @property {AnalyticsSummary} summary
+ * @property {string} to
+ * @property {(Array<AnalyticsTopProduct>|null)} top_products
+ */
+
+/**
+ * @typedef {Object} AnalyticsResponse
+ * @property {AnalyticsReport} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} AnalyticsSummary
+ * @property {number} cart_adds
+ * @property {number} conversion_rate
+ * @property {number} orders
+ * @property {number} purchases
+ * @property {number} revenue
+ * @property {number} units_sold
+ */
+
+/**
+ * @typedef {Object} AnalyticsTopProduct
+ * @property {string} name
+ * @property {number} product_id
+ * @property {number} revenue
+ * @property {number} units_sold
+ */
+
+/**
+ * @typedef {Object} Answer
+ * @property {string} body
+ * @property {string} created_at
+ * @property {number} id
+ * @property {boolean} is_buyer - Автор ответа покупал товар
+ * @property {boolean} is_seller - Ответ продавца товара или администратора
+ * @property {number} question_id
+ * @property {string} status - published, pending или hidden
+ * @property {string} username
+ * @property {number} votes
+ */
+
+/**
+ * @typedef {Object} AnswerRequest
+ * @property {string} body
+ */
+
+/**
+ * @typedef {Object} AnswerResponse
+ * @property {Answer} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} AppliedSale
+ * @property {string} ends_at
+ * @property {number} id
+ * @property {string} name
+ */
+
+/**
+ * @typedef {Object} AuthData
+ * @property {string} token
+ * @property {AuthUser} user
+ */
+
+/**
+ * @typedef {Object} AuthResponse
+ * @property {AuthData} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} AuthUser
+ * @property {string} email
+ * @property {number} id
+ * @property {string} [pending_email] - Новый email, ожидающий подтверждения
+ * @property {string} role
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} BulkUserRequest
+ * @property {string} action
+ * @property {string} role
+ * @property {(Array<number>|null)} user_ids - До 100 пользователей
+ */
+
+/**
+ * @typedef {Object} BulkUserResult
+ * @property {string} [code]
+ * @property {string} [error]
+ * @property {boolean} success
+ * @property {number} user_id
+ */
+
+/**
+ * @typedef {Object} BulkUsersResponse
+ * @property {(Array<BulkUserResult>|null)} data
+ * @property {string} message
+ * @property {boolean} success - true, если изменены все пользователи
+ */
+
+/**
+ * @typedef {Object} Cart
+ * @property {number} count
+ * @property {string} currency
+ * @property {(Array<CartItem>|null)} items
+ * @property {Recommendations} recommendations
+ * @property {(ShippingQuote|null)} shipping - Расчет доставки, если передан delivery_method
+ * @property {number} subtotal - Сумма товаров
+ * @property {number} tax - Все налоги корзины, включенные в цены и начисленные сверху
+ * @property {(Array<TaxLine>|null)} taxes - Разбивка налогов по ставкам
+ * @property {number} total - Сумма товаров, налогов сверх цен и доставки
+ * @property {number} weight - Вес корзины в граммах
+ */
+
+/**
+ * @typedef {Object} CartItem
+ * @property {number} id
+ * @property {string} image
+ * @property {string} name
+ * @property {number} original_price
+ * @property {number} price - Цена с учетом действующей акции
+ * @property {number} product_id
+ * @property {number} quantity
+ * @property {(AppliedSale|null)} [sale]
+ * @property {(ItemTax|null)} tax - Налог позиции; null, если товар налогом не облагается
+ * @property {number} weight - Вес единицы товара в граммах
+ */
+
+/**
+ * @typedef {Object} CartResponse
+ * @property {Cart} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} CheckoutRequest
+ * @property {number} address_id
+ * @property {number} delivery_method_id
+ */
+
+/**
+ * @typedef {Object} CompareRequest
+ * @property {number} product_id
+ */
+
+/**
+ * @typedef {Object} CompareRow
+ * @property {boolean} differs - Значения у товаров различаются
+ * @property {string} name
+ * @property {(Array<string>|null)} values - Значения в порядке products; пустая строка — характеристика не указана
+ */
+
+/**
+ * @typedef {Object} Comparison
+ * @property {string} category - Категория товаров в списке; пустая у пустого списка или товаров без категории
+ * @property {number} limit - Сколько товаров можно добавить
+ * @property {(Array<Product>|null)} products
+ * @property {(Array<CompareRow>|null)} rows
+ */
+
+/**
+ * @typedef {Object} ComparisonResponse
+ * @property {Comparison} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} CreateProductResponse
+ * @property {CreatedProduct} data
+ * @property {string} message
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} CreatedProduct
+ * @property {number} id
+ * @property {string} image
+ */
+
+/**
+ * @typedef {Object} Currencies
+ * @property {string} base
+ * @property {Object<string, number>} rates - Сколько базовой валюты стоит единица валюты
+ */
+
+/**
+ * @typedef {Object} CurrenciesResponse
+ * @property {Currencies} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} DeleteAccountRequest
+ * @property {string} password
+ */
+
+/**
+ * @typedef {Object} DeliveryMethod
+ * @property {number} id
+ * @property {boolean} is_active
+ * @property {string} kind - pickup, courier или post
+ * @property {string} name
+ * @property {string} pricing - weight — по весу, total — по сумме товаров
+ * @property {(Array<DeliveryRule>|null)} rules
+ */
+
+/**
+ * @typedef {Object} DeliveryMethodListResponse
+ * @property {(Array<DeliveryMethod>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} DeliveryMethodRequest
+ * @property {boolean} is_active
+ * @property {string} kind
+ * @property {string} name
+ * @property {string} pricing
+ * @property {(Array<DeliveryRule>|null)} rules
+ */
+
+/**
+ * @typedef {Object} DeliveryMethodResponse
+ * @property {DeliveryMethod} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} DeliveryRule
+ * @property {number} cost
+ * @property {number} from - Порог: вес в граммах (pricing=weight) или сумма товаров (pricing=total)
+ */
+
+/**
+ * @typedef {Object} EmailConfirmRequest
+ * @property {string} token
+ */
+
+/**
+ * @typedef {Object} ErrorResponse
+ * @property {string} code
+ * @property {(Array<FieldError>|null)} [details]
+ * @property {string} error
+ * @property {string} [request_id]
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} FieldError
+ * @property {string} code
+ * @property {string} field
+ * @property {string} message
+ */
+
+/**
+ * @typedef {Object} GraphQLError
+ * @property {GraphQLExtensions} extensions
+ * @property {(Array<GraphQLLocation>|null)} [locations]
+ * @property {string} message
+ * @property {(Array<*>|null)} [path] - Поле, которое не удалось получить: имена полей и номера элементов списков
+ */
+
+/**
+ * @typedef {Object} GraphQLExtensions
+ * @property {string} code
+ * @property {string} [request_id]
+ */
+
+/**
+ * @typedef {Object} GraphQLLocation
+ * @property {number} column
+ * @property {number} line
+ */
+
+/**
+ * @typedef {Object} GraphQLRequest
+ * @property {string} [operationName]
+ * @property {string} query
+ * @property {Object<string, *>} [variables]
+ */
+
+/**
+ * @typedef {Object} GraphQLResponse
+ * @property {*} [data]
+ * @property {(Array<GraphQLError>|null)} [errors]
+ */
+
+/**
+ * @typedef {Object} ImportReport
+ * @property {boolean} committed
+ * @property {number} created
+ * @property {boolean} dry_run
+ * @property {number} failed
+ * @property {(Array<ImportRowResult>|null)} rows
+ * @property {number} total
+ * @property {number} updated
+ */
+
+/**
+ * @typedef {Object} ImportResponse
+ * @property {ImportReport} data
+ * @property {string} message
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ImportRow
+ * @property {string} description
+ * @property {string} image
+ * @property {string} name
+ * @property {number} price
+ * @property {string} sku
+ * @property {number} stock
+ */
+
+/**
+ * @typedef {Object} ImportRowResult
+ * @property {string} action
+ * @property {(Array<FieldError>|null)} [errors]
+ * @property {number} [product_id]
+ * @property {number} row
+ * @property {string} sku
+ */
+
+/**
+ * @typedef {Object} ItemTax
+ * @property {number} amount
+ * @property {boolean} inclusive - Налог уже включен в цену
+ * @property {string} name
+ * @property {number} rate - Ставка в процентах
+ */
+
+/**
+ * @typedef {Object} LoginRecord
+ * @property {string} created_at
+ * @property {string} ip
+ * @property {string} user_agent
+ */
+
+/**
+ * @typedef {Object} LoginRequest
+ * @property {string} password
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} MessageResponse
+ * @property {string} message
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ModerationQueue
+ * @property {(Array<Answer>|null)} answers
+ * @property {(Array<Question>|null)} questions
+ */
+
+/**
+ * @typedef {Object} ModerationQueueResponse
+ * @property {ModerationQueue} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ModerationRequest
+ * @property {string} status
+ */
+
+/**
+ * @typedef {Object} Notification
+ * @property {number} [count] - Сколько товаров ждут модерации после импорта
+ * @property {string} created_at
+ * @property {number} id
+ * @property {string} message
+ * @property {number} [order_id]
+ * @property {number} [product_id]
+ * @property {string} [product_name] - Название на момент события: отклоненный товар удаляется
+ * @property {boolean} read
+ * @property {string} [status] - Новый статус заказа
+ * @property {string} type - product_approved, product_rejected, product_pending, order_status или back_in_stock
+ */
+
+/**
+ * @typedef {Object} NotificationInbox
+ * @property {(Array<Notification>|null)} notifications
+ * @property {number} unread
+ */
+
+/**
+ * @typedef {Object} NotificationListResponse
+ * @property {NotificationInbox} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} Order
+ * @property {(Address|null)} address
+ * @property {string} created_at
+ * @property {string} currency
+ * @property {number} id
+ * @property {(Array<OrderItem>|null)} items
+ * @property {number} refunded - Сумма, возвращенная по заявкам на возврат
+ * @property {(ShippingQuote|null)} shipping
+ * @property {string} status
+ * @property {number} subtotal
+ * @property {number} tax - Все налоги заказа, включенные в цены и начисленные сверху
+ * @property {(Array<TaxLine>|null)} taxes - Разбивка налогов по ставкам
+ * @property {number} total
+ */
+
+/**
+ * @typedef {Object} OrderItem
+ * @property {number} id
+ * @property {string} name
+ * @property {number} price
+ * @property {number} product_id - 0, если товар удален из каталога
+ * @property {number} quantity
+ * @property {(ItemTax|null)} tax - Налог позиции на момент оформления; null — без налога
+ */
+
+/**
+ * @typedef {Object} OrderListResponse
+ * @property {(Array<Order>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} OrderResponse
+ * @property {Order} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} OrderStatusRequest
+ * @property {string} status
+ */
+
+/**
+ * @typedef {Object} PasswordChangeRequest
+ * @property {string} current_password
+ * @property {string} new_password
+ */
+
+/**
+ * @typedef {Object} PriceAlert
+ * @property {string} created_at
+ * @property {number} current_price
+ * @property {number} id
+ * @property {number} product_id
+ * @property {string} product_name
+ * @property {number} target_price
+ * @property {(string|null)} triggered_at
+ * @property {(number|null)} triggered_price
+ */
+
+/**
+ * @typedef {Object} PriceAlertListResponse
+ * @property {(Array<PriceAlert>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} PriceAlertRequest
+ * @property {number} target_price
+ */
+
+/**
+ * @typedef {Object} PriceChange
+ * @property {string} changed_at
+ * @property {number} id
+ * @property {number} new_price
+ * @property {(number|null)} old_price
+ * @property {number} product_id
+ * @property {string} source
+ */
+
+/**
+ * @typedef {Object} PriceHistoryResponse
+ * @property {(Array<PriceChange>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} Product
+ * @property {Object<string, string>} [attributes]
+ * @property {string} [category] - Категория; сравнивать можно только товары одной категории
+ * @property {string} [created_at]
+ * @property {string} [currency] - Валюта цены в каталоге; в остальных ответах цена в базовой валюте
+ * @property {string} description
+ * @property {number} id
+ * @property {string} image
+ * @property {boolean} is_approved
+ * @property {string} name
+ * @property {(number|null)} [original_price]
+ * @property {number} price
+ * @property {(AppliedSale|null)} [sale] - Акция, которая снижает цену сейчас
+ * @property {string} [sku]
+ * @property {number} stock
+ * @property {string} updated_at
+ * @property {(number|null)} [user_id]
+ * @property {string} [username]
+ * @property {number} weight - Вес в граммах
+ */
+
+/**
+ * @typedef {Object} ProductCursorPage
+ * @property {boolean} has_more
+ * @property {number} limit
+ * @property {string} [next_cursor]
+ * @property {(Array<Product>|null)} products
+ */
+
+/**
+ * @typedef {Object} ProductCursorResponse
+ * @property {ProductCursorPage} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ProductDetail
+ * @property {Object<string, string>} [attributes]
+ * @property {string} [category] - Категория; сравнивать можно только товары одной категории
+ * @property {string} [created_at]
+ * @property {string} [currency] - Валюта цены в каталоге; в остальных ответах цена в базовой валюте
+ * @property {string} description
+ * @property {number} id
+ * @property {string} image
+ * @property {boolean} is_approved
+ * @property {string} name
+ * @property {(number|null)} [original_price]
+ * @property {number} price
+ * @property {(Array<Question>|null)} questions - Последние вопросы с ответами
+ * @property {Recommendations} recommendations
+ * @property {(AppliedSale|null)} [sale] - Акция, которая снижает цену сейчас
+ * @property {string} [sku]
+ * @property {number} stock
+ * @property {string} updated_at
+ * @property {(number|null)} [user_id]
+ * @property {string} [username]
+ * @property {number} weight - Вес в граммах
+ */
+
+/**
+ * @typedef {Object} ProductListResponse
+ * @property {(Array<Product>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ProductPage
+ * @property {number} limit
+ * @property {number} page
+ * @property {(Array<Product>|null)} products
+ * @property {number} total
+ * @property {number} totalPages
+ */
+
+/**
+ * @typedef {Object} ProductPageResponse
+ * @property {ProductPage} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ProductResponse
+ * @property {ProductDetail} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ProfileRequest
+ * @property {string} email
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} ProfileResponse
+ * @property {User} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} Question
+ * @property {(Array<Answer>|null)} answers - Опубликованные ответы: сначала ответы продавца, затем по числу голосов
+ * @property {string} body
+ * @property {string} created_at
+ * @property {number} id
+ * @property {number} product_id
+ * @property {string} status - published, pending или hidden
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} QuestionListResponse
+ * @property {(Array<Question>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} QuestionRequest
+ * @property {string} body
+ */
+
+/**
+ * @typedef {Object} QuestionResponse
+ * @property {Question} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} Recommendations
+ * @property {(Array<Product>|null)} bought_together - С этим товаром покупают
+ * @property {(Array<Product>|null)} recently_viewed - Недавно просмотренные товары
+ */
+
+/**
+ * @typedef {Object} RegisterRequest
+ * @property {string} email
+ * @property {string} password
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} Return
+ * @property {number} amount
+ * @property {string} comment - Комментарий продавца или администратора
+ * @property {string} created_at
+ * @property {number} id
+ * @property {number} order_id
+ * @property {number} order_item_id
+ * @property {(Array<string>|null)} photos - Имена файлов из /api/upload
+ * @property {number} product_id - 0, если товар удален из каталога
+ * @property {string} product_name
+ * @property {number} quantity
+ * @property {string} reason
+ * @property {string} status - requested, rejected, awaiting_item, received или refunded
+ * @property {string} updated_at
+ */
+
+/**
+ * @typedef {Object} ReturnListResponse
+ * @property {(Array<Return>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ReturnRequest
+ * @property {number} order_item_id
+ * @property {(Array<string>|null)} photos - До 5 имен файлов из /api/upload
+ * @property {number} quantity
+ * @property {string} reason
+ */
+
+/**
+ * @typedef {Object} ReturnResponse
+ * @property {Return} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ReturnStatusRequest
+ * @property {string} comment
+ * @property {string} status
+ */
+
+/**
+ * @typedef {Object} RoleChange
+ * @property {string} new_role
+ * @property {string} role_name - Название роли на языке запроса
+ * @property {number} user_id
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} RoleChangeResponse
+ * @property {RoleChange} data
+ * @property {string} message
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} RoleChangeUser
+ * @property {string} email
+ * @property {number} id
+ * @property {boolean} is_active
+ * @property {string} role
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} Sale
+ * @property {string} category - Товары этой категории (без учета регистра); пустая — только product_ids
+ * @property {string} created_at
+ * @property {string} discount_type - percent — процент от цены, fixed — сумма в базовой валюте
+ * @property {number} discount_value
+ * @property {string} ends_at
+ * @property {number} id
+ * @property {string} name
+ * @property {(Array<number>|null)} product_ids
+ * @property {(number|null)} seller_id - Продавец, на чьи товары действует акция; null — акция магазина
+ * @property {string} starts_at
+ * @property {string} status - scheduled, active, ended или cancelled
+ */
+
+/**
+ * @typedef {Object} SaleListResponse
+ * @property {(Array<Sale>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} SaleRequest
+ * @property {string} category
+ * @property {string} discount_type
+ * @property {number} discount_value - Процент (до 90) или сумма в базовой валюте
+ * @property {string} ends_at
+ * @property {string} name
+ * @property {(Array<number>|null)} product_ids - До 500 товаров; нужна категория или хотя бы один товар
+ * @property {string} starts_at
+ */
+
+/**
+ * @typedef {Object} SaleResponse
+ * @property {Sale} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} SelfRoleChange
+ * @property {string} new_token - Токен с новой ролью, старый больше не подходит
+ * @property {RoleChangeUser} user
+ */
+
+/**
+ * @typedef {Object} SelfRoleChangeResponse
+ * @property {SelfRoleChange} data
+ * @property {string} message
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} ShippingQuote
+ * @property {number} cost
+ * @property {string} kind
+ * @property {number} method_id
+ * @property {string} name
+ * @property {number} weight - Вес заказа в граммах
+ */
+
+/**
+ * @typedef {Object} StreamToken
+ * @property {string} expires_at
+ * @property {string} token
+ */
+
+/**
+ * @typedef {Object} StreamTokenResponse
+ * @property {StreamToken} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} TaxLine
+ * @property {number} amount
+ * @property {number} base - Сумма позиций, облагаемых по этой ставке
+ * @property {boolean} inclusive
+ * @property {string} name
+ * @property {number} rate
+ */
+
+/**
+ * @typedef {Object} TaxRate
+ * @property {string} category - Категория товара (без учета регистра); пусто — любая
+ * @property {string} created_at
+ * @property {number} id
+ * @property {boolean} inclusive - true — цены уже включают налог, false — налог начисляется сверху
+ * @property {string} name - Название для чеков и счетов, например «НДС 20%»
+ * @property {number} rate - Ставка в процентах
+ * @property {string} region - Регион доставки (без учета регистра); пусто — любой
+ */
+
+/**
+ * @typedef {Object} TaxRateListResponse
+ * @property {(Array<TaxRate>|null)} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} TaxRateRequest
+ * @property {string} category
+ * @property {boolean} inclusive
+ * @property {string} name
+ * @property {number} rate - Ставка в процентах
+ * @property {string} region
+ */
+
+/**
+ * @typedef {Object} TaxRateResponse
+ * @property {TaxRate} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} UpdateCartItemRequest
+ * @property {number} quantity
+ */
+
+/**
+ * @typedef {Object} UpdateRoleRequest
+ * @property {string} role
+ */
+
+/**
+ * @typedef {Object} UploadResponse
+ * @property {string} filename
+ * @property {boolean} success
+ * @property {string} url - Путь к изображению от корня сервера
+ */
+
+/**
+ * @typedef {Object} User
+ * @property {string} [created_at]
+ * @property {string} email
+ * @property {number} id
+ * @property {boolean} is_active
+ * @property {string} [pending_email] - Новый email, ожидающий подтверждения
+ * @property {string} role
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} UserDetail
+ * @property {string} created_at
+ * @property {string} email
+ * @property {number} id
+ * @property {boolean} is_active
+ * @property {boolean} is_protected
+ * @property {string} role
+ * @property {string} username
+ */
+
+/**
+ * @typedef {Object} UserDetailResponse
+ * @property {AdminUserDetail} data
+ * @property {boolean} success
+ */
+
+/**
+ * @typedef {Object} UserPage
+ * @property {number} limit
+ * @property {number} page
+ * @property {number} total
+ * @property {number} totalPages
+ * @property {(Array<UserDetail>|null)} users
+ */
+
+/**
+ * @typedef {Object} UserPageResponse
+ * @property {UserPage} data
+ * @property {boolean} success
+ */
+
+/**
+ * Добавить товар в корзину
+ * @param {AddToCartRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function addToCart(body) {
+  return api.post('/api/cart/add', body)
+}
+
+/**
+ * Добавить товар в сравнение (только одной категории)
+ * @param {CompareRequest} body
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<ComparisonResponse>>}
+ */
+export function addToComparison(body, params = {}) {
+  return api.post('/api/compare', body, { params })
+}
+
+/**
+ * Ответить на вопрос: продавец товара или покупатель, который его заказывал
+ * @param {number} id
+ * @param {AnswerRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AnswerResponse>>}
+ */
+export function answerQuestion(id, body) {
+  return api.post(`/api/questions/${id}/answers`, body)
+}
+
+/**
+ * Одобрить товар
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function approveProduct(id) {
+  return api.put(`/api/admin/products/${id}/approve`)
+}
+
+/**
+ * Задать вопрос о товаре
+ * @param {number} id
+ * @param {QuestionRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<QuestionResponse>>}
+ */
+export function askQuestion(id, body) {
+  return api.post(`/api/products/${id}/questions`, body)
+}
+
+/**
+ * Заблокировать, разблокировать или сменить роль нескольким пользователям; итог по каждому — в data
+ * @param {BulkUserRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<BulkUsersResponse>>}
+ */
+export function bulkUpdateUsers(body) {
+  return api.post('/api/admin/users/bulk', body)
+}
+
+/**
+ * Отменить запланированную или идущую акцию
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<SaleResponse>>}
+ */
+export function cancelSale(id) {
+  return api.delete(`/api/seller/sales/${id}`)
+}
+
+/**
+ * Сменить пароль; остальные сессии завершаются, ответ содержит новый токен
+ * @param {PasswordChangeRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AuthResponse>>}
+ */
+export function changePassword(body) {
+  return api.put('/api/profile/password', body)
+}
+
+/**
+ * Оформить заказ из корзины
+ * @param {CheckoutRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<OrderResponse>>}
+ */
+export function checkout(body) {
+  return api.post('/api/orders', body)
+}
+
+/**
+ * Очистить список сравнения
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function clearComparison() {
+  return api.delete('/api/compare')
+}
+
+/**
+ * Подтвердить новый email кодом из письма
+ * @param {EmailConfirmRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<ProfileResponse>>}
+ */
+export function confirmEmail(body) {
+  return api.post('/api/profile/email/confirm', body)
+}
+
+/**
+ * Добавить адрес; первый адрес становится адресом по умолчанию
+ * @param {AddressRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AddressResponse>>}
+ */
+export function createAddress(body) {
+  return api.post('/api/addresses', body)
+}
+
+/**
+ * Создать способ доставки
+ * @param {DeliveryMethodRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<DeliveryMethodResponse>>}
+ */
+export function createDeliveryMethod(body) {
+  return api.post('/api/admin/delivery-methods', body)
+}
+
+/**
+ * Токен на минуту для подключения к потоку уведомлений через EventSource
+ * @returns {Promise<import('axios').AxiosResponse<StreamTokenResponse>>}
+ */
+export function createNotificationStreamToken() {
+  return api.post('/api/notifications/stream-token')
+}
+
+/**
+ * Создать товар
+ * @param {URLSearchParams|FormData} body
+ * @returns {Promise<import('axios').AxiosResponse<CreateProductResponse>>}
+ */
+export function createProduct(body) {
+  return api.post('/api/seller/products', body)
+}
+
+/**
+ * Заявка на возврат позиции доставленного заказа
+ * @param {number} id
+ * @param {ReturnRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<ReturnResponse>>}
+ */
+export function createReturn(id, body) {
+  return api.post(`/api/orders/${id}/returns`, body)
+}
+
+/**
+ * Запланировать акцию на товары или категорию; акция продавца действует только на его товары
+ * @param {SaleRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<SaleResponse>>}
+ */
+export function createSale(body) {
+  return api.post('/api/seller/sales', body)
+}
+
+/**
+ * Создать ставку налога; пара категория и регион уникальна
+ * @param {TaxRateRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<TaxRateResponse>>}
+ */
+export function createTaxRate(body) {
+  return api.post('/api/admin/tax-rates', body)
+}
+
+/**
+ * Удалить аккаунт: данные обезличиваются, заказы сохраняются
+ * @param {DeleteAccountRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function deleteAccount(body) {
+  return api.delete('/api/profile', { data: body })
+}
+
+/**
+ * Удалить адрес
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function deleteAddress(id) {
+  return api.delete(`/api/addresses/${id}`)
+}
+
+/**
+ * Удалить способ доставки; в оформленных заказах он сохраняется
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function deleteDeliveryMethod(id) {
+  return api.delete(`/api/admin/delivery-methods/${id}`)
+}
+
+/**
+ * Отменить подписку на цену
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function deletePriceAlert(id) {
+  return api.delete(`/api/products/${id}/price-alert`)
+}
+
+/**
+ * Удалить товар или скрыть, если он есть в корзинах
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function deleteProduct(id) {
+  return api.delete(`/api/seller/products/${id}`)
+}
+
+/**
+ * Удалить ставку налога
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function deleteTaxRate(id) {
+  return api.delete(`/api/admin/tax-rates/${id}`)
+}
+
+/**
+ * Выгрузка своих товаров
+ * @param {{format?: "csv"|"json"}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<(Array<Product>|null)|string>>}
+ */
+export function exportProducts(params = {}) {
+  return api.get('/api/seller/products/export', { params })
+}
+
+/**
+ * Удалить товар вместе с позициями корзин
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function forceDeleteProduct(id) {
+  return api.delete(`/api/admin/products/${id}/force`)
+}
+
+/**
+ * Адресная книга
+ * @returns {Promise<import('axios').AxiosResponse<AddressListResponse>>}
+ */
+export function getAddresses() {
+  return api.get('/api/addresses')
+}
+
+/**
+ * Аналитика продаж магазина
+ * @param {{from?: string, to?: string, top?: number}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<AnalyticsResponse>>}
+ */
+export function getAdminAnalytics(params = {}) {
+  return api.get('/api/admin/analytics', { params })
+}
+
+/**
+ * Все способы доставки, включая отключенные
+ * @returns {Promise<import('axios').AxiosResponse<DeliveryMethodListResponse>>}
+ */
+export function getAllDeliveryMethods() {
+  return api.get('/api/admin/delivery-methods')
+}
+
+/**
+ * Поиск пользователей с фильтрами и постраничным выводом, новые первыми
+ * @param {{q?: string, role?: "customer"|"seller"|"admin", active?: boolean, protected?: boolean, page?: number, limit?: number}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<UserPageResponse>>}
+ */
+export function getAllUsers(params = {}) {
+  return api.get('/api/admin/users', { params })
+}
+
+/**
+ * Корзина с налогами и рекомендациями; с delivery_method — вместе с расчетом доставки
+ * @param {{currency?: string, delivery_method?: number, address?: number}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<CartResponse>>}
+ */
+export function getCart(params = {}) {
+  return api.get('/api/cart', { params })
+}
+
+/**
+ * Список сравнения с таблицей характеристик; владелец — пользователь по токену или сессия
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<ComparisonResponse>>}
+ */
+export function getComparison(params = {}) {
+  return api.get('/api/compare', { params })
+}
+
+/**
+ * Базовая валюта и курсы для параметра currency
+ * @returns {Promise<import('axios').AxiosResponse<CurrenciesResponse>>}
+ */
+export function getCurrencies() {
+  return api.get('/api/currencies')
+}
+
+/**
+ * Доступные способы доставки и их тарифы
+ * @returns {Promise<import('axios').AxiosResponse<DeliveryMethodListResponse>>}
+ */
+export function getDeliveryMethods() {
+  return api.get('/api/delivery-methods')
+}
+
+/**
+ * PDF-счет к заказу для покупателя, продавцов позиций и администратора
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<Blob>>}
+ */
+export function getInvoice(id) {
+  return api.get(`/api/orders/${id}/invoice`, { responseType: 'blob' })
+}
+
+/**
+ * Вопросы и ответы, ожидающие модерации
+ * @returns {Promise<import('axios').AxiosResponse<ModerationQueueResponse>>}
+ */
+export function getModerationQueue() {
+  return api.get('/api/admin/moderation')
+}
+
+/**
+ * Товары продавца
+ * @returns {Promise<import('axios').AxiosResponse<ProductListResponse>>}
+ */
+export function getMyProducts() {
+  return api.get('/api/seller/my-products')
+}
+
+/**
+ * Входящие уведомления, новые первыми, и число непрочитанных
+ * @param {{unread?: boolean, limit?: number}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<NotificationListResponse>>}
+ */
+export function getNotifications(params = {}) {
+  return api.get('/api/notifications', { params })
+}
+
+/**
+ * Спецификация OpenAPI
+ * @returns {Promise<import('axios').AxiosResponse<Object>>}
+ */
+export function getOpenAPI() {
+  return api.get('/api/openapi.json')
+}
+
+/**
+ * Заказ покупателя
+ * @param {number} id
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<OrderResponse>>}
+ */
+export function getOrder(id, params = {}) {
+  return api.get(`/api/orders/${id}`, { params })
+}
+
+/**
+ * Заказы покупателя, новые первыми
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<OrderListResponse>>}
+ */
+export function getOrders(params = {}) {
+  return api.get('/api/orders', { params })
+}
+
+/**
+ * Товары на модерации
+ * @returns {Promise<import('axios').AxiosResponse<ProductListResponse>>}
+ */
+export function getPendingProducts() {
+  return api.get('/api/admin/pending-products')
+}
+
+/**
+ * Подписки на снижение цены
+ * @returns {Promise<import('axios').AxiosResponse<PriceAlertListResponse>>}
+ */
+export function getPriceAlerts() {
+  return api.get('/api/price-alerts')
+}
+
+/**
+ * История цены товара от старых изменений к новым
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<PriceHistoryResponse>>}
+ */
+export function getPriceHistory(id) {
+  return api.get(`/api/products/${id}/price-history`)
+}
+
+/**
+ * Карточка товара с последними вопросами, на которые ответили, и рекомендациями. Токен необязателен: с ним просмотр попадает в историю пользователя
+ * @param {number} id
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<ProductResponse>>}
+ */
+export function getProduct(id, params = {}) {
+  return api.get(`/api/products/${id}`, { params })
+}
+
+/**
+ * Каталог одобренных товаров: по номеру страницы или по курсору
+ * @param {{page?: number, limit?: number, cursor?: string, currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<(ProductPageResponse|ProductCursorResponse)>>}
+ */
+export function getProducts(params = {}) {
+  return api.get('/api/products', { params })
+}
+
+/**
+ * Профиль текущего пользователя
+ * @returns {Promise<import('axios').AxiosResponse<ProfileResponse>>}
+ */
+export function getProfile() {
+  return api.get('/api/profile')
+}
+
+/**
+ * Вопросы о товаре с опубликованными ответами
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<QuestionListResponse>>}
+ */
+export function getQuestions(id) {
+  return api.get(`/api/products/${id}/questions`)
+}
+
+/**
+ * Заявки покупателя на возврат
+ * @returns {Promise<import('axios').AxiosResponse<ReturnListResponse>>}
+ */
+export function getReturns() {
+  return api.get('/api/returns')
+}
+
+/**
+ * Акции продавца; администратору — все
+ * @returns {Promise<import('axios').AxiosResponse<SaleListResponse>>}
+ */
+export function getSales() {
+  return api.get('/api/seller/sales')
+}
+
+/**
+ * Аналитика продаж продавца
+ * @param {{from?: string, to?: string, top?: number}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<AnalyticsResponse>>}
+ */
+export function getSellerAnalytics(params = {}) {
+  return api.get('/api/seller/analytics', { params })
+}
+
+/**
+ * Заявки на возврат по товарам продавца; администратору — все
+ * @returns {Promise<import('axios').AxiosResponse<ReturnListResponse>>}
+ */
+export function getSellerReturns() {
+  return api.get('/api/seller/returns')
+}
+
+/**
+ * Ставки налогов по категориям и регионам
+ * @returns {Promise<import('axios').AxiosResponse<TaxRateListResponse>>}
+ */
+export function getTaxRates() {
+  return api.get('/api/admin/tax-rates')
+}
+
+/**
+ * Карточка пользователя: товары, заказы и последние входы
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<UserDetailResponse>>}
+ */
+export function getUserDetail(id) {
+  return api.get(`/api/admin/users/${id}`)
+}
+
+/**
+ * Запрос GraphQL: каталог без токена, корзина и заказы с токеном; ошибки полей — в errors с кодом 200
+ * @param {GraphQLRequest} body
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<GraphQLResponse>>}
+ */
+export function graphql(body, params = {}) {
+  return api.post('/api/graphql', body, { params })
+}
+
+/**
+ * Массовый импорт товаров из CSV или JSON
+ * @param {Array<ImportRow>|FormData|string} body
+ * @param {{format?: "csv"|"json", dry_run?: boolean}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<ImportResponse>>}
+ */
+export function importProducts(body, params = {}) {
+  return api.post('/api/seller/products/import', body, { params })
+}
+
+/**
+ * Вход по логину и паролю
+ * @param {LoginRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AuthResponse>>}
+ */
+export function login(body) {
+  return api.post('/api/login', body)
+}
+
+/**
+ * Отметить прочитанными все уведомления
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function markAllNotificationsRead() {
+  return api.put('/api/notifications/read')
+}
+
+/**
+ * Отметить уведомление прочитанным
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function markNotificationRead(id) {
+  return api.put(`/api/notifications/${id}/read`)
+}
+
+/**
+ * Опубликовать или скрыть ответ
+ * @param {number} id
+ * @param {ModerationRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function moderateAnswer(id, body) {
+  return api.put(`/api/admin/answers/${id}/status`, body)
+}
+
+/**
+ * Опубликовать или скрыть вопрос
+ * @param {number} id
+ * @param {ModerationRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function moderateQuestion(id, body) {
+  return api.put(`/api/admin/questions/${id}/status`, body)
+}
+
+/**
+ * Регистрация покупателя
+ * @param {RegisterRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AuthResponse>>}
+ */
+export function register(body) {
+  return api.post('/api/register', body)
+}
+
+/**
+ * Удалить позицию корзины
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function removeFromCart(id) {
+  return api.delete(`/api/cart/remove/${id}`)
+}
+
+/**
+ * Убрать товар из сравнения
+ * @param {number} id
+ * @param {{currency?: string}} [params]
+ * @returns {Promise<import('axios').AxiosResponse<ComparisonResponse>>}
+ */
+export function removeFromComparison(id, params = {}) {
+  return api.delete(`/api/compare/${id}`, { params })
+}
+
+/**
+ * Подписаться на снижение цены до target_price
+ * @param {number} id
+ * @param {PriceAlertRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function setPriceAlert(id, body) {
+  return api.put(`/api/products/${id}/price-alert`, body)
+}
+
+/**
+ * Поток Server-Sent Events: событие notification с уведомлением в JSON, id события — id уведомления
+ * @param {Object<string, function(*, MessageEvent): void>} handlers - обработчики по типу события, получают разобранный JSON
+ * @param {{last_event_id?: number}} [params]
+ * @returns {function(): void} закрывает поток
+ */
+export function streamNotifications(handlers, params = {}) {
+  return eventSource('/api/notifications/stream', params, createNotificationStreamToken, handlers)
+}
+
+/**
+ * Заблокировать или разблокировать пользователя
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function toggleUserActive(id) {
+  return api.put(`/api/admin/users/${id}/active`)
+}
+
+/**
+ * Снять отметку «полезный ответ»
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<AnswerResponse>>}
+ */
+export function unvoteAnswer(id) {
+  return api.delete(`/api/answers/${id}/vote`)
+}
+
+/**
+ * Изменить адрес
+ * @param {number} id
+ * @param {AddressRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AddressResponse>>}
+ */
+export function updateAddress(id, body) {
+  return api.put(`/api/addresses/${id}`, body)
+}
+
+/**
+ * Изменить количество; 0 и меньше удаляет позицию
+ * @param {number} id
+ * @param {UpdateCartItemRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function updateCartItem(id, body) {
+  return api.put(`/api/cart/update/${id}`, body)
+}
+
+/**
+ * Изменить способ доставки и заменить ступени тарифа
+ * @param {number} id
+ * @param {DeliveryMethodRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<DeliveryMethodResponse>>}
+ */
+export function updateDeliveryMethod(id, body) {
+  return api.put(`/api/admin/delivery-methods/${id}`, body)
+}
+
+/**
+ * Перевести заказ в следующий статус; отмена возвращает товары на склад
+ * @param {number} id
+ * @param {OrderStatusRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<OrderResponse>>}
+ */
+export function updateOrderStatus(id, body) {
+  return api.put(`/api/admin/orders/${id}/status`, body)
+}
+
+/**
+ * Изменить товар
+ * @param {number} id
+ * @param {URLSearchParams|FormData} body
+ * @returns {Promise<import('axios').AxiosResponse<MessageResponse>>}
+ */
+export function updateProduct(id, body) {
+  return api.put(`/api/seller/products/${id}`, body)
+}
+
+/**
+ * Изменить имя и email. Новый email вступает в силу после подтверждения кодом из письма; ответ содержит новый токен
+ * @param {ProfileRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<AuthResponse>>}
+ */
+export function updateProfile(body) {
+  return api.put('/api/profile', body)
+}
+
+/**
+ * Одобрить или отклонить заявку, отметить получение товара или возврат денег
+ * @param {number} id
+ * @param {ReturnStatusRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<ReturnResponse>>}
+ */
+export function updateReturnStatus(id, body) {
+  return api.put(`/api/seller/returns/${id}/status`, body)
+}
+
+/**
+ * Изменить ставку налога; оформленные заказы не пересчитываются
+ * @param {number} id
+ * @param {TaxRateRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<TaxRateResponse>>}
+ */
+export function updateTaxRate(id, body) {
+  return api.put(`/api/admin/tax-rates/${id}`, body)
+}
+
+/**
+ * Изменить роль пользователя
+ * @param {number} id
+ * @param {UpdateRoleRequest} body
+ * @returns {Promise<import('axios').AxiosResponse<(RoleChangeResponse|SelfRoleChangeResponse)>>}
+ */
+export function updateUserRole(id, body) {
+  return api.put(`/api/admin/users/${id}/role`, body)
+}
+
+/**
+ * Загрузить изображение JPEG, PNG или WebP
+ * @param {FormData} body
+ * @returns {Promise<import('axios').AxiosResponse<UploadResponse>>}
+ */
+export function uploadImage(body) {
+  return api.post('/api/upload', body)
+}
+
+/**
+ * Отметить ответ полезным
+ * @param {number} id
+ * @returns {Promise<import('axios').AxiosResponse<AnswerResponse>>}
+ */
+export function voteAnswer(id) {
+  return api.put(`/api/answers/${id}/vote`)
+}
@@ -1,745 +0,0 @@
---
--- PostgreSQL database dump
---
-
-\restrict j9O9ezaxO8ve4BY9wbWuPcyOuNhHabkVeddF03LyCBlth4xZ0UTYyjcPn8nXnAl
-
--- Dumped from database version 17.7
--- Dumped by pg_dump version 17.7
-/*
-SET statement_timeout = 0;
-SET lock_timeout = 0;
-SET idle_in_transaction_session_timeout = 0;
-SET transaction_timeout = 0;
-SET client_encoding = 'UTF8';
-SET standard_conforming_strings = on;
-SELECT pg_catalog.set_config('search_path', '', false);
-SET check_function_bodies = false;
-SET xmloption = content;
-SET client_min_messages = warning;
-SET row_security = off;
-
-
-DO $$
-BEGIN
-    IF NOT EXISTS (SELECT FROM pg_catalog.pg_roles WHERE rolname = 'barsikuser') THEN
-        CREATE USER barsikuser WITH PASSWORD 'barsik_password';
-        RAISE NOTICE 'Пользователь barsikuser создан';
-    ELSE
-        RAISE NOTICE 'Пользователь barsikuser уже существует';
-    END IF;
-END
-$$;
-
-
-DO $$
-BEGIN
-    PERFORM 1 FROM pg_database WHERE datname = 'barsikdb';
-    IF NOT FOUND THEN
-        PERFORM dblink_exec('dbname=postgres', 'CREATE DATABASE barsikdb OWNER barsikuser');
-        RAISE NOTICE 'База данных barsikdb создана';
-    ELSE
-        RAISE NOTICE 'База данных barsikdb уже существует';
-    END IF;
-END
-$$;
-
-\c barsikdb
-
-
-ALTER DATABASE barsikdb OWNER TO barsikuser;
-
-
-GRANT ALL PRIVILEGES ON DATABASE barsikdb TO barsikuser;
-
-
-
-
---
--- Name: pgcrypto; Type: EXTENSION; Schema: -; Owner: -
---
-*/
-CREATE EXTENSION IF NOT EXISTS pgcrypto WITH SCHEMA public;
-
-
---
--- Name: EXTENSION pgcrypto; Type: COMMENT; Schema: -; Owner: 
---
-
-COMMENT ON EXTENSION pgcrypto IS 'cryptographic functions';
-
-
-SET default_tablespace = '';
-
-SET default_table_access_method = heap;
-
---
--- Name: cart_items; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.cart_items (
-    id integer NOT NULL,
-    user_id integer,
-    product_id integer,
-    quantity integer DEFAULT 1,
-    added_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
-);
-
-
-ALTER TABLE public.cart_items OWNER TO barsikuser;
-
---
--- Name: cart_items_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.cart_items_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER SEQUENCE public.cart_items_id_seq OWNER TO barsikuser;
-
---
--- Name: cart_items_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.cart_items_id_seq OWNED BY public.cart_items.id;
-
-
---
--- Name: order_items; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.order_items (
-    id integer NOT NULL,
-    order_id integer,
-    product_id integer,
-    quantity integer,
-    price_at_time numeric(10,2)
-);
-
-
-ALTER TABLE public.order_items OWNER TO barsikuser;
-
---
--- Name: order_items_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.order_items_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER SEQUENCE public.order_items_id_seq OWNER TO barsikuser;
-
---
--- Name: order_items_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.order_items_id_seq OWNED BY public.order_items.id;
-
-
---
--- Name: orders; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.orders (
-    id integer NOT NULL,
-    user_id integer,
-    total_amount numeric(10,2),
-    status character varying(20) DEFAULT 'pending'::character varying,
-    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
-);
-
-
-ALTER TABLE public.orders OWNER TO barsikuser;
-
---
--- Name: orders_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.orders_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER SEQUENCE public.orders_id_seq OWNER TO barsikuser;
-
---
--- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.orders_id_seq OWNED BY public.orders.id;
-
-
---
--- Name: products; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.products (
-    id integer NOT NULL,
-    name character varying(255) NOT NULL,
-    description text,
-    price numeric(10,2),
-    image character varying(255),
-    stock integer DEFAULT 0,
-    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
-    user_id integer,
-    is_approved boolean DEFAULT true
-);
-
-
-ALTER TABLE public.products OWNER TO barsikuser;
-
---
--- Name: products_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.products_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER SEQUENCE public.products_id_seq OWNER TO barsikuser;
-
---
--- Name: products_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.products_id_seq OWNED BY public.products.id;
-
-
---
--- Name: sessions; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.sessions (
-    id integer NOT NULL,
-    user_id integer,
-    session_token character varying(255) NOT NULL,
-    expires_at timestamp without time zone NOT NULL,
-    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP
-);
-
-
-ALTER TABLE public.sessions OWNER TO barsikuser;
-
---
--- Name: sessions_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.sessions_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER SEQUENCE public.sessions_id_seq OWNER TO barsikuser;
-
---
--- Name: sessions_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.sessions_id_seq OWNED BY public.sessions.id;
-
-
---
--- Name: users; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.users (
-    id integer NOT NULL,
-    username character varying(50) NOT NULL,
-    email character varying(100) NOT NULL,
-    password_hash character varying(255) NOT NULL,
-    role character varying(20) DEFAULT 'customer'::character varying,
-    created_at timestamp without time zone DEFAULT CURRENT_TIMESTAMP,
-    is_active boolean DEFAULT true,
-    is_protected boolean DEFAULT false
-);
-
-
-ALTER TABLE public.users OWNER TO barsikuser;
-
---
--- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: postgres
---
-
-CREATE SEQUENCE public.users_id_seq
-    AS integer
-    START WITH 1
-    INCREMENT BY 1
-    NO MINVALUE
-    NO MAXVALUE
-    CACHE 1;
-
-
-ALTER SEQUENCE public.users_id_seq OWNER TO barsikuser;
-
---
--- Name: users_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: postgres
---
-
-ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
-
-
---
--- Name: cart_items id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.cart_items ALTER COLUMN id SET DEFAULT nextval('public.cart_items_id_seq'::regclass);
-
-
---
--- Name: order_items id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.order_items ALTER COLUMN id SET DEFAULT nextval('public.order_items_id_seq'::regclass);
-
-
---
--- Name: orders id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.orders ALTER COLUMN id SET DEFAULT nextval('public.orders_id_seq'::regclass);
-
-
---
--- Name: products id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.products ALTER COLUMN id SET DEFAULT nextval('public.products_id_seq'::regclass);
-
-
---
--- Name: sessions id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.sessions ALTER COLUMN id SET DEFAULT nextval('public.sessions_id_seq'::regclass);
-
-
---
--- Name: users id; Type: DEFAULT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
-
-
---
--- Data for Name: cart_items; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.cart_items (id, user_id, product_id, quantity, added_at) FROM stdin;
-\.
-
-
---
--- Data for Name: order_items; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.order_items (id, order_id, product_id, quantity, price_at_time) FROM stdin;
-\.
-
-
---
--- Data for Name: orders; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.orders (id, user_id, total_amount, status, created_at) FROM stdin;
-\.
-
-
---
--- Data for Name: products; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.products (id, name, description, price, image, stock, created_at, user_id, is_approved) FROM stdin;
-1	Ноутбук Acer	Характеристики: AMD Ryzen 5 6600H, 32 ГБ DDR5, RTX 3050 6 ГБ, 1 ТБ SSD, 15.6" IPS 144 Гц	90032.00	t1.webp	2	2026-01-15 22:47:17.654624	4	t
-4	Рабочая станция «Эльбрус 801-РС» (ТВГИ.466535.175)	Набор микросхем: 1 процессор Эльбрус-8С1 (1891ВМ028 — 8 ядер, 1200 МГц). Оперативная память: 32 Гбайт DDR3-1600 ECC Registered. Накопитель: 1 Тбайт SATA. Видеокарта: AMD Radeon R5 230.	267480.00	MCST.jpg	0	2026-01-15 22:47:17.654624	4	t
-3	Ноутбук Lenovo LQ	Характеристики: AMD Ryzen 5 7235HS, 64 ГБ DDR5, RTX 3050 6 ГБ, 1 ТБ SSD, 15.6" IPS 144 Гц	132481.00	t3.webp	1	2026-01-15 22:47:17.654624	4	t
-2	Ноутбук Asus Tuf Gaming	Характеристики: AMD Ryzen 7 7435HS, 16 ГБ DDR5, RTX 4060 8 ГБ, 512 ГБ SSD, 15.6" IPS 144 Гц	111023.00	t2.webp	8	2026-01-15 22:47:17.654624	4	t
-5	Игровой ПК MSI	Intel Core i7-13700K, 32 ГБ DDR5, RTX 4070 Ti, 2 ТБ NVMe SSD, жидкостное охлаждение	185000.00	1768818786707064493_2QjhsVrm.webp	5	2026-01-15 22:47:17.654624	4	t
-6	Монитор Dell S2721DGF	27" QHD 2560x1440, 165 Гц, IPS, 1 мс, AMD FreeSync Premium Pro, регулируемая подставка	34999.00	1768818839529473636_YXBlpaTp.webp	8	2026-01-15 22:47:17.654624	4	t
-9	Наушники HyperX Cloud II	Игровые наушники с виртуальным 7.1 звуком, микрофон с шумоподавлением, тканевые амбушюры	7999.00	1768819039225840455_ZH4c3opm.webp	7	2026-01-15 22:47:17.654624	4	t
-10	Ноутбук Apple MacBook Pro 16"	Apple M3 Pro, 36 ГБ RAM, 1 ТБ SSD, дисплей Liquid Retina XDR, 18 часов работы	289999.00	1768819141870699837_YdabMy0V.webp	4	2026-01-15 22:47:17.654624	4	t
-11	Планшет Samsung Galaxy Tab S9	11" Dynamic AMOLED 2X, 120 Гц, Snapdragon 8 Gen 2, 8 ГБ RAM, 256 ГБ, S Pen в комплекте	89999.00	1768819214904796535_fbvKcKgt.webp	6	2026-01-15 22:47:17.654624	4	t
-7	Клавиатура Logitech G Pro X	Механическая игровая клавиатура, переключатели GX Blue, RGB подсветка, съемный кабель	12999.00	1768818886425689112_K8cPWpnH.jpg	15	2026-01-15 22:47:17.654624	4	t
-8	Мышь Razer DeathAdder V3 Pro	Беспроводная игровая мышь, 30000 DPI, оптический сенсор Focus Pro 30K, 90 часов работы	11999.00	1768818997548375552_EygoHvFZ.webp	12	2026-01-15 22:47:17.654624	4	t
-23	Смартфон iPhone 15 Pro	6.1" Super Retina XDR, A17 Pro, 256 ГБ, титановый корпус, камера 48 Мп	124999.00	1768830716452497279_Z7nhNkkD.webp	10	2026-01-19 18:51:56.453058	5	t
-24	Игровая консоль PlayStation 5	825 ГБ SSD, Ultra HD Blu-ray, контроллер DualSense, поддержка 4K 120 Гц	59999.00	1768830755785687690_6bRcLNT4.webp	3	2026-01-19 18:52:35.786145	5	t
-\.
-
-
---
--- Data for Name: sessions; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.sessions (id, user_id, session_token, expires_at, created_at) FROM stdin;
-\.
-
-
---
--- Data for Name: users; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.users (id, username, email, password_hash, role, created_at, is_active, is_protected) FROM stdin;
-4	CatPC	catpc@catpc.ru	$2a$10$r92oYXaji0nY2/KpV01K0Oq4hHX/hbhMEooHPj1ruka.D4EiLGIby	admin	2026-01-16 18:51:21.046296	t	t
-2	seller1	seller1@example.com	$2a$10$HashedPassword1	customer	2026-01-16 18:25:07.559101	t	f
-3	customer1	customer1@example.com	$2a$10$HashedPassword2	seller	2026-01-16 18:25:07.559101	t	f
-5	YaKotikTvoy	YaKotikTvoy@yandex.ru	$2a$10$Be5gPGR0EcW8WNUprGh05u/FYr9H8xSsRlZD/TL2tpiA009.U/qWO	admin	2026-01-16 19:23:57.918043	t	f
-7	YaKotikTvoy1	YaKotikTvoy1@yandex.ru	$2a$10$U2Hsen4dC2ysOImIMXKbAe.2NM1FKAC..f0ge8xhGCM/FE9Jn8AFy	seller	2026-01-19 18:56:39.091371	f	f
-\.
-
-
---
--- Name: cart_items_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.cart_items_id_seq', 100, true);
-
-
---
--- Name: order_items_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.order_items_id_seq', 1, false);
-
-
---
--- Name: orders_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.orders_id_seq', 1, false);
-
-
---
--- Name: products_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.products_id_seq', 25, true);
-
-
---
--- Name: sessions_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.sessions_id_seq', 1, false);
-
-
---
--- Name: users_id_seq; Type: SEQUENCE SET; Schema: public; Owner: postgres
---
-
-SELECT pg_catalog.setval('public.users_id_seq', 7, true);
-
-
---
--- Name: cart_items cart_items_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.cart_items
-    ADD CONSTRAINT cart_items_pkey PRIMARY KEY (id);
-
-
---
--- Name: cart_items cart_items_user_id_product_id_key; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.cart_items
-    ADD CONSTRAINT cart_items_user_id_product_id_key UNIQUE (user_id, product_id);
-
-
---
--- Name: order_items order_items_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.order_items
-    ADD CONSTRAINT order_items_pkey PRIMARY KEY (id);
-
-
---
--- Name: orders orders_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.orders
-    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
-
-
---
--- Name: products products_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.products
-    ADD CONSTRAINT products_pkey PRIMARY KEY (id);
-
-
---
--- Name: sessions sessions_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.sessions
-    ADD CONSTRAINT sessions_pkey PRIMARY KEY (id);
-
-
---
--- Name: sessions sessions_session_token_key; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.sessions
-    ADD CONSTRAINT sessions_session_token_key UNIQUE (session_token);
-
-
---
--- Name: users users_email_key; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_email_key UNIQUE (email);
-
-
---
--- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
-
-
---
--- Name: users users_username_key; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.users
-    ADD CONSTRAINT users_username_key UNIQUE (username);
-
-
---
--- Name: idx_cart_user; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX idx_cart_user ON public.cart_items USING btree (user_id);
-
-
---
--- Name: idx_products_user; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX idx_products_user ON public.products USING btree (user_id);
-
-
---
--- Name: idx_sessions_token; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX idx_sessions_token ON public.sessions USING btree (session_token);
-
-
---
--- Name: idx_users_email; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX idx_users_email ON public.users USING btree (email);
-
-
---
--- Name: idx_users_username; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX idx_users_username ON public.users USING btree (username);
-
-
---
--- Name: cart_items cart_items_product_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.cart_items
-    ADD CONSTRAINT cart_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES public.products(id) ON DELETE CASCADE;
-
-
---
--- Name: cart_items cart_items_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.cart_items
-    ADD CONSTRAINT cart_items_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
-
-
---
--- Name: order_items order_items_order_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.order_items
-    ADD CONSTRAINT order_items_order_id_fkey FOREIGN KEY (order_id) REFERENCES public.orders(id) ON DELETE CASCADE;
-
-
---
--- Name: order_items order_items_product_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.order_items
-    ADD CONSTRAINT order_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES public.products(id);
-
-
---
--- Name: orders orders_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.orders
-    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id);
-
-
---
--- Name: products products_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.products
-    ADD CONSTRAINT products_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE SET NULL;
-
-
---
--- Name: sessions sessions_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.sessions
-    ADD CONSTRAINT sessions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
-
-
---
--- Name: TABLE cart_items; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON TABLE public.cart_items TO barsikuser;
-
-
---
--- Name: SEQUENCE cart_items_id_seq; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON SEQUENCE public.cart_items_id_seq TO barsikuser;
-
-
---
--- Name: TABLE order_items; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON TABLE public.order_items TO barsikuser;
-
-
---
--- Name: SEQUENCE order_items_id_seq; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON SEQUENCE public.order_items_id_seq TO barsikuser;
-
-
---
--- Name: TABLE orders; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON TABLE public.orders TO barsikuser;
-
-
---
--- Name: SEQUENCE orders_id_seq; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON SEQUENCE public.orders_id_seq TO barsikuser;
-
-
---
--- Name: TABLE products; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON TABLE public.products TO barsikuser;
-
-
---
--- Name: SEQUENCE products_id_seq; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON SEQUENCE public.products_id_seq TO barsikuser;
-
-
---
--- Name: TABLE sessions; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON TABLE public.sessions TO barsikuser;
-
-
---
--- Name: SEQUENCE sessions_id_seq; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON SEQUENCE public.sessions_id_seq TO barsikuser;
-
-
---
--- Name: TABLE users; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON TABLE public.users TO barsikuser;
-
-
---
--- Name: SEQUENCE users_id_seq; Type: ACL; Schema: public; Owner: postgres
---
-
-GRANT ALL ON SEQUENCE public.users_id_seq TO barsikuser;
-
-
---
--- Name: DEFAULT PRIVILEGES FOR SEQUENCES; Type: DEFAULT ACL; Schema: public; Owner: postgres
---
-
-ALTER DEFAULT PRIVILEGES FOR ROLE postgres IN SCHEMA public GRANT ALL ON SEQUENCES TO barsikuser;
-
-
---
--- Name: DEFAULT PRIVILEGES FOR TABLES; Type: DEFAULT ACL; Schema: public; Owner: postgres
---
-
-ALTER DEFAULT PRIVILEGES FOR ROLE postgres IN SCHEMA public GRANT ALL ON TABLES TO barsikuser;
-
-
--- Даем все права пользователю barsikuser на все таблицы и последовательности
-GRANT ALL PRIVILEGES ON ALL TABLES IN SCHEMA public TO barsikuser;
-GRANT ALL PRIVILEGES ON ALL SEQUENCES IN SCHEMA public TO barsikuser;
-GRANT ALL PRIVILEGES ON ALL FUNCTIONS IN SCHEMA public TO barsikuser;
-
--- Даем право создавать новые объекты
-GRANT CREATE ON SCHEMA public TO barsikuser;
-
--- Даем право на использование схемы
-GRANT USAGE ON SCHEMA public TO barsikuser;
-
--- Даем права по умолчанию для будущих объектов
-ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON TABLES TO barsikuser;
-ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON SEQUENCES TO barsikuser;
-ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT ALL ON FUNCTIONS TO barsikuser;
-
---
--- PostgreSQL database dump complete
---
-
-\unrestrict j9O9ezaxO8ve4BY9wbWuPcyOuNhHabkVeddF03LyCBlth4xZ0UTYyjcPn8nXnAl
-
@@ -0,0 +1,30 @@
+-- Демонстрационные данные CatPC.
+-- Схему создает backend: ./catpc-backend migrate up
+-- Скрипт можно запускать повторно: существующие записи не перезаписываются.
+
+INSERT INTO users (id, username, email, password_hash, role, created_at, is_active, is_protected) VALUES
+    (2, 'seller1', 'seller1@example.com', '$2a$10$HashedPassword1', 'customer', '2026-01-16 18:25:07.559101', true, false),
+    (3, 'customer1', 'customer1@example.com', '$2a$10$HashedPassword2', 'seller', '2026-01-16 18:25:07.559101', true, false),
+    (4, 'CatPC', 'catpc@catpc.ru', '$2a$10$r92oYXaji0nY2/KpV01K0Oq4hHX/hbhMEooHPj1ruka.D4EiLGIby', 'admin', '2026-01-16 18:51:21.046296', true, true),
+    (5, 'YaKotikTvoy', 'YaKotikTvoy@yandex.ru', '$2a$10$Be5gPGR0EcW8WNUprGh05u/FYr9H8xSsRlZD/TL2tpiA009.U/qWO', 'admin', '2026-01-16 19:23:57.918043', true, false),
+    (7, 'YaKotikTvoy1', 'YaKotikTvoy1@yandex.ru', '$2a$10$U2Hsen4dC2ysOImIMXKbAe.2NM1FKAC..f0ge8xhGCM/FE9Jn8AFy', 'seller', '2026-01-19 18:56:39.091371', false, false)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO products (id, name, description, price, image, stock, created_at, user_id, is_approved) VALUES
+    (1, 'Ноутбук Acer', 'Характеристики: AMD Ryzen 5 6600H, 32 ГБ DDR5, RTX 3050 6 ГБ, 1 ТБ SSD, 15.6" IPS 144 Гц', 90032.00, 't1.webp', 2, '2026-01-15 22:47:17.654624', 4, true),
+    (2, 'Ноутбук Asus Tuf Gaming', 'Характеристики: AMD Ryzen 7 7435HS, 16 ГБ DDR5, RTX 4060 8 ГБ, 512 ГБ SSD, 15.6" IPS 144 Гц', 111023.00, 't2.webp', 8, '2026-01-15 22:47:17.654624', 4, true),
+    (3, 'Ноутбук Lenovo LQ', 'Характеристики: AMD Ryzen 5 7235HS, 64 ГБ DDR5, RTX 3050 6 ГБ, 1 ТБ SSD, 15.6" IPS 144 Гц', 132481.00, 't3.webp', 1, '2026-01-15 22:47:17.654624', 4, true),
+    (4, 'Рабочая станция «Эльбрус 801-РС» (ТВГИ.466535.175)', 'Набор микросхем: 1 процессор Эльбрус-8С1 (1891ВМ028 — 8 ядер, 1200 МГц). Оперативная память: 32 Гбайт DDR3-1600 ECC Registered. Накопитель: 1 Тбайт SATA. Видеокарта: AMD Radeon R5 230.', 267480.00, 'MCST.jpg', 0, '2026-01-15 22:47:17.654624', 4, true),
+    (5, 'Игровой ПК MSI', 'Intel Core i7-13700K, 32 ГБ DDR5, RTX 4070 Ti, 2 ТБ NVMe SSD, жидкостное охлаждение', 185000.00, '1768818786707064493_2QjhsVrm.webp', 5, '2026-01-15 22:47:17.654624', 4, true),
+    (6, 'Монитор Dell S2721DGF', '27" QHD 2560x1440, 165 Гц, IPS, 1 мс, AMD FreeSync Premium Pro, регулируемая подставка', 34999.00, '1768818839529473636_YXBlpaTp.webp', 8, '2026-01-15 22:47:17.654624', 4, true),
+    (7, 'Клавиатура Logitech G Pro X', 'Механическая игровая клавиатура, переключатели GX Blue, RGB подсветка, съемный кабель', 12999.00, '1768818886425689112_K8cPWpnH.jpg', 15, '2026-01-15 22:47:17.654624', 4, true),
+    (8, 'Мышь Razer DeathAdder V3 Pro', 'Беспроводная игровая мышь, 30000 DPI, оптический сенсор Focus Pro 30K, 90 часов работы', 11999.00, '1768818997548375552_EygoHvFZ.webp', 12, '2026-01-15 22:47:17.654624', 4, true),
+    (9, 'Наушники HyperX Cloud II', 'Игровые наушники с виртуальным 7.1 звуком, микрофон с шумоподавлением, тканевые амбушюры', 7999.00, '1768819039225840455_ZH4c3opm.webp', 7, '2026-01-15 22:47:17.654624', 4, true),
+    (10, 'Ноутбук Apple MacBook Pro 16"', 'Apple M3 Pro, 36 ГБ RAM, 1 ТБ SSD, дисплей Liquid Retina XDR, 18 часов работы', 289999.00, '1768819141870699837_YdabMy0V.webp', 4, '2026-01-15 22:47:17.654624', 4, true),
+    (11, 'Планшет Samsung Galaxy Tab S9', '11" Dynamic AMOLED 2X, 120 Гц, Snapdragon 8 Gen 2, 8 ГБ RAM, 256 ГБ, S Pen в комплекте', 89999.00, '1768819214904796535_fbvKcKgt.webp', 6, '2026-01-15 22:47:17.654624', 4, true),
+    (23, 'Смартфон iPhone 15 Pro', '6.1" Super Retina XDR, A17 Pro, 256 ГБ, титановый корпус, камера 48 Мп', 124999.00, '1768830716452497279_Z7nhNkkD.webp', 10, '2026-01-19 18:51:56.453058', 5, true),
+    (24, 'Игровая консоль PlayStation 5', '825 ГБ SSD, Ultra HD Blu-ray, контроллер DualSense, поддержка 4K 120 Гц', 59999.00, '1768830755785687690_6bRcLNT4.webp', 3, '2026-01-19 18:52:35.786145', 5, true)
+ON CONFLICT (id) DO NOTHING;
+
+SELECT setval('users_id_seq', GREATEST((SELECT MAX(id) FROM users), 1));
+SELECT setval('products_id_seq', GREATEST((SELECT MAX(id) FROM products), 1));
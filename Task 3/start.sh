@@ -5,9 +5,11 @@ sleep 3
 
 psql -U postgres -f /opt/catpc/init-db.sql
 
-PGPASSWORD=barsik_password psql -U barsikuser -d barsikdb -f /opt/catpc/schema.sql
-
 cd /opt/catpc/backend
+./catpc-backend migrate up || exit 1
+
+PGPASSWORD=barsik_password psql -U barsikuser -d barsikdb -f /opt/catpc/seed.sql
+
 ./catpc-backend &
 
 cd /opt/catpc/frontend